@@ -7,6 +7,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/holiman/uint256"
 )
 
 type DynamicFeeTxWithoutSignature struct {
@@ -41,6 +42,47 @@ type LegacyTxWithoutSignature struct {
 	Data     []byte          // contract invocation input data
 }
 
+// LegacyEIP155TxWithoutSignature is the EIP-155 signing payload of the legacy tx,
+// the chain id and zero r, s are appended to the legacy fields
+type LegacyEIP155TxWithoutSignature struct {
+	Nonce    uint64          // nonce of sender account
+	GasPrice *big.Int        // wei per gas
+	Gas      uint64          // gas limit
+	To       *common.Address `rlp:"nil"` // nil means contract creation
+	Value    *big.Int        // wei amount
+	Data     []byte          // contract invocation input data
+	ChainID  *big.Int        // destination chain ID
+	R        uint64          // always zero
+	S        uint64          // always zero
+}
+
+type BlobTxWithoutSignature struct {
+	ChainID    *uint256.Int
+	Nonce      uint64
+	GasTipCap  *uint256.Int // a.k.a. maxPriorityFeePerGas
+	GasFeeCap  *uint256.Int // a.k.a. maxFeePerGas
+	Gas        uint64
+	To         common.Address // blob txs can't create contracts
+	Value      *uint256.Int
+	Data       []byte
+	AccessList types.AccessList
+	BlobFeeCap *uint256.Int // a.k.a. maxFeePerBlobGas
+	BlobHashes []common.Hash
+}
+
+type SetCodeTxWithoutSignature struct {
+	ChainID    *uint256.Int
+	Nonce      uint64
+	GasTipCap  *uint256.Int // a.k.a. maxPriorityFeePerGas
+	GasFeeCap  *uint256.Int // a.k.a. maxFeePerGas
+	Gas        uint64
+	To         common.Address // set code txs can't create contracts
+	Value      *uint256.Int
+	Data       []byte
+	AccessList types.AccessList
+	AuthList   []types.SetCodeAuthorization // EIP-7702 delegations, signed by the delegating accounts
+}
+
 // Custom decoder as go-ethereum does not trivially allow decoding of unsigned payloads
 func DecodeUnsignedPayload(msg []byte) (types.TxData, error) {
 	if len(msg) <= 1 {
@@ -74,9 +116,59 @@ func DecodeUnsignedPayload(msg []byte) (types.TxData, error) {
 			Data:       res.Data,
 			AccessList: res.AccessList,
 		}, err
+	case types.BlobTxType:
+		var res BlobTxWithoutSignature
+		err := rlp.DecodeBytes(msg[1:], &res)
+		return &types.BlobTx{
+			ChainID:    res.ChainID,
+			Nonce:      res.Nonce,
+			GasTipCap:  res.GasTipCap,
+			GasFeeCap:  res.GasFeeCap,
+			Gas:        res.Gas,
+			To:         res.To,
+			Value:      res.Value,
+			Data:       res.Data,
+			AccessList: res.AccessList,
+			BlobFeeCap: res.BlobFeeCap,
+			BlobHashes: res.BlobHashes,
+		}, err
+	case types.SetCodeTxType:
+		var res SetCodeTxWithoutSignature
+		err := rlp.DecodeBytes(msg[1:], &res)
+		return &types.SetCodeTx{
+			ChainID:    res.ChainID,
+			Nonce:      res.Nonce,
+			GasTipCap:  res.GasTipCap,
+			GasFeeCap:  res.GasFeeCap,
+			Gas:        res.Gas,
+			To:         res.To,
+			Value:      res.Value,
+			Data:       res.Data,
+			AccessList: res.AccessList,
+			AuthList:   res.AuthList,
+		}, err
 	case types.LegacyTxType:
+		return decodeLegacyPayload(msg[1:])
+	default:
+		return nil, fmt.Errorf("unsupported transaction type: %v", msg[0])
+	}
+}
+
+// decodeLegacyPayload decodes the pre-EIP-155 payload of 6 fields or the EIP-155 payload of 9 fields.
+// The chain id of the EIP-155 payload is bound to the tx via V, so the tx is replay protected
+func decodeLegacyPayload(payload []byte) (types.TxData, error) {
+	content, _, err := rlp.SplitList(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to split legacy tx payload: %w", err)
+	}
+	fields, err := rlp.CountValues(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count legacy tx fields: %w", err)
+	}
+
+	if fields != 9 {
 		var res LegacyTxWithoutSignature
-		err := rlp.DecodeBytes(msg[1:], &res)
+		err = rlp.DecodeBytes(payload, &res)
 		return &types.LegacyTx{
 			Nonce:    res.Nonce,
 			GasPrice: res.GasPrice,
@@ -85,7 +177,32 @@ func DecodeUnsignedPayload(msg []byte) (types.TxData, error) {
 			Value:    res.Value,
 			Data:     res.Data,
 		}, err
-	default:
-		return nil, fmt.Errorf("unsupported transaction type: %v", msg[0])
 	}
+
+	var res LegacyEIP155TxWithoutSignature
+	err = rlp.DecodeBytes(payload, &res)
+	if err != nil {
+		return nil, err
+	}
+	if res.ChainID == nil || res.ChainID.Sign() == 0 {
+		return nil, fmt.Errorf("legacy tx payload has no chain id")
+	}
+	if res.R != 0 || res.S != 0 {
+		return nil, fmt.Errorf("legacy tx payload must have zero r and s: r=%d, s=%d", res.R, res.S)
+	}
+
+	// V = chain_id * 2 + 35, r and s are set once the tx is signed
+	v := new(big.Int).Mul(res.ChainID, big.NewInt(2))
+	v.Add(v, big.NewInt(35))
+	return &types.LegacyTx{
+		Nonce:    res.Nonce,
+		GasPrice: res.GasPrice,
+		Gas:      res.Gas,
+		To:       res.To,
+		Value:    res.Value,
+		Data:     res.Data,
+		V:        v,
+		R:        new(big.Int),
+		S:        new(big.Int),
+	}, nil
 }
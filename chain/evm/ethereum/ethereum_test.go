@@ -7,6 +7,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/stretchr/testify/require"
 	"github.com/vultisig/mobile-tss-lib/tss"
@@ -42,3 +43,37 @@ func TestEthereum_ComputeTxHash(t *testing.T) {
 	require.Nil(t, err, "NewEthereum().ComputeTxHash")
 	require.Equal(t, expectedTxHash, txHash, "NewEthereum().ComputeTxHash")
 }
+
+func TestDecodeUnsignedPayload_LegacyEIP155(t *testing.T) {
+	to := common.HexToAddress("0x087b027b0573d4f01345ef8d081e0e7d3b378d14")
+	payload, err := rlp.EncodeToBytes(&LegacyEIP155TxWithoutSignature{
+		Nonce:    7,
+		GasPrice: big.NewInt(20_000_000_000),
+		Gas:      21_000,
+		To:       &to,
+		Value:    big.NewInt(1),
+		ChainID:  big.NewInt(42161),
+	})
+	require.NoError(t, err)
+
+	txData, err := DecodeUnsignedPayload(append([]byte{types.LegacyTxType}, payload...))
+	require.NoError(t, err)
+
+	tx := types.NewTx(txData)
+	require.True(t, tx.Protected())
+	require.Equal(t, int64(42161), tx.ChainId().Int64())
+	// the decoded tx is signed over the same payload
+	require.Equal(t, crypto.Keccak256Hash(payload), types.NewEIP155Signer(big.NewInt(42161)).Hash(tx))
+
+	// r and s of the signing payload are zero
+	payload, err = rlp.EncodeToBytes(&LegacyEIP155TxWithoutSignature{
+		GasPrice: big.NewInt(20_000_000_000),
+		To:       &to,
+		Value:    big.NewInt(1),
+		ChainID:  big.NewInt(42161),
+		R:        1,
+	})
+	require.NoError(t, err)
+	_, err = DecodeUnsignedPayload(append([]byte{types.LegacyTxType}, payload...))
+	require.ErrorContains(t, err, "must have zero r and s")
+}
@@ -37,6 +37,15 @@ func NewNativeEVMProtocol(chainID, nativeSymbol, name, description string) types
 						{Name: "amount", Type: "decimal", Description: fmt.Sprintf("The amount of %s to transfer", strings.ToUpper(nativeSymbol))},
 					},
 				},
+				{
+					ID:          "deploy",
+					Name:        "Deploy contract",
+					Description: fmt.Sprintf("Deploy a contract on %s, the tx has no recipient", chainID),
+					Parameters: []*types.FunctionParam{
+						{Name: "code", Type: "bytes", Description: "The contract init code"},
+						{Name: "amount", Type: "decimal", Description: fmt.Sprintf("The amount of %s sent to the contract", strings.ToUpper(nativeSymbol))},
+					},
+				},
 			},
 		},
 		nativeSymbol: strings.ToLower(nativeSymbol),
@@ -2,6 +2,7 @@ package ethereum
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"fmt"
 	"math/big"
@@ -65,7 +66,7 @@ func evaluateParameterConstraints(params map[string]interface{}, policyParamCons
 
 			// Call the resolver
 			// TODO implement memo when supported chains require it
-			expectedValue, _, err := resolver.Resolve(magicConstant, chainID, asset)
+			expectedValue, _, err := resolver.Resolve(context.Background(), magicConstant, chainID, asset)
 			if err != nil {
 				return false, fmt.Errorf("failed to resolve magic constant %v: %w", magicConstant, err)
 			}
@@ -217,6 +218,15 @@ func NewETH() types.Protocol {
 						{Name: "amount", Type: "decimal", Description: "The amount of Ether to transfer"},
 					},
 				},
+				{
+					ID:          "deploy",
+					Name:        "Deploy contract",
+					Description: "Deploy a contract on Ethereum, the tx has no recipient",
+					Parameters: []*types.FunctionParam{
+						{Name: "code", Type: "bytes", Description: "The contract init code"},
+						{Name: "amount", Type: "decimal", Description: "The amount of Ether sent to the contract"},
+					},
+				},
 			},
 		},
 	}
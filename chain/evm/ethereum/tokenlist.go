@@ -1,10 +1,14 @@
 package ethereum
 
 import (
+	_ "embed"
 	"encoding/json"
 	"fmt"
 )
 
+//go:embed tokenlist.json
+var defaultTokenListJSON []byte
+
 // Token represents a token in a token list
 type Token struct {
 	ChainId  int      `json:"chainId"`
@@ -40,6 +44,11 @@ func ParseTokenList(tokenListJSON []byte) (*TokenList, error) {
 	return &tokenList, nil
 }
 
+// DefaultTokenList returns the token list embedded in the package
+func DefaultTokenList() (*TokenList, error) {
+	return ParseTokenList(defaultTokenListJSON)
+}
+
 // GetTokenBySymbol returns a token by its symbol
 func (t *TokenList) GetTokenBySymbol(symbol string) (*Token, bool) {
 	for i, token := range t.Tokens {
@@ -13,7 +13,7 @@ import (
 func main() {
 	// Define command-line flags
 	outputPath := flag.String("output", "RESOURCES.md", "Output file path")
-	tokenListPath := flag.String("tokenlist", "tokenlist.json", "Path to token list JSON file")
+	tokenListPath := flag.String("tokenlist", "chain/evm/ethereum/tokenlist.json", "Path to token list JSON file")
 	abiDirPath := flag.String("abi", "chain/evm/abi", "Path to directory containing ABI JSON files")
 	flag.Parse()
 
@@ -0,0 +1,189 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vultisig/recipes/engine/compare"
+	"github.com/vultisig/recipes/types"
+	"github.com/vultisig/vultisig-go/common"
+)
+
+// EvaluateBundle checks txs which are signed together, e.g. the ERC20 approval and the swap of sdk/swap.SwapBundle.
+// Every tx must be allowed by a distinct rule and must not match any deny rule, the bundle size must fit
+// max_txs_per_window. Chain engines implementing BundleValidator also check relations between the txs.
+// Returns the rule matched by each tx, in the order of txs.
+// Once the bundle is signed, call RecordBundleExecution and RecordSpend for every tx.
+func (e *Engine) EvaluateBundle(policy *types.Policy, chain common.Chain, txs [][]byte) ([]*types.Rule, error) {
+	return e.EvaluateBundleContext(context.Background(), policy, chain, txs)
+}
+
+// EvaluateBundleContext is EvaluateBundle with a context, which is passed down to chain engines
+func (e *Engine) EvaluateBundleContext(
+	ctx context.Context,
+	policy *types.Policy,
+	chain common.Chain,
+	txs [][]byte,
+) ([]*types.Rule, error) {
+	if len(txs) == 0 {
+		return nil, errors.New("empty bundle")
+	}
+	// the bundle is signed at once, so it must fit max_txs_per_window even if the window isn't set
+	if policy.MaxTxsPerWindow != nil && len(txs) > int(policy.GetMaxTxsPerWindow()) {
+		return nil, fmt.Errorf("bundle of %d txs exceeds max_txs_per_window=%d", len(txs), policy.GetMaxTxsPerWindow())
+	}
+
+	err := e.checkRateLimit(ctx, policy, uint32(len(txs)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check rate limit: %w", err)
+	}
+
+	rules, err := e.chainRules(policy, chain, &EvaluationReport{})
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, errors.New("no matching rule")
+	}
+
+	chainEngine, err := e.registry.GetEngine(chain)
+	if err != nil {
+		e.logger.Printf("No engine available for chain %s: %v", chain.String(), err)
+		return nil, errors.New("no matching rule")
+	}
+
+	// candidates[i] are indexes of the allow rules matching txs[i]
+	candidates := make([][]int, len(txs))
+	for i, txBytes := range txs {
+		candidates[i], err = e.bundleCandidates(ctx, chain, chainEngine, rules, txBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate tx %d: %w", i, err)
+		}
+	}
+
+	assigned := assignDistinctRules(candidates, len(rules))
+	if assigned == nil {
+		return nil, errors.New("bundle txs must match distinct rules")
+	}
+
+	matched := make([]*types.Rule, len(txs))
+	for i, ruleIndex := range assigned {
+		matched[i] = rules[ruleIndex]
+	}
+
+	// rules sharing the spend key, e.g. of the same id, must fit the period limit together
+	err = e.assertBundleSpendLimits(ctx, chain, matched, chainEngine, txs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check bundle spend limits: %w", err)
+	}
+
+	validator, ok := chainEngine.(BundleValidator)
+	if ok {
+		err = validator.ValidateBundle(matched, txs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to validate bundle: %w", err)
+		}
+	}
+
+	e.logger.Printf("Bundle of %d txs validated for %s", len(txs), chain.String())
+	return matched, nil
+}
+
+// RecordBundleExecution counts the signed bundle against the rate limit window of the policy instance of the context
+func (e *Engine) RecordBundleExecution(ctx context.Context, policy *types.Policy, txs int) error {
+	return e.recordExecution(ctx, policy, uint32(txs))
+}
+
+// bundleCandidates rejects the tx if it matches a deny rule, otherwise returns indexes of all allow rules matching it
+func (e *Engine) bundleCandidates(
+	ctx context.Context,
+	chain common.Chain,
+	chainEngine ChainEngine,
+	rules []*types.Rule,
+	txBytes []byte,
+) ([]int, error) {
+	for _, rule := range rules {
+		if rule.GetEffect() != types.Effect_EFFECT_DENY {
+			continue
+		}
+
+		er := e.matchRule(ctx, chain, chainEngine, rule, txBytes)
+		if er == nil {
+			e.logger.Printf("Tx denied for %s by rule %s", chain.String(), rule.GetId())
+			return nil, fmt.Errorf("tx denied by rule: id=%s, resource=%s", rule.GetId(), rule.GetResource())
+		}
+		if !compare.IsMismatch(er) {
+			return nil, fmt.Errorf("failed to evaluate deny rule: id=%s, resource=%s: %w", rule.GetId(), rule.GetResource(), er)
+		}
+	}
+
+	var out []int
+	var errs []string
+	for i, rule := range rules {
+		if rule.GetEffect() == types.Effect_EFFECT_DENY {
+			continue
+		}
+
+		er := e.evaluateRule(ctx, chain, chainEngine, rule, txBytes)
+		if er != nil {
+			errs = append(errs, fmt.Sprintf("%s(%s)", rule.GetResource(), er.Error()))
+			continue
+		}
+		out = append(out, i)
+	}
+	if len(out) > 0 {
+		return out, nil
+	}
+	if len(errs) == 0 {
+		return nil, errors.New("no matching rule")
+	}
+	return nil, fmt.Errorf("no matching rule: %s", strings.Join(errs, " "))
+}
+
+// assignDistinctRules picks a distinct rule for every tx out of its candidates,
+// preferring rules in policy order. Returns nil if there is no such assignment
+func assignDistinctRules(candidates [][]int, ruleCount int) []int {
+	// owner[r] is the tx the rule r is assigned to, -1 if unassigned
+	owner := make([]int, ruleCount)
+	for i := range owner {
+		owner[i] = -1
+	}
+
+	// assign takes a free rule, otherwise finds an augmenting path reassigning the owner of a taken one
+	var assign func(tx int, visited []bool) bool
+	assign = func(tx int, visited []bool) bool {
+		for _, r := range candidates[tx] {
+			if owner[r] == -1 {
+				owner[r] = tx
+				return true
+			}
+		}
+		for _, r := range candidates[tx] {
+			if visited[r] {
+				continue
+			}
+			visited[r] = true
+			if assign(owner[r], visited) {
+				owner[r] = tx
+				return true
+			}
+		}
+		return false
+	}
+
+	for tx := range candidates {
+		if !assign(tx, make([]bool, ruleCount)) {
+			return nil
+		}
+	}
+
+	out := make([]int, len(candidates))
+	for r, tx := range owner {
+		if tx != -1 {
+			out[tx] = r
+		}
+	}
+	return out
+}
@@ -0,0 +1,267 @@
+package engine
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+	"github.com/vultisig/vultisig-go/common"
+
+	"github.com/vultisig/recipes/engine/evm"
+	"github.com/vultisig/recipes/sdk/evm/codegen/erc20"
+	"github.com/vultisig/recipes/sdk/evm/codegen/uniswapv2_router"
+	"github.com/vultisig/recipes/types"
+)
+
+const (
+	bundleToken  = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	bundleRouter = "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"
+)
+
+func anyParam(name string) *types.ParameterConstraint {
+	return &types.ParameterConstraint{
+		ParameterName: name,
+		Constraint: &types.Constraint{
+			Type: types.ConstraintType_CONSTRAINT_TYPE_ANY,
+		},
+	}
+}
+
+func bundlePolicy() *types.Policy {
+	return &types.Policy{
+		Id: "bundle",
+		Rules: []*types.Rule{{
+			Id:       "approve",
+			Resource: "ethereum.erc20.approve",
+			Effect:   types.Effect_EFFECT_ALLOW,
+			Target: &types.Target{
+				TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+				Target:     &types.Target_Address{Address: bundleToken},
+			},
+			ParameterConstraints: []*types.ParameterConstraint{
+				anyParam("spender"),
+				anyParam("amount"),
+			},
+		}, {
+			Id:       "swap",
+			Resource: "ethereum.uniswapV2_router.swapExactTokensForTokens",
+			Effect:   types.Effect_EFFECT_ALLOW,
+			Target: &types.Target{
+				TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+				Target:     &types.Target_Address{Address: bundleRouter},
+			},
+			ParameterConstraints: []*types.ParameterConstraint{
+				anyParam("amountIn"),
+				anyParam("amountOutMin"),
+				anyParam("path"),
+				anyParam("to"),
+				anyParam("deadline"),
+			},
+		}},
+	}
+}
+
+func approveTx(nonce uint64, spender string, amount int64) []byte {
+	data := erc20.NewErc20().PackApprove(ecommon.HexToAddress(spender), big.NewInt(amount))
+	return buildUnsignedTxWithNonce(nonce, ecommon.HexToAddress(bundleToken), data, big.NewInt(0))
+}
+
+func swapTx(nonce uint64, amountIn int64) []byte {
+	data := uniswapv2_router.NewUniswapv2Router().PackSwapExactTokensForTokens(
+		big.NewInt(amountIn),
+		big.NewInt(1),
+		[]ecommon.Address{ecommon.HexToAddress(bundleToken), ecommon.HexToAddress(bundleRouter)},
+		ecommon.HexToAddress("0x1111111111111111111111111111111111111111"),
+		big.NewInt(1700000000),
+	)
+	return buildUnsignedTxWithNonce(nonce, ecommon.HexToAddress(bundleRouter), data, big.NewInt(0))
+}
+
+func TestEvaluateBundle(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		policy  func() *types.Policy
+		txs     [][]byte
+		wantIDs []string
+		wantErr string
+	}{
+		{
+			name:    "approve and swap",
+			policy:  bundlePolicy,
+			txs:     [][]byte{approveTx(7, bundleRouter, 1000), swapTx(8, 1000)},
+			wantIDs: []string{"approve", "swap"},
+		},
+		{
+			name:    "approval exceeding swap amount",
+			policy:  bundlePolicy,
+			txs:     [][]byte{approveTx(7, bundleRouter, 5000), swapTx(8, 1000)},
+			wantErr: "approved amount 5000 exceeds swap amount 1000",
+		},
+		{
+			name:    "approval within the slack",
+			opts:    []Option{WithApprovalSlack(50)},
+			policy:  bundlePolicy,
+			txs:     [][]byte{approveTx(7, bundleRouter, 1005), swapTx(8, 1000)},
+			wantIDs: []string{"approve", "swap"},
+		},
+		{
+			name: "swap input set by the option",
+			opts: []Option{WithSwapInput("uniswapV2_router", "swapExactTokensForTokens", evm.SwapInput{
+				Token:  "path[1]",
+				Amount: "amountIn",
+			})},
+			policy:  bundlePolicy,
+			txs:     [][]byte{approveTx(7, bundleRouter, 1000), swapTx(8, 1000)},
+			wantErr: "approved token is not the swap input token",
+		},
+		{
+			name:    "empty bundle",
+			policy:  bundlePolicy,
+			wantErr: "empty bundle",
+		},
+		{
+			name:    "nonce gap",
+			policy:  bundlePolicy,
+			txs:     [][]byte{approveTx(7, bundleRouter, 1000), swapTx(9, 1000)},
+			wantErr: "bundle nonces must be sequential",
+		},
+		{
+			name:    "approval spender is not the swap router",
+			policy:  bundlePolicy,
+			txs:     [][]byte{approveTx(7, "0x2222222222222222222222222222222222222222", 1000), swapTx(8, 1000)},
+			wantErr: "is not the swap target",
+		},
+		{
+			name:    "approval below swap amount",
+			policy:  bundlePolicy,
+			txs:     [][]byte{approveTx(7, bundleRouter, 999), swapTx(8, 1000)},
+			wantErr: "is less than swap amount",
+		},
+		{
+			name:    "approval without swap",
+			policy:  bundlePolicy,
+			txs:     [][]byte{swapTx(7, 1000), approveTx(8, bundleRouter, 1000)},
+			wantErr: "is not followed by a swap tx",
+		},
+		{
+			name:    "two txs for one rule",
+			policy:  bundlePolicy,
+			txs:     [][]byte{swapTx(7, 1000), swapTx(8, 1000)},
+			wantErr: "bundle txs must match distinct rules",
+		},
+		{
+			name: "bundle exceeds max_txs_per_window",
+			policy: func() *types.Policy {
+				p := bundlePolicy()
+				p.RateLimitWindow = uint32Ptr(60)
+				p.MaxTxsPerWindow = uint32Ptr(1)
+				return p
+			},
+			txs:     [][]byte{approveTx(7, bundleRouter, 1000), swapTx(8, 1000)},
+			wantErr: "exceeds max_txs_per_window",
+		},
+		{
+			name: "bundle exceeds max_txs_per_window without the window",
+			policy: func() *types.Policy {
+				p := bundlePolicy()
+				p.MaxTxsPerWindow = uint32Ptr(1)
+				return p
+			},
+			txs:     [][]byte{approveTx(7, bundleRouter, 1000), swapTx(8, 1000)},
+			wantErr: "bundle of 2 txs exceeds max_txs_per_window=1",
+		},
+		{
+			name: "denied tx",
+			policy: func() *types.Policy {
+				p := bundlePolicy()
+				deny := bundlePolicy().GetRules()[1]
+				deny.Id = "no swaps"
+				deny.Effect = types.Effect_EFFECT_DENY
+				p.Rules = append(p.Rules, deny)
+				return p
+			},
+			txs:     [][]byte{approveTx(7, bundleRouter, 1000), swapTx(8, 1000)},
+			wantErr: "tx denied by rule: id=no swaps",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, err := NewEngine(tt.opts...)
+			require.NoError(t, err)
+
+			ctx := WithPolicyInstance(context.Background(), "vault-1")
+			rules, err := engine.EvaluateBundleContext(ctx, tt.policy(), common.Ethereum, tt.txs)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var ids []string
+			for _, rule := range rules {
+				ids = append(ids, rule.GetId())
+			}
+			require.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func TestEvaluateBundle_PeriodSpendLimit(t *testing.T) {
+	engine, err := NewEngine()
+	require.NoError(t, err)
+
+	recipient := ecommon.HexToAddress("0xcf0475d9B0a29975bc5132A3066010eC898d8CaB")
+	transferRule := func() *types.Rule {
+		return &types.Rule{
+			Id:       "eth transfer",
+			Resource: "ethereum.eth.transfer",
+			Effect:   types.Effect_EFFECT_ALLOW,
+			Target: &types.Target{
+				TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+				Target: &types.Target_Address{
+					Address: recipient.Hex(),
+				},
+			},
+			ParameterConstraints: []*types.ParameterConstraint{{
+				ParameterName: "amount",
+				Constraint: &types.Constraint{
+					Type:          types.ConstraintType_CONSTRAINT_TYPE_MAX,
+					Value:         &types.Constraint_MaxValue{MaxValue: "1000000000000000000"},
+					Period:        "day",
+					DenominatedIn: "wei",
+				},
+			}},
+		}
+	}
+	// both rules count against the same daily budget
+	policy := &types.Policy{
+		Id:    "spend-limit-policy",
+		Rules: []*types.Rule{transferRule(), transferRule()},
+	}
+	ctx := WithPolicyInstance(context.Background(), "vault-1")
+
+	// 0.6 ETH each
+	amount := big.NewInt(600_000_000_000_000_000)
+	txs := [][]byte{
+		buildUnsignedTxWithNonce(7, recipient, nil, amount),
+		buildUnsignedTxWithNonce(8, recipient, nil, amount),
+	}
+	_, err = engine.EvaluateBundleContext(ctx, policy, common.Ethereum, txs)
+	require.ErrorContains(t, err, "period limit exceeded")
+
+	// 0.4 ETH fits the budget together with 0.6 ETH
+	txs[1] = buildUnsignedTxWithNonce(8, recipient, nil, big.NewInt(400_000_000_000_000_000))
+	_, err = engine.EvaluateBundleContext(ctx, policy, common.Ethereum, txs)
+	require.NoError(t, err)
+}
+
+func TestAssignDistinctRules(t *testing.T) {
+	// the first tx would take rule 0 greedily, it must move to rule 1 to free rule 0 for the second tx
+	require.Equal(t, []int{1, 0}, assignDistinctRules([][]int{{0, 1}, {0}}, 2))
+	require.Equal(t, []int{0, 1}, assignDistinctRules([][]int{{0, 1}, {0, 1}}, 2))
+	require.Nil(t, assignDistinctRules([][]int{{0}, {0}}, 1))
+}
@@ -1,6 +1,7 @@
 package compare
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 
@@ -33,133 +34,206 @@ func (f *Falsy[T]) Max(_ T) bool {
 	return false
 }
 
+func newConstraintError(constraint *types.ParameterConstraint, expected string, actual any, reason string) error {
+	return &ConstraintError{
+		Parameter: constraint.GetParameterName(),
+		Type:      constraint.GetConstraint().GetType().String(),
+		Expected:  expected,
+		Actual:    fmt.Sprintf("%v", actual),
+		Reason:    reason,
+	}
+}
+
+// AssertArg checks the actual value against every constraint of the named parameter,
+// constraints of the same parameter are combined with AND
 func AssertArg[T any](
+	ctx context.Context,
+	registry *resolver.MagicConstantRegistry,
 	chain string,
 	expectedList []*types.ParameterConstraint,
 	expectedName string,
 	actual T,
 	makeComparer Constructor[T],
+) error {
+	found := false
+	for _, constraint := range expectedList {
+		if constraint.GetParameterName() != expectedName {
+			continue
+		}
+		found = true
+
+		err := AssertConstraint(ctx, registry, chain, constraint, actual, makeComparer)
+		if err != nil {
+			return err
+		}
+	}
+	if !found {
+		return fmt.Errorf("arg not found: %s", expectedName)
+	}
+	return nil
+}
+
+// AssertConstraint checks the actual value against a single parameter constraint
+func AssertConstraint[T any](
+	ctx context.Context,
+	registry *resolver.MagicConstantRegistry,
+	chain string,
+	constraint *types.ParameterConstraint,
+	actual T,
+	makeComparer Constructor[T],
 ) error {
 	const magicAssetIdDefault = "default"
 
-	for _, constraint := range expectedList {
-		if constraint.GetParameterName() == expectedName {
-			kind := constraint.GetConstraint().GetType()
-
-			switch kind {
-			case types.ConstraintType_CONSTRAINT_TYPE_ANY:
-				return nil
-
-			case types.ConstraintType_CONSTRAINT_TYPE_FIXED:
-				comparer, err := makeComparer(constraint.GetConstraint().GetFixedValue())
-				if err != nil {
-					return fmt.Errorf(
-						"failed to build exact fixed type from constraint: %s",
-						constraint.GetConstraint().GetFixedValue(),
-					)
-				}
-				if comparer.Fixed(actual) {
-					return nil
-				}
-				return fmt.Errorf(
-					"failed to compare fixed values: expected=%v, actual=%v",
-					constraint.GetConstraint().GetFixedValue(),
-					actual,
-				)
-
-			case types.ConstraintType_CONSTRAINT_TYPE_MIN:
-				comparer, err := makeComparer(constraint.GetConstraint().GetMinValue())
-				if err != nil {
-					return fmt.Errorf(
-						"failed to build exact min type from constraint: %s",
-						constraint.GetConstraint().GetMinValue(),
-					)
-				}
-				if comparer.Min(actual) {
-					return nil
-				}
-				return fmt.Errorf(
-					"failed to compare min values: expected=%v, actual=%v",
-					constraint.GetConstraint().GetMinValue(),
-					actual,
-				)
-
-			case types.ConstraintType_CONSTRAINT_TYPE_MAX:
-				comparer, err := makeComparer(constraint.GetConstraint().GetMaxValue())
-				if err != nil {
-					return fmt.Errorf(
-						"failed to build exact max type from constraint: %s",
-						constraint.GetConstraint().GetMaxValue(),
-					)
-				}
-				if comparer.Max(actual) {
-					return nil
-				}
-				return fmt.Errorf(
-					"failed to compare max values: expected=%v, actual=%v",
-					constraint.GetConstraint().GetMaxValue(),
-					actual,
-				)
-
-			case types.ConstraintType_CONSTRAINT_TYPE_MAGIC_CONSTANT:
-				resolve, err := resolver.NewMagicConstantRegistry().GetResolver(
-					constraint.GetConstraint().GetMagicConstantValue(),
-				)
-				if err != nil {
-					return fmt.Errorf(
-						"failed to get magic const resolver: magic_const=%s",
-						constraint.GetConstraint().GetMagicConstantValue().String(),
-					)
-				}
-
-				resolvedAddr, _, err := resolve.Resolve(
-					constraint.GetConstraint().GetMagicConstantValue(),
-					chain,
-					magicAssetIdDefault,
-				)
-				if err != nil {
-					return fmt.Errorf(
-						"failed to resolve magic const: magic_const=%s",
-						constraint.GetConstraint().GetMagicConstantValue().String(),
-					)
-				}
-
-				comparer, err := makeComparer(resolvedAddr)
-				if err != nil {
-					return fmt.Errorf(
-						"failed to build exact type from magic_const: resolved=%s",
-						resolvedAddr,
-					)
-				}
-				if comparer.Fixed(actual) {
-					return nil
-				}
-				return fmt.Errorf(
-					"failed to compare magic values: expected(resolved magic addr)=%v, actual(in tx)=%v",
-					resolvedAddr,
-					actual,
-				)
-
-			case types.ConstraintType_CONSTRAINT_TYPE_REGEXP:
-				strVal := fmt.Sprintf("%v", actual)
-				ok, err := regexp.MatchString(
-					constraint.GetConstraint().GetRegexpValue(),
-					strVal,
-				)
-				if err != nil {
-					return fmt.Errorf("regexp match failed: expected=%v, actual=%v",
-						constraint.GetConstraint().GetRegexpValue(), actual)
-				}
-				if ok {
-					return nil
-				}
-				return fmt.Errorf("regexp value constraint failed: expected=%v, actual=%v",
-					constraint.GetConstraint().GetRegexpValue(), actual)
-
-			default:
-				return fmt.Errorf("unknown constraint type: %s", constraint.GetConstraint().GetType())
-			}
+	kind := constraint.GetConstraint().GetType()
+
+	switch kind {
+	case types.ConstraintType_CONSTRAINT_TYPE_ANY:
+		return nil
+
+	case types.ConstraintType_CONSTRAINT_TYPE_FIXED:
+		comparer, err := makeComparer(constraint.GetConstraint().GetFixedValue())
+		if err != nil {
+			return fmt.Errorf(
+				"failed to build exact fixed type from constraint: %s",
+				constraint.GetConstraint().GetFixedValue(),
+			)
+		}
+		if comparer.Fixed(actual) {
+			return nil
+		}
+		return newConstraintError(
+			constraint,
+			constraint.GetConstraint().GetFixedValue(),
+			actual,
+			fmt.Sprintf(
+				"failed to compare fixed values: expected=%v, actual=%v",
+				constraint.GetConstraint().GetFixedValue(),
+				actual,
+			),
+		)
+
+	case types.ConstraintType_CONSTRAINT_TYPE_MIN:
+		comparer, err := makeComparer(constraint.GetConstraint().GetMinValue())
+		if err != nil {
+			return fmt.Errorf(
+				"failed to build exact min type from constraint: %s",
+				constraint.GetConstraint().GetMinValue(),
+			)
+		}
+		if comparer.Min(actual) {
+			return nil
+		}
+		return newConstraintError(
+			constraint,
+			constraint.GetConstraint().GetMinValue(),
+			actual,
+			fmt.Sprintf(
+				"failed to compare min values: expected=%v, actual=%v",
+				constraint.GetConstraint().GetMinValue(),
+				actual,
+			),
+		)
+
+	case types.ConstraintType_CONSTRAINT_TYPE_MAX:
+		comparer, err := makeComparer(constraint.GetConstraint().GetMaxValue())
+		if err != nil {
+			return fmt.Errorf(
+				"failed to build exact max type from constraint: %s",
+				constraint.GetConstraint().GetMaxValue(),
+			)
+		}
+		if comparer.Max(actual) {
+			return nil
+		}
+		return newConstraintError(
+			constraint,
+			constraint.GetConstraint().GetMaxValue(),
+			actual,
+			fmt.Sprintf(
+				"failed to compare max values: expected=%v, actual=%v",
+				constraint.GetConstraint().GetMaxValue(),
+				actual,
+			),
+		)
+
+	case types.ConstraintType_CONSTRAINT_TYPE_MAGIC_CONSTANT:
+		resolve, err := registry.GetResolver(
+			constraint.GetConstraint().GetMagicConstantValue(),
+		)
+		if err != nil {
+			return fmt.Errorf(
+				"failed to get magic const resolver: magic_const=%s",
+				constraint.GetConstraint().GetMagicConstantValue().String(),
+			)
+		}
+
+		resolvedAddr, _, err := resolve.Resolve(
+			ctx,
+			constraint.GetConstraint().GetMagicConstantValue(),
+			chain,
+			magicAssetIdDefault,
+		)
+		if err != nil {
+			return fmt.Errorf(
+				"failed to resolve magic const: magic_const=%s",
+				constraint.GetConstraint().GetMagicConstantValue().String(),
+			)
+		}
+
+		comparer, err := makeComparer(resolvedAddr)
+		if err != nil {
+			return fmt.Errorf(
+				"failed to build exact type from magic_const: resolved=%s",
+				resolvedAddr,
+			)
+		}
+		if comparer.Fixed(actual) {
+			return nil
+		}
+		return newConstraintError(
+			constraint,
+			resolvedAddr,
+			actual,
+			fmt.Sprintf(
+				"failed to compare magic values: expected(resolved magic addr)=%v, actual(in tx)=%v",
+				resolvedAddr,
+				actual,
+			),
+		)
+
+	case types.ConstraintType_CONSTRAINT_TYPE_REGEXP:
+		strVal := fmt.Sprintf("%v", actual)
+		ok, err := regexp.MatchString(
+			constraint.GetConstraint().GetRegexpValue(),
+			strVal,
+		)
+		if err != nil {
+			return fmt.Errorf("regexp match failed: expected=%v, actual=%v",
+				constraint.GetConstraint().GetRegexpValue(), actual)
+		}
+		if ok {
+			return nil
 		}
+		return newConstraintError(
+			constraint,
+			constraint.GetConstraint().GetRegexpValue(),
+			actual,
+			fmt.Sprintf("regexp value constraint failed: expected=%v, actual=%v",
+				constraint.GetConstraint().GetRegexpValue(), actual),
+		)
+
+	case types.ConstraintType_CONSTRAINT_TYPE_RANGE:
+		return assertRange(constraint, actual, makeComparer)
+
+	case types.ConstraintType_CONSTRAINT_TYPE_IN_SET, types.ConstraintType_CONSTRAINT_TYPE_NOT_IN_SET:
+		return assertSet(constraint, actual, makeComparer)
+
+	case types.ConstraintType_CONSTRAINT_TYPE_ALL_OF,
+		types.ConstraintType_CONSTRAINT_TYPE_ANY_OF,
+		types.ConstraintType_CONSTRAINT_TYPE_NOT:
+		return assertComposite(ctx, registry, chain, constraint, actual, makeComparer)
+
+	default:
+		return fmt.Errorf("unknown constraint type: %s", constraint.GetConstraint().GetType())
 	}
-	return fmt.Errorf("arg not found: %s", expectedName)
 }
@@ -0,0 +1,150 @@
+package compare
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vultisig/recipes/resolver"
+	"github.com/vultisig/recipes/types"
+)
+
+func assertRange[T any](
+	constraint *types.ParameterConstraint,
+	actual T,
+	makeComparer Constructor[T],
+) error {
+	rng := constraint.GetConstraint().GetRangeValue()
+	if rng == nil || rng.GetMin() == "" || rng.GetMax() == "" {
+		return fmt.Errorf("range constraint requires both min and max: parameter=%s", constraint.GetParameterName())
+	}
+	expected := fmt.Sprintf("[%s, %s]", rng.GetMin(), rng.GetMax())
+
+	minComparer, err := makeComparer(rng.GetMin())
+	if err != nil {
+		return fmt.Errorf("failed to build range min type from constraint: %s", rng.GetMin())
+	}
+	maxComparer, err := makeComparer(rng.GetMax())
+	if err != nil {
+		return fmt.Errorf("failed to build range max type from constraint: %s", rng.GetMax())
+	}
+	if minComparer.Min(actual) && maxComparer.Max(actual) {
+		return nil
+	}
+	return newConstraintError(
+		constraint,
+		expected,
+		actual,
+		fmt.Sprintf("failed to compare range values: expected=%s, actual=%v", expected, actual),
+	)
+}
+
+func assertSet[T any](
+	constraint *types.ParameterConstraint,
+	actual T,
+	makeComparer Constructor[T],
+) error {
+	values := constraint.GetConstraint().GetSetValue().GetValues()
+	if len(values) == 0 {
+		return fmt.Errorf("set constraint requires at least one value: parameter=%s", constraint.GetParameterName())
+	}
+	expected := "[" + strings.Join(values, ", ") + "]"
+
+	found := false
+	for _, value := range values {
+		comparer, err := makeComparer(value)
+		if err != nil {
+			return fmt.Errorf("failed to build exact set type from constraint: %s", value)
+		}
+		if comparer.Fixed(actual) {
+			found = true
+			break
+		}
+	}
+
+	isIn := constraint.GetConstraint().GetType() == types.ConstraintType_CONSTRAINT_TYPE_IN_SET
+	if found == isIn {
+		return nil
+	}
+	if isIn {
+		return newConstraintError(
+			constraint,
+			expected,
+			actual,
+			fmt.Sprintf("value not in set: expected one of=%s, actual=%v", expected, actual),
+		)
+	}
+	return newConstraintError(
+		constraint,
+		expected,
+		actual,
+		fmt.Sprintf("value in denied set: expected none of=%s, actual=%v", expected, actual),
+	)
+}
+
+func assertComposite[T any](
+	ctx context.Context,
+	registry *resolver.MagicConstantRegistry,
+	chain string,
+	constraint *types.ParameterConstraint,
+	actual T,
+	makeComparer Constructor[T],
+) error {
+	kind := constraint.GetConstraint().GetType()
+	nested := constraint.GetConstraint().GetCompositeValue().GetConstraints()
+	if len(nested) == 0 {
+		return fmt.Errorf("%s constraint requires nested constraints: parameter=%s",
+			kind.String(), constraint.GetParameterName())
+	}
+	if kind == types.ConstraintType_CONSTRAINT_TYPE_NOT && len(nested) != 1 {
+		return fmt.Errorf("not constraint requires exactly one nested constraint: parameter=%s, got=%d",
+			constraint.GetParameterName(), len(nested))
+	}
+
+	var violations []string
+	for _, c := range nested {
+		err := AssertConstraint(ctx, registry, chain, &types.ParameterConstraint{
+			ParameterName: constraint.GetParameterName(),
+			Constraint:    c,
+		}, actual, makeComparer)
+		if err != nil {
+			var violation *ConstraintError
+			if !errors.As(err, &violation) {
+				// misconfigured constraint, must not be treated as a mismatch
+				return err
+			}
+			if kind == types.ConstraintType_CONSTRAINT_TYPE_ALL_OF {
+				return err
+			}
+			violations = append(violations, violation.Reason)
+			continue
+		}
+
+		switch kind {
+		case types.ConstraintType_CONSTRAINT_TYPE_ANY_OF:
+			return nil
+		case types.ConstraintType_CONSTRAINT_TYPE_NOT:
+			return newConstraintError(
+				constraint,
+				"not "+c.GetType().String(),
+				actual,
+				fmt.Sprintf("negated constraint matched: constraint=%s, actual=%v", c.GetType().String(), actual),
+			)
+		}
+	}
+
+	switch kind {
+	case types.ConstraintType_CONSTRAINT_TYPE_ANY_OF:
+		return newConstraintError(
+			constraint,
+			"any of "+strconv.Itoa(len(nested))+" constraints",
+			actual,
+			fmt.Sprintf("no constraint matched: %s", strings.Join(violations, "; ")),
+		)
+	default:
+		// all_of passed every constraint, not failed its only constraint
+		return nil
+	}
+}
@@ -0,0 +1,200 @@
+package compare
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/vultisig/recipes/resolver"
+	"github.com/vultisig/recipes/types"
+)
+
+func fixed(v string) *types.Constraint {
+	return &types.Constraint{
+		Type:  types.ConstraintType_CONSTRAINT_TYPE_FIXED,
+		Value: &types.Constraint_FixedValue{FixedValue: v},
+	}
+}
+
+func set(kind types.ConstraintType, values ...string) *types.Constraint {
+	return &types.Constraint{
+		Type:  kind,
+		Value: &types.Constraint_SetValue{SetValue: &types.SetValue{Values: values}},
+	}
+}
+
+func rng(min, max string) *types.Constraint {
+	return &types.Constraint{
+		Type:  types.ConstraintType_CONSTRAINT_TYPE_RANGE,
+		Value: &types.Constraint_RangeValue{RangeValue: &types.RangeValue{Min: min, Max: max}},
+	}
+}
+
+func composite(kind types.ConstraintType, nested ...*types.Constraint) *types.Constraint {
+	return &types.Constraint{
+		Type:  kind,
+		Value: &types.Constraint_CompositeValue{CompositeValue: &types.CompositeValue{Constraints: nested}},
+	}
+}
+
+func TestAssertArg_Composite_BigInt(t *testing.T) {
+	tests := []struct {
+		name       string
+		constraint *types.Constraint
+		actual     int64
+		wantErr    bool
+	}{
+		{name: "range inside", constraint: rng("10", "100"), actual: 50},
+		{name: "range lower bound inclusive", constraint: rng("10", "100"), actual: 10},
+		{name: "range upper bound inclusive", constraint: rng("10", "100"), actual: 100},
+		{name: "range below", constraint: rng("10", "100"), actual: 9, wantErr: true},
+		{name: "range above", constraint: rng("10", "100"), actual: 101, wantErr: true},
+		{
+			name: "all_of min and max",
+			constraint: composite(types.ConstraintType_CONSTRAINT_TYPE_ALL_OF, &types.Constraint{
+				Type:  types.ConstraintType_CONSTRAINT_TYPE_MIN,
+				Value: &types.Constraint_MinValue{MinValue: "10"},
+			}, &types.Constraint{
+				Type:  types.ConstraintType_CONSTRAINT_TYPE_MAX,
+				Value: &types.Constraint_MaxValue{MaxValue: "100"},
+			}),
+			actual:  101,
+			wantErr: true,
+		},
+		{
+			name:       "any_of second matches",
+			constraint: composite(types.ConstraintType_CONSTRAINT_TYPE_ANY_OF, fixed("1"), rng("10", "100")),
+			actual:     20,
+		},
+		{
+			name:       "any_of none matches",
+			constraint: composite(types.ConstraintType_CONSTRAINT_TYPE_ANY_OF, fixed("1"), rng("10", "100")),
+			actual:     5,
+			wantErr:    true,
+		},
+		{
+			name:       "not range",
+			constraint: composite(types.ConstraintType_CONSTRAINT_TYPE_NOT, rng("10", "100")),
+			actual:     5,
+		},
+		{
+			name:       "not range violated",
+			constraint: composite(types.ConstraintType_CONSTRAINT_TYPE_NOT, rng("10", "100")),
+			actual:     50,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := AssertArg(context.Background(), resolver.NewMagicConstantRegistry(), "ethereum", []*types.ParameterConstraint{{
+				ParameterName: "amount",
+				Constraint:    tt.constraint,
+			}}, "amount", big.NewInt(tt.actual), NewBigInt)
+			if !tt.wantErr {
+				require.NoError(t, err)
+				return
+			}
+
+			var violation *ConstraintError
+			require.True(t, errors.As(err, &violation), "expected constraint violation, got: %v", err)
+			require.Equal(t, "amount", violation.Parameter)
+		})
+	}
+}
+
+func TestAssertArg_Set_String(t *testing.T) {
+	allowlist := set(types.ConstraintType_CONSTRAINT_TYPE_IN_SET, "alice", "bob", "carol")
+	denylist := set(types.ConstraintType_CONSTRAINT_TYPE_NOT_IN_SET, "mallory")
+
+	assert := func(c *types.Constraint, actual string) error {
+		return AssertArg(context.Background(), resolver.NewMagicConstantRegistry(), "ethereum", []*types.ParameterConstraint{{
+			ParameterName: "recipient",
+			Constraint:    c,
+		}}, "recipient", actual, NewString)
+	}
+
+	require.NoError(t, assert(allowlist, "bob"))
+	require.ErrorContains(t, assert(allowlist, "mallory"), "value not in set")
+	require.NoError(t, assert(denylist, "bob"))
+	require.ErrorContains(t, assert(denylist, "mallory"), "value in denied set")
+
+	both := composite(types.ConstraintType_CONSTRAINT_TYPE_ALL_OF, allowlist, denylist)
+	require.NoError(t, assert(both, "alice"))
+}
+
+func TestAssertArg_Composite_Misconfigured(t *testing.T) {
+	assert := func(c *types.Constraint) error {
+		return AssertArg(context.Background(), resolver.NewMagicConstantRegistry(), "ethereum", []*types.ParameterConstraint{{
+			ParameterName: "amount",
+			Constraint:    c,
+		}}, "amount", big.NewInt(1), NewBigInt)
+	}
+
+	var violation *ConstraintError
+	err := assert(rng("10", ""))
+	require.Error(t, err)
+	require.False(t, errors.As(err, &violation))
+
+	err = assert(set(types.ConstraintType_CONSTRAINT_TYPE_IN_SET))
+	require.Error(t, err)
+	require.False(t, errors.As(err, &violation))
+
+	err = assert(composite(types.ConstraintType_CONSTRAINT_TYPE_NOT, fixed("1"), fixed("2")))
+	require.Error(t, err)
+	require.False(t, errors.As(err, &violation))
+
+	// misconfigured nested constraint must not be negated into a pass
+	err = assert(composite(types.ConstraintType_CONSTRAINT_TYPE_NOT, rng("", "10")))
+	require.Error(t, err)
+	require.False(t, errors.As(err, &violation))
+}
+
+func TestAssertArg_EveryConstraint(t *testing.T) {
+	constraints := []*types.ParameterConstraint{
+		{
+			ParameterName: "amount",
+			Constraint: &types.Constraint{
+				Type:  types.ConstraintType_CONSTRAINT_TYPE_MIN,
+				Value: &types.Constraint_MinValue{MinValue: "10"},
+			},
+		},
+		{
+			ParameterName: "amount",
+			Constraint: &types.Constraint{
+				Type:  types.ConstraintType_CONSTRAINT_TYPE_MAX,
+				Value: &types.Constraint_MaxValue{MaxValue: "100"},
+			},
+		},
+	}
+	assert := func(actual int64) error {
+		return AssertArg(context.Background(), resolver.NewMagicConstantRegistry(), "ethereum",
+			constraints, "amount", big.NewInt(actual), NewBigInt)
+	}
+
+	require.NoError(t, assert(50))
+	require.ErrorContains(t, assert(5), "failed to compare min values")
+	require.ErrorContains(t, assert(500), "failed to compare max values")
+	require.ErrorContains(t, AssertArg(context.Background(), resolver.NewMagicConstantRegistry(), "ethereum",
+		constraints, "recipient", "alice", NewString), "arg not found")
+}
+
+func TestConstraint_CompositeJSON(t *testing.T) {
+	c := composite(types.ConstraintType_CONSTRAINT_TYPE_ANY_OF,
+		set(types.ConstraintType_CONSTRAINT_TYPE_IN_SET, "a", "b"),
+		rng("1", "2"),
+	)
+
+	raw, err := protojson.Marshal(c)
+	require.NoError(t, err)
+
+	var decoded types.Constraint
+	require.NoError(t, protojson.Unmarshal(raw, &decoded))
+	require.Equal(t, []string{"a", "b"},
+		decoded.GetCompositeValue().GetConstraints()[0].GetSetValue().GetValues())
+	require.Equal(t, "2", decoded.GetCompositeValue().GetConstraints()[1].GetRangeValue().GetMax())
+}
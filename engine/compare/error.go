@@ -0,0 +1,44 @@
+package compare
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ConstraintError is returned when the tx value doesn't satisfy the parameter constraint.
+// Chain engines wrap it, use errors.As to get expected and actual values.
+type ConstraintError struct {
+	Parameter string `json:"parameter"`
+	Type      string `json:"type"`
+	Expected  string `json:"expected"`
+	Actual    string `json:"actual"`
+	Reason    string `json:"reason"`
+}
+
+func (e *ConstraintError) Error() string {
+	return e.Reason
+}
+
+// MismatchError is returned when the tx doesn't match the rule resource, e.g. it calls another
+// function or pays to another target. Like ConstraintError it means the rule was evaluated
+// and doesn't apply, unlike decoding or magic constant resolution errors
+type MismatchError struct {
+	Reason string `json:"reason"`
+}
+
+func (e *MismatchError) Error() string {
+	return e.Reason
+}
+
+// NewMismatchError formats the reason of the mismatch
+func NewMismatchError(format string, args ...any) error {
+	return &MismatchError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// IsMismatch reports whether the error means the tx doesn't match the rule, as opposed to
+// the rule which can't be evaluated against the tx
+func IsMismatch(err error) bool {
+	var constraintErr *ConstraintError
+	var mismatchErr *MismatchError
+	return errors.As(err, &constraintErr) || errors.As(err, &mismatchErr)
+}
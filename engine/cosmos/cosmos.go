@@ -2,6 +2,7 @@
 package cosmos
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"math/big"
@@ -42,8 +43,9 @@ type Config struct {
 
 // Engine is a generic Cosmos engine that can be configured for different chains.
 type Engine struct {
-	config Config
-	cdc    codec.Codec
+	config    Config
+	cdc       codec.Codec
+	resolvers *resolver.MagicConstantRegistry
 }
 
 // NewEngine creates a new Cosmos engine with the given configuration.
@@ -62,11 +64,17 @@ func NewEngine(config Config) *Engine {
 	}
 
 	return &Engine{
-		config: config,
-		cdc:    codec.NewProtoCodec(ir),
+		config:    config,
+		cdc:       codec.NewProtoCodec(ir),
+		resolvers: resolver.NewMagicConstantRegistry(),
 	}
 }
 
+// SetMagicConstantRegistry sets the registry used to resolve magic constants
+func (e *Engine) SetMagicConstantRegistry(registry *resolver.MagicConstantRegistry) {
+	e.resolvers = registry
+}
+
 // Supports returns true if this engine supports the given chain.
 func (e *Engine) Supports(chain common.Chain) bool {
 	for _, c := range e.config.SupportedChains {
@@ -78,11 +86,16 @@ func (e *Engine) Supports(chain common.Chain) bool {
 }
 
 // Evaluate validates a Cosmos transaction against policy rules.
-func (e *Engine) Evaluate(rule *types.Rule, txBytes []byte) error {
+func (e *Engine) Evaluate(ctx context.Context, rule *types.Rule, txBytes []byte) error {
 	if rule.GetEffect().String() != types.Effect_EFFECT_ALLOW.String() {
 		return fmt.Errorf("only allow rules supported, got: %s", rule.GetEffect().String())
 	}
+	return e.Match(ctx, rule, txBytes)
+}
 
+// Match validates the transaction message against the rule, ignoring the rule
+// effect. A nil error means the rule matches the transaction.
+func (e *Engine) Match(ctx context.Context, rule *types.Rule, txBytes []byte) error {
 	r, err := util.ParseResource(rule.GetResource())
 	if err != nil {
 		return fmt.Errorf("failed to parse rule resource: %w", err)
@@ -111,17 +124,43 @@ func (e *Engine) Evaluate(rule *types.Rule, txBytes []byte) error {
 		return err
 	}
 
-	if err := e.validateTarget(r, rule.GetTarget(), txData, mt); err != nil {
+	if err := e.validateTarget(ctx, r, rule.GetTarget(), txData, mt); err != nil {
 		return fmt.Errorf("failed to validate target: %w", err)
 	}
 
-	if err := e.validateParameterConstraints(r, rule.GetParameterConstraints(), txData, mt); err != nil {
+	if err := e.validateParameterConstraints(ctx, r, rule.GetParameterConstraints(), txData, mt); err != nil {
 		return fmt.Errorf("failed to validate parameter constraints: %w", err)
 	}
 
 	return nil
 }
 
+// ParameterValue returns the numeric value of the named rule parameter in the transaction.
+func (e *Engine) ParameterValue(_ *types.Rule, txBytes []byte, name string) (*big.Int, error) {
+	txData, err := e.parseTransaction(txBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s transaction: %w", e.config.ChainID, err)
+	}
+	if txData.Body == nil || len(txData.Body.Messages) != 1 {
+		return nil, fmt.Errorf("only single-message transactions supported")
+	}
+
+	mt, err := e.detectMessageType(txData.Body.Messages[0])
+	if err != nil {
+		return nil, fmt.Errorf("unsupported message type: %w", err)
+	}
+
+	value, err := e.extractParameterValue(name, txData, mt)
+	if err != nil {
+		return nil, err
+	}
+	amount, ok := value.(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("parameter %s is not numeric: %T", name, value)
+	}
+	return amount, nil
+}
+
 // parseTransaction parses Cosmos transaction bytes into a Cosmos SDK transaction.
 func (e *Engine) parseTransaction(txBytes []byte) (*tx.Tx, error) {
 	const maxTxBytes = 32 * 1024 // 32 KB
@@ -163,7 +202,7 @@ func (e *Engine) ensureResourceMessageCompatibility(resource *types.ResourcePath
 	}
 
 	if mt != expectedMT {
-		return fmt.Errorf("resource %s.%s only allows %s, got %s",
+		return compare.NewMismatchError("resource %s.%s only allows %s, got %s",
 			resource.GetProtocolId(), resource.GetFunctionId(), expectedMT, mt)
 	}
 	return nil
@@ -209,7 +248,7 @@ func (e *Engine) unpackMsgDeposit(msg *codectypes.Any) (*types.MsgDeposit, error
 
 
 // validateTarget validates the transaction target against the rule target.
-func (e *Engine) validateTarget(resource *types.ResourcePath, target *types.Target, txData *tx.Tx, mt cosmos.MessageType) error {
+func (e *Engine) validateTarget(ctx context.Context, resource *types.ResourcePath, target *types.Target, txData *tx.Tx, mt cosmos.MessageType) error {
 	if target == nil || target.GetTargetType() == types.TargetType_TARGET_TYPE_UNSPECIFIED {
 		return nil
 	}
@@ -236,12 +275,12 @@ func (e *Engine) validateTarget(resource *types.ResourcePath, target *types.Targ
 			return fmt.Errorf("target address cannot be empty")
 		}
 		if msgSend.ToAddress != expectedAddress {
-			return fmt.Errorf("target address mismatch: expected=%s, actual=%s",
+			return compare.NewMismatchError("target address mismatch: expected=%s, actual=%s",
 				expectedAddress, msgSend.ToAddress)
 		}
 
 	case types.TargetType_TARGET_TYPE_MAGIC_CONSTANT:
-		resolve, err := resolver.NewMagicConstantRegistry().GetResolver(target.GetMagicConstant())
+		resolve, err := e.resolvers.GetResolver(target.GetMagicConstant())
 		if err != nil {
 			return fmt.Errorf(
 				"failed to get resolver: magic_const=%s",
@@ -250,6 +289,7 @@ func (e *Engine) validateTarget(resource *types.ResourcePath, target *types.Targ
 		}
 
 		resolvedAddr, _, err := resolve.Resolve(
+			ctx,
 			target.GetMagicConstant(),
 			resource.ChainId,
 			"default",
@@ -263,7 +303,7 @@ func (e *Engine) validateTarget(resource *types.ResourcePath, target *types.Targ
 		}
 
 		if msgSend.ToAddress != resolvedAddr {
-			return fmt.Errorf(
+			return compare.NewMismatchError(
 				"tx target is wrong: tx_to=%s, rule_magic_const_resolved=%s",
 				msgSend.ToAddress,
 				resolvedAddr,
@@ -278,7 +318,7 @@ func (e *Engine) validateTarget(resource *types.ResourcePath, target *types.Targ
 }
 
 // validateParameterConstraints validates all parameter constraints.
-func (e *Engine) validateParameterConstraints(resource *types.ResourcePath, constraints []*types.ParameterConstraint, txData *tx.Tx, mt cosmos.MessageType) error {
+func (e *Engine) validateParameterConstraints(ctx context.Context, resource *types.ResourcePath, constraints []*types.ParameterConstraint, txData *tx.Tx, mt cosmos.MessageType) error {
 	for _, constraint := range constraints {
 		paramName := constraint.GetParameterName()
 
@@ -287,7 +327,7 @@ func (e *Engine) validateParameterConstraints(resource *types.ResourcePath, cons
 			return fmt.Errorf("failed to extract parameter %s: %w", paramName, err)
 		}
 
-		if err := e.assertArgsByType(resource.ChainId, paramName, value, constraints); err != nil {
+		if err := e.assertArgsByType(ctx, resource.ChainId, paramName, value, constraints); err != nil {
 			return fmt.Errorf("constraint validation failed for parameter %s: %w", paramName, err)
 		}
 	}
@@ -387,10 +427,12 @@ func (e *Engine) extractParameterFromMsgDeposit(paramName string, msgDeposit *ty
 }
 
 // assertArgsByType validates constraints using the appropriate comparator based on Go type.
-func (e *Engine) assertArgsByType(chainId, inputName string, arg any, constraints []*types.ParameterConstraint) error {
+func (e *Engine) assertArgsByType(ctx context.Context, chainId, inputName string, arg any, constraints []*types.ParameterConstraint) error {
 	switch actual := arg.(type) {
 	case string:
 		err := compare.AssertArg(
+			ctx,
+			e.resolvers,
 			chainId,
 			constraints,
 			inputName,
@@ -403,6 +445,8 @@ func (e *Engine) assertArgsByType(chainId, inputName string, arg any, constraint
 
 	case *big.Int:
 		err := compare.AssertArg(
+			ctx,
+			e.resolvers,
 			chainId,
 			constraints,
 			inputName,
@@ -1,8 +1,12 @@
 package gaia
 
 import (
+	"context"
+	"math/big"
+
 	"github.com/vultisig/recipes/chain/cosmos"
 	cosmosengine "github.com/vultisig/recipes/engine/cosmos"
+	"github.com/vultisig/recipes/resolver"
 	"github.com/vultisig/recipes/types"
 	"github.com/vultisig/vultisig-go/common"
 )
@@ -35,8 +39,23 @@ func (g *Gaia) Supports(chain common.Chain) bool {
 }
 
 // Evaluate validates a transaction against the given rule.
-func (g *Gaia) Evaluate(rule *types.Rule, txBytes []byte) error {
-	return g.engine.Evaluate(rule, txBytes)
+func (g *Gaia) Evaluate(ctx context.Context, rule *types.Rule, txBytes []byte) error {
+	return g.engine.Evaluate(ctx, rule, txBytes)
+}
+
+// Match checks the transaction against the given rule regardless of its effect.
+func (g *Gaia) Match(ctx context.Context, rule *types.Rule, txBytes []byte) error {
+	return g.engine.Match(ctx, rule, txBytes)
+}
+
+// ParameterValue returns the numeric value of the named rule parameter in the transaction.
+func (g *Gaia) ParameterValue(rule *types.Rule, txBytes []byte, name string) (*big.Int, error) {
+	return g.engine.ParameterValue(rule, txBytes, name)
+}
+
+// SetMagicConstantRegistry sets the registry used to resolve magic constants.
+func (g *Gaia) SetMagicConstantRegistry(registry *resolver.MagicConstantRegistry) {
+	g.engine.SetMagicConstantRegistry(registry)
 }
 
 // ExtractTxBytes extracts transaction bytes from a base64-encoded Cosmos transaction.
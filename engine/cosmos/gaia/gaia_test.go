@@ -0,0 +1,62 @@
+package gaia
+
+import (
+	"context"
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	tx "github.com/cosmos/cosmos-sdk/types/tx"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vultisig/recipes/engine/compare"
+	"github.com/vultisig/recipes/types"
+)
+
+const (
+	sender    = "cosmos1qypqxpq9qcrsszg2pvxq6rs0zqg3yyc5lzv7xu"
+	recipient = "cosmos1zg69v7ys40x77y352eufp27daufrg4nc0eexqd"
+	other     = "cosmos1xv9tklw7d82sezh9haa573wufgy59vmwe6xxe5"
+)
+
+func buildMsgSendTx(t *testing.T, to string, amount int64) []byte {
+	msg, err := codectypes.NewAnyWithValue(&banktypes.MsgSend{
+		FromAddress: sender,
+		ToAddress:   to,
+		Amount:      sdk.NewCoins(sdk.NewCoin("uatom", sdkmath.NewInt(amount))),
+	})
+	require.NoError(t, err)
+
+	txBytes, err := (&tx.Tx{Body: &tx.TxBody{Messages: []*codectypes.Any{msg}}}).Marshal()
+	require.NoError(t, err)
+	return txBytes
+}
+
+func TestGaia_Match_DenyRecipient(t *testing.T) {
+	gaia := NewGaia()
+
+	// deny any send to the recipient, whatever the amount
+	rule := &types.Rule{
+		Effect:   types.Effect_EFFECT_DENY,
+		Resource: "cosmos.atom.transfer",
+		ParameterConstraints: []*types.ParameterConstraint{
+			{
+				ParameterName: "recipient",
+				Constraint: &types.Constraint{
+					Type:  types.ConstraintType_CONSTRAINT_TYPE_FIXED,
+					Value: &types.Constraint_FixedValue{FixedValue: recipient},
+				},
+			},
+		},
+	}
+
+	err := gaia.Match(context.Background(), rule, buildMsgSendTx(t, recipient, 1000000))
+	assert.NoError(t, err)
+
+	err = gaia.Match(context.Background(), rule, buildMsgSendTx(t, other, 1000000))
+	require.Error(t, err)
+	assert.True(t, compare.IsMismatch(err), err)
+}
@@ -1,11 +1,15 @@
 package thorchain
 
 import (
+	"context"
+	"math/big"
+
 	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 
 	"github.com/vultisig/recipes/chain/cosmos"
 	cosmosengine "github.com/vultisig/recipes/engine/cosmos"
+	"github.com/vultisig/recipes/resolver"
 	"github.com/vultisig/recipes/types"
 	"github.com/vultisig/vultisig-go/common"
 )
@@ -44,8 +48,23 @@ func (t *Thorchain) Supports(chain common.Chain) bool {
 }
 
 // Evaluate validates a transaction against the given rule.
-func (t *Thorchain) Evaluate(rule *types.Rule, txBytes []byte) error {
-	return t.engine.Evaluate(rule, txBytes)
+func (t *Thorchain) Evaluate(ctx context.Context, rule *types.Rule, txBytes []byte) error {
+	return t.engine.Evaluate(ctx, rule, txBytes)
+}
+
+// Match checks the transaction against the given rule regardless of its effect.
+func (t *Thorchain) Match(ctx context.Context, rule *types.Rule, txBytes []byte) error {
+	return t.engine.Match(ctx, rule, txBytes)
+}
+
+// ParameterValue returns the numeric value of the named rule parameter in the transaction.
+func (t *Thorchain) ParameterValue(rule *types.Rule, txBytes []byte, name string) (*big.Int, error) {
+	return t.engine.ParameterValue(rule, txBytes, name)
+}
+
+// SetMagicConstantRegistry sets the registry used to resolve magic constants.
+func (t *Thorchain) SetMagicConstantRegistry(registry *resolver.MagicConstantRegistry) {
+	t.engine.SetMagicConstantRegistry(registry)
 }
 
 // ExtractTxBytes extracts transaction bytes from a base64-encoded Thorchain transaction.
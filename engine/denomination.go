@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vultisig/recipes/engine/compare"
+	"github.com/vultisig/recipes/engine/price"
+	"github.com/vultisig/recipes/engine/units"
+	"github.com/vultisig/recipes/types"
+	"github.com/vultisig/recipes/util"
+	"github.com/vultisig/vultisig-go/common"
+)
+
+// assertDenominations checks that amounts denominated in a token or the native asset are amounts of that asset,
+// e.g. max 100 usdc doesn't allow 100 units of another token with the same decimals.
+// The asset of the amount is reported by the chain engine, or derived from the rule if the engine can't report it
+func (e *Engine) assertDenominations(
+	chain common.Chain,
+	rule *types.Rule,
+	chainEngine ChainEngine,
+	txBytes []byte,
+) error {
+	for _, pc := range rule.GetParameterConstraints() {
+		denominations := assetDenominations(chain, pc.GetConstraint(), nil)
+		if len(denominations) == 0 {
+			continue
+		}
+
+		actual, err := parameterAsset(chain, rule, chainEngine, txBytes, pc.GetParameterName())
+		if err != nil {
+			return err
+		}
+
+		for _, denomination := range denominations {
+			expected, er := e.units.Asset(chain, denomination)
+			if er != nil {
+				return fmt.Errorf("failed to resolve asset of %s: %w", denomination, er)
+			}
+			if !strings.EqualFold(expected, actual) {
+				return compare.NewMismatchError(
+					"asset of %s doesn't match the denomination: denominated_in=%s, asset=%s",
+					pc.GetParameterName(),
+					denomination,
+					assetName(actual),
+				)
+			}
+		}
+	}
+	return nil
+}
+
+// assetDenominations returns token and native denominations of the constraint and its nested constraints,
+// fiat currencies and base units aren't bound to an asset
+func assetDenominations(chain common.Chain, c *types.Constraint, out []string) []string {
+	denomination := c.GetDenominatedIn()
+	if denomination != "" && !price.IsFiat(denomination) && !units.IsBaseUnits(chain, denomination) {
+		out = append(out, denomination)
+	}
+	for _, nested := range c.GetCompositeValue().GetConstraints() {
+		out = assetDenominations(chain, nested, out)
+	}
+	return out
+}
+
+// parameterAsset returns the asset of the numeric parameter in the tx, "" for the native asset.
+// Chain engines which can't report it are assumed to move the native asset of native protocol rules
+// and the token of the rule target otherwise
+func parameterAsset(
+	chain common.Chain,
+	rule *types.Rule,
+	chainEngine ChainEngine,
+	txBytes []byte,
+	parameter string,
+) (string, error) {
+	assets, ok := chainEngine.(ParameterAssetResolver)
+	if ok {
+		asset, err := assets.ParameterAsset(withoutFiatConstraints(rule), txBytes, parameter)
+		if err != nil {
+			return "", fmt.Errorf("failed to get parameter asset: parameter=%s, error=%w", parameter, err)
+		}
+		return asset, nil
+	}
+
+	resource, err := util.ParseResource(rule.GetResource())
+	if err != nil {
+		return "", fmt.Errorf("failed to parse rule resource: %w", err)
+	}
+	nativeSymbol, err := chain.NativeSymbol()
+	if err == nil && strings.EqualFold(resource.ProtocolId, nativeSymbol) {
+		return "", nil
+	}
+	if address := rule.GetTarget().GetAddress(); address != "" {
+		return strings.ToLower(address), nil
+	}
+	return "", fmt.Errorf("failed to get parameter asset: parameter=%s, error=unknown asset of %s", parameter, resource.ProtocolId)
+}
+
+func assetName(asset string) string {
+	if asset == "" {
+		return "native"
+	}
+	return asset
+}
@@ -1,35 +1,114 @@
 package engine
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/kaptinlin/jsonschema"
+	"github.com/vultisig/recipes/engine/compare"
+	"github.com/vultisig/recipes/engine/evm"
+	"github.com/vultisig/recipes/engine/price"
+	"github.com/vultisig/recipes/engine/spend"
+	"github.com/vultisig/recipes/engine/units"
 	"github.com/vultisig/recipes/metarule"
+	"github.com/vultisig/recipes/resolver"
 	"github.com/vultisig/recipes/types"
 	"github.com/vultisig/recipes/util"
 	"github.com/vultisig/vultisig-go/common"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
 type Engine struct {
-	logger   *log.Logger
-	registry *ChainEngineRegistry
+	logger      *log.Logger
+	registry    *ChainEngineRegistry
+	resolvers   *resolver.MagicConstantRegistry
+	abis        *evm.ABIRegistry
+	units       *units.Registry
+	prices      price.Oracle
+	maxPriceAge time.Duration
+	spendStore  spend.Store
+	rateLimiter *RateLimiter
+	now         func() time.Time
 }
 
-func NewEngine() (*Engine, error) {
-	reg, err := NewChainEngineRegistry()
+func NewEngine(opts ...Option) (*Engine, error) {
+	o := &options{
+		logger:         log.New(io.Discard, "", 0),
+		chains:         DefaultChains(),
+		resolverConfig: resolver.DefaultRegistryConfig(),
+		maxPriceAge:    price.DefaultMaxAge,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	reg, err := NewChainEngineRegistryForChains(o.chains)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create registry: %w", err)
 	}
+	resolvers := o.resolvers
+	if resolvers == nil {
+		// single cached registry shared by all chain engines, so magic constants
+		// are resolved over the network once per TTL instead of once per constraint
+		resolvers = resolver.NewMagicConstantRegistryWithConfig(o.resolverConfig).WithCache(resolver.DefaultCacheConfig())
+	}
+	reg.SetMagicConstantRegistry(resolvers)
+
+	abis := o.abis
+	if abis == nil {
+		// single registry shared by all EVM engines, so ABIs registered at runtime apply to every chain
+		abis, err = evm.NewABIRegistry()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create abi registry: %w", err)
+		}
+	}
+	reg.SetABIRegistry(abis)
+	if o.deployments != nil {
+		reg.SetDeploymentRegistry(o.deployments)
+	}
+	if o.approvalSlack != 0 {
+		reg.SetApprovalSlack(o.approvalSlack)
+	}
+	for method, input := range o.swapInputs {
+		protocolID, functionID, _ := strings.Cut(method, ".")
+		reg.RegisterSwapInput(protocolID, functionID, input)
+	}
+
+	// custom engines are registered once the shared registries are set on the built-in ones,
+	// so the registries they were configured with are kept
+	for chain, chainEngine := range o.engines {
+		err = reg.Register(chain, chainEngine)
+		if err != nil {
+			return nil, fmt.Errorf("failed to register chain engine: %w", err)
+		}
+	}
+
+	tokens := o.units
+	if tokens == nil {
+		tokens, err = units.DefaultRegistry()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create token registry: %w", err)
+		}
+	}
 
 	return &Engine{
-		logger:   log.New(io.Discard, "", 0),
-		registry: reg,
+		logger:      o.logger,
+		registry:    reg,
+		resolvers:   resolvers,
+		abis:        abis,
+		units:       tokens,
+		prices:      o.prices,
+		maxPriceAge: o.maxPriceAge,
+		spendStore:  spend.NewMemoryStore(),
+		rateLimiter: NewRateLimiter(NewMemoryRateLimitStore(), nil),
+		now:         time.Now,
 	}, nil
 }
 
@@ -37,8 +116,232 @@ func (e *Engine) SetLogger(log *log.Logger) {
 	e.logger = log
 }
 
+// SetMagicConstantRegistry replaces the registry used by all chain engines to resolve magic constants
+func (e *Engine) SetMagicConstantRegistry(registry *resolver.MagicConstantRegistry) {
+	e.registry.SetMagicConstantRegistry(registry)
+	e.resolvers = registry
+}
+
+// ABIRegistry returns the registry shared by EVM chain engines, register ABIs in it
+// to evaluate rules of protocols unknown at build time
+func (e *Engine) ABIRegistry() *evm.ABIRegistry {
+	return e.abis
+}
+
+// SetSpendStore replaces the default in-memory store used for period-limited constraints
+func (e *Engine) SetSpendStore(store spend.Store) {
+	e.spendStore = store
+}
+
+// SetRateLimiter replaces the default in-memory rate limiter used for policy rate_limit_window
+func (e *Engine) SetRateLimiter(r *RateLimiter) {
+	e.rateLimiter = r
+}
+
+// RecordExecution counts the signed tx against the rate limit window of the policy instance of the context,
+// see WithPolicyInstance, or of the policy id if the context has no instance
+func (e *Engine) RecordExecution(ctx context.Context, policy *types.Policy) error {
+	return e.recordExecution(ctx, policy, 1)
+}
+
+// Evaluate finds the rule which allows the tx. Deny rules are checked first:
+// if the tx matches any deny rule it is rejected even when an allow rule also matches.
+// Once the tx is signed, call RecordExecution and RecordSpend to count it against the policy limits.
+// Rate and spend limits are counted per policy instance, evaluate policies having them with EvaluateContext
+// and the context of WithPolicyInstance. Without the instance rate limits are counted per policy id,
+// and policies with spend limits can't be evaluated.
 func (e *Engine) Evaluate(policy *types.Policy, chain common.Chain, txBytes []byte) (*types.Rule, error) {
+	return e.EvaluateContext(context.Background(), policy, chain, txBytes)
+}
+
+// EvaluateContext is Evaluate with a context, which is passed down to chain engines
+// and cancels magic constant resolution
+func (e *Engine) EvaluateContext(
+	ctx context.Context,
+	policy *types.Policy,
+	chain common.Chain,
+	txBytes []byte,
+) (*types.Rule, error) {
+	report, err := e.EvaluateWithReport(ctx, policy, chain, txBytes)
+	if err != nil {
+		return nil, err
+	}
+	return report.MatchedRule, nil
+}
+
+// EvaluateWithReport evaluates the tx same as Evaluate and returns the report of every rule considered.
+// The report is returned together with the error when the tx is rejected.
+func (e *Engine) EvaluateWithReport(
+	ctx context.Context,
+	policy *types.Policy,
+	chain common.Chain,
+	txBytes []byte,
+) (*EvaluationReport, error) {
+	report := &EvaluationReport{
+		PolicyID: policy.GetId(),
+		Chain:    chain.String(),
+	}
+
+	err := e.checkRateLimit(ctx, policy, 1)
+	if err != nil {
+		var rateErr *RateLimitError
+		if errors.As(err, &rateErr) {
+			report.RateLimit = rateErr
+		}
+		return report, fmt.Errorf("failed to check rate limit: %w", err)
+	}
+
+	rules, err := e.chainRules(policy, chain, report)
+	if err != nil {
+		return report, err
+	}
+	if len(rules) == 0 {
+		return report, errors.New("no matching rule")
+	}
+
+	// Get the appropriate engine for this chain
+	chainEngine, err := e.registry.GetEngine(chain)
+	if err != nil {
+		e.logger.Printf("No engine available for chain %s: %v", chain.String(), err)
+		for _, rule := range rules {
+			report.skip(rule, SkipReasonNoEngine, err)
+		}
+		return report, errors.New("no matching rule")
+	}
+
+	for i, rule := range rules {
+		if rule.GetEffect() != types.Effect_EFFECT_DENY {
+			continue
+		}
+
+		e.logger.Printf("Evaluating deny rule: %s: %s", rule.GetId(), rule.GetResource())
+		er := e.matchRule(ctx, chain, chainEngine, rule, txBytes)
+		if compare.IsMismatch(er) {
+			e.logger.Printf("Deny rule %s not matched for %s: %v", rule.GetId(), chain.String(), er)
+			report.notMatched(rule, er)
+			continue
+		}
+		if er != nil {
+			// a deny rule which can't be evaluated must not let the tx through
+			e.logger.Printf("Failed to evaluate deny rule %s for %s: %v", rule.GetId(), chain.String(), er)
+			report.failed(rule, er)
+			addNotEvaluated(report, rules[i+1:], types.Effect_EFFECT_DENY)
+			addNotEvaluated(report, rules, types.Effect_EFFECT_ALLOW)
+			return report, fmt.Errorf("failed to evaluate deny rule: id=%s, resource=%s: %w", rule.GetId(), rule.GetResource(), er)
+		}
+
+		e.logger.Printf("Tx denied for %s by rule %s", chain.String(), rule.GetId())
+		report.add(rule, RuleOutcomeMatched)
+		report.DeniedByRuleID = rule.GetId()
+		addNotEvaluated(report, rules[i+1:], types.Effect_EFFECT_DENY)
+		addNotEvaluated(report, rules, types.Effect_EFFECT_ALLOW)
+		return report, fmt.Errorf("tx denied by rule: id=%s, resource=%s", rule.GetId(), rule.GetResource())
+	}
+
 	var errs []error
+	for i, rule := range rules {
+		if rule.GetEffect() == types.Effect_EFFECT_DENY {
+			continue
+		}
+
+		resourcePathString := rule.GetResource()
+		e.logger.Printf("Evaluating rule: %s: %s", rule.GetId(), resourcePathString)
+
+		// Evaluate using the chain-specific engine, then fiat and period limits
+		er := e.evaluateRule(ctx, chain, chainEngine, rule, txBytes)
+		if er != nil {
+			errs = append(errs, fmt.Errorf("%s(%w)", resourcePathString, er))
+			e.logger.Printf("Failed to evaluate tx for %s: %v", chain.String(), er)
+			report.notMatched(rule, er)
+			continue
+		}
+
+		e.logger.Printf("Tx validated for %s", chain.String())
+		report.add(rule, RuleOutcomeMatched)
+		report.MatchedRuleID = rule.GetId()
+		report.MatchedRule = rule
+		addNotEvaluated(report, rules[i+1:], types.Effect_EFFECT_ALLOW)
+		return report, nil
+	}
+	if len(errs) == 0 {
+		return report, errors.New("no matching rule")
+	}
+
+	var errStrs []string
+	for _, err := range errs {
+		errStrs = append(errStrs, err.Error())
+	}
+	return report, fmt.Errorf("failed to evaluate tx: %s", strings.Join(errStrs, " "))
+}
+
+func addNotEvaluated(report *EvaluationReport, rules []*types.Rule, effect types.Effect) {
+	for _, rule := range rules {
+		isDeny := rule.GetEffect() == types.Effect_EFFECT_DENY
+		if isDeny != (effect == types.Effect_EFFECT_DENY) {
+			continue
+		}
+		report.add(rule, RuleOutcomeNotEvaluated)
+	}
+}
+
+// chainRules expands meta-rules of the policy and returns the rules targeting the given chain,
+// other rules are added to the report as skipped
+func (e *Engine) chainRules(policy *types.Policy, chain common.Chain, report *EvaluationReport) ([]*types.Rule, error) {
+	rules, err := policyRules(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*types.Rule
+	for _, rule := range rules {
+		resourcePathString := rule.GetResource()
+		resourcePath, er := util.ParseResource(resourcePathString)
+		if er != nil {
+			e.logger.Printf(
+				"Skipping rule %s: invalid resource path %s: %v",
+				rule.GetId(),
+				resourcePathString,
+				er,
+			)
+			report.skip(rule, SkipReasonInvalidResource, er)
+			continue
+		}
+
+		if resourcePath.ChainId != strings.ToLower(chain.String()) {
+			e.logger.Printf(
+				"Skipping rule %s: target chain %s is not '%s'",
+				rule.GetId(),
+				resourcePath.ChainId,
+				chain.String(),
+			)
+			report.skip(rule, SkipReasonWrongChain, nil)
+			continue
+		}
+
+		// amounts denominated in tokens or native units are compared in base units
+		normalized, er := e.units.NormalizeRule(chain, rule)
+		if er != nil {
+			if rule.GetEffect() == types.Effect_EFFECT_DENY {
+				// the deny rule can't be skipped, the tx may be one it denies
+				return nil, fmt.Errorf("failed to normalize amounts of rule %s: %w", rule.GetId(), er)
+			}
+			e.logger.Printf("Skipping rule %s: invalid amount: %v", rule.GetId(), er)
+			report.skip(rule, SkipReasonInvalidAmount, er)
+			continue
+		}
+		rule = normalized
+
+		e.logger.Printf("Targeting: Chain='%s', Asset='%s', Function='%s'",
+			resourcePath.ChainId, resourcePath.ProtocolId, resourcePath.FunctionId)
+		out = append(out, rule)
+	}
+	return out, nil
+}
+
+// policyRules expands meta-rules of the policy into concrete rules of all chains,
+// with the effect of the source rule and the policy defaults of EVM tx fields
+func policyRules(policy *types.Policy) ([]*types.Rule, error) {
+	var out []*types.Rule
 	for _, ruleRaw := range policy.GetRules() {
 		if ruleRaw == nil {
 			continue
@@ -50,60 +353,53 @@ func (e *Engine) Evaluate(policy *types.Policy, chain common.Chain, txBytes []by
 		}
 
 		for _, rule := range rules {
-			resourcePathString := rule.GetResource()
-			resourcePath, er := util.ParseResource(resourcePathString)
-			if er != nil {
-				e.logger.Printf(
-					"Skipping rule %s: invalid resource path %s: %v",
-					rule.GetId(),
-					resourcePathString,
-					er,
-				)
-				continue
-			}
-
-			if resourcePath.ChainId != strings.ToLower(chain.String()) {
-				e.logger.Printf(
-					"Skipping rule %s: target chain %s is not '%s'",
-					rule.GetId(),
-					resourcePath.ChainId,
-					chain.String(),
-				)
-				continue
+			if ruleRaw.GetEffect() == types.Effect_EFFECT_DENY {
+				// meta-rules may expand to helper rules (e.g. approve) with hardcoded ALLOW effect,
+				// all of them must deny if the source rule denies
+				rule.Effect = types.Effect_EFFECT_DENY
 			}
 
-			e.logger.Printf("Evaluating rule: %s: %s", rule.GetId(), resourcePathString)
-			e.logger.Printf("Targeting: Chain='%s', Asset='%s', Function='%s'",
-				resourcePath.ChainId, resourcePath.ProtocolId, resourcePath.FunctionId)
-
-			// Get the appropriate engine for this chain
-			chainEngine, er := e.registry.GetEngine(chain)
-			if er != nil {
-				e.logger.Printf("No engine available for chain %s: %v", chain.String(), er)
-				continue
+			if isEvmRule(rule) && rule.GetEffect() != types.Effect_EFFECT_DENY {
+				rule = withEvmTxConstraints(policy, rule)
 			}
+			out = append(out, rule)
+		}
+	}
+	return out, nil
+}
 
-			// Evaluate using the chain-specific engine
-			er = chainEngine.Evaluate(rule, txBytes)
-			if er != nil {
-				errs = append(errs, fmt.Errorf("%s(%w)", resourcePathString, er))
-				e.logger.Printf("Failed to evaluate tx for %s: %v", chain.String(), er)
-				continue
-			}
+func isEvmRule(rule *types.Rule) bool {
+	resource, err := util.ParseResource(rule.GetResource())
+	if err != nil {
+		return false
+	}
+	chain, err := common.FromString(resource.ChainId)
+	if err != nil {
+		return false
+	}
+	return chain.IsEvm()
+}
 
-			e.logger.Printf("Tx validated for %s", chain.String())
-			return rule, nil
-		}
+// withEvmTxConstraints returns the copy of the allow rule with policy-level tx field constraints
+// appended, except the fields which the rule constrains itself
+func withEvmTxConstraints(policy *types.Policy, rule *types.Rule) *types.Rule {
+	if len(policy.GetEvmTxConstraints()) == 0 {
+		return rule
 	}
-	if len(errs) == 0 {
-		return nil, errors.New("no matching rule")
+
+	own := make(map[string]bool)
+	for _, c := range rule.GetParameterConstraints() {
+		own[c.GetParameterName()] = true
 	}
 
-	var errStrs []string
-	for _, err := range errs {
-		errStrs = append(errStrs, err.Error())
+	out := proto.Clone(rule).(*types.Rule)
+	for _, c := range policy.GetEvmTxConstraints() {
+		if own[c.GetParameterName()] {
+			continue
+		}
+		out.ParameterConstraints = append(out.ParameterConstraints, c)
 	}
-	return nil, fmt.Errorf("failed to evaluate tx: %s", strings.Join(errStrs, " "))
+	return out
 }
 
 func (e *Engine) ValidatePolicyWithSchema(policy *types.Policy, schema *types.RecipeSchema) error {
@@ -207,6 +503,10 @@ func (e *Engine) validateParameterConstraints(rule *types.Rule, resourcePattern
 	// Check each parameter constraint in the rule
 	for _, paramConstraint := range rule.GetParameterConstraints() {
 		paramName := paramConstraint.GetParameterName()
+		if evm.IsTxField(paramName) {
+			// reserved tx fields are checked by the engine, not declared by schemas
+			continue
+		}
 
 		// Check if parameter is supported
 		paramCap, exists := paramCapabilities[paramName]
@@ -234,3 +534,17 @@ func (e *Engine) ExtractTxBytes(chain common.Chain, txData string) ([]byte, erro
 	}
 	return chainEngine.ExtractTxBytes(txData)
 }
+
+// ExtractPSBTBytes extracts the serialized PSBT from the given transaction data for the specified UTXO chain.
+// Unlike ExtractTxBytes, input UTXOs are kept, so input, fee and sighash constraints can be evaluated
+func (e *Engine) ExtractPSBTBytes(chain common.Chain, txData string) ([]byte, error) {
+	chainEngine, err := e.registry.GetEngine(chain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get engine for chain %s: %w", chain.String(), err)
+	}
+	extractor, ok := chainEngine.(PSBTExtractor)
+	if !ok {
+		return nil, fmt.Errorf("chain %s doesn't support PSBTs", chain.String())
+	}
+	return extractor.ExtractPSBTBytes(txData)
+}
@@ -1,10 +1,12 @@
 package engine
 
 import (
+	"context"
 	"log"
 	"math/big"
 	"os"
 	"testing"
+	"time"
 
 	ecommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -19,6 +21,10 @@ import (
 )
 
 func buildUnsignedTx(to ecommon.Address, data []byte, value *big.Int) []byte {
+	return buildUnsignedTxWithNonce(0, to, data, value)
+}
+
+func buildUnsignedTxWithNonce(nonce uint64, to ecommon.Address, data []byte, value *big.Int) []byte {
 	unsigned := struct {
 		ChainID    *big.Int
 		Nonce      uint64
@@ -31,7 +37,7 @@ func buildUnsignedTx(to ecommon.Address, data []byte, value *big.Int) []byte {
 		AccessList etypes.AccessList
 	}{
 		ChainID:    big.NewInt(1),
-		Nonce:      0,
+		Nonce:      nonce,
 		GasTipCap:  big.NewInt(2_000_000_000),  // 2 gwei
 		GasFeeCap:  big.NewInt(20_000_000_000), // 20 gwei
 		Gas:        300_000,
@@ -141,3 +147,414 @@ func TestEngine(t *testing.T) {
 		})
 	}
 }
+
+func erc20TransferRule(effect types.Effect, recipient *types.Constraint) *types.Rule {
+	return &types.Rule{
+		Id:       "erc20 transfer " + effect.String(),
+		Resource: "ethereum.erc20.transfer",
+		Effect:   effect,
+		Target: &types.Target{
+			TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+			Target: &types.Target_Address{
+				Address: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+			},
+		},
+		ParameterConstraints: []*types.ParameterConstraint{{
+			ParameterName: "recipient",
+			Constraint:    recipient,
+		}, {
+			ParameterName: "amount",
+			Constraint: &types.Constraint{
+				Type: types.ConstraintType_CONSTRAINT_TYPE_ANY,
+			},
+		}},
+	}
+}
+
+func TestEvaluate_DenyOverridesAllow(t *testing.T) {
+	engine, err := NewEngine()
+	require.NoError(t, err)
+
+	blocked := ecommon.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	allowed := ecommon.HexToAddress("0xcf0475d9B0a29975bc5132A3066010eC898d8CaB")
+
+	allowAny := erc20TransferRule(types.Effect_EFFECT_ALLOW, &types.Constraint{
+		Type: types.ConstraintType_CONSTRAINT_TYPE_ANY,
+	})
+	denyBlocked := erc20TransferRule(types.Effect_EFFECT_DENY, &types.Constraint{
+		Type:  types.ConstraintType_CONSTRAINT_TYPE_FIXED,
+		Value: &types.Constraint_FixedValue{FixedValue: blocked.Hex()},
+	})
+	// no resolver is registered for the magic constant, the deny rule can't be evaluated
+	denyUnresolvable := erc20TransferRule(types.Effect_EFFECT_DENY, &types.Constraint{
+		Type:  types.ConstraintType_CONSTRAINT_TYPE_MAGIC_CONSTANT,
+		Value: &types.Constraint_MagicConstantValue{MagicConstantValue: types.MagicConstant(-1)},
+	})
+
+	buildTransfer := func(to ecommon.Address) []byte {
+		return buildUnsignedTx(
+			ecommon.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"),
+			erc20.NewErc20().PackTransfer(to, big.NewInt(1000000)),
+			big.NewInt(0),
+		)
+	}
+
+	tests := []struct {
+		name      string
+		rules     []*types.Rule
+		to        ecommon.Address
+		wantRule  *types.Rule
+		wantError string
+	}{
+		{
+			name:     "allowed recipient passes",
+			rules:    []*types.Rule{allowAny, denyBlocked},
+			to:       allowed,
+			wantRule: allowAny,
+		},
+		{
+			name:      "blocked recipient denied",
+			rules:     []*types.Rule{allowAny, denyBlocked},
+			to:        blocked,
+			wantError: "tx denied by rule",
+		},
+		{
+			name:      "deny applies regardless of rule order",
+			rules:     []*types.Rule{denyBlocked, allowAny},
+			to:        blocked,
+			wantError: "tx denied by rule",
+		},
+		{
+			name:      "deny rule which can't be evaluated rejects the tx",
+			rules:     []*types.Rule{allowAny, denyUnresolvable},
+			to:        allowed,
+			wantError: "failed to evaluate deny rule",
+		},
+		{
+			name:      "deny rule alone never allows",
+			rules:     []*types.Rule{denyBlocked},
+			to:        allowed,
+			wantError: "no matching rule",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := engine.Evaluate(&types.Policy{Rules: tt.rules}, common.Ethereum, buildTransfer(tt.to))
+			if tt.wantError != "" {
+				require.ErrorContains(t, err, tt.wantError)
+				require.Nil(t, rule)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantRule.GetId(), rule.GetId())
+		})
+	}
+}
+
+func TestEvaluate_PeriodSpendLimit(t *testing.T) {
+	engine, err := NewEngine()
+	require.NoError(t, err)
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	engine.now = func() time.Time {
+		return now
+	}
+
+	recipient := ecommon.HexToAddress("0xcf0475d9B0a29975bc5132A3066010eC898d8CaB")
+	policy := &types.Policy{
+		Id: "spend-limit-policy",
+		Rules: []*types.Rule{{
+			Id:       "eth transfer",
+			Resource: "ethereum.eth.transfer",
+			Effect:   types.Effect_EFFECT_ALLOW,
+			Target: &types.Target{
+				TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+				Target: &types.Target_Address{
+					Address: recipient.Hex(),
+				},
+			},
+			ParameterConstraints: []*types.ParameterConstraint{{
+				ParameterName: "amount",
+				Constraint: &types.Constraint{
+					Type:          types.ConstraintType_CONSTRAINT_TYPE_MAX,
+					Value:         &types.Constraint_MaxValue{MaxValue: "1000000000000000000"},
+					Period:        "day",
+					DenominatedIn: "wei",
+				},
+			}},
+		}},
+	}
+
+	// 0.6 ETH
+	txBytes := buildUnsignedTx(recipient, nil, big.NewInt(600_000_000_000_000_000))
+	ctx := WithPolicyInstance(context.Background(), "vault-1")
+
+	_, err = engine.Evaluate(policy, common.Ethereum, txBytes)
+	require.ErrorContains(t, err, "policy instance is required")
+
+	rule, err := engine.EvaluateContext(ctx, policy, common.Ethereum, txBytes)
+	require.NoError(t, err)
+
+	// evaluated but not signed tx doesn't consume the limit
+	rule, err = engine.EvaluateContext(ctx, policy, common.Ethereum, txBytes)
+	require.NoError(t, err)
+	require.NoError(t, engine.RecordSpend(ctx, rule, common.Ethereum, txBytes))
+
+	_, err = engine.EvaluateContext(ctx, policy, common.Ethereum, txBytes)
+	require.ErrorContains(t, err, "period limit exceeded")
+
+	// another installation of the same plugin policy has its own budget
+	_, err = engine.EvaluateContext(WithPolicyInstance(context.Background(), "vault-2"), policy, common.Ethereum, txBytes)
+	require.NoError(t, err)
+
+	// 0.4 ETH still fits the daily budget
+	smallTx := buildUnsignedTx(recipient, nil, big.NewInt(400_000_000_000_000_000))
+	_, err = engine.EvaluateContext(ctx, policy, common.Ethereum, smallTx)
+	require.NoError(t, err)
+
+	now = now.Add(25 * time.Hour)
+	_, err = engine.EvaluateContext(ctx, policy, common.Ethereum, txBytes)
+	require.NoError(t, err)
+
+	// both txs were evaluated before either was recorded, only one of them fits the limit
+	first, err := engine.EvaluateContext(ctx, policy, common.Ethereum, txBytes)
+	require.NoError(t, err)
+	second, err := engine.EvaluateContext(ctx, policy, common.Ethereum, txBytes)
+	require.NoError(t, err)
+	require.NoError(t, engine.RecordSpend(ctx, first, common.Ethereum, txBytes))
+	err = engine.RecordSpend(ctx, second, common.Ethereum, txBytes)
+	require.ErrorContains(t, err, "period limit exceeded")
+
+	// the rejected tx wasn't counted
+	_, err = engine.EvaluateContext(ctx, policy, common.Ethereum, smallTx)
+	require.NoError(t, err)
+}
+
+func TestEvaluate_PeriodRequiresMaxConstraint(t *testing.T) {
+	engine, err := NewEngine()
+	require.NoError(t, err)
+
+	recipient := ecommon.HexToAddress("0xcf0475d9B0a29975bc5132A3066010eC898d8CaB")
+	policy := &types.Policy{
+		Rules: []*types.Rule{{
+			Resource: "ethereum.eth.transfer",
+			Effect:   types.Effect_EFFECT_ALLOW,
+			Target: &types.Target{
+				TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+				Target: &types.Target_Address{
+					Address: recipient.Hex(),
+				},
+			},
+			ParameterConstraints: []*types.ParameterConstraint{{
+				ParameterName: "amount",
+				Constraint: &types.Constraint{
+					Type:   types.ConstraintType_CONSTRAINT_TYPE_ANY,
+					Period: "day",
+				},
+			}},
+		}},
+	}
+
+	_, err = engine.Evaluate(policy, common.Ethereum, buildUnsignedTx(recipient, nil, big.NewInt(1)))
+	require.ErrorContains(t, err, "period is only supported for max constraints")
+}
+
+func TestEvaluate_PolicyEvmTxConstraints(t *testing.T) {
+	engine, err := NewEngine()
+	require.NoError(t, err)
+
+	maxFee := func(value string) *types.ParameterConstraint {
+		return &types.ParameterConstraint{
+			ParameterName: "tx.max_fee_per_gas",
+			Constraint: &types.Constraint{
+				Type:  types.ConstraintType_CONSTRAINT_TYPE_MAX,
+				Value: &types.Constraint_MaxValue{MaxValue: value},
+			},
+		}
+	}
+
+	allowAny := erc20TransferRule(types.Effect_EFFECT_ALLOW, &types.Constraint{
+		Type: types.ConstraintType_CONSTRAINT_TYPE_ANY,
+	})
+	allowHighFee := erc20TransferRule(types.Effect_EFFECT_ALLOW, &types.Constraint{
+		Type: types.ConstraintType_CONSTRAINT_TYPE_ANY,
+	})
+	allowHighFee.ParameterConstraints = append(allowHighFee.ParameterConstraints, maxFee("50000000000"))
+
+	// 20 gwei fee cap
+	txBytes := buildUnsignedTx(
+		ecommon.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"),
+		erc20.NewErc20().PackTransfer(ecommon.HexToAddress("0xcf0475d9B0a29975bc5132A3066010eC898d8CaB"), big.NewInt(1000000)),
+		big.NewInt(0),
+	)
+
+	tests := []struct {
+		name      string
+		rule      *types.Rule
+		ceiling   string
+		wantError string
+	}{
+		{
+			name:    "fee under policy ceiling",
+			rule:    allowAny,
+			ceiling: "30000000000",
+		},
+		{
+			name:      "fee over policy ceiling",
+			rule:      allowAny,
+			ceiling:   "10000000000",
+			wantError: "failed to assert tx.max_fee_per_gas",
+		},
+		{
+			name:    "rule constraint overrides policy ceiling",
+			rule:    allowHighFee,
+			ceiling: "10000000000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := &types.Policy{
+				Rules:            []*types.Rule{tt.rule},
+				EvmTxConstraints: []*types.ParameterConstraint{maxFee(tt.ceiling)},
+			}
+
+			rule, err := engine.Evaluate(policy, common.Ethereum, txBytes)
+			if tt.wantError != "" {
+				require.ErrorContains(t, err, tt.wantError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.rule.GetId(), rule.GetId())
+		})
+	}
+
+	// policy defaults don't leak into the policy rules
+	require.Len(t, allowAny.GetParameterConstraints(), 2)
+}
+
+func TestEvaluate_DenominatedAmount(t *testing.T) {
+	engine, err := NewEngine()
+	require.NoError(t, err)
+
+	recipient := ecommon.HexToAddress("0xcf0475d9B0a29975bc5132A3066010eC898d8CaB")
+	rule := func(denomination string) *types.Rule {
+		r := erc20TransferRule(types.Effect_EFFECT_ALLOW, &types.Constraint{
+			Type: types.ConstraintType_CONSTRAINT_TYPE_ANY,
+		})
+		r.ParameterConstraints[1].Constraint = &types.Constraint{
+			Type:          types.ConstraintType_CONSTRAINT_TYPE_MAX,
+			Value:         &types.Constraint_MaxValue{MaxValue: "100.5"},
+			DenominatedIn: denomination,
+		}
+		return r
+	}
+	transfer := func(amount int64) []byte {
+		return buildUnsignedTx(
+			ecommon.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"),
+			erc20.NewErc20().PackTransfer(recipient, big.NewInt(amount)),
+			big.NewInt(0),
+		)
+	}
+
+	policy := &types.Policy{Id: "denominated", Rules: []*types.Rule{rule("USDC")}}
+
+	_, err = engine.Evaluate(policy, common.Ethereum, transfer(100_500_000))
+	require.NoError(t, err)
+
+	_, err = engine.Evaluate(policy, common.Ethereum, transfer(100_500_001))
+	require.ErrorContains(t, err, "failed to evaluate tx")
+
+	// usdt has the same decimals, but the amount is a usdc amount
+	policy = &types.Policy{Id: "other-token", Rules: []*types.Rule{rule("USDT")}}
+	_, err = engine.Evaluate(policy, common.Ethereum, transfer(1))
+	require.ErrorContains(t, err, "asset of amount doesn't match the denomination: denominated_in=USDT")
+
+	policy = &types.Policy{Id: "native", Rules: []*types.Rule{rule("ETH")}}
+	_, err = engine.Evaluate(policy, common.Ethereum, transfer(1))
+	require.ErrorContains(t, err, "denominated_in=ETH, asset=0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+
+	// a deny rule on another token doesn't match
+	deny := rule("USDT")
+	deny.Effect = types.Effect_EFFECT_DENY
+	policy = &types.Policy{Id: "deny-other-token", Rules: []*types.Rule{deny, rule("USDC")}}
+	_, err = engine.Evaluate(policy, common.Ethereum, transfer(1))
+	require.NoError(t, err)
+
+	// the rule with an unknown token is skipped
+	policy = &types.Policy{Id: "unknown-token", Rules: []*types.Rule{rule("NOPE")}}
+	_, err = engine.Evaluate(policy, common.Ethereum, transfer(1))
+	require.ErrorContains(t, err, "no matching rule")
+}
+
+func TestChainRules_TestdataPolicies(t *testing.T) {
+	engine, err := NewEngine()
+	require.NoError(t, err)
+
+	tests := []struct {
+		policyPath string
+		chain      common.Chain
+	}{
+		{policyPath: "../testdata/payroll.json", chain: common.Ethereum},
+		{policyPath: "../testdata/invalid_configuration_payroll.json", chain: common.Bitcoin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policyPath, func(t *testing.T) {
+			policyFileBytes, err := os.ReadFile(tt.policyPath)
+			require.NoError(t, err)
+
+			var policy types.Policy
+			require.NoError(t, protojson.Unmarshal(policyFileBytes, &policy))
+
+			report := &EvaluationReport{}
+			rules, err := engine.chainRules(&policy, tt.chain, report)
+			require.NoError(t, err)
+			require.Empty(t, report.Rules, "no rule is skipped")
+			require.Len(t, rules, len(policy.GetRules()))
+
+			// amounts denominated in base units are kept as is
+			for i, rule := range rules {
+				for j, pc := range rule.GetParameterConstraints() {
+					source := policy.GetRules()[i].GetParameterConstraints()[j].GetConstraint()
+					require.Equal(t, source.GetFixedValue(), pc.GetConstraint().GetFixedValue())
+					require.Equal(t, source.GetMaxValue(), pc.GetConstraint().GetMaxValue())
+				}
+			}
+		})
+	}
+}
+
+func TestChainRules_InvalidAmount(t *testing.T) {
+	engine, err := NewEngine()
+	require.NoError(t, err)
+
+	invalid := erc20TransferRule(types.Effect_EFFECT_ALLOW, &types.Constraint{
+		Type: types.ConstraintType_CONSTRAINT_TYPE_ANY,
+	})
+	invalid.Id = "invalid"
+	invalid.ParameterConstraints[1].Constraint = &types.Constraint{
+		Type:          types.ConstraintType_CONSTRAINT_TYPE_MAX,
+		Value:         &types.Constraint_MaxValue{MaxValue: "100"},
+		DenominatedIn: "NOPE",
+	}
+	valid := erc20TransferRule(types.Effect_EFFECT_ALLOW, &types.Constraint{
+		Type: types.ConstraintType_CONSTRAINT_TYPE_ANY,
+	})
+	valid.Id = "valid"
+
+	report := &EvaluationReport{}
+	rules, err := engine.chainRules(&types.Policy{Rules: []*types.Rule{invalid, valid}}, common.Ethereum, report)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, "valid", rules[0].GetId())
+	require.Len(t, report.Rules, 1)
+	require.Equal(t, SkipReasonInvalidAmount, report.Rules[0].SkipReason)
+	require.Contains(t, report.Rules[0].Error, "unknown token NOPE on Ethereum")
+
+	// the deny rule may deny the tx, the policy can't be evaluated without it
+	invalid.Effect = types.Effect_EFFECT_DENY
+	_, err = engine.chainRules(&types.Policy{Rules: []*types.Rule{invalid, valid}}, common.Ethereum, &EvaluationReport{})
+	require.ErrorContains(t, err, "failed to normalize amounts of rule invalid")
+}
@@ -0,0 +1,197 @@
+package evm
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	abi_embed "github.com/vultisig/recipes/chain/evm/abi"
+)
+
+// AllChains registers the ABI on every EVM chain
+const AllChains = ""
+
+// embeddedABIChains restricts embedded ABIs of protocols deployed only on some chains,
+// ABIs not listed here are available on every EVM chain
+var embeddedABIChains = map[protocolID][]string{
+	"polymarket_ctf":          {"polygon"},
+	"polymarket_ctf_exchange": {"polygon"},
+}
+
+// ABIRegistry resolves resource protocol IDs to ABIs per chain. Protocols are registered
+// for all chains or for a single chain, and contracts without a named protocol are registered
+// by address: the lowercase hex address is the protocol ID, e.g. `polygon.0xabc...def.deposit`
+type ABIRegistry struct {
+	mu     sync.RWMutex
+	shared map[protocolID]abi.ABI
+	chains map[string]map[protocolID]abi.ABI
+	// contracts are the addresses the contract ABIs are bound to, by chain and protocol ID
+	contracts map[string]map[protocolID]common.Address
+}
+
+// NewABIRegistry creates the registry with the ABIs embedded in chain/evm/abi
+func NewABIRegistry() (*ABIRegistry, error) {
+	r := &ABIRegistry{
+		shared:    make(map[protocolID]abi.ABI),
+		chains:    make(map[string]map[protocolID]abi.ABI),
+		contracts: make(map[string]map[protocolID]common.Address),
+	}
+
+	abis, err := loadAbiDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load abi dir: %w", err)
+	}
+	for id, a := range abis {
+		chains, ok := embeddedABIChains[id]
+		if !ok {
+			r.Register(AllChains, id, a)
+			continue
+		}
+		for _, chain := range chains {
+			r.Register(chain, id, a)
+		}
+	}
+	return r, nil
+}
+
+func loadAbiDir() (map[protocolID]abi.ABI, error) {
+	base := "."
+
+	entries, err := abi_embed.Dir.ReadDir(base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read abi dir: err=%w", err)
+	}
+
+	abis := make(map[string]abi.ABI)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		filepath := path.Join(base, entry.Name())
+		file, er := abi_embed.Dir.Open(filepath)
+		if er != nil {
+			return nil, fmt.Errorf("failed to open abi json: path=%s, err=%w", filepath, er)
+		}
+
+		a, er := abi.JSON(file)
+		_ = file.Close()
+		if er != nil {
+			return nil, fmt.Errorf("failed to parse abi json: %w", er)
+		}
+
+		abis[strings.TrimSuffix(entry.Name(), ".json")] = a
+	}
+	return abis, nil
+}
+
+// Register sets the protocol ABI on the chain (resource chain ID, e.g. "polygon"),
+// or on every chain for AllChains. A chain ABI takes precedence over the shared one
+func (r *ABIRegistry) Register(chain string, id string, a abi.ABI) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	chain = strings.ToLower(chain)
+	if chain == AllChains {
+		r.shared[id] = a
+		return
+	}
+	if r.chains[chain] == nil {
+		r.chains[chain] = make(map[protocolID]abi.ABI)
+	}
+	r.chains[chain][id] = a
+}
+
+// RegisterJSON is Register with the ABI in JSON
+func (r *ABIRegistry) RegisterJSON(chain string, id string, abiJSON []byte) error {
+	a, err := abi.JSON(bytes.NewReader(abiJSON))
+	if err != nil {
+		return fmt.Errorf("failed to parse abi json: %w", err)
+	}
+	r.Register(chain, id, a)
+	return nil
+}
+
+// RegisterContract sets the ABI of the contract deployed on the chain, it's resolved by
+// the lowercase hex address as protocol ID and only matches txs calling that address.
+// Returns the protocol ID
+func (r *ABIRegistry) RegisterContract(chain string, address common.Address, a abi.ABI) string {
+	id := strings.ToLower(address.Hex())
+	r.Register(chain, id, a)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	chain = strings.ToLower(chain)
+	if r.contracts[chain] == nil {
+		r.contracts[chain] = make(map[protocolID]common.Address)
+	}
+	r.contracts[chain][id] = address
+	return id
+}
+
+// Unregister removes the protocol ABI from the chain, or the shared one for AllChains
+func (r *ABIRegistry) Unregister(chain string, id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	chain = strings.ToLower(chain)
+	if chain == AllChains {
+		delete(r.shared, id)
+		return
+	}
+	delete(r.chains[chain], id)
+	delete(r.contracts[chain], id)
+}
+
+// Get returns the protocol ABI on the chain
+func (r *ABIRegistry) Get(chain string, id string) (abi.ABI, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	chain = strings.ToLower(chain)
+	a, ok := r.chains[chain][id]
+	if ok {
+		return a, true
+	}
+	a, ok = r.shared[id]
+	return a, ok
+}
+
+// Contract returns the address the protocol ABI is bound to on the chain,
+// false for protocols not registered with RegisterContract
+func (r *ABIRegistry) Contract(chain string, id string) (common.Address, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	addr, ok := r.contracts[strings.ToLower(chain)][id]
+	return addr, ok
+}
+
+// Protocols returns IDs of all protocols available on the chain, sorted
+func (r *ABIRegistry) Protocols(chain string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	chain = strings.ToLower(chain)
+	ids := make([]string, 0, len(r.shared)+len(r.chains[chain]))
+	for id := range r.shared {
+		ids = append(ids, id)
+	}
+	for id := range r.chains[chain] {
+		if _, ok := r.shared[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
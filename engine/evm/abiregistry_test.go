@@ -0,0 +1,94 @@
+package evm
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+	"github.com/vultisig/recipes/types"
+)
+
+const vaultABIJSON = `[{"type":"function","name":"deposit","stateMutability":"nonpayable",
+"inputs":[{"name":"assets","type":"uint256"},{"name":"receiver","type":"address"}],"outputs":[]}]`
+
+func TestABIRegistry_EmbeddedChains(t *testing.T) {
+	r, err := NewABIRegistry()
+	require.NoError(t, err)
+
+	_, ok := r.Get("ethereum", "erc20")
+	require.True(t, ok)
+	_, ok = r.Get("arbitrum", "erc20")
+	require.True(t, ok)
+
+	_, ok = r.Get("polygon", "polymarket_ctf_exchange")
+	require.True(t, ok)
+	_, ok = r.Get("ethereum", "polymarket_ctf_exchange")
+	require.False(t, ok, "polymarket is deployed only on polygon")
+
+	require.Contains(t, r.Protocols("polygon"), "polymarket_ctf")
+	require.NotContains(t, r.Protocols("ethereum"), "polymarket_ctf")
+}
+
+func TestEvaluate_RuntimeABI(t *testing.T) {
+	const (
+		vault    = "0x3333333333333333333333333333333333333333"
+		receiver = "0x1111111111111111111111111111111111111111"
+	)
+
+	evm, err := NewEvm("ETH")
+	require.NoError(t, err)
+
+	a, err := abi.JSON(strings.NewReader(vaultABIJSON))
+	require.NoError(t, err)
+	data, err := a.Pack("deposit", big.NewInt(100), common.HexToAddress(receiver))
+	require.NoError(t, err)
+
+	rule := func(resource, target string) *types.Rule {
+		return &types.Rule{
+			Effect:   types.Effect_EFFECT_ALLOW,
+			Resource: resource,
+			Target: &types.Target{
+				TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+				Target:     &types.Target_Address{Address: target},
+			},
+			ParameterConstraints: []*types.ParameterConstraint{
+				paramConstraint("assets", types.ConstraintType_CONSTRAINT_TYPE_MAX, "100"),
+				paramConstraint("receiver", types.ConstraintType_CONSTRAINT_TYPE_FIXED, receiver),
+			},
+		}
+	}
+
+	baseTx := buildEVMTestTx(8453, common.HexToAddress(vault), data, big.NewInt(0))
+	ethTx := buildEVMTestTx(1, common.HexToAddress(vault), data, big.NewInt(0))
+
+	err = evm.Evaluate(context.Background(), rule("base.vault.deposit", vault), baseTx)
+	require.ErrorContains(t, err, "failed to get abi: chainId=base, protocolId=vault")
+
+	require.NoError(t, evm.ABIRegistry().RegisterJSON("base", "vault", []byte(vaultABIJSON)))
+
+	err = evm.Evaluate(context.Background(), rule("base.vault.deposit", vault), baseTx)
+	require.NoError(t, err)
+
+	err = evm.Evaluate(context.Background(), rule("ethereum.vault.deposit", vault), ethTx)
+	require.ErrorContains(t, err, "failed to get abi: chainId=ethereum, protocolId=vault")
+
+	// contract registered by address only matches calls to it
+	id := evm.ABIRegistry().RegisterContract("ethereum", common.HexToAddress(vault), a)
+	require.Equal(t, strings.ToLower(vault), id)
+
+	err = evm.Evaluate(context.Background(), rule("ethereum."+id+".deposit", vault), ethTx)
+	require.NoError(t, err)
+
+	const other = "0x4444444444444444444444444444444444444444"
+	otherTx := buildEVMTestTx(1, common.HexToAddress(other), data, big.NewInt(0))
+	err = evm.Evaluate(context.Background(), rule("ethereum."+id+".deposit", other), otherTx)
+	require.ErrorContains(t, err, "tx doesn't call the contract of the protocol")
+
+	evm.ABIRegistry().Unregister("ethereum", id)
+	err = evm.Evaluate(context.Background(), rule("ethereum."+id+".deposit", vault), ethTx)
+	require.ErrorContains(t, err, "failed to get abi")
+}
@@ -0,0 +1,187 @@
+package evm
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	etypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/vultisig/recipes/chain/evm/ethereum"
+	"github.com/vultisig/recipes/types"
+	"github.com/vultisig/recipes/util"
+)
+
+// SwapInput locates the token and the amount spent by a swap method, as paths of its ABI args, e.g. params.tokenIn
+type SwapInput struct {
+	Token  string
+	Amount string
+}
+
+func defaultSwapInputs() map[string]SwapInput {
+	v2In := SwapInput{Token: "path[0]", Amount: "amountIn"}
+	v2Out := SwapInput{Token: "path[0]", Amount: "amountInMax"}
+	deposit := SwapInput{Token: "asset", Amount: "amount"}
+	return map[string]SwapInput{
+		"uniswapV2_router.swapExactTokensForTokens":                              v2In,
+		"uniswapV2_router.swapExactTokensForTokensSupportingFeeOnTransferTokens": v2In,
+		"uniswapV2_router.swapExactTokensForETH":                                 v2In,
+		"uniswapV2_router.swapExactTokensForETHSupportingFeeOnTransferTokens":    v2In,
+		"uniswapV2_router.swapTokensForExactTokens":                              v2Out,
+		"uniswapV2_router.swapTokensForExactETH":                                 v2Out,
+		"uniswapV3_router.exactInputSingle":                                      {Token: "params.tokenIn", Amount: "params.amountIn"},
+		"uniswapV3_router.exactOutputSingle":                                     {Token: "params.tokenIn", Amount: "params.amountInMaximum"},
+		"routerV6_1inch.swap":                                                    {Token: "desc.srcToken", Amount: "desc.amount"},
+		"thorchain_router.deposit":                                               deposit,
+		"thorchain_router.depositWithExpiry":                                     deposit,
+		"mayachain_router.deposit":                                               deposit,
+		"mayachain_router.depositWithExpiry":                                     deposit,
+	}
+}
+
+// RegisterSwapInput sets the args of the protocol method holding the token and the amount it spends,
+// approvals in bundles are only matched with swaps of methods having a swap input
+func (e *Evm) RegisterSwapInput(protocolID, functionID string, input SwapInput) {
+	e.swapInputs[protocolID+"."+functionID] = input
+}
+
+// ValidateBundle checks txs signed together in a single keysign session, rules[i] is the rule matched by txs[i].
+// Nonces must be sequential, and every ERC20 approval must be followed by the swap it approves:
+// the approved token must be the swap input token, the spender must be the swap target,
+// and the approved amount must cover the swap amount without exceeding it by more than the approval slack
+func (e *Evm) ValidateBundle(rules []*types.Rule, txs [][]byte) error {
+	if len(rules) != len(txs) {
+		return fmt.Errorf("rules and txs count mismatch: rules=%d, txs=%d", len(rules), len(txs))
+	}
+
+	decoded := make([]*etypes.Transaction, 0, len(txs))
+	for i, txBytes := range txs {
+		txData, err := ethereum.DecodeUnsignedPayload(txBytes)
+		if err != nil {
+			return fmt.Errorf("failed to decode tx payload: index=%d, error=%w", i, err)
+		}
+		tx := etypes.NewTx(txData)
+
+		if i > 0 && tx.Nonce() != decoded[i-1].Nonce()+1 {
+			return fmt.Errorf(
+				"bundle nonces must be sequential: tx %d has nonce %d, previous tx has nonce %d",
+				i,
+				tx.Nonce(),
+				decoded[i-1].Nonce(),
+			)
+		}
+		decoded = append(decoded, tx)
+	}
+
+	for i, rule := range rules {
+		r, err := util.ParseResource(rule.GetResource())
+		if err != nil {
+			return fmt.Errorf("failed to parse rule resource: %w", err)
+		}
+		if r.ProtocolId != "erc20" || r.FunctionId != "approve" {
+			continue
+		}
+
+		if i+1 >= len(txs) {
+			return fmt.Errorf("approval tx %d is not followed by a swap tx", i)
+		}
+		err = e.assertApprovalCoversSwap(r, decoded[i], rules[i+1], decoded[i+1])
+		if err != nil {
+			return fmt.Errorf("failed to match approval tx %d with swap tx %d: %w", i, i+1, err)
+		}
+	}
+	return nil
+}
+
+func (e *Evm) assertApprovalCoversSwap(
+	approveResource *types.ResourcePath,
+	approveTx *etypes.Transaction,
+	swapRule *types.Rule,
+	swapTx *etypes.Transaction,
+) error {
+	method, args, err := e.unpackArgs(approveResource, approveTx.Data())
+	if err != nil {
+		return fmt.Errorf("failed to unpack approval: %w", err)
+	}
+
+	var spender common.Address
+	var approved *big.Int
+	for i, input := range method.Inputs {
+		switch input.Name {
+		case "spender":
+			spender, _ = args[i].(common.Address)
+		case "amount":
+			approved, _ = args[i].(*big.Int)
+		}
+	}
+	if approved == nil {
+		return fmt.Errorf("approval amount not found")
+	}
+
+	if swapTx.To() == nil {
+		return fmt.Errorf("swap tx has no target")
+	}
+	if !addrEqual(spender, *swapTx.To()) {
+		return fmt.Errorf("approval spender %s is not the swap target %s", spender.Hex(), swapTx.To().Hex())
+	}
+
+	token, swapAmount, err := e.swapInput(swapRule, swapTx)
+	if err != nil {
+		return err
+	}
+	if approveTx.To() == nil || !addrEqual(*approveTx.To(), token) {
+		return fmt.Errorf("approved token is not the swap input token %s", token.Hex())
+	}
+
+	if approved.Cmp(swapAmount) < 0 {
+		return fmt.Errorf("approved amount %s is less than swap amount %s", approved.String(), swapAmount.String())
+	}
+	// swapAmount * (10000 + slack) / 10000
+	bound := new(big.Int).Mul(swapAmount, new(big.Int).SetUint64(10_000+e.approvalSlackBps))
+	bound.Quo(bound, big.NewInt(10_000))
+	if approved.Cmp(bound) > 0 {
+		return fmt.Errorf("approved amount %s exceeds swap amount %s by more than %d bps",
+			approved.String(), swapAmount.String(), e.approvalSlackBps)
+	}
+	return nil
+}
+
+// swapInput returns the token and the amount spent by the swap
+func (e *Evm) swapInput(swapRule *types.Rule, swapTx *etypes.Transaction) (common.Address, *big.Int, error) {
+	r, err := util.ParseResource(swapRule.GetResource())
+	if err != nil {
+		return common.Address{}, nil, fmt.Errorf("failed to parse rule resource: %w", err)
+	}
+	nodes, err := e.argNodes(r, swapTx.Data())
+	if err != nil {
+		return common.Address{}, nil, fmt.Errorf("failed to unpack swap: %w", err)
+	}
+
+	p, ok := e.swapInputs[r.ProtocolId+"."+r.FunctionId]
+	if !ok {
+		return common.Address{}, nil, fmt.Errorf("unknown swap token and amount args: resource=%s", swapRule.GetResource())
+	}
+	tokenNode, err := findArg(nodes, p.Token)
+	if err != nil {
+		return common.Address{}, nil, fmt.Errorf("failed to find swap token: %w", err)
+	}
+	amountNode, err := findArg(nodes, p.Amount)
+	if err != nil {
+		return common.Address{}, nil, fmt.Errorf("failed to find swap amount: %w", err)
+	}
+
+	token, ok := tokenNode.value.(common.Address)
+	if !ok {
+		return common.Address{}, nil, fmt.Errorf("swap token %s is not an address: %s", p.Token, tokenNode.typ.String())
+	}
+	amount, ok := bigIntValue(amountNode.value)
+	if !ok {
+		return common.Address{}, nil, fmt.Errorf("swap amount %s is not numeric: %s", p.Amount, amountNode.typ.String())
+	}
+	return token, amount, nil
+}
+
+// SetApprovalSlack sets how much, in basis points, an approval in a bundle may exceed the amount of the swap it
+// approves by. Zero, the default, requires the exact swap amount
+func (e *Evm) SetApprovalSlack(bps uint64) {
+	e.approvalSlackBps = bps
+}
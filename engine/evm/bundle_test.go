@@ -0,0 +1,164 @@
+package evm
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+	"github.com/vultisig/recipes/sdk/evm/codegen/erc20"
+	"github.com/vultisig/recipes/sdk/evm/codegen/routerv6_1inch"
+	"github.com/vultisig/recipes/types"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+func TestValidateBundle_1inchSwap(t *testing.T) {
+	const (
+		router1inch = "0x111111125421cA6dc452d289314280a0f8842A65"
+		usdc        = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+		weth        = "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+	)
+
+	native, _ := vgcommon.Ethereum.NativeSymbol()
+	evm, err := NewEvm(native)
+	require.NoError(t, err)
+
+	rules := []*types.Rule{
+		{Resource: "ethereum.erc20.approve"},
+		{Resource: "ethereum.routerV6_1inch.swap"},
+	}
+
+	approve := func(amount int64) []byte {
+		data := erc20.NewErc20().PackApprove(common.HexToAddress(router1inch), big.NewInt(amount))
+		return buildUnsignedTx(common.HexToAddress(usdc), data, big.NewInt(0))
+	}
+	swap := func(nonce uint64) []byte {
+		return buildUnsignedTxWithNonce(nonce, common.HexToAddress(router1inch), routerv6_1inch.NewRouterv61inch().PackSwap(
+			common.HexToAddress(router1inch),
+			routerv6_1inch.GenericRouterSwapDescription{
+				SrcToken:        common.HexToAddress(usdc),
+				DstToken:        common.HexToAddress(weth),
+				SrcReceiver:     common.HexToAddress(router1inch),
+				DstReceiver:     common.HexToAddress(router1inch),
+				Amount:          big.NewInt(1000),
+				MinReturnAmount: big.NewInt(1),
+				Flags:           big.NewInt(0),
+			},
+			nil,
+		), big.NewInt(0))
+	}
+
+	amount, err := evm.ParameterValue(rules[1], swap(1), "desc.amount")
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), amount.Int64())
+
+	err = evm.ValidateBundle(rules, [][]byte{approve(1000), swap(1)})
+	require.NoError(t, err)
+
+	err = evm.ValidateBundle(rules, [][]byte{approve(999), swap(1)})
+	require.ErrorContains(t, err, "approved amount 999 is less than swap amount 1000")
+
+	err = evm.ValidateBundle(rules, [][]byte{approve(1000), swap(2)})
+	require.ErrorContains(t, err, "bundle nonces must be sequential")
+
+	// approval of another token
+	data := erc20.NewErc20().PackApprove(common.HexToAddress(router1inch), big.NewInt(1000))
+	err = evm.ValidateBundle(rules, [][]byte{buildUnsignedTx(common.HexToAddress(weth), data, big.NewInt(0)), swap(1)})
+	require.ErrorContains(t, err, "approved token is not the swap input token")
+
+	// approval above the swap amount, up to the slack
+	err = evm.ValidateBundle(rules, [][]byte{approve(1001), swap(1)})
+	require.ErrorContains(t, err, "approved amount 1001 exceeds swap amount 1000 by more than 0 bps")
+	evm.SetApprovalSlack(100)
+	require.NoError(t, evm.ValidateBundle(rules, [][]byte{approve(1010), swap(1)}))
+	err = evm.ValidateBundle(rules, [][]byte{approve(1011), swap(1)})
+	require.ErrorContains(t, err, "approved amount 1011 exceeds swap amount 1000 by more than 100 bps")
+}
+
+const lifiABIJSON = `[{
+	"type": "function",
+	"name": "swapTokensSingleV3ERC20ToERC20",
+	"stateMutability": "nonpayable",
+	"inputs": [
+		{"name": "_transactionId", "type": "bytes32"},
+		{"name": "_integrator", "type": "string"},
+		{"name": "_referrer", "type": "string"},
+		{"name": "_receiver", "type": "address"},
+		{"name": "_minAmountOut", "type": "uint256"},
+		{"name": "_swapData", "type": "tuple", "components": [
+			{"name": "callTo", "type": "address"},
+			{"name": "approveTo", "type": "address"},
+			{"name": "sendingAssetId", "type": "address"},
+			{"name": "receivingAssetId", "type": "address"},
+			{"name": "fromAmount", "type": "uint256"},
+			{"name": "callData", "type": "bytes"},
+			{"name": "requiresDeposit", "type": "bool"}
+		]}
+	],
+	"outputs": []
+}]`
+
+func TestValidateBundle_RegisteredSwapInput(t *testing.T) {
+	const (
+		lifiDiamond = "0x1231DEB6f5749EF6cE6943a275A1D3E7486F4EaE"
+		usdc        = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+		weth        = "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+	)
+
+	native, _ := vgcommon.Ethereum.NativeSymbol()
+	evm, err := NewEvm(native)
+	require.NoError(t, err)
+	require.NoError(t, evm.ABIRegistry().RegisterJSON(AllChains, "lifi", []byte(lifiABIJSON)))
+
+	lifi, err := abi.JSON(strings.NewReader(lifiABIJSON))
+	require.NoError(t, err)
+	type swapData struct {
+		CallTo           common.Address
+		ApproveTo        common.Address
+		SendingAssetId   common.Address
+		ReceivingAssetId common.Address
+		FromAmount       *big.Int
+		CallData         []byte
+		RequiresDeposit  bool
+	}
+	data, err := lifi.Pack("swapTokensSingleV3ERC20ToERC20",
+		[32]byte{1}, "vultisig", "", common.HexToAddress(lifiDiamond), big.NewInt(1),
+		swapData{
+			CallTo:           common.HexToAddress(lifiDiamond),
+			ApproveTo:        common.HexToAddress(lifiDiamond),
+			SendingAssetId:   common.HexToAddress(usdc),
+			ReceivingAssetId: common.HexToAddress(weth),
+			FromAmount:       big.NewInt(1000),
+		},
+	)
+	require.NoError(t, err)
+
+	rules := []*types.Rule{
+		{Resource: "ethereum.erc20.approve"},
+		{Resource: "ethereum.lifi.swapTokensSingleV3ERC20ToERC20"},
+	}
+	approve := erc20.NewErc20().PackApprove(common.HexToAddress(lifiDiamond), big.NewInt(1000))
+	txs := [][]byte{
+		buildUnsignedTxWithNonce(1, common.HexToAddress(usdc), approve, big.NewInt(0)),
+		buildUnsignedTxWithNonce(2, common.HexToAddress(lifiDiamond), data, big.NewInt(0)),
+	}
+
+	// args of the swap are unknown until they're registered
+	err = evm.ValidateBundle(rules, txs)
+	require.ErrorContains(t, err, "unknown swap token and amount args: resource=ethereum.lifi.swapTokensSingleV3ERC20ToERC20")
+
+	evm.RegisterSwapInput("lifi", "swapTokensSingleV3ERC20ToERC20", SwapInput{
+		Token:  "_swapData.sendingAssetId",
+		Amount: "_swapData.fromAmount",
+	})
+	require.NoError(t, evm.ValidateBundle(rules, txs))
+
+	evm.RegisterSwapInput("lifi", "swapTokensSingleV3ERC20ToERC20", SwapInput{
+		Token:  "_swapData.receivingAssetId",
+		Amount: "_swapData.fromAmount",
+	})
+	err = evm.ValidateBundle(rules, txs)
+	require.ErrorContains(t, err, "approved token is not the swap input token")
+}
@@ -0,0 +1,77 @@
+package evm
+
+import (
+	"fmt"
+	"math/big"
+
+	etypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/vultisig/recipes/sdk/swap"
+	"github.com/vultisig/recipes/types"
+	vultisigcommon "github.com/vultisig/vultisig-go/common"
+)
+
+// ChainID returns the EIP-155 chain ID of the EVM chain. IDs come from sdk/swap chain configs,
+// chains not listed there fall back to the ID known by vultisig-go
+func ChainID(chain vultisigcommon.Chain) (*big.Int, error) {
+	id, err := swap.GetEVMChainID(chain.String())
+	if err == nil {
+		return id, nil
+	}
+
+	id, err = chain.EvmID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain id of %s: %w", chain.String(), err)
+	}
+	return id, nil
+}
+
+// NewEvmForChain creates the engine bound to the chain, it rejects txs signed for any other chain
+// even if the chain shares the native symbol (e.g. Ethereum and Arbitrum)
+func NewEvmForChain(chain vultisigcommon.Chain) (*Evm, error) {
+	nativeSymbol, err := chain.NativeSymbol()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get native symbol for %s: %w", chain.String(), err)
+	}
+
+	chainID, err := ChainID(chain)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := NewEvm(nativeSymbol)
+	if err != nil {
+		return nil, err
+	}
+	e.chainID = chainID
+	return e, nil
+}
+
+// assertChainID checks the tx is EIP-155 protected and signed for the chain the engine is bound to,
+// or for the chain of the rule resource if the engine isn't bound
+func (e *Evm) assertChainID(resource *types.ResourcePath, tx *etypes.Transaction) error {
+	if !tx.Protected() {
+		return fmt.Errorf("tx is not replay protected: type=%d", tx.Type())
+	}
+	return e.assertChainIDValue(resource, tx.ChainId())
+}
+
+// assertChainIDValue checks the chain id of a tx or a signed message against the bound or the rule chain
+func (e *Evm) assertChainIDValue(resource *types.ResourcePath, actual *big.Int) error {
+	expected := e.chainID
+	if expected == nil {
+		chain, err := vultisigcommon.FromString(resource.ChainId)
+		if err != nil {
+			return fmt.Errorf("failed to parse chain: %w", err)
+		}
+
+		expected, err = ChainID(chain)
+		if err != nil {
+			return err
+		}
+	}
+
+	if actual.Cmp(expected) != 0 {
+		return fmt.Errorf("tx chain id mismatch: expected=%s, actual=%s", expected, actual)
+	}
+	return nil
+}
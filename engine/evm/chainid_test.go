@@ -0,0 +1,151 @@
+package evm
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	etypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+	"github.com/vultisig/recipes/chain/evm/ethereum"
+	"github.com/vultisig/recipes/types"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+// buildUnsignedLegacyTxForChain builds the EIP-155 signing payload of the legacy tx
+func buildUnsignedLegacyTxForChain(chainID int64, to common.Address, value *big.Int) []byte {
+	return encodeUnsignedTx(etypes.LegacyTxType, ethereum.LegacyEIP155TxWithoutSignature{
+		GasPrice: big.NewInt(20_000_000_000),
+		Gas:      21_000,
+		To:       &to,
+		Value:    value,
+		ChainID:  big.NewInt(chainID),
+	})
+}
+
+func TestChainID(t *testing.T) {
+	tests := []struct {
+		chain vgcommon.Chain
+		want  int64
+	}{
+		{chain: vgcommon.Ethereum, want: 1},
+		{chain: vgcommon.Arbitrum, want: 42161},
+		{chain: vgcommon.Zksync, want: 324},
+		{chain: vgcommon.Mantle, want: 5000},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.chain.String(), func(t *testing.T) {
+			id, err := ChainID(tc.chain)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, id.Int64())
+		})
+	}
+
+	_, err := ChainID(vgcommon.Bitcoin)
+	require.Error(t, err)
+}
+
+func TestEvaluate_ChainIDBinding(t *testing.T) {
+	const recipient = "0x1111111111111111111111111111111111111111"
+
+	transferRule := func(chain string) *types.Rule {
+		return &types.Rule{
+			Effect:   types.Effect_EFFECT_ALLOW,
+			Resource: chain + ".eth.transfer",
+			Target: &types.Target{
+				TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+				Target:     &types.Target_Address{Address: recipient},
+			},
+			ParameterConstraints: []*types.ParameterConstraint{{
+				ParameterName: "amount",
+				Constraint:    &types.Constraint{Type: types.ConstraintType_CONSTRAINT_TYPE_ANY},
+			}},
+		}
+	}
+
+	arbitrum, err := NewEvmForChain(vgcommon.Arbitrum)
+	require.NoError(t, err)
+	require.True(t, arbitrum.Supports(vgcommon.Arbitrum))
+	require.False(t, arbitrum.Supports(vgcommon.Ethereum))
+
+	unbound, err := NewEvm("ETH")
+	require.NoError(t, err)
+	require.True(t, unbound.Supports(vgcommon.Arbitrum))
+	require.True(t, unbound.Supports(vgcommon.Ethereum))
+
+	to := common.HexToAddress(recipient)
+	unprotected := encodeUnsignedTx(etypes.LegacyTxType, ethereum.LegacyTxWithoutSignature{
+		GasPrice: big.NewInt(20_000_000_000),
+		Gas:      21_000,
+		To:       &to,
+		Value:    big.NewInt(1),
+	})
+
+	tests := []struct {
+		name   string
+		engine *Evm
+		rule   *types.Rule
+		tx     []byte
+		errMsg string
+	}{
+		{
+			name:   "bound engine accepts its chain",
+			engine: arbitrum,
+			rule:   transferRule("arbitrum"),
+			tx:     buildEVMTestTx(42161, to, nil, big.NewInt(1)),
+		},
+		{
+			name:   "bound engine rejects ethereum tx",
+			engine: arbitrum,
+			rule:   transferRule("arbitrum"),
+			tx:     buildEVMTestTx(1, to, nil, big.NewInt(1)),
+			errMsg: "tx chain id mismatch: expected=42161, actual=1",
+		},
+		{
+			name:   "unbound engine checks rule chain",
+			engine: unbound,
+			rule:   transferRule("arbitrum"),
+			tx:     buildEVMTestTx(1, to, nil, big.NewInt(1)),
+			errMsg: "tx chain id mismatch: expected=42161, actual=1",
+		},
+		{
+			name:   "unbound engine accepts rule chain",
+			engine: unbound,
+			rule:   transferRule("ethereum"),
+			tx:     buildEVMTestTx(1, to, nil, big.NewInt(1)),
+		},
+		{
+			name:   "legacy tx without chain id",
+			engine: arbitrum,
+			rule:   transferRule("arbitrum"),
+			tx:     unprotected,
+			errMsg: "tx is not replay protected",
+		},
+		{
+			name:   "legacy EIP-155 tx of the bound chain",
+			engine: arbitrum,
+			rule:   transferRule("arbitrum"),
+			tx:     buildUnsignedLegacyTxForChain(42161, to, big.NewInt(1)),
+		},
+		{
+			name:   "legacy EIP-155 tx of another chain",
+			engine: arbitrum,
+			rule:   transferRule("arbitrum"),
+			tx:     buildUnsignedLegacyTxForChain(1, to, big.NewInt(1)),
+			errMsg: "tx chain id mismatch: expected=42161, actual=1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.engine.Evaluate(context.Background(), tc.rule, tc.tx)
+			if tc.errMsg != "" {
+				require.ErrorContains(t, err, tc.errMsg)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
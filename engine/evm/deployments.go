@@ -0,0 +1,193 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	stdcompare "github.com/vultisig/recipes/engine/compare"
+	"github.com/vultisig/recipes/resolver"
+	"github.com/vultisig/recipes/sdk/evm/aavev3"
+	"github.com/vultisig/recipes/types"
+	vultisigcommon "github.com/vultisig/vultisig-go/common"
+)
+
+// DeploymentSource returns addresses of the protocol contracts on the chain.
+// It returns an error if the protocol is not deployed on the chain
+type DeploymentSource func(
+	ctx context.Context,
+	resolvers *resolver.MagicConstantRegistry,
+	chain vultisigcommon.Chain,
+) ([]common.Address, error)
+
+// DeploymentRegistry holds known contract deployments of protocols, the engine with the registry
+// set rejects txs calling a protocol with registered deployments at any other address.
+// Protocols without registered deployments (e.g. erc20, safe) are not checked
+type DeploymentRegistry struct {
+	mu      sync.RWMutex
+	sources map[protocolID][]DeploymentSource
+}
+
+// NewDeploymentRegistry creates the registry with deployments known to the SDK and magic constant resolvers
+func NewDeploymentRegistry() *DeploymentRegistry {
+	r := &DeploymentRegistry{
+		sources: make(map[protocolID][]DeploymentSource),
+	}
+
+	r.RegisterSource("aavev3_pool", aaveDeployment(func(d aavev3.Deployment) common.Address {
+		return d.Pool
+	}))
+	r.RegisterSource("aavev3_dataprovider", aaveDeployment(func(d aavev3.Deployment) common.Address {
+		return d.DataProvider
+	}))
+	r.RegisterSource("routerV6_1inch", MagicConstantDeployment(types.MagicConstant_ONEINCH_ROUTER))
+	r.RegisterSource("uniswap_universal_router", MagicConstantDeployment(types.MagicConstant_UNISWAP_UNIVERSAL_ROUTER))
+	r.RegisterSource("thorchain_router", MagicConstantDeployment(types.MagicConstant_THORCHAIN_ROUTER))
+	r.RegisterSource("mayachain_router", MagicConstantDeployment(types.MagicConstant_MAYACHAIN_ROUTER))
+	r.RegisterSource("uniswapV3_router", func(_ context.Context, _ *resolver.MagicConstantRegistry, chain vultisigcommon.Chain) ([]common.Address, error) {
+		router, ok := resolver.UniswapSwapRouter02(chain.String())
+		if !ok {
+			return nil, fmt.Errorf("uniswap swap router 02 is not deployed on %s", chain.String())
+		}
+		return []common.Address{common.HexToAddress(router)}, nil
+	})
+
+	r.RegisterSource("permit2", func(context.Context, *resolver.MagicConstantRegistry, vultisigcommon.Chain) ([]common.Address, error) {
+		return []common.Address{Permit2Address}, nil
+	})
+
+	r.Register(vultisigcommon.Ethereum, "uniswapV2_router",
+		common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"))
+	r.Register(vultisigcommon.Polygon, "polymarket_ctf",
+		common.HexToAddress("0x4D97DCd97eC945f40cF65F87097ACe5EA0476045"))
+	r.Register(vultisigcommon.Polygon, "polymarket_ctf_exchange",
+		common.HexToAddress("0x4bFb41d5B3570DeFd03C39a9A4D8dE6Bd8B8982E"), // CTF Exchange
+		common.HexToAddress("0xC5d563A36AE78145C45a50134d48A1215220f80a"), // NegRisk CTF Exchange
+	)
+	return r
+}
+
+// RegisterSource adds the source of the protocol deployments, the protocol is deployed
+// at addresses returned by any of its sources
+func (r *DeploymentRegistry) RegisterSource(id string, source DeploymentSource) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sources[id] = append(r.sources[id], source)
+}
+
+// Register adds static deployments of the protocol on the chain
+func (r *DeploymentRegistry) Register(chain vultisigcommon.Chain, id string, addresses ...common.Address) {
+	r.RegisterSource(id, func(_ context.Context, _ *resolver.MagicConstantRegistry, c vultisigcommon.Chain) ([]common.Address, error) {
+		if c != chain {
+			return nil, nil
+		}
+		return addresses, nil
+	})
+}
+
+// Lookup returns known deployments of the protocol on the chain,
+// false if the protocol has no registered deployments on any chain
+func (r *DeploymentRegistry) Lookup(
+	ctx context.Context,
+	resolvers *resolver.MagicConstantRegistry,
+	chain vultisigcommon.Chain,
+	id string,
+) ([]common.Address, bool, error) {
+	r.mu.RLock()
+	sources := r.sources[id]
+	r.mu.RUnlock()
+
+	if len(sources) == 0 {
+		return nil, false, nil
+	}
+
+	var (
+		addresses []common.Address
+		errs      []string
+	)
+	for _, source := range sources {
+		addrs, err := source(ctx, resolvers, chain)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		addresses = append(addresses, addrs...)
+	}
+	if len(addresses) == 0 && len(errs) > 0 {
+		return nil, true, fmt.Errorf("failed to get deployments of %s on %s: %s", id, chain.String(), strings.Join(errs, "; "))
+	}
+	return addresses, true, nil
+}
+
+// MagicConstantDeployment is the source of deployments resolved with the magic constant, e.g. swap routers
+func MagicConstantDeployment(constant types.MagicConstant) DeploymentSource {
+	return func(ctx context.Context, resolvers *resolver.MagicConstantRegistry, chain vultisigcommon.Chain) ([]common.Address, error) {
+		resolve, err := resolvers.GetResolver(constant)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get resolver: magic_const=%s", constant.String())
+		}
+
+		addr, _, err := resolve.Resolve(ctx, constant, chain.String(), "default")
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve magic const: value=%s, error=%w", constant.String(), err)
+		}
+		return []common.Address{common.HexToAddress(addr)}, nil
+	}
+}
+
+func aaveDeployment(contract func(aavev3.Deployment) common.Address) DeploymentSource {
+	return func(_ context.Context, _ *resolver.MagicConstantRegistry, chain vultisigcommon.Chain) ([]common.Address, error) {
+		chainID, err := ChainID(chain)
+		if err != nil {
+			return nil, err
+		}
+
+		d, ok := aavev3.GetDeployment(chainID)
+		if !ok {
+			return nil, fmt.Errorf("aave v3 is not deployed on %s", chain.String())
+		}
+		return []common.Address{contract(d)}, nil
+	}
+}
+
+// SetDeploymentRegistry enables the check that txs call a known deployment of the resource protocol,
+// nil disables it
+func (e *Evm) SetDeploymentRegistry(registry *DeploymentRegistry) {
+	e.deployments = registry
+}
+
+func (e *Evm) assertDeployment(ctx context.Context, resource *types.ResourcePath, to *common.Address) error {
+	if e.deployments == nil || resource.ProtocolId == e.nativeSymbol {
+		return nil
+	}
+
+	chain, err := vultisigcommon.FromString(resource.ChainId)
+	if err != nil {
+		return fmt.Errorf("failed to parse chain: %w", err)
+	}
+
+	addresses, known, err := e.deployments.Lookup(ctx, e.resolvers, chain, resource.ProtocolId)
+	if err != nil {
+		return err
+	}
+	if !known {
+		return nil
+	}
+
+	if to != nil {
+		for _, addr := range addresses {
+			if addrEqual(*to, addr) {
+				return nil
+			}
+		}
+	}
+
+	toHex := "nil"
+	if to != nil {
+		toHex = to.Hex()
+	}
+	return stdcompare.NewMismatchError("tx target is not a known deployment of %s on %s: tx_to=%s", resource.ProtocolId, chain.String(), toHex)
+}
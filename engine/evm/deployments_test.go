@@ -0,0 +1,103 @@
+package evm
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+	"github.com/vultisig/recipes/resolver"
+	"github.com/vultisig/recipes/sdk/evm/aavev3"
+	"github.com/vultisig/recipes/sdk/evm/codegen/uniswapv2_router"
+	"github.com/vultisig/recipes/types"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+func TestDeploymentRegistry_Lookup(t *testing.T) {
+	r := NewDeploymentRegistry()
+	resolvers := resolver.NewMagicConstantRegistry()
+	ctx := context.Background()
+
+	aave, ok := aavev3.GetDeployment(big.NewInt(42161))
+	require.True(t, ok)
+	addrs, known, err := r.Lookup(ctx, resolvers, vgcommon.Arbitrum, "aavev3_pool")
+	require.NoError(t, err)
+	require.True(t, known)
+	require.Equal(t, []common.Address{aave.Pool}, addrs)
+
+	addrs, known, err = r.Lookup(ctx, resolvers, vgcommon.Ethereum, "routerV6_1inch")
+	require.NoError(t, err)
+	require.True(t, known)
+	require.Equal(t, []common.Address{common.HexToAddress("0x111111125421cA6dc452d289314280a0f8842A65")}, addrs)
+
+	_, known, err = r.Lookup(ctx, resolvers, vgcommon.Ethereum, "erc20")
+	require.NoError(t, err)
+	require.False(t, known)
+
+	// polymarket is only deployed on polygon
+	addrs, known, err = r.Lookup(ctx, resolvers, vgcommon.Ethereum, "polymarket_ctf_exchange")
+	require.NoError(t, err)
+	require.True(t, known)
+	require.Empty(t, addrs)
+
+	_, _, err = r.Lookup(ctx, resolvers, vgcommon.Blast, "routerV6_1inch")
+	require.ErrorContains(t, err, "failed to get deployments of routerV6_1inch on Blast")
+
+	custom := common.HexToAddress("0x5555555555555555555555555555555555555555")
+	r.Register(vgcommon.Ethereum, "erc20", custom)
+	addrs, known, err = r.Lookup(ctx, resolvers, vgcommon.Ethereum, "erc20")
+	require.NoError(t, err)
+	require.True(t, known)
+	require.Equal(t, []common.Address{custom}, addrs)
+}
+
+func TestEvaluate_KnownDeployment(t *testing.T) {
+	const (
+		router    = "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"
+		lookalike = "0x6666666666666666666666666666666666666666"
+		usdc      = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+		weth      = "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+	)
+
+	evm, err := NewEvm("ETH")
+	require.NoError(t, err)
+
+	rule := func(target string) *types.Rule {
+		return &types.Rule{
+			Effect:   types.Effect_EFFECT_ALLOW,
+			Resource: "ethereum.uniswapV2_router.swapExactTokensForTokens",
+			Target: &types.Target{
+				TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+				Target:     &types.Target_Address{Address: target},
+			},
+			ParameterConstraints: []*types.ParameterConstraint{
+				paramConstraint("amountIn", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+				paramConstraint("amountOutMin", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+				paramConstraint("path", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+				paramConstraint("to", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+				paramConstraint("deadline", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+			},
+		}
+	}
+	swap := func(to string) []byte {
+		data := uniswapv2_router.NewUniswapv2Router().PackSwapExactTokensForTokens(
+			big.NewInt(1000),
+			big.NewInt(1),
+			[]common.Address{common.HexToAddress(usdc), common.HexToAddress(weth)},
+			common.HexToAddress(lookalike),
+			big.NewInt(1_900_000_000),
+		)
+		return buildUnsignedTx(common.HexToAddress(to), data, big.NewInt(0))
+	}
+
+	// not checked until the registry is set
+	require.NoError(t, evm.Evaluate(context.Background(), rule(lookalike), swap(lookalike)))
+
+	evm.SetDeploymentRegistry(NewDeploymentRegistry())
+
+	require.NoError(t, evm.Evaluate(context.Background(), rule(router), swap(router)))
+
+	err = evm.Evaluate(context.Background(), rule(lookalike), swap(lookalike))
+	require.ErrorContains(t, err, "tx target is not a known deployment of uniswapV2_router on Ethereum")
+}
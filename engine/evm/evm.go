@@ -2,17 +2,15 @@ package evm
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"fmt"
 	"math/big"
-	"path"
-	"reflect"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	etypes "github.com/ethereum/go-ethereum/core/types"
-	abi_embed "github.com/vultisig/recipes/chain/evm/abi"
 	stdcompare "github.com/vultisig/recipes/engine/compare"
 	"github.com/vultisig/recipes/engine/evm/compare"
 	"github.com/vultisig/recipes/chain/evm/ethereum"
@@ -24,33 +22,84 @@ import (
 
 type Evm struct {
 	nativeSymbol string
-	abi          map[protocolID]abi.ABI
+	abis         *ABIRegistry
+	decoders     map[string]InnerCallDecoder
+	resolvers    *resolver.MagicConstantRegistry
+	// deployments are known contract addresses of protocols, nil disables the check
+	deployments *DeploymentRegistry
+	// chainID is the EIP-155 chain ID the engine is bound to, nil if not bound
+	chainID *big.Int
+	// approvalSlackBps is how much approvals in bundles may exceed the swap amount, in basis points
+	approvalSlackBps uint64
+	// swapInputs are the args of swap methods approved in bundles, by protocol.method
+	swapInputs map[string]SwapInput
 }
 
 func NewEvm(nativeSymbol string) (*Evm, error) {
-	abis, err := loadAbiDir()
+	abis, err := NewABIRegistry()
 	if err != nil {
-		return nil, fmt.Errorf("failed to load abi dir: %w", err)
+		return nil, fmt.Errorf("failed to create abi registry: %w", err)
 	}
 
 	return &Evm{
 		nativeSymbol: strings.ToLower(nativeSymbol),
-		abi:          abis,
+		abis:         abis,
+		decoders:     defaultInnerCallDecoders(),
+		resolvers:    resolver.NewMagicConstantRegistry(),
+		swapInputs:   defaultSwapInputs(),
 	}, nil
 }
 
-// Supports returns true if this engine supports the given chain (all EVM chains)
+// SetABIRegistry sets the registry resolving resource protocol IDs to ABIs,
+// it lets engines of all EVM chains share ABIs registered at runtime
+func (e *Evm) SetABIRegistry(registry *ABIRegistry) {
+	e.abis = registry
+}
+
+// ABIRegistry returns the registry resolving resource protocol IDs to ABIs
+func (e *Evm) ABIRegistry() *ABIRegistry {
+	return e.abis
+}
+
+// SetMagicConstantRegistry sets the registry used to resolve magic constants
+func (e *Evm) SetMagicConstantRegistry(registry *resolver.MagicConstantRegistry) {
+	e.resolvers = registry
+}
+
+// Supports returns true if this engine supports the given chain: EVM chains with the engine native symbol,
+// or only the bound chain for engines created with NewEvmForChain
 func (e *Evm) Supports(chain vultisigcommon.Chain) bool {
 	nativeSymbol, _ := chain.NativeSymbol()
+	if !chain.IsEvm() || e.nativeSymbol != strings.ToLower(nativeSymbol) {
+		return false
+	}
+	if e.chainID == nil {
+		return true
+	}
 
-	return chain.IsEvm() && e.nativeSymbol == strings.ToLower(nativeSymbol)
+	chainID, err := ChainID(chain)
+	return err == nil && chainID.Cmp(e.chainID) == 0
 }
 
-func (e *Evm) Evaluate(rule *types.Rule, txBytes []byte) error {
+func (e *Evm) Evaluate(ctx context.Context, rule *types.Rule, txBytes []byte) error {
 	if rule.GetEffect().String() != types.Effect_EFFECT_ALLOW.String() {
 		return fmt.Errorf("only allow rules supported, got: %s", rule.GetEffect().String())
 	}
+	return e.match(ctx, rule, txBytes, false)
+}
+
+// Match checks the tx against the rule resource, target and parameter constraints
+// regardless of the rule effect. A nil error means the rule matches the tx.
+// Unlike Evaluate, args without constraints match any value and a constraint addressing
+// array elements by the [*] wildcard matches if any element satisfies it, so a deny rule
+// applies to every tx having the constrained values
+func (e *Evm) Match(ctx context.Context, rule *types.Rule, txBytes []byte) error {
+	return e.match(ctx, rule, txBytes, true)
+}
 
+// match checks the tx against the rule, allow rules must cover every arg.
+// Partial matching checks only the constrained args
+func (e *Evm) match(ctx context.Context, rule *types.Rule, txBytes []byte, partial bool) error {
 	r, err := util.ParseResource(rule.GetResource())
 	if err != nil {
 		return fmt.Errorf("failed to parse rule resource: %w", err)
@@ -62,42 +111,177 @@ func (e *Evm) Evaluate(rule *types.Rule, txBytes []byte) error {
 	}
 	tx := etypes.NewTx(txData)
 
-	err = assertTarget(r, rule.GetTarget(), tx.To())
+	err = e.assertChainID(r, tx)
+	if err != nil {
+		return fmt.Errorf("failed to assert chain id: %w", err)
+	}
+
+	if r.ProtocolId == e.nativeSymbol && r.FunctionId == nativeDeploy {
+		// deployments have no target, the rule target is ignored
+		if tx.To() != nil {
+			return stdcompare.NewMismatchError("tx is not a contract deployment: tx_to=%s", tx.To().Hex())
+		}
+	} else {
+		err = e.assertTarget(ctx, r, rule.GetTarget(), tx.To())
+		if err != nil {
+			return fmt.Errorf("failed to assert target: %w", err)
+		}
+	}
+
+	err = e.assertDeployment(ctx, r, tx.To())
+	if err != nil {
+		return fmt.Errorf("failed to assert deployment: %w", err)
+	}
+
+	contract, ok := e.abis.Contract(r.ChainId, r.ProtocolId)
+	if ok && (tx.To() == nil || !addrEqual(*tx.To(), contract)) {
+		return stdcompare.NewMismatchError("tx doesn't call the contract of the protocol: protocolId=%s", r.ProtocolId)
+	}
+
+	argConstraints, fieldConstraints := splitTxFieldConstraints(rule.GetParameterConstraints())
+
+	err = e.assertTxFields(ctx, r.ChainId, fieldConstraints, tx, partial)
 	if err != nil {
-		return fmt.Errorf("failed to assert target: %w", err)
+		return fmt.Errorf("failed to assert tx fields: %w", err)
 	}
 
 	if r.ProtocolId == e.nativeSymbol {
-		er := assertArgsNative(r, rule, tx)
+		er := e.assertArgsNative(ctx, r, argConstraints, tx, partial)
 		if er != nil {
 			return fmt.Errorf("failed to Evaluate native: symbol=%s, error=%w", e.nativeSymbol, er)
 		}
 		return nil
 	}
 
-	er := e.assertArgsAbi(r, rule, tx.Data())
+	er := e.assertArgsAbi(ctx, r, argConstraints, tx.Data(), partial)
 	if er != nil {
 		return fmt.Errorf("failed to Evaluate ABI: %w", er)
 	}
 	return nil
 }
 
-func assertArgsNative(resource *types.ResourcePath, rule *types.Rule, tx *etypes.Transaction) error {
-	if resource.FunctionId != "transfer" {
+// ParameterValue returns the numeric value of the named rule parameter in the tx:
+// tx value for native transfers, the ABI argument for contract calls, or the reserved tx field
+func (e *Evm) ParameterValue(rule *types.Rule, txBytes []byte, name string) (*big.Int, error) {
+	r, err := util.ParseResource(rule.GetResource())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rule resource: %w", err)
+	}
+
+	txData, err := ethereum.DecodeUnsignedPayload(txBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode tx payload: %w", err)
+	}
+	tx := etypes.NewTx(txData)
+
+	if IsTxField(name) {
+		return txFieldValue(tx, name)
+	}
+
+	if r.ProtocolId == e.nativeSymbol {
+		if name != "amount" {
+			return nil, fmt.Errorf("unknown native parameter: %s", name)
+		}
+		return tx.Value(), nil
+	}
+
+	nodes, err := e.argNodes(r, tx.Data())
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := findArg(nodes, name)
+	if err != nil {
+		return nil, err
+	}
+	v, ok := bigIntValue(node.value)
+	if !ok {
+		return nil, fmt.Errorf("parameter %s is not numeric: %s", name, node.typ.String())
+	}
+	return v, nil
+}
+
+// ParameterAsset returns the asset of the numeric rule parameter: "" for native amounts and tx fields,
+// the lowercase token address for erc20 amounts
+func (e *Evm) ParameterAsset(rule *types.Rule, txBytes []byte, name string) (string, error) {
+	r, err := util.ParseResource(rule.GetResource())
+	if err != nil {
+		return "", fmt.Errorf("failed to parse rule resource: %w", err)
+	}
+	if IsTxField(name) || r.ProtocolId == e.nativeSymbol {
+		return "", nil
+	}
+	if r.ProtocolId != "erc20" {
+		return "", fmt.Errorf("asset of %s parameter %s is unknown", r.ProtocolId, name)
+	}
+
+	txData, err := ethereum.DecodeUnsignedPayload(txBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode tx payload: %w", err)
+	}
+	to := etypes.NewTx(txData).To()
+	if to == nil {
+		return "", fmt.Errorf("tx has no token contract")
+	}
+	return strings.ToLower(to.Hex()), nil
+}
+
+// Native protocol functions: value transfer and contract deployment (tx without `to`)
+const (
+	nativeTransfer = "transfer"
+	nativeDeploy   = "deploy"
+)
+
+func (e *Evm) assertArgsNative(
+	ctx context.Context,
+	resource *types.ResourcePath,
+	constraints []*types.ParameterConstraint,
+	tx *etypes.Transaction,
+	partial bool,
+) error {
+	var expected int
+	switch resource.FunctionId {
+	case nativeTransfer:
+		expected = 1
+	case nativeDeploy:
+		expected = 2
+	default:
 		return fmt.Errorf(
-			"only 'transfer' function supported for native: symbol=%s, function_id=%s",
+			"only '%s' and '%s' functions supported for native: symbol=%s, function_id=%s",
+			nativeTransfer,
+			nativeDeploy,
 			resource.ProtocolId,
 			resource.FunctionId,
 		)
 	}
 
-	if len(rule.GetParameterConstraints()) != 1 {
-		return fmt.Errorf("expected 1 parameter constraint, got: %d", len(rule.GetParameterConstraints()))
+	if !partial && len(constraints) != expected {
+		return fmt.Errorf("expected %d parameter constraint, got: %d", expected, len(constraints))
+	}
+
+	if resource.FunctionId == nativeDeploy && (!partial || hasConstraint(constraints, "code")) {
+		err := stdcompare.AssertArg(
+			ctx,
+			e.resolvers,
+			resource.ChainId,
+			constraints,
+			"code",
+			tx.Data(),
+			stdcompare.NewBytes,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to assert code arg (init code): %w", err)
+		}
 	}
 
+	if partial && !hasConstraint(constraints, "amount") {
+		return nil
+	}
 	err := stdcompare.AssertArg(
+		ctx,
+		e.resolvers,
 		resource.ChainId,
-		rule.GetParameterConstraints(),
+		constraints,
 		"amount",
 		tx.Value(),
 		stdcompare.NewBigInt,
@@ -108,7 +292,7 @@ func assertArgsNative(resource *types.ResourcePath, rule *types.Rule, tx *etypes
 	return nil
 }
 
-func assertTarget(resource *types.ResourcePath, target *types.Target, to *common.Address) error {
+func (e *Evm) assertTarget(ctx context.Context, resource *types.ResourcePath, target *types.Target, to *common.Address) error {
 	targetKind := target.GetTargetType()
 	switch targetKind {
 	case types.TargetType_TARGET_TYPE_ADDRESS:
@@ -117,7 +301,7 @@ func assertTarget(resource *types.ResourcePath, target *types.Target, to *common
 			if to != nil {
 				toHex = to.Hex()
 			}
-			return fmt.Errorf(
+			return stdcompare.NewMismatchError(
 				"tx target is wrong: tx_to=%s, rule_target_address=%s",
 				toHex,
 				target.GetAddress(),
@@ -126,7 +310,7 @@ func assertTarget(resource *types.ResourcePath, target *types.Target, to *common
 		return nil
 
 	case types.TargetType_TARGET_TYPE_MAGIC_CONSTANT:
-		resolve, err := resolver.NewMagicConstantRegistry().GetResolver(target.GetMagicConstant())
+		resolve, err := e.resolvers.GetResolver(target.GetMagicConstant())
 		if err != nil {
 			return fmt.Errorf(
 				"failed to get resolver: magic_const=%s",
@@ -135,6 +319,7 @@ func assertTarget(resource *types.ResourcePath, target *types.Target, to *common
 		}
 
 		resolvedAddr, _, err := resolve.Resolve(
+			ctx,
 			target.GetMagicConstant(),
 			resource.ChainId,
 			"default",
@@ -151,7 +336,7 @@ func assertTarget(resource *types.ResourcePath, target *types.Target, to *common
 			if to != nil {
 				toHex = to.Hex()
 			}
-			return fmt.Errorf(
+			return stdcompare.NewMismatchError(
 				"tx target is wrong: tx_to=%s, rule_magic_const_resolved=%s",
 				toHex,
 				resolvedAddr,
@@ -166,61 +351,27 @@ func assertTarget(resource *types.ResourcePath, target *types.Target, to *common
 
 type protocolID = string
 
-func loadAbiDir() (map[protocolID]abi.ABI, error) {
-	base := "."
-
-	entries, err := abi_embed.Dir.ReadDir(base)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read abi dir: err=%w", err)
-	}
-
-	abis := make(map[string]abi.ABI)
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-
-		if !strings.HasSuffix(entry.Name(), ".json") {
-			continue
-		}
-
-		filepath := path.Join(base, entry.Name())
-		file, er := abi_embed.Dir.Open(filepath)
-		if er != nil {
-			return nil, fmt.Errorf("failed to open abi json: path=%s, err=%w", filepath, er)
-		}
-
-		a, er := abi.JSON(file)
-		_ = file.Close()
-		if er != nil {
-			return nil, fmt.Errorf("failed to parse abi json: %w", er)
-		}
-
-		abis[strings.TrimSuffix(entry.Name(), ".json")] = a
-	}
-	return abis, nil
-}
-
-func (e *Evm) assertArgsAbi(resource *types.ResourcePath, rule *types.Rule, data []byte) error {
-	a, ok := e.abi[resource.ProtocolId]
+// unpackArgs finds the ABI method of the resource and unpacks calldata args of it
+func (e *Evm) unpackArgs(resource *types.ResourcePath, data []byte) (abi.Method, []any, error) {
+	a, ok := e.abis.Get(resource.ChainId, resource.ProtocolId)
 	if !ok {
-		return fmt.Errorf("failed to get abi: protocolId=%s", resource.ProtocolId)
+		return abi.Method{}, nil, fmt.Errorf("failed to get abi: chainId=%s, protocolId=%s", resource.ChainId, resource.ProtocolId)
 	}
 
 	method, ok := a.Methods[resource.FunctionId]
 	if !ok {
-		return fmt.Errorf("failed to find abi method: %s", resource.FunctionId)
+		return abi.Method{}, nil, fmt.Errorf("failed to find abi method: %s", resource.FunctionId)
 	}
 
 	const dataOffset = 4
 	if len(data) < dataOffset {
-		return fmt.Errorf("calldata too short: expected at least %d bytes, got %d", dataOffset, len(data))
+		return abi.Method{}, nil, stdcompare.NewMismatchError("calldata too short: expected at least %d bytes, got %d", dataOffset, len(data))
 	}
 
 	actualMethodDescriptor := data[:dataOffset]
 	expectedMethodDescriptor := method.ID
 	if !bytes.Equal(actualMethodDescriptor, expectedMethodDescriptor) {
-		return fmt.Errorf(
+		return abi.Method{}, nil, stdcompare.NewMismatchError(
 			"method descriptor mismatch: expected %x, got %x",
 			expectedMethodDescriptor,
 			actualMethodDescriptor,
@@ -229,12 +380,51 @@ func (e *Evm) assertArgsAbi(resource *types.ResourcePath, rule *types.Rule, data
 
 	args, err := method.Inputs.Unpack(data[dataOffset:])
 	if err != nil {
-		return fmt.Errorf("failed to unpack abi args: %w", err)
+		return abi.Method{}, nil, fmt.Errorf("failed to unpack abi args: %w", err)
 	}
+	return method, args, nil
+}
 
+// argNodes unpacks calldata args of the resource method, calls wrapped by the method
+// are decoded into the `calls` node
+func (e *Evm) argNodes(resource *types.ResourcePath, data []byte) ([]argNode, error) {
+	method, args, err := e.unpackArgs(resource, data)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]argNode, 0, len(args)+1)
 	for i, arg := range args {
-		input := method.Inputs[i]
-		err = assertArgsByType(resource.GetChainId(), input.Name, arg, rule.GetParameterConstraints(), &input)
+		nodes = append(nodes, newArgNode(method.Inputs[i], arg))
+	}
+
+	calls, err := e.decodeInnerCalls(resource.ChainId, resource.ProtocolId, method, args, 0)
+	if err != nil {
+		return nil, err
+	}
+	if calls != nil {
+		nodes = append(nodes, newInnerCallsNode(nil, calls))
+	}
+	return nodes, nil
+}
+
+func (e *Evm) assertArgsAbi(
+	ctx context.Context,
+	resource *types.ResourcePath,
+	constraints []*types.ParameterConstraint,
+	data []byte,
+	partial bool,
+) error {
+	nodes, err := e.argNodes(resource, data)
+	if err != nil {
+		return err
+	}
+	if partial {
+		return e.matchArgNodes(ctx, resource.GetChainId(), nodes, constraints)
+	}
+
+	for _, node := range nodes {
+		err = e.assertArgNode(ctx, resource.GetChainId(), node, constraints, false)
 		if err != nil {
 			return fmt.Errorf("failed to assert args by type: %w", err)
 		}
@@ -246,143 +436,120 @@ func addrEqual(a, b common.Address) bool {
 	return bytes.Equal(a[:], b[:])
 }
 
-func getNestedConstraints(name string, cnstr []*types.ParameterConstraint) []*types.ParameterConstraint {
-	res := make([]*types.ParameterConstraint, 0)
-	for _, c := range cnstr {
-		if strings.HasPrefix(c.GetParameterName(), name+".") {
-			c.ParameterName = strings.TrimPrefix(c.GetParameterName(), name+".")
-			res = append(res, c)
+// assertArgNode checks the value against the constraints addressing it, then recursively checks
+// tuple fields and array elements. Every value must be covered by a constraint on itself or
+// on one of its parents, `covered` is true if a parent constraint was already applied
+func (e *Evm) assertArgNode(ctx context.Context, chainId string, node argNode, constraints []*types.ParameterConstraint, covered bool) error {
+	matched := node.constraints(constraints)
+	for _, c := range matched {
+		err := e.assertArgByType(ctx, chainId, node, c)
+		if err != nil {
+			return err
 		}
 	}
-	return res
-}
+	covered = covered || len(matched) > 0
 
-func assertArgsByType(chainId, inputName string, arg any, constraints []*types.ParameterConstraint, input *abi.Argument) error {
-	switch actual := arg.(type) {
-	case string:
-		er := stdcompare.AssertArg(
-			chainId,
-			constraints,
-			inputName,
-			actual,
-			stdcompare.NewString,
-		)
-		if er != nil {
-			return fmt.Errorf("failed to assert: %w", er)
+	if !node.isContainer() {
+		if !covered {
+			return fmt.Errorf("arg not found: %s", node.path())
 		}
+		return nil
+	}
 
-	case common.Address:
-		er := stdcompare.AssertArg(
-			chainId,
-			constraints,
-			inputName,
-			actual,
-			compare.NewAddress,
-		)
-		if er != nil {
-			return fmt.Errorf("failed to assert: %w", er)
+	if !node.hasNestedConstraints(constraints) {
+		if !covered {
+			return fmt.Errorf("arg not found: %s", node.path())
 		}
+		return nil
+	}
 
-	case []common.Address:
-		er := stdcompare.AssertArg(
-			chainId,
-			constraints,
-			inputName,
-			actual,
-			compare.NewAddressSlice,
-		)
-		if er != nil {
-			return fmt.Errorf("failed to assert: %w", er)
+	for _, child := range node.children() {
+		err := e.assertArgNode(ctx, chainId, child, constraints, covered)
+		if err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-	case *big.Int:
-		er := stdcompare.AssertArg(
-			chainId,
-			constraints,
-			inputName,
-			actual,
-			stdcompare.NewBigInt,
-		)
-		if er != nil {
-			return fmt.Errorf("failed to assert: %w", er)
+// matchArgNodes checks only the args addressed by the constraints. A constraint addressing
+// array elements by the [*] wildcard is satisfied by any of the elements
+func (e *Evm) matchArgNodes(ctx context.Context, chainId string, nodes []argNode, constraints []*types.ParameterConstraint) error {
+	for _, c := range constraints {
+		matched := nodesOnPath(nodes, c.GetParameterName())
+		if len(matched) == 0 {
+			return stdcompare.NewMismatchError("arg not found: %s", c.GetParameterName())
 		}
 
-	case uint8:
-		er := stdcompare.AssertArg(
-			chainId,
-			constraints,
-			inputName,
-			actual,
-			stdcompare.NewUint8,
-		)
-		if er != nil {
-			return fmt.Errorf("failed to assert: %w", er)
+		var err error
+		for _, node := range matched {
+			err = e.assertArgByType(ctx, chainId, node, c)
+			if err == nil || !stdcompare.IsMismatch(err) {
+				break
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to assert args by type: %w", err)
 		}
+	}
+	return nil
+}
 
-	case bool:
-		er := stdcompare.AssertArg(
-			chainId,
-			constraints,
-			inputName,
-			actual,
-			stdcompare.NewBool,
-		)
-		if er != nil {
-			return fmt.Errorf("failed to assert: %w", er)
+func hasConstraint(constraints []*types.ParameterConstraint, name string) bool {
+	for _, c := range constraints {
+		if c.GetParameterName() == name {
+			return true
 		}
+	}
+	return false
+}
 
+// assertArgByType checks the value against a single constraint using the comparator of its Go type
+func (e *Evm) assertArgByType(ctx context.Context, chainId string, node argNode, constraint *types.ParameterConstraint) error {
+	var err error
+	switch actual := node.value.(type) {
+	case string:
+		err = stdcompare.AssertConstraint(ctx, e.resolvers, chainId, constraint, actual, stdcompare.NewString)
+	case common.Address:
+		err = stdcompare.AssertConstraint(ctx, e.resolvers, chainId, constraint, actual, compare.NewAddress)
+	case []common.Address:
+		err = stdcompare.AssertConstraint(ctx, e.resolvers, chainId, constraint, actual, compare.NewAddressSlice)
+	case *big.Int:
+		err = stdcompare.AssertConstraint(ctx, e.resolvers, chainId, constraint, actual, stdcompare.NewBigInt)
+	case uint8:
+		err = stdcompare.AssertConstraint(ctx, e.resolvers, chainId, constraint, actual, stdcompare.NewUint8)
+	case uint16:
+		err = stdcompare.AssertConstraint(ctx, e.resolvers, chainId, constraint, actual, stdcompare.NewUint16)
+	case uint64:
+		err = stdcompare.AssertConstraint(ctx, e.resolvers, chainId, constraint, actual, stdcompare.NewUint64)
+	case bool:
+		err = stdcompare.AssertConstraint(ctx, e.resolvers, chainId, constraint, actual, stdcompare.NewBool)
 	case [32]byte:
-		er := stdcompare.AssertArg(
-			chainId,
-			constraints,
-			inputName,
-			actual,
-			stdcompare.NewBytes32,
-		)
-		if er != nil {
-			return fmt.Errorf("failed to assert: %w", er)
-		}
+		err = stdcompare.AssertConstraint(ctx, e.resolvers, chainId, constraint, actual, stdcompare.NewBytes32)
 	case []byte:
-		er := stdcompare.AssertArg(
-			chainId,
-			constraints,
-			inputName,
-			actual,
-			stdcompare.NewBytes,
-		)
-		if er != nil {
-			return fmt.Errorf("failed to assert: %w", er)
+		err = stdcompare.AssertConstraint(ctx, e.resolvers, chainId, constraint, actual, stdcompare.NewBytes)
+	default:
+		if v, ok := bigIntValue(actual); ok {
+			err = stdcompare.AssertConstraint(ctx, e.resolvers, chainId, constraint, v, stdcompare.NewBigInt)
+			break
 		}
-	case interface{}:
-		if input == nil {
-			return fmt.Errorf("unsopported nil input")
+		if v, ok := fixedBytesValue(actual); ok {
+			err = stdcompare.AssertConstraint(ctx, e.resolvers, chainId, constraint, v, stdcompare.NewBytes)
+			break
 		}
-		v := reflect.ValueOf(actual)
-		switch v.Kind() {
-		case reflect.Struct:
-			cnstr := getNestedConstraints(inputName, constraints)
-			if len(cnstr) != v.NumField() || len(cnstr) != len(input.Type.TupleElems) {
-				return fmt.Errorf(
-					"nested constraints for %s must be same list as ABI args: constraints_len=%d, abi_args_len=%d",
-					inputName,
-					len(cnstr),
-					v.NumField(),
-				)
-			}
-
-			elems := input.Type.TupleRawNames
-
-			for j := 0; j < v.NumField(); j++ {
-				err := assertArgsByType(chainId, elems[j], v.Field(j).Interface(), cnstr, nil)
-				if err != nil {
-					return fmt.Errorf("failed to assert: %w", err)
-				}
-			}
-		default:
-			return fmt.Errorf("unsupported arg type: %s", input.Type.String())
+		// tuples and arrays without a comparator can only be constrained as a whole with ANY,
+		// use paths to constrain their fields and elements
+		if constraint.GetConstraint().GetType() != types.ConstraintType_CONSTRAINT_TYPE_ANY {
+			return fmt.Errorf(
+				"unsupported constraint for arg: path=%s, type=%s, constraint=%s",
+				node.path(),
+				node.typ.String(),
+				constraint.GetConstraint().GetType().String(),
+			)
 		}
-	default:
-		return fmt.Errorf("unsupported arg type: %s", input.Type.String())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to assert %s: %w", node.path(), err)
 	}
 	return nil
 }
@@ -1,6 +1,7 @@
 package evm
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 	"strings"
@@ -188,7 +189,7 @@ func TestRecurringSend_Native(t *testing.T) {
 			evmEngine, err := NewEvm(nativeSymbol)
 			require.NoError(t, err)
 
-			err = evmEngine.Evaluate(rules[0], txBytes)
+			err = evmEngine.Evaluate(context.Background(), rules[0], txBytes)
 			if tc.shouldPass {
 				assert.NoError(t, err, "Expected transaction to pass validation")
 			} else {
@@ -316,7 +317,7 @@ func TestRecurringSend_ERC20(t *testing.T) {
 			evmEngine, err := NewEvm(nativeSymbol)
 			require.NoError(t, err)
 
-			err = evmEngine.Evaluate(rules[0], txBytes)
+			err = evmEngine.Evaluate(context.Background(), rules[0], txBytes)
 			if tc.shouldPass {
 				assert.NoError(t, err, "Expected transaction to pass validation")
 			} else {
@@ -473,7 +474,7 @@ func TestRecurringSwap_SameChain_1inch(t *testing.T) {
 			evmEngine, err := NewEvm(nativeSymbol)
 			require.NoError(t, err)
 
-			err = evmEngine.Evaluate(swapConcreteRule, txBytes)
+			err = evmEngine.Evaluate(context.Background(), swapConcreteRule, txBytes)
 			if tc.shouldPass {
 				assert.NoError(t, err, "Expected swap transaction to pass validation")
 			} else {
@@ -716,7 +717,7 @@ func TestRecurringSend_AllEVMChains(t *testing.T) {
 			evmEngine, err := NewEvm(nativeSymbol)
 			require.NoError(t, err)
 
-			err = evmEngine.Evaluate(rules[0], txBytes)
+			err = evmEngine.Evaluate(context.Background(), rules[0], txBytes)
 			assert.NoError(t, err, "Native send should pass for %s", tc.chain.String())
 		})
 	}
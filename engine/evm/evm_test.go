@@ -1,6 +1,7 @@
 package evm
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"math/big"
@@ -18,6 +19,10 @@ import (
 )
 
 func buildUnsignedTx(to common.Address, data []byte, value *big.Int) []byte {
+	return buildUnsignedTxWithNonce(0, to, data, value)
+}
+
+func buildUnsignedTxWithNonce(nonce uint64, to common.Address, data []byte, value *big.Int) []byte {
 	unsigned := struct {
 		ChainID    *big.Int
 		Nonce      uint64
@@ -30,7 +35,7 @@ func buildUnsignedTx(to common.Address, data []byte, value *big.Int) []byte {
 		AccessList etypes.AccessList
 	}{
 		ChainID:    big.NewInt(1),
-		Nonce:      0,
+		Nonce:      nonce,
 		GasTipCap:  big.NewInt(2_000_000_000),  // 2 gwei
 		GasFeeCap:  big.NewInt(20_000_000_000), // 20 gwei
 		Gas:        300_000,
@@ -453,7 +458,7 @@ func TestEvaluate_ERC20Transfer(t *testing.T) {
 			if err != nil {
 				t.Fatalf("Failed to create EVM: %v", err)
 			}
-			err = evm.Evaluate(tc.rule, txBytes)
+			err = evm.Evaluate(context.Background(), tc.rule, txBytes)
 
 			if tc.shouldError && err == nil {
 				t.Errorf("Expected error but got none")
@@ -732,7 +737,7 @@ func TestEvaluate_NativeTransfer(t *testing.T) {
 			if err != nil {
 				t.Fatalf("Failed to create EVM: %v", err)
 			}
-			er := evm.Evaluate(tc.rule, txBytes)
+			er := evm.Evaluate(context.Background(), tc.rule, txBytes)
 
 			if tc.shouldError && er == nil {
 				t.Errorf("Expected error but got none")
@@ -859,7 +864,7 @@ func TestEvaluate_UniswapAddressArray(t *testing.T) {
 			if err != nil {
 				t.Fatalf("Failed to create EVM: %v", err)
 			}
-			err = evm.Evaluate(tc.rule, txBytes)
+			err = evm.Evaluate(context.Background(), tc.rule, txBytes)
 
 			if tc.shouldError && err == nil {
 				t.Errorf("Expected error but got none")
@@ -1060,7 +1065,7 @@ func TestEvaluate_UniswapUint8AndBytes32(t *testing.T) {
 			if err != nil {
 				t.Fatalf("Failed to create EVM: %v", err)
 			}
-			err = evm.Evaluate(tc.rule, txBytes)
+			err = evm.Evaluate(context.Background(), tc.rule, txBytes)
 
 			if tc.shouldError && err == nil {
 				t.Errorf("Expected error but got none")
@@ -1468,7 +1473,7 @@ func TestEvaluate_1inchSwap(t *testing.T) {
 			if err != nil {
 				t.Fatalf("Failed to create EVM: %v", err)
 			}
-			err = evm.Evaluate(tc.rule, txBytes)
+			err = evm.Evaluate(context.Background(), tc.rule, txBytes)
 
 			if tc.shouldError && err == nil {
 				t.Errorf("Expected error but got none")
@@ -1585,7 +1590,7 @@ func TestEvaluate_ErrorCases(t *testing.T) {
 			if err != nil {
 				t.Fatalf("Failed to create EVM: %v", err)
 			}
-			er := evm.Evaluate(tc.rule, tc.txBytes)
+			er := evm.Evaluate(context.Background(), tc.rule, tc.txBytes)
 
 			if tc.shouldError && er == nil {
 				t.Errorf("Expected error but got none")
@@ -1737,7 +1742,7 @@ func TestEvaluate_MethodDescriptorValidation(t *testing.T) {
 			if err != nil {
 				t.Fatalf("Failed to create EVM: %v", err)
 			}
-			err = evm.Evaluate(tc.rule, txBytes)
+			err = evm.Evaluate(context.Background(), tc.rule, txBytes)
 
 			if tc.shouldError && err == nil {
 				t.Errorf("Expected error but got none")
@@ -1862,7 +1867,7 @@ func TestEvaluate_RegexpConstraints(t *testing.T) {
 			if err != nil {
 				t.Fatalf("Failed to create EVM: %v", err)
 			}
-			err = evm.Evaluate(tc.rule, txBytes)
+			err = evm.Evaluate(context.Background(), tc.rule, txBytes)
 
 			if tc.shouldError && err == nil {
 				t.Errorf("Expected error but got none")
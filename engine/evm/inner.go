@@ -0,0 +1,330 @@
+package evm
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// maxInnerCallDepth limits the nesting of wrapper calls, e.g. multicall inside Safe execTransaction
+const maxInnerCallDepth = 4
+
+// InnerCall is a call wrapped in the bytes args of another call, e.g. a Universal Router command.
+// Inner calls of the tx are constrained as `calls[i].function` and `calls[i].<arg>` parameters,
+// calls wrapped by an inner call are nested as `calls[i].calls[j]...`
+type InnerCall struct {
+	// Function is the sub-command name (e.g. V3_SWAP_EXACT_IN), or protocol.method for calls decoded with a known ABI
+	Function string
+	Inputs   abi.Arguments
+	Args     []any
+	// Protocol and Method are set for calls decoded with a known ABI, they are used to decode nested wrappers
+	Protocol string
+	Method   string
+	// Calls are the calls wrapped by this call, nil if it's not a wrapper
+	Calls []InnerCall
+}
+
+// CallDecoder decodes calldata with the ABIs known to the engine on the chain of the tx
+type CallDecoder interface {
+	// DecodeCall decodes calldata of the protocol method selected by the first 4 bytes
+	DecodeCall(protocolID string, data []byte) (InnerCall, error)
+	// DecodeAnyCall is DecodeCall looking up the method selector in every known ABI
+	DecodeAnyCall(data []byte) (InnerCall, error)
+}
+
+// InnerCallDecoder decodes the calls wrapped in args of a wrapper call, args are keyed by ABI input names.
+// It must return an error for any sub-command it can't decode, so unknown actions are rejected
+type InnerCallDecoder func(d CallDecoder, protocolID string, args map[string]any) ([]InnerCall, error)
+
+// anyProtocol registers the decoder for the method of every protocol, e.g. multicall
+const anyProtocol = "*"
+
+func defaultInnerCallDecoders() map[string]InnerCallDecoder {
+	return map[string]InnerCallDecoder{
+		"uniswap_universal_router.execute":  decodeUniversalRouterExecute,
+		"uniswap_universal_router.execute0": decodeUniversalRouterExecute,
+		"safe.execTransaction":              decodeSafeExecTransaction,
+		anyProtocol + ".multicall":          decodeMulticall,
+		anyProtocol + ".multicall0":         decodeMulticall,
+		anyProtocol + ".multicall1":         decodeMulticall,
+	}
+}
+
+// RegisterInnerCallDecoder sets the decoder of calls wrapped by the protocol method,
+// use "*" as protocolID to decode the method of every protocol
+func (e *Evm) RegisterInnerCallDecoder(protocolID, functionID string, decoder InnerCallDecoder) {
+	e.decoders[protocolID+"."+functionID] = decoder
+}
+
+func (e *Evm) innerCallDecoder(protocolID, functionID string) (InnerCallDecoder, bool) {
+	d, ok := e.decoders[protocolID+"."+functionID]
+	if ok {
+		return d, true
+	}
+	d, ok = e.decoders[anyProtocol+"."+functionID]
+	return d, ok
+}
+
+// chainDecoder is the CallDecoder with ABIs of the chain
+type chainDecoder struct {
+	e     *Evm
+	chain string
+}
+
+func (d chainDecoder) DecodeCall(protocolID string, data []byte) (InnerCall, error) {
+	return d.e.DecodeCall(d.chain, protocolID, data)
+}
+
+func (d chainDecoder) DecodeAnyCall(data []byte) (InnerCall, error) {
+	return d.e.DecodeAnyCall(d.chain, data)
+}
+
+// DecodeCall decodes calldata of the protocol method selected by the first 4 bytes, with the protocol ABI on the chain
+func (e *Evm) DecodeCall(chain, protocolID string, data []byte) (InnerCall, error) {
+	a, ok := e.abis.Get(chain, protocolID)
+	if !ok {
+		return InnerCall{}, fmt.Errorf("failed to get abi: chainId=%s, protocolId=%s", chain, protocolID)
+	}
+
+	const dataOffset = 4
+	if len(data) < dataOffset {
+		return InnerCall{}, fmt.Errorf("calldata too short: expected at least %d bytes, got %d", dataOffset, len(data))
+	}
+
+	method, err := a.MethodById(data[:dataOffset])
+	if err != nil {
+		return InnerCall{}, fmt.Errorf("unknown method of %s: selector=%x", protocolID, data[:dataOffset])
+	}
+
+	args, err := method.Inputs.Unpack(data[dataOffset:])
+	if err != nil {
+		return InnerCall{}, fmt.Errorf("failed to unpack abi args of %s.%s: %w", protocolID, method.Name, err)
+	}
+
+	return InnerCall{
+		Function: protocolID + "." + method.Name,
+		Inputs:   method.Inputs,
+		Args:     args,
+		Protocol: protocolID,
+		Method:   method.Name,
+	}, nil
+}
+
+// DecodeAnyCall is DecodeCall looking up the method selector in every ABI on the chain, in protocol ID order
+func (e *Evm) DecodeAnyCall(chain string, data []byte) (InnerCall, error) {
+	if len(data) < 4 {
+		return InnerCall{}, fmt.Errorf("calldata too short: expected at least 4 bytes, got %d", len(data))
+	}
+
+	for _, protocolID := range e.abis.Protocols(chain) {
+		a, _ := e.abis.Get(chain, protocolID)
+		_, err := a.MethodById(data[:4])
+		if err != nil {
+			continue
+		}
+		return e.DecodeCall(chain, protocolID, data)
+	}
+	return InnerCall{}, fmt.Errorf("unknown method: selector=%x", data[:4])
+}
+
+// decodeInnerCalls decodes calls wrapped by the method, recursively. Returns nil if the method is not a wrapper
+func (e *Evm) decodeInnerCalls(chain, protocolID string, method abi.Method, args []any, depth int) ([]InnerCall, error) {
+	decode, ok := e.innerCallDecoder(protocolID, method.Name)
+	if !ok {
+		return nil, nil
+	}
+	if depth >= maxInnerCallDepth {
+		return nil, fmt.Errorf("inner calls are nested too deep: max_depth=%d", maxInnerCallDepth)
+	}
+
+	named := make(map[string]any, len(args))
+	for i, input := range method.Inputs {
+		named[input.Name] = args[i]
+	}
+
+	calls, err := decode(chainDecoder{e: e, chain: chain}, protocolID, named)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode inner calls of %s.%s: %w", protocolID, method.Name, err)
+	}
+	if calls == nil {
+		calls = []InnerCall{}
+	}
+
+	for i, call := range calls {
+		if call.Protocol == "" {
+			continue
+		}
+		a, _ := e.abis.Get(chain, call.Protocol)
+		nestedMethod, ok := a.Methods[call.Method]
+		if !ok {
+			continue
+		}
+		nested, err := e.decodeInnerCalls(chain, call.Protocol, nestedMethod, call.Args, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		calls[i].Calls = nested
+	}
+	return calls, nil
+}
+
+func decodeMulticall(d CallDecoder, protocolID string, args map[string]any) ([]InnerCall, error) {
+	data, ok := args["data"].([][]byte)
+	if !ok {
+		return nil, fmt.Errorf("multicall data must be bytes[]")
+	}
+
+	calls := make([]InnerCall, 0, len(data))
+	for i, item := range data {
+		call, err := d.DecodeCall(protocolID, item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode call %d: %w", i, err)
+		}
+		calls = append(calls, call)
+	}
+	return calls, nil
+}
+
+func decodeSafeExecTransaction(d CallDecoder, _ string, args map[string]any) ([]InnerCall, error) {
+	data, ok := args["data"].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("safe tx data must be bytes")
+	}
+	if len(data) == 0 {
+		// plain value transfer
+		return nil, nil
+	}
+
+	call, err := d.DecodeAnyCall(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode safe tx data: %w", err)
+	}
+	return []InnerCall{call}, nil
+}
+
+// universalRouterCommand is the Universal Router command with ABI encoding of its input
+type universalRouterCommand struct {
+	name   string
+	inputs abi.Arguments
+}
+
+// universalRouterCommandMask selects the command type, the high bit is the allow-revert flag
+const universalRouterCommandMask = 0x3f
+
+var universalRouterCommands = func() map[byte]universalRouterCommand {
+	permitDetails := []abi.ArgumentMarshaling{
+		{Name: "token", Type: "address"},
+		{Name: "amount", Type: "uint160"},
+		{Name: "expiration", Type: "uint48"},
+		{Name: "nonce", Type: "uint48"},
+	}
+
+	return map[byte]universalRouterCommand{
+		0x00: {"V3_SWAP_EXACT_IN", abi.Arguments{
+			arg("recipient", "address"), arg("amountIn", "uint256"), arg("amountOutMin", "uint256"),
+			arg("path", "bytes"), arg("payerIsUser", "bool"),
+		}},
+		0x01: {"V3_SWAP_EXACT_OUT", abi.Arguments{
+			arg("recipient", "address"), arg("amountOut", "uint256"), arg("amountInMax", "uint256"),
+			arg("path", "bytes"), arg("payerIsUser", "bool"),
+		}},
+		0x02: {"PERMIT2_TRANSFER_FROM", abi.Arguments{
+			arg("token", "address"), arg("recipient", "address"), arg("amount", "uint160"),
+		}},
+		0x03: {"PERMIT2_PERMIT_BATCH", abi.Arguments{
+			tupleArg("permitBatch", "tuple", []abi.ArgumentMarshaling{
+				{Name: "details", Type: "tuple[]", Components: permitDetails},
+				{Name: "spender", Type: "address"},
+				{Name: "sigDeadline", Type: "uint256"},
+			}),
+			arg("signature", "bytes"),
+		}},
+		0x04: {"SWEEP", abi.Arguments{
+			arg("token", "address"), arg("recipient", "address"), arg("amountMin", "uint256"),
+		}},
+		0x05: {"TRANSFER", abi.Arguments{
+			arg("token", "address"), arg("recipient", "address"), arg("value", "uint256"),
+		}},
+		0x06: {"PAY_PORTION", abi.Arguments{
+			arg("token", "address"), arg("recipient", "address"), arg("bips", "uint256"),
+		}},
+		0x08: {"V2_SWAP_EXACT_IN", abi.Arguments{
+			arg("recipient", "address"), arg("amountIn", "uint256"), arg("amountOutMin", "uint256"),
+			arg("path", "address[]"), arg("payerIsUser", "bool"),
+		}},
+		0x09: {"V2_SWAP_EXACT_OUT", abi.Arguments{
+			arg("recipient", "address"), arg("amountOut", "uint256"), arg("amountInMax", "uint256"),
+			arg("path", "address[]"), arg("payerIsUser", "bool"),
+		}},
+		0x0a: {"PERMIT2_PERMIT", abi.Arguments{
+			tupleArg("permitSingle", "tuple", []abi.ArgumentMarshaling{
+				{Name: "details", Type: "tuple", Components: permitDetails},
+				{Name: "spender", Type: "address"},
+				{Name: "sigDeadline", Type: "uint256"},
+			}),
+			arg("signature", "bytes"),
+		}},
+		0x0b: {"WRAP_ETH", abi.Arguments{
+			arg("recipient", "address"), arg("amountMin", "uint256"),
+		}},
+		0x0c: {"UNWRAP_WETH", abi.Arguments{
+			arg("recipient", "address"), arg("amountMin", "uint256"),
+		}},
+		0x0d: {"PERMIT2_TRANSFER_FROM_BATCH", abi.Arguments{
+			tupleArg("batchDetails", "tuple[]", []abi.ArgumentMarshaling{
+				{Name: "from", Type: "address"},
+				{Name: "to", Type: "address"},
+				{Name: "amount", Type: "uint160"},
+				{Name: "token", Type: "address"},
+			}),
+		}},
+		0x0e: {"BALANCE_CHECK_ERC20", abi.Arguments{
+			arg("owner", "address"), arg("token", "address"), arg("minBalance", "uint256"),
+		}},
+	}
+}()
+
+func decodeUniversalRouterExecute(_ CallDecoder, _ string, args map[string]any) ([]InnerCall, error) {
+	commands, ok := args["commands"].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("universal router commands must be bytes")
+	}
+	inputs, ok := args["inputs"].([][]byte)
+	if !ok {
+		return nil, fmt.Errorf("universal router inputs must be bytes[]")
+	}
+	if len(commands) != len(inputs) {
+		return nil, fmt.Errorf("commands and inputs length mismatch: commands=%d, inputs=%d", len(commands), len(inputs))
+	}
+
+	calls := make([]InnerCall, 0, len(commands))
+	for i, b := range commands {
+		command, ok := universalRouterCommands[b&universalRouterCommandMask]
+		if !ok {
+			return nil, fmt.Errorf("unknown universal router command: index=%d, command=0x%02x", i, b)
+		}
+
+		values, err := command.inputs.Unpack(inputs[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to unpack %s input: %w", command.name, err)
+		}
+		calls = append(calls, InnerCall{
+			Function: command.name,
+			Inputs:   command.inputs,
+			Args:     values,
+		})
+	}
+	return calls, nil
+}
+
+func arg(name, typ string) abi.Argument {
+	return tupleArg(name, typ, nil)
+}
+
+func tupleArg(name, typ string, components []abi.ArgumentMarshaling) abi.Argument {
+	t, err := abi.NewType(typ, "", components)
+	if err != nil {
+		panic(fmt.Errorf("invalid abi type %s: %w", typ, err))
+	}
+	return abi.Argument{Name: name, Type: t}
+}
@@ -0,0 +1,301 @@
+package evm
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+	"github.com/vultisig/recipes/sdk/evm/codegen/erc20"
+	"github.com/vultisig/recipes/sdk/evm/codegen/safe"
+	"github.com/vultisig/recipes/sdk/evm/codegen/uniswap_universal_router"
+	"github.com/vultisig/recipes/sdk/evm/codegen/uniswapv3_router"
+	"github.com/vultisig/recipes/types"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+const (
+	innerRouter    = "0x66a9893cC07D91D95644AEDD05D03f95e1dBA8Af"
+	innerRecipient = "0x1111111111111111111111111111111111111111"
+	innerToken     = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+)
+
+func newTestEvm(t *testing.T) *Evm {
+	t.Helper()
+
+	native, _ := vgcommon.Ethereum.NativeSymbol()
+	evm, err := NewEvm(native)
+	require.NoError(t, err)
+	return evm
+}
+
+func packCommand(t *testing.T, command byte, args ...any) []byte {
+	t.Helper()
+
+	data, err := universalRouterCommands[command].inputs.Pack(args...)
+	require.NoError(t, err)
+	return data
+}
+
+func TestEvaluate_UniversalRouterExecute(t *testing.T) {
+	evm := newTestEvm(t)
+
+	wrap := packCommand(t, 0x0b, common.HexToAddress(innerRouter), big.NewInt(1000))
+	swap := packCommand(t, 0x00,
+		common.HexToAddress(innerRecipient),
+		big.NewInt(1000),
+		big.NewInt(1),
+		[]byte{0x01, 0x02},
+		false,
+	)
+
+	rule := func(maxAmountIn string) *types.Rule {
+		return &types.Rule{
+			Effect:   types.Effect_EFFECT_ALLOW,
+			Resource: "ethereum.uniswap_universal_router.execute0",
+			Target: &types.Target{
+				TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+				Target:     &types.Target_Address{Address: innerRouter},
+			},
+			ParameterConstraints: []*types.ParameterConstraint{
+				paramConstraint("commands", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+				paramConstraint("inputs", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+				paramConstraint("deadline", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+				paramConstraint("calls[*]", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+				paramConstraint("calls[*].function", types.ConstraintType_CONSTRAINT_TYPE_IN_SET, "WRAP_ETH,V3_SWAP_EXACT_IN"),
+				paramConstraint("calls[1].recipient", types.ConstraintType_CONSTRAINT_TYPE_FIXED, innerRecipient),
+				paramConstraint("calls[1].amountIn", types.ConstraintType_CONSTRAINT_TYPE_MAX, maxAmountIn),
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		commands []byte
+		inputs   [][]byte
+		maxIn    string
+		wantErr  string
+	}{
+		{
+			name:     "wrap and swap",
+			commands: []byte{0x0b, 0x00},
+			inputs:   [][]byte{wrap, swap},
+			maxIn:    "1000",
+		},
+		{
+			name:     "allow revert flag",
+			commands: []byte{0x0b, 0x80},
+			inputs:   [][]byte{wrap, swap},
+			maxIn:    "1000",
+		},
+		{
+			name:     "swap amount over the limit",
+			commands: []byte{0x0b, 0x00},
+			inputs:   [][]byte{wrap, swap},
+			maxIn:    "999",
+			wantErr:  "calls[1].amountIn",
+		},
+		{
+			name:     "command not in the set",
+			commands: []byte{0x0b, 0x0c},
+			inputs:   [][]byte{wrap, wrap},
+			maxIn:    "1000",
+			wantErr:  "calls[1].function",
+		},
+		{
+			name:     "unknown command",
+			commands: []byte{0x0b, 0x21},
+			inputs:   [][]byte{wrap, swap},
+			maxIn:    "1000",
+			wantErr:  "unknown universal router command: index=1, command=0x21",
+		},
+		{
+			name:     "commands and inputs mismatch",
+			commands: []byte{0x0b},
+			inputs:   [][]byte{wrap, swap},
+			maxIn:    "1000",
+			wantErr:  "commands and inputs length mismatch",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := uniswap_universal_router.NewUniswapUniversalRouter().PackExecute0(tt.commands, tt.inputs, big.NewInt(1700000000))
+			txBytes := buildUnsignedTx(common.HexToAddress(innerRouter), data, big.NewInt(1000))
+
+			err := evm.Evaluate(context.Background(), rule(tt.maxIn), txBytes)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestEvaluate_InnerCallsMustBeConstrained(t *testing.T) {
+	evm := newTestEvm(t)
+
+	data := uniswap_universal_router.NewUniswapUniversalRouter().PackExecute(
+		[]byte{0x0b},
+		[][]byte{packCommand(t, 0x0b, common.HexToAddress(innerRouter), big.NewInt(1))},
+	)
+	txBytes := buildUnsignedTx(common.HexToAddress(innerRouter), data, big.NewInt(1))
+
+	err := evm.Evaluate(context.Background(), &types.Rule{
+		Effect:   types.Effect_EFFECT_ALLOW,
+		Resource: "ethereum.uniswap_universal_router.execute",
+		Target: &types.Target{
+			TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+			Target:     &types.Target_Address{Address: innerRouter},
+		},
+		ParameterConstraints: []*types.ParameterConstraint{
+			paramConstraint("commands", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+			paramConstraint("inputs", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+		},
+	}, txBytes)
+	require.ErrorContains(t, err, "arg not found: calls")
+}
+
+func TestEvaluate_Multicall(t *testing.T) {
+	evm := newTestEvm(t)
+	router := uniswapv3_router.NewUniswapv3Router()
+
+	data := router.PackMulticall0(big.NewInt(1700000000), [][]byte{
+		router.PackExactInputSingle(uniswapv3_router.IV3SwapRouterExactInputSingleParams{
+			TokenIn:           common.HexToAddress(innerToken),
+			TokenOut:          common.HexToAddress(innerRouter),
+			Fee:               big.NewInt(500),
+			Recipient:         common.HexToAddress(innerRecipient),
+			AmountIn:          big.NewInt(1000),
+			AmountOutMinimum:  big.NewInt(1),
+			SqrtPriceLimitX96: big.NewInt(0),
+		}),
+		router.PackRefundETH(),
+	})
+	txBytes := buildUnsignedTx(common.HexToAddress(innerRouter), data, big.NewInt(0))
+
+	rule := &types.Rule{
+		Effect:   types.Effect_EFFECT_ALLOW,
+		Resource: "ethereum.uniswapV3_router.multicall0",
+		Target: &types.Target{
+			TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+			Target:     &types.Target_Address{Address: innerRouter},
+		},
+		ParameterConstraints: []*types.ParameterConstraint{
+			paramConstraint("deadline", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+			paramConstraint("data", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+			paramConstraint("calls[*]", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+			paramConstraint("calls[0].function", types.ConstraintType_CONSTRAINT_TYPE_FIXED, "uniswapV3_router.exactInputSingle"),
+			paramConstraint("calls[0].params.recipient", types.ConstraintType_CONSTRAINT_TYPE_FIXED, innerRecipient),
+			paramConstraint("calls[1].function", types.ConstraintType_CONSTRAINT_TYPE_FIXED, "uniswapV3_router.refundETH"),
+		},
+	}
+	require.NoError(t, evm.Evaluate(context.Background(), rule, txBytes))
+
+	amount, err := evm.ParameterValue(rule, txBytes, "calls[0].params.amountIn")
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), amount.Int64())
+
+	rule.ParameterConstraints[4] = paramConstraint("calls[0].params.recipient", types.ConstraintType_CONSTRAINT_TYPE_FIXED, innerRouter)
+	require.ErrorContains(t, evm.Evaluate(context.Background(), rule, txBytes), "calls[0].params.recipient")
+
+	unknown := router.PackMulticall0(big.NewInt(1700000000), [][]byte{{0xde, 0xad, 0xbe, 0xef}})
+	err = evm.Evaluate(context.Background(), rule, buildUnsignedTx(common.HexToAddress(innerRouter), unknown, big.NewInt(0)))
+	require.ErrorContains(t, err, "unknown method of uniswapV3_router")
+}
+
+func TestEvaluate_SafeExecTransaction(t *testing.T) {
+	const safeAddr = "0x2222222222222222222222222222222222222222"
+
+	evm := newTestEvm(t)
+	router := uniswapv3_router.NewUniswapv3Router()
+
+	execTx := func(to string, inner []byte) []byte {
+		data := safe.NewSafe().PackExecTransaction(
+			common.HexToAddress(to),
+			big.NewInt(0),
+			inner,
+			0,
+			big.NewInt(0),
+			big.NewInt(0),
+			big.NewInt(0),
+			common.Address{},
+			common.Address{},
+			[]byte{0x01},
+		)
+		return buildUnsignedTx(common.HexToAddress(safeAddr), data, big.NewInt(0))
+	}
+
+	rule := func(constraints ...*types.ParameterConstraint) *types.Rule {
+		base := []*types.ParameterConstraint{
+			paramConstraint("to", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+			paramConstraint("value", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+			paramConstraint("data", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+			paramConstraint("operation", types.ConstraintType_CONSTRAINT_TYPE_FIXED, "0"),
+			paramConstraint("safeTxGas", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+			paramConstraint("baseGas", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+			paramConstraint("gasPrice", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+			paramConstraint("gasToken", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+			paramConstraint("refundReceiver", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+			paramConstraint("signatures", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+		}
+		return &types.Rule{
+			Effect:   types.Effect_EFFECT_ALLOW,
+			Resource: "ethereum.safe.execTransaction",
+			Target: &types.Target{
+				TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+				Target:     &types.Target_Address{Address: safeAddr},
+			},
+			ParameterConstraints: append(base, constraints...),
+		}
+	}
+
+	transfer := execTx(innerToken, erc20.NewErc20().PackTransfer(common.HexToAddress(innerRecipient), big.NewInt(5)))
+	err := evm.Evaluate(context.Background(), rule(
+		paramConstraint("calls[0].function", types.ConstraintType_CONSTRAINT_TYPE_FIXED, "erc20.transfer"),
+		paramConstraint("calls[0].recipient", types.ConstraintType_CONSTRAINT_TYPE_FIXED, innerRecipient),
+		paramConstraint("calls[0].amount", types.ConstraintType_CONSTRAINT_TYPE_MAX, "10"),
+	), transfer)
+	require.NoError(t, err)
+
+	// multicall inside the safe tx is decoded recursively
+	nested := execTx(innerRouter, router.PackMulticall([][]byte{router.PackRefundETH()}))
+	err = evm.Evaluate(context.Background(), rule(
+		paramConstraint("calls[0].function", types.ConstraintType_CONSTRAINT_TYPE_FIXED, "uniswapV3_router.multicall"),
+		paramConstraint("calls[0].data", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+		paramConstraint("calls[0].calls[*].function", types.ConstraintType_CONSTRAINT_TYPE_FIXED, "uniswapV3_router.refundETH"),
+	), nested)
+	require.NoError(t, err)
+
+	// empty data is a plain value transfer without inner calls
+	err = evm.Evaluate(context.Background(), rule(paramConstraint("calls", types.ConstraintType_CONSTRAINT_TYPE_ANY, "")), execTx(innerRecipient, nil))
+	require.NoError(t, err)
+
+	err = evm.Evaluate(context.Background(), rule(paramConstraint("calls", types.ConstraintType_CONSTRAINT_TYPE_ANY, "")), execTx(innerRecipient, []byte{0xde, 0xad, 0xbe, 0xef}))
+	require.ErrorContains(t, err, "unknown method: selector=deadbeef")
+}
+
+func TestRegisterInnerCallDecoder(t *testing.T) {
+	evm := newTestEvm(t)
+	evm.RegisterInnerCallDecoder("erc20", "transfer", func(_ CallDecoder, _ string, args map[string]any) ([]InnerCall, error) {
+		return []InnerCall{{Function: "TRANSFER_HOOK"}}, nil
+	})
+
+	data := erc20.NewErc20().PackTransfer(common.HexToAddress(innerRecipient), big.NewInt(5))
+	err := evm.Evaluate(context.Background(), &types.Rule{
+		Effect:   types.Effect_EFFECT_ALLOW,
+		Resource: "ethereum.erc20.transfer",
+		Target: &types.Target{
+			TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+			Target:     &types.Target_Address{Address: innerToken},
+		},
+		ParameterConstraints: []*types.ParameterConstraint{
+			paramConstraint("recipient", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+			paramConstraint("amount", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+			paramConstraint("calls[0].function", types.ConstraintType_CONSTRAINT_TYPE_FIXED, "TRANSFER_HOOK"),
+		},
+	}, buildUnsignedTx(common.HexToAddress(innerToken), data, big.NewInt(0)))
+	require.NoError(t, err)
+}
@@ -0,0 +1,260 @@
+package evm
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/vultisig/recipes/types"
+)
+
+// argNode is a decoded ABI value addressed by parameter paths.
+// Tuple fields are addressed as `desc.srcToken`, array elements as `path[0]`,
+// and `orders[*].maker` addresses the field of every element of the array.
+type argNode struct {
+	// paths of the value, the concrete path goes first followed by the same path with [*] wildcards
+	paths []string
+	typ   abi.Type
+	value any
+	// fields are the children of synthetic nodes, e.g. inner calls which have no ABI type
+	fields []argNode
+}
+
+func newArgNode(input abi.Argument, value any) argNode {
+	return argNode{
+		paths: []string{input.Name},
+		typ:   input.Type,
+		value: value,
+	}
+}
+
+func (n argNode) path() string {
+	return n.paths[0]
+}
+
+// isContainer is true for tuples and arrays, which are checked by their elements
+func (n argNode) isContainer() bool {
+	if n.fields != nil {
+		return true
+	}
+	switch n.typ.T {
+	case abi.TupleTy, abi.SliceTy, abi.ArrayTy:
+		return true
+	default:
+		return false
+	}
+}
+
+// children returns tuple fields or array elements of the node, nil for scalars
+func (n argNode) children() []argNode {
+	if n.fields != nil {
+		return n.fields
+	}
+	v := reflect.ValueOf(n.value)
+
+	switch n.typ.T {
+	case abi.TupleTy:
+		out := make([]argNode, 0, len(n.typ.TupleElems))
+		for j, elem := range n.typ.TupleElems {
+			paths := make([]string, 0, len(n.paths))
+			for _, p := range n.paths {
+				paths = append(paths, p+"."+n.typ.TupleRawNames[j])
+			}
+			out = append(out, argNode{
+				paths: paths,
+				typ:   *elem,
+				value: v.Field(j).Interface(),
+			})
+		}
+		return out
+
+	case abi.SliceTy, abi.ArrayTy:
+		out := make([]argNode, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			paths := make([]string, 0, 2*len(n.paths))
+			for _, p := range n.paths {
+				paths = append(paths, p+"["+strconv.Itoa(i)+"]")
+			}
+			for _, p := range n.paths {
+				paths = append(paths, p+"[*]")
+			}
+			out = append(out, argNode{
+				paths: paths,
+				typ:   *n.typ.Elem,
+				value: v.Index(i).Interface(),
+			})
+		}
+		return out
+
+	default:
+		return nil
+	}
+}
+
+// constraints returns the constraints addressing the node by any of its paths
+func (n argNode) constraints(all []*types.ParameterConstraint) []*types.ParameterConstraint {
+	var out []*types.ParameterConstraint
+	for _, c := range all {
+		for _, p := range n.paths {
+			if c.GetParameterName() == p {
+				out = append(out, c)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// hasNestedConstraints is true if any constraint addresses a field or an element of the node
+func (n argNode) hasNestedConstraints(all []*types.ParameterConstraint) bool {
+	for _, c := range all {
+		for _, p := range n.paths {
+			if strings.HasPrefix(c.GetParameterName(), p+".") || strings.HasPrefix(c.GetParameterName(), p+"[") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findArg returns the value at the concrete parameter path, wildcards are not allowed
+func findArg(nodes []argNode, path string) (argNode, error) {
+	if strings.Contains(path, "[*]") {
+		return argNode{}, fmt.Errorf("wildcard path is not allowed: %s", path)
+	}
+
+	for _, node := range nodes {
+		p := node.path()
+		if path != p && !strings.HasPrefix(path, p+".") && !strings.HasPrefix(path, p+"[") {
+			continue
+		}
+
+		for node.path() != path {
+			next, ok := childOnPath(node, path)
+			if !ok {
+				return argNode{}, fmt.Errorf("arg not found: %s", path)
+			}
+			node = next
+		}
+		return node, nil
+	}
+	return argNode{}, fmt.Errorf("arg not found: %s", path)
+}
+
+// newInnerCallsNode builds the `calls` node under the parent paths (nil for the tx call),
+// every call is a node of its function name, decoded args and nested calls
+func newInnerCallsNode(parent []string, calls []InnerCall) argNode {
+	paths := []string{"calls"}
+	if parent != nil {
+		paths = make([]string, 0, len(parent))
+		for _, p := range parent {
+			paths = append(paths, p+".calls")
+		}
+	}
+
+	node := argNode{
+		paths:  paths,
+		fields: make([]argNode, 0, len(calls)),
+	}
+	for i, call := range calls {
+		callPaths := make([]string, 0, 2*len(paths))
+		for _, p := range paths {
+			callPaths = append(callPaths, p+"["+strconv.Itoa(i)+"]")
+		}
+		for _, p := range paths {
+			callPaths = append(callPaths, p+"[*]")
+		}
+
+		callNode := argNode{
+			paths:  callPaths,
+			fields: make([]argNode, 0, len(call.Args)+2),
+		}
+		callNode.fields = append(callNode.fields, argNode{
+			paths: withSuffix(callPaths, ".function"),
+			typ:   stringType,
+			value: call.Function,
+		})
+		for j, input := range call.Inputs {
+			callNode.fields = append(callNode.fields, argNode{
+				paths: withSuffix(callPaths, "."+input.Name),
+				typ:   input.Type,
+				value: call.Args[j],
+			})
+		}
+		if call.Calls != nil {
+			callNode.fields = append(callNode.fields, newInnerCallsNode(callPaths, call.Calls))
+		}
+		node.fields = append(node.fields, callNode)
+	}
+	return node
+}
+
+func withSuffix(paths []string, suffix string) []string {
+	out := make([]string, 0, len(paths))
+	for _, p := range paths {
+		out = append(out, p+suffix)
+	}
+	return out
+}
+
+var stringType, _ = abi.NewType("string", "", nil)
+
+// nodesOnPath returns the values addressed by the parameter path, with the [*] wildcard
+// every element of the array
+func nodesOnPath(nodes []argNode, path string) []argNode {
+	var out []argNode
+	for _, node := range nodes {
+		if slices.Contains(node.paths, path) {
+			out = append(out, node)
+			continue
+		}
+		if slices.ContainsFunc(node.paths, func(p string) bool {
+			return strings.HasPrefix(path, p+".") || strings.HasPrefix(path, p+"[")
+		}) {
+			out = append(out, nodesOnPath(node.children(), path)...)
+		}
+	}
+	return out
+}
+
+func childOnPath(n argNode, path string) (argNode, bool) {
+	for _, child := range n.children() {
+		p := child.path()
+		if path == p || strings.HasPrefix(path, p+".") || strings.HasPrefix(path, p+"[") {
+			return child, true
+		}
+	}
+	return argNode{}, false
+}
+
+// bigIntValue converts any ABI integer to *big.Int
+func bigIntValue(v any) (*big.Int, bool) {
+	if b, ok := v.(*big.Int); ok {
+		return b, true
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(rv.Uint()), true
+	default:
+		return nil, false
+	}
+}
+
+// fixedBytesValue converts ABI bytesN (other than bytes32) and function values to []byte
+func fixedBytesValue(v any) ([]byte, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Array || rv.Type().Elem().Kind() != reflect.Uint8 {
+		return nil, false
+	}
+	out := make([]byte, rv.Len())
+	reflect.Copy(reflect.ValueOf(out), rv)
+	return out, true
+}
@@ -0,0 +1,274 @@
+package evm
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+	stdcompare "github.com/vultisig/recipes/engine/compare"
+	"github.com/vultisig/recipes/sdk/evm/codegen/erc20"
+	"github.com/vultisig/recipes/sdk/evm/codegen/polymarket_ctf_exchange"
+	"github.com/vultisig/recipes/sdk/evm/codegen/routerv6_1inch"
+	"github.com/vultisig/recipes/types"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestEvaluate_ArgPaths_TupleArray(t *testing.T) {
+	const (
+		exchange = "0x4bFb41d5B3570DeFd03C39a9A4D8dE6Bd8B8982E"
+		maker    = "0x1111111111111111111111111111111111111111"
+		other    = "0x2222222222222222222222222222222222222222"
+	)
+
+	order := func(m string) polymarket_ctf_exchange.Order {
+		return polymarket_ctf_exchange.Order{
+			Salt:          big.NewInt(1),
+			Maker:         common.HexToAddress(m),
+			Signer:        common.HexToAddress(m),
+			Taker:         common.Address{},
+			TokenId:       big.NewInt(42),
+			MakerAmount:   big.NewInt(100),
+			TakerAmount:   big.NewInt(50),
+			Expiration:    big.NewInt(0),
+			Nonce:         big.NewInt(0),
+			FeeRateBps:    big.NewInt(0),
+			Side:          0,
+			SignatureType: 0,
+			Signature:     []byte{0x01},
+		}
+	}
+
+	rule := func(constraints ...*types.ParameterConstraint) *types.Rule {
+		return &types.Rule{
+			Effect:   types.Effect_EFFECT_ALLOW,
+			Resource: "polygon.polymarket_ctf_exchange.fillOrders",
+			Target: &types.Target{
+				TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+				Target:     &types.Target_Address{Address: exchange},
+			},
+			ParameterConstraints: constraints,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		rule    *types.Rule
+		orders  []polymarket_ctf_exchange.Order
+		wantErr string
+	}{
+		{
+			name: "wildcard field of every element",
+			rule: rule(
+				paramConstraint("orders", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+				paramConstraint("orders[*].maker", types.ConstraintType_CONSTRAINT_TYPE_FIXED, maker),
+				paramConstraint("fillAmounts[*]", types.ConstraintType_CONSTRAINT_TYPE_MAX, "100"),
+			),
+			orders: []polymarket_ctf_exchange.Order{order(maker), order(maker)},
+		},
+		{
+			name: "wildcard field mismatch in second element",
+			rule: rule(
+				paramConstraint("orders", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+				paramConstraint("orders[*].maker", types.ConstraintType_CONSTRAINT_TYPE_FIXED, maker),
+				paramConstraint("fillAmounts", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+			),
+			orders:  []polymarket_ctf_exchange.Order{order(maker), order(other)},
+			wantErr: "orders[1].maker",
+		},
+		{
+			name: "indexed element",
+			rule: rule(
+				paramConstraint("orders[*]", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+				paramConstraint("orders[0].maker", types.ConstraintType_CONSTRAINT_TYPE_FIXED, maker),
+				paramConstraint("fillAmounts[1]", types.ConstraintType_CONSTRAINT_TYPE_MAX, "10"),
+				paramConstraint("fillAmounts[0]", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+			),
+			orders:  []polymarket_ctf_exchange.Order{order(maker), order(other)},
+			wantErr: "fillAmounts[1]",
+		},
+		{
+			name: "uncovered element",
+			rule: rule(
+				paramConstraint("orders[0].maker", types.ConstraintType_CONSTRAINT_TYPE_FIXED, maker),
+				paramConstraint("fillAmounts", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+			),
+			orders:  []polymarket_ctf_exchange.Order{order(maker)},
+			wantErr: "arg not found: orders[0].salt",
+		},
+		{
+			name: "non-any constraint on a tuple array",
+			rule: rule(
+				paramConstraint("orders", types.ConstraintType_CONSTRAINT_TYPE_FIXED, maker),
+				paramConstraint("fillAmounts", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+			),
+			orders:  []polymarket_ctf_exchange.Order{order(maker)},
+			wantErr: "unsupported constraint for arg: path=orders",
+		},
+	}
+
+	native, _ := vgcommon.Ethereum.NativeSymbol()
+	evm, err := NewEvm(native)
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amounts := make([]*big.Int, len(tt.orders))
+			for i := range amounts {
+				amounts[i] = big.NewInt(int64(50 * (i + 1)))
+			}
+			data := polymarket_ctf_exchange.NewPolymarketCtfExchange().PackFillOrders(tt.orders, amounts)
+			txBytes := buildEVMTestTx(137, common.HexToAddress(exchange), data, big.NewInt(0))
+
+			err := evm.Evaluate(context.Background(), tt.rule, txBytes)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestEvaluate_ArgPaths_Arrays(t *testing.T) {
+	const router1inch = "0x111111125421cA6dc452d289314280a0f8842A65"
+
+	native, _ := vgcommon.Ethereum.NativeSymbol()
+	evm, err := NewEvm(native)
+	require.NoError(t, err)
+
+	data := routerv6_1inch.NewRouterv61inch().PackCancelOrders(
+		[]*big.Int{big.NewInt(7), big.NewInt(9)},
+		[][32]byte{{0x01}, {0x02}},
+	)
+	txBytes := buildUnsignedTx(common.HexToAddress(router1inch), data, big.NewInt(0))
+
+	rule := &types.Rule{
+		Effect:   types.Effect_EFFECT_ALLOW,
+		Resource: "ethereum.routerV6_1inch.cancelOrders",
+		Target: &types.Target{
+			TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+			Target:     &types.Target_Address{Address: router1inch},
+		},
+		ParameterConstraints: []*types.ParameterConstraint{
+			paramConstraint("makerTraits[*]", types.ConstraintType_CONSTRAINT_TYPE_MAX, "9"),
+			paramConstraint("makerTraits[0]", types.ConstraintType_CONSTRAINT_TYPE_FIXED, "7"),
+			paramConstraint("orderHashes", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+		},
+	}
+	require.NoError(t, evm.Evaluate(context.Background(), rule, txBytes))
+
+	// the rule is not mutated by evaluation and can be reused
+	require.NoError(t, evm.Evaluate(context.Background(), rule, txBytes))
+
+	v, err := evm.ParameterValue(rule, txBytes, "makerTraits[1]")
+	require.NoError(t, err)
+	require.Equal(t, int64(9), v.Int64())
+
+	_, err = evm.ParameterValue(rule, txBytes, "makerTraits[*]")
+	require.ErrorContains(t, err, "wildcard path is not allowed")
+
+	_, err = evm.ParameterValue(rule, txBytes, "makerTraits[2]")
+	require.ErrorContains(t, err, "arg not found")
+
+	rule.ParameterConstraints[0] = paramConstraint("makerTraits[*]", types.ConstraintType_CONSTRAINT_TYPE_MAX, "8")
+	require.ErrorContains(t, evm.Evaluate(context.Background(), rule, txBytes), "makerTraits[1]")
+}
+
+func TestMatch_PartialArgs(t *testing.T) {
+	const (
+		router1inch = "0x111111125421cA6dc452d289314280a0f8842A65"
+		usdc        = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+		blocked     = "0x1111111111111111111111111111111111111111"
+	)
+
+	native, _ := vgcommon.Ethereum.NativeSymbol()
+	evm, err := NewEvm(native)
+	require.NoError(t, err)
+
+	cancelTx := buildUnsignedTx(common.HexToAddress(router1inch), routerv6_1inch.NewRouterv61inch().PackCancelOrders(
+		[]*big.Int{big.NewInt(7), big.NewInt(9)},
+		[][32]byte{{0x01}, {0x02}},
+	), big.NewInt(0))
+	cancelRule := func(constraints ...*types.ParameterConstraint) *types.Rule {
+		return &types.Rule{
+			Effect:   types.Effect_EFFECT_DENY,
+			Resource: "ethereum.routerV6_1inch.cancelOrders",
+			Target: &types.Target{
+				TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+				Target:     &types.Target_Address{Address: router1inch},
+			},
+			ParameterConstraints: constraints,
+		}
+	}
+
+	transferTx := buildUnsignedTx(common.HexToAddress(usdc), erc20.NewErc20().PackTransfer(
+		common.HexToAddress(blocked),
+		big.NewInt(1_000_000),
+	), big.NewInt(0))
+	transferRule := &types.Rule{
+		Effect:   types.Effect_EFFECT_DENY,
+		Resource: "ethereum.erc20.transfer",
+		Target: &types.Target{
+			TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+			Target:     &types.Target_Address{Address: usdc},
+		},
+		ParameterConstraints: []*types.ParameterConstraint{
+			paramConstraint("recipient", types.ConstraintType_CONSTRAINT_TYPE_FIXED, blocked),
+		},
+	}
+
+	tests := []struct {
+		name    string
+		rule    *types.Rule
+		txBytes []byte
+		wantErr string
+	}{
+		{
+			name:    "args without constraints match any value",
+			rule:    transferRule,
+			txBytes: transferTx,
+		},
+		{
+			name:    "rule without constraints matches any call of the function",
+			rule:    cancelRule(),
+			txBytes: cancelTx,
+		},
+		{
+			name:    "wildcard matches if any element satisfies the constraint",
+			rule:    cancelRule(paramConstraint("makerTraits[*]", types.ConstraintType_CONSTRAINT_TYPE_FIXED, "9")),
+			txBytes: cancelTx,
+		},
+		{
+			name:    "wildcard doesn't match if no element satisfies the constraint",
+			rule:    cancelRule(paramConstraint("makerTraits[*]", types.ConstraintType_CONSTRAINT_TYPE_FIXED, "8")),
+			txBytes: cancelTx,
+			wantErr: "failed to compare fixed values",
+		},
+		{
+			name:    "other function doesn't match",
+			rule:    transferRule,
+			txBytes: cancelTx,
+			wantErr: "tx target is wrong",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := evm.Match(context.Background(), tt.rule, tt.txBytes)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				require.True(t, stdcompare.IsMismatch(err))
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	// allow rules must still cover every arg
+	allow := proto.Clone(transferRule).(*types.Rule)
+	allow.Effect = types.Effect_EFFECT_ALLOW
+	require.ErrorContains(t, evm.Evaluate(context.Background(), allow, transferTx), "arg not found: amount")
+}
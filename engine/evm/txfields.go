@@ -0,0 +1,183 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	etypes "github.com/ethereum/go-ethereum/core/types"
+	stdcompare "github.com/vultisig/recipes/engine/compare"
+	"github.com/vultisig/recipes/engine/evm/compare"
+	"github.com/vultisig/recipes/types"
+)
+
+// Reserved parameter names constraining fields of the tx itself rather than its calldata.
+// They are optional, a rule which doesn't constrain a tx field allows any value of it
+const (
+	TxGasLimit             = "tx.gas_limit"
+	TxGasPrice             = "tx.gas_price"
+	TxMaxFeePerGas         = "tx.max_fee_per_gas"
+	TxMaxPriorityFeePerGas = "tx.max_priority_fee_per_gas"
+	TxChainID              = "tx.chain_id"
+	TxNonce                = "tx.nonce"
+	TxMaxFeePerBlobGas     = "tx.max_fee_per_blob_gas"
+	TxBlobCount            = "tx.blob_count"
+	TxAuthorizationCount   = "tx.authorization_count"
+	// TxAuthorizationAddress and TxAuthorizationChainID constrain every EIP-7702 authorization of the tx,
+	// authorization chain id 0 means the delegation is valid on any chain
+	TxAuthorizationAddress = "tx.authorization_address"
+	TxAuthorizationChainID = "tx.authorization_chain_id"
+)
+
+const txFieldPrefix = "tx."
+
+// IsTxField reports whether the parameter name is reserved for tx fields
+func IsTxField(name string) bool {
+	return strings.HasPrefix(name, txFieldPrefix)
+}
+
+// splitTxFieldConstraints separates tx field constraints from calldata ones
+func splitTxFieldConstraints(constraints []*types.ParameterConstraint) (args, fields []*types.ParameterConstraint) {
+	for _, c := range constraints {
+		if IsTxField(c.GetParameterName()) {
+			fields = append(fields, c)
+			continue
+		}
+		args = append(args, c)
+	}
+	return args, fields
+}
+
+// txFieldValue returns the value of the tx field. For legacy and access list txs
+// both fee caps are the gas price, for dynamic fee txs the gas price is the fee cap
+func txFieldValue(tx *etypes.Transaction, name string) (*big.Int, error) {
+	switch name {
+	case TxGasLimit:
+		return new(big.Int).SetUint64(tx.Gas()), nil
+	case TxGasPrice:
+		return tx.GasPrice(), nil
+	case TxMaxFeePerGas:
+		return tx.GasFeeCap(), nil
+	case TxMaxPriorityFeePerGas:
+		return tx.GasTipCap(), nil
+	case TxNonce:
+		return new(big.Int).SetUint64(tx.Nonce()), nil
+	case TxMaxFeePerBlobGas:
+		if tx.Type() != etypes.BlobTxType {
+			return new(big.Int), nil
+		}
+		return tx.BlobGasFeeCap(), nil
+	case TxBlobCount:
+		return big.NewInt(int64(len(tx.BlobHashes()))), nil
+	case TxAuthorizationCount:
+		return big.NewInt(int64(len(tx.SetCodeAuthorizations()))), nil
+	case TxChainID:
+		if tx.Type() == etypes.LegacyTxType {
+			return nil, fmt.Errorf("%s is not available for legacy txs", TxChainID)
+		}
+		return tx.ChainId(), nil
+	default:
+		return nil, fmt.Errorf("unknown tx field: %s", name)
+	}
+}
+
+// assertTxFields checks the tx fields against the constraints. Txs carrying EIP-7702 authorizations
+// are rejected unless the constraints explicitly cover the delegate address, since a delegation
+// hands the control of the vault to the delegate code. Partial matching checks only the constrained
+// fields, authorization constraints match if any authorization of the tx satisfies them
+func (e *Evm) assertTxFields(
+	ctx context.Context,
+	chainId string,
+	constraints []*types.ParameterConstraint,
+	tx *etypes.Transaction,
+	partial bool,
+) error {
+	var authConstraints []*types.ParameterConstraint
+	for _, c := range constraints {
+		switch c.GetParameterName() {
+		case TxAuthorizationAddress, TxAuthorizationChainID:
+			authConstraints = append(authConstraints, c)
+			continue
+		}
+
+		v, err := txFieldValue(tx, c.GetParameterName())
+		if err != nil {
+			return err
+		}
+
+		err = stdcompare.AssertConstraint(ctx, e.resolvers, chainId, c, v, stdcompare.NewBigInt)
+		if err != nil {
+			return fmt.Errorf("failed to assert %s: %w", c.GetParameterName(), err)
+		}
+	}
+
+	auths := tx.SetCodeAuthorizations()
+	if partial {
+		return e.assertAnyAuthorization(ctx, chainId, authConstraints, auths)
+	}
+
+	if len(auths) > 0 && !hasConstraint(authConstraints, TxAuthorizationAddress) {
+		return fmt.Errorf("tx has %d authorizations, but %s is not constrained", len(auths), TxAuthorizationAddress)
+	}
+	for i, auth := range auths {
+		err := e.assertAuthorization(ctx, chainId, authConstraints, i, auth)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assertAnyAuthorization checks that at least one authorization of the tx satisfies all the constraints,
+// so a deny rule on the delegate matches the tx whatever the other authorizations are
+func (e *Evm) assertAnyAuthorization(
+	ctx context.Context,
+	chainId string,
+	constraints []*types.ParameterConstraint,
+	auths []etypes.SetCodeAuthorization,
+) error {
+	if len(constraints) == 0 {
+		return nil
+	}
+	if len(auths) == 0 {
+		return stdcompare.NewMismatchError("tx has no authorizations")
+	}
+
+	var errs []error
+	for i, auth := range auths {
+		err := e.assertAuthorization(ctx, chainId, constraints, i, auth)
+		if err == nil {
+			return nil
+		}
+		if !stdcompare.IsMismatch(err) {
+			return err
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// assertAuthorization checks the authorization with index i against the authorization constraints
+func (e *Evm) assertAuthorization(
+	ctx context.Context,
+	chainId string,
+	constraints []*types.ParameterConstraint,
+	i int,
+	auth etypes.SetCodeAuthorization,
+) error {
+	for _, c := range constraints {
+		var err error
+		switch c.GetParameterName() {
+		case TxAuthorizationAddress:
+			err = stdcompare.AssertConstraint(ctx, e.resolvers, chainId, c, auth.Address, compare.NewAddress)
+		case TxAuthorizationChainID:
+			err = stdcompare.AssertConstraint(ctx, e.resolvers, chainId, c, auth.ChainID.ToBig(), stdcompare.NewBigInt)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to assert %s of authorization %d: %w", c.GetParameterName(), i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package evm
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	etypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+	"github.com/vultisig/recipes/types"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+func paramConstraint(name string, typ types.ConstraintType, value string) *types.ParameterConstraint {
+	c := &types.Constraint{Type: typ}
+	switch typ {
+	case types.ConstraintType_CONSTRAINT_TYPE_FIXED:
+		c.Value = &types.Constraint_FixedValue{FixedValue: value}
+	case types.ConstraintType_CONSTRAINT_TYPE_MAX:
+		c.Value = &types.Constraint_MaxValue{MaxValue: value}
+	case types.ConstraintType_CONSTRAINT_TYPE_MIN:
+		c.Value = &types.Constraint_MinValue{MinValue: value}
+	case types.ConstraintType_CONSTRAINT_TYPE_IN_SET:
+		c.Value = &types.Constraint_SetValue{SetValue: &types.SetValue{Values: strings.Split(value, ",")}}
+	}
+	return &types.ParameterConstraint{ParameterName: name, Constraint: c}
+}
+
+func TestEvaluate_TxFields(t *testing.T) {
+	const recipient = "0x1111111111111111111111111111111111111111"
+
+	native, err := vgcommon.Ethereum.NativeSymbol()
+	require.NoError(t, err)
+	evm, err := NewEvm(native)
+	require.NoError(t, err)
+
+	rule := func(constraints ...*types.ParameterConstraint) *types.Rule {
+		return &types.Rule{
+			Effect:   types.Effect_EFFECT_ALLOW,
+			Resource: "ethereum.eth.transfer",
+			Target: &types.Target{
+				TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+				Target:     &types.Target_Address{Address: recipient},
+			},
+			ParameterConstraints: append([]*types.ParameterConstraint{
+				paramConstraint("amount", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+			}, constraints...),
+		}
+	}
+
+	// 300k gas, 20 gwei fee cap, 2 gwei tip, chain id 1
+	tx := buildUnsignedTxWithNonce(7, common.HexToAddress(recipient), nil, big.NewInt(1))
+
+	tests := []struct {
+		name       string
+		constraint *types.ParameterConstraint
+		errMsg     string
+	}{
+		{
+			name:       "no tx field constraints",
+			constraint: nil,
+		},
+		{
+			name:       "gas limit under ceiling",
+			constraint: paramConstraint(TxGasLimit, types.ConstraintType_CONSTRAINT_TYPE_MAX, "300000"),
+		},
+		{
+			name:       "gas limit over ceiling",
+			constraint: paramConstraint(TxGasLimit, types.ConstraintType_CONSTRAINT_TYPE_MAX, "299999"),
+			errMsg:     "failed to assert tx.gas_limit",
+		},
+		{
+			name:       "max fee per gas over ceiling",
+			constraint: paramConstraint(TxMaxFeePerGas, types.ConstraintType_CONSTRAINT_TYPE_MAX, "10000000000"),
+			errMsg:     "failed to assert tx.max_fee_per_gas",
+		},
+		{
+			name:       "max priority fee per gas under ceiling",
+			constraint: paramConstraint(TxMaxPriorityFeePerGas, types.ConstraintType_CONSTRAINT_TYPE_MAX, "2000000000"),
+		},
+		{
+			name:       "gas price is fee cap for dynamic fee tx",
+			constraint: paramConstraint(TxGasPrice, types.ConstraintType_CONSTRAINT_TYPE_FIXED, "20000000000"),
+		},
+		{
+			name:       "chain id matches",
+			constraint: paramConstraint(TxChainID, types.ConstraintType_CONSTRAINT_TYPE_FIXED, "1"),
+		},
+		{
+			name:       "chain id mismatch",
+			constraint: paramConstraint(TxChainID, types.ConstraintType_CONSTRAINT_TYPE_FIXED, "56"),
+			errMsg:     "failed to assert tx.chain_id",
+		},
+		{
+			name:       "nonce under min",
+			constraint: paramConstraint(TxNonce, types.ConstraintType_CONSTRAINT_TYPE_MIN, "8"),
+			errMsg:     "failed to assert tx.nonce",
+		},
+		{
+			name:       "unknown tx field",
+			constraint: paramConstraint("tx.blob_fee", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+			errMsg:     "unknown tx field: tx.blob_fee",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := rule()
+			if tc.constraint != nil {
+				r = rule(tc.constraint)
+			}
+
+			err := evm.Evaluate(context.Background(), r, tx)
+			if tc.errMsg != "" {
+				require.ErrorContains(t, err, tc.errMsg)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestTxFieldValue_Legacy(t *testing.T) {
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	tx := etypes.NewTx(&etypes.LegacyTx{
+		Nonce:    3,
+		GasPrice: big.NewInt(5_000_000_000),
+		Gas:      21_000,
+		To:       &to,
+		Value:    big.NewInt(1),
+	})
+
+	for _, name := range []string{TxGasPrice, TxMaxFeePerGas, TxMaxPriorityFeePerGas} {
+		v, err := txFieldValue(tx, name)
+		require.NoError(t, err)
+		require.Equal(t, int64(5_000_000_000), v.Int64(), name)
+	}
+
+	_, err := txFieldValue(tx, TxChainID)
+	require.ErrorContains(t, err, "not available for legacy txs")
+}
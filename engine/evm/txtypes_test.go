@@ -0,0 +1,301 @@
+package evm
+
+import (
+	"context"
+	"encoding/base64"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	etypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+	"github.com/vultisig/recipes/chain/evm/ethereum"
+	stdcompare "github.com/vultisig/recipes/engine/compare"
+	"github.com/vultisig/recipes/types"
+)
+
+const txTypesRecipient = "0x1111111111111111111111111111111111111111"
+
+func encodeUnsignedTx(txType byte, payload any) []byte {
+	b, err := rlp.EncodeToBytes(payload)
+	if err != nil {
+		panic(err)
+	}
+	return append([]byte{txType}, b...)
+}
+
+func buildUnsignedBlobTx(to common.Address, blobs int) []byte {
+	hashes := make([]common.Hash, blobs)
+	for i := range hashes {
+		hashes[i] = common.Hash{0x01, byte(i)}
+	}
+	return encodeUnsignedTx(etypes.BlobTxType, ethereum.BlobTxWithoutSignature{
+		ChainID:    uint256.NewInt(1),
+		GasTipCap:  uint256.NewInt(2_000_000_000),
+		GasFeeCap:  uint256.NewInt(20_000_000_000),
+		Gas:        21_000,
+		To:         to,
+		Value:      uint256.NewInt(1),
+		BlobFeeCap: uint256.NewInt(3_000_000_000),
+		BlobHashes: hashes,
+	})
+}
+
+func buildUnsignedSetCodeTx(to common.Address, delegates ...common.Address) []byte {
+	auths := make([]etypes.SetCodeAuthorization, 0, len(delegates))
+	for _, d := range delegates {
+		auths = append(auths, etypes.SetCodeAuthorization{
+			ChainID: *uint256.NewInt(1),
+			Address: d,
+		})
+	}
+	return encodeUnsignedTx(etypes.SetCodeTxType, ethereum.SetCodeTxWithoutSignature{
+		ChainID:   uint256.NewInt(1),
+		GasTipCap: uint256.NewInt(2_000_000_000),
+		GasFeeCap: uint256.NewInt(20_000_000_000),
+		Gas:       100_000,
+		To:        to,
+		Value:     uint256.NewInt(1),
+		AuthList:  auths,
+	})
+}
+
+func buildUnsignedDeployTx(code []byte, value *big.Int) []byte {
+	return encodeUnsignedTx(etypes.DynamicFeeTxType, ethereum.DynamicFeeTxWithoutSignature{
+		ChainID:   big.NewInt(1),
+		GasTipCap: big.NewInt(2_000_000_000),
+		GasFeeCap: big.NewInt(20_000_000_000),
+		Gas:       1_000_000,
+		Value:     value,
+		Data:      code,
+	})
+}
+
+func ethTransferRule(constraints ...*types.ParameterConstraint) *types.Rule {
+	return &types.Rule{
+		Effect:   types.Effect_EFFECT_ALLOW,
+		Resource: "ethereum.eth.transfer",
+		Target: &types.Target{
+			TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+			Target:     &types.Target_Address{Address: txTypesRecipient},
+		},
+		ParameterConstraints: append([]*types.ParameterConstraint{
+			paramConstraint("amount", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+		}, constraints...),
+	}
+}
+
+func TestEvaluate_BlobTx(t *testing.T) {
+	evm, err := NewEvm("ETH")
+	require.NoError(t, err)
+
+	tx := buildUnsignedBlobTx(common.HexToAddress(txTypesRecipient), 2)
+
+	err = evm.Evaluate(context.Background(), ethTransferRule(), tx)
+	require.NoError(t, err)
+
+	err = evm.Evaluate(context.Background(), ethTransferRule(
+		paramConstraint(TxBlobCount, types.ConstraintType_CONSTRAINT_TYPE_MAX, "2"),
+		paramConstraint(TxMaxFeePerBlobGas, types.ConstraintType_CONSTRAINT_TYPE_MAX, "3000000000"),
+	), tx)
+	require.NoError(t, err)
+
+	err = evm.Evaluate(context.Background(), ethTransferRule(
+		paramConstraint(TxBlobCount, types.ConstraintType_CONSTRAINT_TYPE_FIXED, "0"),
+	), tx)
+	require.ErrorContains(t, err, "failed to assert tx.blob_count")
+
+	// non-blob txs have no blobs and zero blob fee
+	plain := buildUnsignedTx(common.HexToAddress(txTypesRecipient), nil, big.NewInt(1))
+	err = evm.Evaluate(context.Background(), ethTransferRule(
+		paramConstraint(TxBlobCount, types.ConstraintType_CONSTRAINT_TYPE_FIXED, "0"),
+		paramConstraint(TxMaxFeePerBlobGas, types.ConstraintType_CONSTRAINT_TYPE_FIXED, "0"),
+	), plain)
+	require.NoError(t, err)
+}
+
+func TestEvaluate_SetCodeTx(t *testing.T) {
+	const (
+		trustedDelegate = "0x63c0c19a282a1B52b07dD5a65b58948A07DAE32B"
+		otherDelegate   = "0x2222222222222222222222222222222222222222"
+	)
+
+	evm, err := NewEvm("ETH")
+	require.NoError(t, err)
+
+	to := common.HexToAddress(txTypesRecipient)
+
+	tests := []struct {
+		name   string
+		rule   *types.Rule
+		tx     []byte
+		errMsg string
+	}{
+		{
+			name: "no authorizations",
+			rule: ethTransferRule(),
+			tx:   buildUnsignedSetCodeTx(to),
+		},
+		{
+			name:   "authorizations rejected unless constrained",
+			rule:   ethTransferRule(),
+			tx:     buildUnsignedSetCodeTx(to, common.HexToAddress(trustedDelegate)),
+			errMsg: "tx has 1 authorizations, but tx.authorization_address is not constrained",
+		},
+		{
+			name: "trusted delegate allowed",
+			rule: ethTransferRule(
+				paramConstraint(TxAuthorizationAddress, types.ConstraintType_CONSTRAINT_TYPE_FIXED, trustedDelegate),
+				paramConstraint(TxAuthorizationChainID, types.ConstraintType_CONSTRAINT_TYPE_FIXED, "1"),
+			),
+			tx: buildUnsignedSetCodeTx(to, common.HexToAddress(trustedDelegate)),
+		},
+		{
+			name: "every authorization checked",
+			rule: ethTransferRule(
+				paramConstraint(TxAuthorizationAddress, types.ConstraintType_CONSTRAINT_TYPE_FIXED, trustedDelegate),
+			),
+			tx:     buildUnsignedSetCodeTx(to, common.HexToAddress(trustedDelegate), common.HexToAddress(otherDelegate)),
+			errMsg: "failed to assert tx.authorization_address of authorization 1",
+		},
+		{
+			name: "authorizations forbidden",
+			rule: ethTransferRule(
+				paramConstraint(TxAuthorizationAddress, types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+				paramConstraint(TxAuthorizationCount, types.ConstraintType_CONSTRAINT_TYPE_FIXED, "0"),
+			),
+			tx:     buildUnsignedSetCodeTx(to, common.HexToAddress(trustedDelegate)),
+			errMsg: "failed to assert tx.authorization_count",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := evm.Evaluate(context.Background(), tc.rule, tc.tx)
+			if tc.errMsg != "" {
+				require.ErrorContains(t, err, tc.errMsg)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestMatch_SetCodeTx_Deny(t *testing.T) {
+	const (
+		trustedDelegate = "0x63c0c19a282a1B52b07dD5a65b58948A07DAE32B"
+		deniedDelegate  = "0x2222222222222222222222222222222222222222"
+	)
+
+	evm, err := NewEvm("ETH")
+	require.NoError(t, err)
+
+	to := common.HexToAddress(txTypesRecipient)
+	denyRule := func(constraint *types.ParameterConstraint) *types.Rule {
+		rule := ethTransferRule(constraint)
+		rule.Effect = types.Effect_EFFECT_DENY
+		return rule
+	}
+
+	tests := []struct {
+		name     string
+		rule     *types.Rule
+		tx       []byte
+		mismatch bool
+	}{
+		{
+			name: "any authorization denied",
+			rule: denyRule(paramConstraint(TxAuthorizationCount, types.ConstraintType_CONSTRAINT_TYPE_MIN, "1")),
+			tx:   buildUnsignedSetCodeTx(to, common.HexToAddress(trustedDelegate)),
+		},
+		{
+			name:     "no authorizations to deny",
+			rule:     denyRule(paramConstraint(TxAuthorizationCount, types.ConstraintType_CONSTRAINT_TYPE_MIN, "1")),
+			tx:       buildUnsignedSetCodeTx(to),
+			mismatch: true,
+		},
+		{
+			name: "one of authorizations delegates to the denied address",
+			rule: denyRule(paramConstraint(TxAuthorizationAddress, types.ConstraintType_CONSTRAINT_TYPE_FIXED, deniedDelegate)),
+			tx: buildUnsignedSetCodeTx(
+				to,
+				common.HexToAddress(trustedDelegate),
+				common.HexToAddress(deniedDelegate),
+			),
+		},
+		{
+			name:     "no authorization delegates to the denied address",
+			rule:     denyRule(paramConstraint(TxAuthorizationAddress, types.ConstraintType_CONSTRAINT_TYPE_FIXED, deniedDelegate)),
+			tx:       buildUnsignedSetCodeTx(to, common.HexToAddress(trustedDelegate)),
+			mismatch: true,
+		},
+		{
+			name:     "no authorizations to match the denied address",
+			rule:     denyRule(paramConstraint(TxAuthorizationAddress, types.ConstraintType_CONSTRAINT_TYPE_FIXED, deniedDelegate)),
+			tx:       buildUnsignedSetCodeTx(to),
+			mismatch: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := evm.Match(context.Background(), tc.rule, tc.tx)
+			if tc.mismatch {
+				require.Error(t, err)
+				require.True(t, stdcompare.IsMismatch(err), err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestEvaluate_Deploy(t *testing.T) {
+	evm, err := NewEvm("ETH")
+	require.NoError(t, err)
+
+	code := []byte{0x60, 0x80, 0x60, 0x40, 0x52}
+
+	deployRule := func(codeConstraint *types.ParameterConstraint) *types.Rule {
+		return &types.Rule{
+			Effect:   types.Effect_EFFECT_ALLOW,
+			Resource: "ethereum.eth.deploy",
+			ParameterConstraints: []*types.ParameterConstraint{
+				paramConstraint("amount", types.ConstraintType_CONSTRAINT_TYPE_MAX, "0"),
+				codeConstraint,
+			},
+		}
+	}
+
+	err = evm.Evaluate(context.Background(), deployRule(
+		paramConstraint("code", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+	), buildUnsignedDeployTx(code, big.NewInt(0)))
+	require.NoError(t, err)
+
+	err = evm.Evaluate(context.Background(), deployRule(
+		paramConstraint("code", types.ConstraintType_CONSTRAINT_TYPE_FIXED, base64.StdEncoding.EncodeToString(code)),
+	), buildUnsignedDeployTx(code, big.NewInt(0)))
+	require.NoError(t, err)
+
+	err = evm.Evaluate(context.Background(), deployRule(
+		paramConstraint("code", types.ConstraintType_CONSTRAINT_TYPE_FIXED, base64.StdEncoding.EncodeToString([]byte{0x00})),
+	), buildUnsignedDeployTx(code, big.NewInt(0)))
+	require.ErrorContains(t, err, "failed to assert code arg")
+
+	err = evm.Evaluate(context.Background(), deployRule(
+		paramConstraint("code", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+	), buildUnsignedDeployTx(code, big.NewInt(1)))
+	require.ErrorContains(t, err, "failed to assert amount arg")
+
+	// a call is not a deployment
+	err = evm.Evaluate(context.Background(), deployRule(
+		paramConstraint("code", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+	), buildUnsignedTx(common.HexToAddress(txTypesRecipient), code, big.NewInt(0)))
+	require.ErrorContains(t, err, "tx is not a contract deployment")
+
+	// and a deployment is not a transfer
+	err = evm.Evaluate(context.Background(), ethTransferRule(), buildUnsignedDeployTx(nil, big.NewInt(1)))
+	require.ErrorContains(t, err, "tx target is wrong: tx_to=nil")
+}
@@ -0,0 +1,238 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	stdcompare "github.com/vultisig/recipes/engine/compare"
+	"github.com/vultisig/recipes/types"
+	"github.com/vultisig/recipes/util"
+)
+
+// Permit2Address is the canonical Permit2 deployment, same on every EVM chain
+var Permit2Address = common.HexToAddress("0x000000000022D473030F116dDEE9F6B43aC78BA3")
+
+// EvaluateTypedData validates EIP-712 typed data (eth_signTypedData_v4 JSON) against an allow rule,
+// e.g. an EIP-2612 or Permit2 permit
+func (e *Evm) EvaluateTypedData(ctx context.Context, rule *types.Rule, data []byte) error {
+	if rule.GetEffect() != types.Effect_EFFECT_ALLOW {
+		return fmt.Errorf("only allow rules supported, got: %s", rule.GetEffect().String())
+	}
+	return e.matchTypedData(ctx, rule, data, false)
+}
+
+// MatchTypedData checks the typed data against the rule regardless of its effect.
+// The resource function is the primary type with the first letter lowercased, e.g.
+// `ethereum.erc20.permit` for EIP-2612 Permit and `ethereum.permit2.permitSingle` for Permit2 PermitSingle.
+// The domain verifying contract is checked as the tx target and the domain chain id as the tx chain id.
+// Message fields are constrained by paths same as ABI args, e.g. `details.token` or `permitted[*].amount`,
+// tx field constraints (tx.*) don't apply to typed data. Same as Match, fields without constraints match any value
+func (e *Evm) MatchTypedData(ctx context.Context, rule *types.Rule, data []byte) error {
+	return e.matchTypedData(ctx, rule, data, true)
+}
+
+func (e *Evm) matchTypedData(ctx context.Context, rule *types.Rule, data []byte, partial bool) error {
+	r, err := util.ParseResource(rule.GetResource())
+	if err != nil {
+		return fmt.Errorf("failed to parse rule resource: %w", err)
+	}
+
+	var td apitypes.TypedData
+	err = json.Unmarshal(data, &td)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal typed data: %w", err)
+	}
+	_, _, err = apitypes.TypedDataAndHash(td)
+	if err != nil {
+		return fmt.Errorf("invalid typed data: %w", err)
+	}
+
+	if r.FunctionId != lowerFirst(td.PrimaryType) {
+		return stdcompare.NewMismatchError("typed data primary type %s doesn't match function: %s", td.PrimaryType, r.FunctionId)
+	}
+
+	if td.Domain.ChainId == nil {
+		return fmt.Errorf("typed data domain has no chain id")
+	}
+	err = e.assertChainIDValue(r, (*big.Int)(td.Domain.ChainId))
+	if err != nil {
+		return fmt.Errorf("failed to assert chain id: %w", err)
+	}
+
+	if !common.IsHexAddress(td.Domain.VerifyingContract) {
+		return fmt.Errorf("typed data domain has no verifying contract")
+	}
+	verifyingContract := common.HexToAddress(td.Domain.VerifyingContract)
+
+	err = e.assertTarget(ctx, r, rule.GetTarget(), &verifyingContract)
+	if err != nil {
+		return fmt.Errorf("failed to assert target: %w", err)
+	}
+	err = e.assertDeployment(ctx, r, &verifyingContract)
+	if err != nil {
+		return fmt.Errorf("failed to assert deployment: %w", err)
+	}
+
+	nodes, err := typedStructNodes(td, td.PrimaryType, td.Message, nil)
+	if err != nil {
+		return fmt.Errorf("failed to decode typed data message: %w", err)
+	}
+
+	argConstraints, _ := splitTxFieldConstraints(rule.GetParameterConstraints())
+	if partial {
+		err = e.matchArgNodes(ctx, r.ChainId, nodes, argConstraints)
+		if err != nil {
+			return fmt.Errorf("failed to assert typed data: %w", err)
+		}
+		return nil
+	}
+	for _, node := range nodes {
+		err = e.assertArgNode(ctx, r.ChainId, node, argConstraints, false)
+		if err != nil {
+			return fmt.Errorf("failed to assert typed data: %w", err)
+		}
+	}
+	return nil
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// typedStructNodes returns nodes of the struct fields, under the parent paths (nil for the message)
+func typedStructNodes(td apitypes.TypedData, typeName string, data map[string]any, parent []string) ([]argNode, error) {
+	fields := td.Types[typeName]
+	out := make([]argNode, 0, len(fields))
+	for _, field := range fields {
+		paths := []string{field.Name}
+		if parent != nil {
+			paths = make([]string, 0, len(parent))
+			for _, p := range parent {
+				paths = append(paths, p+"."+field.Name)
+			}
+		}
+
+		node, err := typedValueNode(td, field.Type, data[field.Name], paths)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, node)
+	}
+	return out, nil
+}
+
+func typedValueNode(td apitypes.TypedData, typ string, value any, paths []string) (argNode, error) {
+	if strings.HasSuffix(typ, "]") {
+		items, ok := value.([]any)
+		if !ok {
+			return argNode{}, fmt.Errorf("%s must be an array", paths[0])
+		}
+
+		elemType := typ[:strings.LastIndex(typ, "[")]
+		elems := make([]argNode, 0, len(items))
+		for i, item := range items {
+			elemPaths := make([]string, 0, 2*len(paths))
+			for _, p := range paths {
+				elemPaths = append(elemPaths, p+"["+strconv.Itoa(i)+"]")
+			}
+			for _, p := range paths {
+				elemPaths = append(elemPaths, p+"[*]")
+			}
+
+			elem, err := typedValueNode(td, elemType, item, elemPaths)
+			if err != nil {
+				return argNode{}, err
+			}
+			elems = append(elems, elem)
+		}
+		return argNode{paths: paths, value: value, fields: elems}, nil
+	}
+
+	if _, ok := td.Types[typ]; ok {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return argNode{}, fmt.Errorf("%s must be a %s struct", paths[0], typ)
+		}
+
+		fields, err := typedStructNodes(td, typ, m, paths)
+		if err != nil {
+			return argNode{}, err
+		}
+		return argNode{paths: paths, value: value, fields: fields}, nil
+	}
+
+	v, err := typedPrimitive(typ, value)
+	if err != nil {
+		return argNode{}, fmt.Errorf("invalid %s value of %s: %w", typ, paths[0], err)
+	}
+	return argNode{paths: paths, value: v}, nil
+}
+
+// typedPrimitive converts the JSON value of the EIP-712 atomic type to the Go type compared by assertArgByType
+func typedPrimitive(typ string, value any) (any, error) {
+	switch {
+	case typ == "address":
+		s, ok := value.(string)
+		if !ok || !common.IsHexAddress(s) {
+			return nil, fmt.Errorf("not a hex address: %v", value)
+		}
+		return common.HexToAddress(s), nil
+
+	case typ == "bool":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("not a bool: %v", value)
+		}
+		return b, nil
+
+	case typ == "string":
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("not a string: %v", value)
+		}
+		return s, nil
+
+	case strings.HasPrefix(typ, "uint"), strings.HasPrefix(typ, "int"):
+		switch v := value.(type) {
+		case string:
+			b, ok := math.ParseBig256(v)
+			if !ok {
+				return nil, fmt.Errorf("not an integer: %s", v)
+			}
+			return b, nil
+		case float64:
+			// validated by TypedDataAndHash to be integral
+			return big.NewInt(int64(v)), nil
+		default:
+			return nil, fmt.Errorf("not an integer: %v", value)
+		}
+
+	case strings.HasPrefix(typ, "bytes"):
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("not hex bytes: %v", value)
+		}
+		b, err := hexutil.Decode(s)
+		if err != nil {
+			return nil, err
+		}
+		if typ == "bytes32" && len(b) == 32 {
+			return [32]byte(b), nil
+		}
+		return b, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported type")
+	}
+}
@@ -0,0 +1,174 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vultisig/recipes/types"
+)
+
+const (
+	permitOwner   = "0x1111111111111111111111111111111111111111"
+	permitSpender = "0x2222222222222222222222222222222222222222"
+	permitUSDC    = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+)
+
+func erc2612Permit(chainID int, value string, deadline int) []byte {
+	return []byte(fmt.Sprintf(`{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Permit": [
+			{"name": "owner", "type": "address"},
+			{"name": "spender", "type": "address"},
+			{"name": "value", "type": "uint256"},
+			{"name": "nonce", "type": "uint256"},
+			{"name": "deadline", "type": "uint256"}
+		]
+	},
+	"primaryType": "Permit",
+	"domain": {"name": "USD Coin", "version": "2", "chainId": %d, "verifyingContract": "%s"},
+	"message": {
+		"owner": "%s",
+		"spender": "%s",
+		"value": "%s",
+		"nonce": "0",
+		"deadline": %d
+	}
+}`, chainID, permitUSDC, permitOwner, permitSpender, value, deadline))
+}
+
+func permit2Single(verifyingContract, token, amount string) []byte {
+	return []byte(fmt.Sprintf(`{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"PermitDetails": [
+			{"name": "token", "type": "address"},
+			{"name": "amount", "type": "uint160"},
+			{"name": "expiration", "type": "uint48"},
+			{"name": "nonce", "type": "uint48"}
+		],
+		"PermitSingle": [
+			{"name": "details", "type": "PermitDetails"},
+			{"name": "spender", "type": "address"},
+			{"name": "sigDeadline", "type": "uint256"}
+		]
+	},
+	"primaryType": "PermitSingle",
+	"domain": {"name": "Permit2", "chainId": "1", "verifyingContract": "%s"},
+	"message": {
+		"details": {"token": "%s", "amount": "%s", "expiration": "1900000000", "nonce": "0"},
+		"spender": "%s",
+		"sigDeadline": "1900000000"
+	}
+}`, verifyingContract, token, amount, permitSpender))
+}
+
+func TestEvaluateTypedData_ERC2612(t *testing.T) {
+	evm, err := NewEvm("ETH")
+	require.NoError(t, err)
+
+	rule := &types.Rule{
+		Effect:   types.Effect_EFFECT_ALLOW,
+		Resource: "ethereum.erc20.permit",
+		Target: &types.Target{
+			TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+			Target:     &types.Target_Address{Address: permitUSDC},
+		},
+		ParameterConstraints: []*types.ParameterConstraint{
+			paramConstraint("owner", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+			paramConstraint("spender", types.ConstraintType_CONSTRAINT_TYPE_FIXED, permitSpender),
+			paramConstraint("value", types.ConstraintType_CONSTRAINT_TYPE_MAX, "1000000"),
+			paramConstraint("nonce", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+			paramConstraint("deadline", types.ConstraintType_CONSTRAINT_TYPE_MAX, "1900000000"),
+			// policy-level tx field defaults don't apply to typed data
+			paramConstraint(TxGasLimit, types.ConstraintType_CONSTRAINT_TYPE_MAX, "100000"),
+		},
+	}
+
+	ctx := context.Background()
+	require.NoError(t, evm.EvaluateTypedData(ctx, rule, erc2612Permit(1, "1000000", 1_800_000_000)))
+
+	err = evm.EvaluateTypedData(ctx, rule, erc2612Permit(1, "1000001", 1_800_000_000))
+	require.ErrorContains(t, err, "failed to assert typed data")
+
+	err = evm.EvaluateTypedData(ctx, rule, erc2612Permit(1, "1000000", 2_000_000_000))
+	require.ErrorContains(t, err, "failed to assert typed data")
+
+	err = evm.EvaluateTypedData(ctx, rule, erc2612Permit(42161, "1000000", 1_800_000_000))
+	require.ErrorContains(t, err, "tx chain id mismatch: expected=1, actual=42161")
+
+	err = evm.EvaluateTypedData(ctx, rule, []byte(`{"primaryType": "Permit"}`))
+	require.ErrorContains(t, err, "invalid typed data")
+
+	transfer := &types.Rule{
+		Effect:   types.Effect_EFFECT_ALLOW,
+		Resource: "ethereum.erc20.transfer",
+		Target:   rule.Target,
+	}
+	err = evm.EvaluateTypedData(ctx, transfer, erc2612Permit(1, "1000000", 1_800_000_000))
+	require.ErrorContains(t, err, "typed data primary type Permit doesn't match function: transfer")
+}
+
+func TestEvaluateTypedData_Permit2(t *testing.T) {
+	const (
+		weth      = "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+		lookalike = "0x6666666666666666666666666666666666666666"
+	)
+
+	evm, err := NewEvm("ETH")
+	require.NoError(t, err)
+	evm.SetDeploymentRegistry(NewDeploymentRegistry())
+
+	rule := func(target string) *types.Rule {
+		return &types.Rule{
+			Effect:   types.Effect_EFFECT_ALLOW,
+			Resource: "ethereum.permit2.permitSingle",
+			Target: &types.Target{
+				TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+				Target:     &types.Target_Address{Address: target},
+			},
+			ParameterConstraints: []*types.ParameterConstraint{
+				paramConstraint("details.token", types.ConstraintType_CONSTRAINT_TYPE_FIXED, permitUSDC),
+				paramConstraint("details.amount", types.ConstraintType_CONSTRAINT_TYPE_MAX, "5000000"),
+				paramConstraint("details.expiration", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+				paramConstraint("details.nonce", types.ConstraintType_CONSTRAINT_TYPE_ANY, ""),
+				paramConstraint("spender", types.ConstraintType_CONSTRAINT_TYPE_FIXED, permitSpender),
+				paramConstraint("sigDeadline", types.ConstraintType_CONSTRAINT_TYPE_MAX, "1900000000"),
+			},
+		}
+	}
+
+	ctx := context.Background()
+	permit2 := Permit2Address.Hex()
+
+	require.NoError(t, evm.EvaluateTypedData(ctx, rule(permit2), permit2Single(permit2, permitUSDC, "5000000")))
+
+	err = evm.EvaluateTypedData(ctx, rule(permit2), permit2Single(permit2, weth, "5000000"))
+	require.ErrorContains(t, err, "failed to assert typed data")
+
+	err = evm.EvaluateTypedData(ctx, rule(permit2), permit2Single(permit2, permitUSDC, "5000001"))
+	require.ErrorContains(t, err, "failed to assert typed data")
+
+	err = evm.EvaluateTypedData(ctx, rule(permit2), permit2Single(lookalike, permitUSDC, "5000000"))
+	require.ErrorContains(t, err, "tx target is wrong")
+
+	err = evm.EvaluateTypedData(ctx, rule(lookalike), permit2Single(lookalike, permitUSDC, "5000000"))
+	require.ErrorContains(t, err, "tx target is not a known deployment of permit2 on Ethereum")
+
+	deny := rule(permit2)
+	deny.Effect = types.Effect_EFFECT_DENY
+	err = evm.EvaluateTypedData(ctx, deny, permit2Single(permit2, permitUSDC, "5000000"))
+	require.ErrorContains(t, err, "only allow rules supported")
+	require.NoError(t, evm.MatchTypedData(ctx, deny, permit2Single(permit2, permitUSDC, "5000000")))
+}
@@ -0,0 +1,256 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/vultisig/recipes/types"
+	"github.com/vultisig/recipes/util"
+)
+
+// PolicyExplanation describes what the policy allows and denies after meta-rules expansion
+type PolicyExplanation struct {
+	PolicyID  string                `json:"policy_id"`
+	RateLimit string                `json:"rate_limit,omitempty"`
+	Resources []ResourceExplanation `json:"resources"`
+}
+
+// ResourceExplanation describes a single concrete rule
+type ResourceExplanation struct {
+	RuleID      string                 `json:"rule_id,omitempty"`
+	Effect      string                 `json:"effect"`
+	Resource    string                 `json:"resource"`
+	Chain       string                 `json:"chain"`
+	Protocol    string                 `json:"protocol"`
+	Function    string                 `json:"function"`
+	Target      *TargetExplanation     `json:"target,omitempty"`
+	Parameters  []ParameterExplanation `json:"parameters,omitempty"`
+	Description string                 `json:"description"`
+}
+
+// TargetExplanation describes the tx target (recipient or contract) required by the rule
+type TargetExplanation struct {
+	Type          string `json:"type"`
+	Address       string `json:"address,omitempty"`
+	MagicConstant string `json:"magic_constant,omitempty"`
+	ResolveError  string `json:"resolve_error,omitempty"`
+	Description   string `json:"description"`
+}
+
+// ParameterExplanation describes the constraint of a single rule parameter
+type ParameterExplanation struct {
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	Required      bool   `json:"required,omitempty"`
+	Period        string `json:"period,omitempty"`
+	DenominatedIn string `json:"denominated_in,omitempty"`
+	Description   string `json:"description"`
+}
+
+// String returns the human-readable explanation, one resource per paragraph
+func (p *PolicyExplanation) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Policy %s", p.PolicyID)
+	if p.RateLimit != "" {
+		fmt.Fprintf(&b, " (%s)", p.RateLimit)
+	}
+	b.WriteString(":\n")
+
+	for _, r := range p.Resources {
+		fmt.Fprintf(&b, "- %s\n", r.Description)
+		if r.Target != nil {
+			fmt.Fprintf(&b, "    target: %s\n", r.Target.Description)
+		}
+		for _, param := range r.Parameters {
+			fmt.Fprintf(&b, "    %s: %s\n", param.Name, param.Description)
+		}
+	}
+	return b.String()
+}
+
+// Explain describes what the policy permits: meta-rules are expanded into concrete resources
+// and magic constants are resolved to their current values
+func (e *Engine) Explain(policy *types.Policy) (*PolicyExplanation, error) {
+	return e.ExplainContext(context.Background(), policy)
+}
+
+// ExplainContext is Explain with a context, which cancels magic constant resolution
+func (e *Engine) ExplainContext(ctx context.Context, policy *types.Policy) (*PolicyExplanation, error) {
+	out := &PolicyExplanation{
+		PolicyID:  policy.GetId(),
+		RateLimit: explainRateLimit(policy),
+		Resources: make([]ResourceExplanation, 0, len(policy.GetRules())),
+	}
+
+	rules, err := policyRules(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range rules {
+		r, err := e.explainRule(ctx, rule)
+		if err != nil {
+			return nil, fmt.Errorf("failed to explain rule %s: %w", rule.GetId(), err)
+		}
+		out.Resources = append(out.Resources, r)
+	}
+	return out, nil
+}
+
+func (e *Engine) explainRule(ctx context.Context, rule *types.Rule) (ResourceExplanation, error) {
+	resource, err := util.ParseResource(rule.GetResource())
+	if err != nil {
+		return ResourceExplanation{}, fmt.Errorf("failed to parse rule resource: %w", err)
+	}
+
+	effect := "allow"
+	if rule.GetEffect() == types.Effect_EFFECT_DENY {
+		effect = "deny"
+	}
+
+	out := ResourceExplanation{
+		RuleID:   rule.GetId(),
+		Effect:   effect,
+		Resource: rule.GetResource(),
+		Chain:    resource.ChainId,
+		Protocol: resource.ProtocolId,
+		Function: resource.FunctionId,
+		Description: fmt.Sprintf(
+			"%s %s.%s on %s",
+			strings.ToUpper(effect[:1])+effect[1:],
+			resource.ProtocolId,
+			resource.FunctionId,
+			resource.ChainId,
+		),
+	}
+
+	if rule.GetTarget() != nil {
+		out.Target = e.explainTarget(ctx, resource.ChainId, rule.GetTarget())
+	}
+
+	for _, pc := range rule.GetParameterConstraints() {
+		c := pc.GetConstraint()
+		out.Parameters = append(out.Parameters, ParameterExplanation{
+			Name:          pc.GetParameterName(),
+			Type:          constraintTypeName(c.GetType()),
+			Required:      c.GetRequired(),
+			Period:        c.GetPeriod(),
+			DenominatedIn: c.GetDenominatedIn(),
+			Description:   e.describeConstraint(ctx, resource.ChainId, c),
+		})
+	}
+	return out, nil
+}
+
+func (e *Engine) explainTarget(ctx context.Context, chainID string, target *types.Target) *TargetExplanation {
+	switch target.GetTargetType() {
+	case types.TargetType_TARGET_TYPE_ADDRESS:
+		return &TargetExplanation{
+			Type:        "address",
+			Address:     target.GetAddress(),
+			Description: target.GetAddress(),
+		}
+	case types.TargetType_TARGET_TYPE_MAGIC_CONSTANT:
+		out := &TargetExplanation{
+			Type:          "magic_constant",
+			MagicConstant: target.GetMagicConstant().String(),
+		}
+		addr, err := e.resolveMagicConstant(ctx, chainID, target.GetMagicConstant())
+		if err != nil {
+			out.ResolveError = err.Error()
+		}
+		out.Address = addr
+		out.Description = describeMagicConstant(target.GetMagicConstant(), addr, err)
+		return out
+	default:
+		return &TargetExplanation{
+			Type:        "unspecified",
+			Description: "any target",
+		}
+	}
+}
+
+func (e *Engine) resolveMagicConstant(ctx context.Context, chainID string, constant types.MagicConstant) (string, error) {
+	resolve, err := e.resolvers.GetResolver(constant)
+	if err != nil {
+		return "", fmt.Errorf("failed to get resolver: %w", err)
+	}
+	addr, _, err := resolve.Resolve(ctx, constant, chainID, "default")
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve: %w", err)
+	}
+	return addr, nil
+}
+
+func describeMagicConstant(constant types.MagicConstant, addr string, err error) string {
+	if err != nil {
+		return fmt.Sprintf("%s (unresolved: %v)", constant.String(), err)
+	}
+	return fmt.Sprintf("%s (currently %s)", constant.String(), addr)
+}
+
+// describeConstraint explains the constraint in plain terms, e.g. "at most 100 per day"
+func (e *Engine) describeConstraint(ctx context.Context, chainID string, c *types.Constraint) string {
+	var out string
+	switch c.GetType() {
+	case types.ConstraintType_CONSTRAINT_TYPE_ANY:
+		out = "any value"
+	case types.ConstraintType_CONSTRAINT_TYPE_FIXED:
+		out = fmt.Sprintf("must be %s", c.GetFixedValue())
+	case types.ConstraintType_CONSTRAINT_TYPE_MAX:
+		out = fmt.Sprintf("at most %s", c.GetMaxValue())
+	case types.ConstraintType_CONSTRAINT_TYPE_MIN:
+		out = fmt.Sprintf("at least %s", c.GetMinValue())
+	case types.ConstraintType_CONSTRAINT_TYPE_MAGIC_CONSTANT:
+		addr, err := e.resolveMagicConstant(ctx, chainID, c.GetMagicConstantValue())
+		out = "must be " + describeMagicConstant(c.GetMagicConstantValue(), addr, err)
+	case types.ConstraintType_CONSTRAINT_TYPE_REGEXP:
+		out = fmt.Sprintf("must match regexp %s", c.GetRegexpValue())
+	case types.ConstraintType_CONSTRAINT_TYPE_RANGE:
+		out = fmt.Sprintf("between %s and %s inclusive", c.GetRangeValue().GetMin(), c.GetRangeValue().GetMax())
+	case types.ConstraintType_CONSTRAINT_TYPE_IN_SET:
+		out = fmt.Sprintf("one of [%s]", strings.Join(c.GetSetValue().GetValues(), ", "))
+	case types.ConstraintType_CONSTRAINT_TYPE_NOT_IN_SET:
+		out = fmt.Sprintf("none of [%s]", strings.Join(c.GetSetValue().GetValues(), ", "))
+	case types.ConstraintType_CONSTRAINT_TYPE_ALL_OF, types.ConstraintType_CONSTRAINT_TYPE_ANY_OF:
+		var nested []string
+		for _, n := range c.GetCompositeValue().GetConstraints() {
+			nested = append(nested, "("+e.describeConstraint(ctx, chainID, n)+")")
+		}
+		op := " and "
+		if c.GetType() == types.ConstraintType_CONSTRAINT_TYPE_ANY_OF {
+			op = " or "
+		}
+		out = strings.Join(nested, op)
+	case types.ConstraintType_CONSTRAINT_TYPE_NOT:
+		var nested []string
+		for _, n := range c.GetCompositeValue().GetConstraints() {
+			nested = append(nested, e.describeConstraint(ctx, chainID, n))
+		}
+		out = fmt.Sprintf("not (%s)", strings.Join(nested, ", "))
+	default:
+		out = fmt.Sprintf("unsupported constraint %s", c.GetType().String())
+	}
+
+	if c.GetDenominatedIn() != "" {
+		out += fmt.Sprintf(" denominated in %s", c.GetDenominatedIn())
+	}
+	if c.GetPeriod() != "" {
+		out += fmt.Sprintf(" per %s", c.GetPeriod())
+	}
+	return out
+}
+
+// constraintTypeName returns the short name of the constraint type, e.g. "max" for CONSTRAINT_TYPE_MAX
+func constraintTypeName(t types.ConstraintType) string {
+	return strings.ToLower(strings.TrimPrefix(t.String(), "CONSTRAINT_TYPE_"))
+}
+
+func explainRateLimit(policy *types.Policy) string {
+	if policy.RateLimitWindow == nil || policy.GetRateLimitWindow() == 0 {
+		return ""
+	}
+	window, maxTxs := policyWindow(policy)
+	return fmt.Sprintf("at most %d txs per %s", maxTxs, window.String())
+}
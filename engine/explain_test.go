@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vultisig/recipes/resolver"
+	"github.com/vultisig/recipes/types"
+)
+
+func TestExplain(t *testing.T) {
+	const vault = "0x2222222222222222222222222222222222222222"
+
+	registry := &resolver.MagicConstantRegistry{}
+	registry.Register(resolver.FromLegacy(&fixedVault{address: vault}))
+
+	engine, err := NewEngine(WithResolverRegistry(registry))
+	require.NoError(t, err)
+
+	policy := &types.Policy{
+		Id:              "explained",
+		RateLimitWindow: uint32Ptr(3600),
+		MaxTxsPerWindow: uint32Ptr(2),
+		Rules: []*types.Rule{{
+			Id:       "send to vault",
+			Resource: "ethereum.send",
+			Effect:   types.Effect_EFFECT_ALLOW,
+			ParameterConstraints: []*types.ParameterConstraint{{
+				ParameterName: "asset",
+				Constraint: &types.Constraint{
+					Type:  types.ConstraintType_CONSTRAINT_TYPE_FIXED,
+					Value: &types.Constraint_FixedValue{FixedValue: ""},
+				},
+			}, {
+				ParameterName: "from_address",
+				Constraint: &types.Constraint{
+					Type: types.ConstraintType_CONSTRAINT_TYPE_ANY,
+				},
+			}, {
+				ParameterName: "amount",
+				Constraint: &types.Constraint{
+					Type:   types.ConstraintType_CONSTRAINT_TYPE_MAX,
+					Value:  &types.Constraint_MaxValue{MaxValue: "1000"},
+					Period: "day",
+				},
+			}, {
+				ParameterName: "to_address",
+				Constraint: &types.Constraint{
+					Type: types.ConstraintType_CONSTRAINT_TYPE_MAGIC_CONSTANT,
+					Value: &types.Constraint_MagicConstantValue{
+						MagicConstantValue: types.MagicConstant_THORCHAIN_VAULT,
+					},
+				},
+			}},
+		}, {
+			Id:       "no big transfers",
+			Resource: "ethereum.erc20.transfer",
+			Effect:   types.Effect_EFFECT_DENY,
+			Target: &types.Target{
+				TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+				Target:     &types.Target_Address{Address: bundleToken},
+			},
+			ParameterConstraints: []*types.ParameterConstraint{{
+				ParameterName: "amount",
+				Constraint: &types.Constraint{
+					Type: types.ConstraintType_CONSTRAINT_TYPE_ANY_OF,
+					Value: &types.Constraint_CompositeValue{CompositeValue: &types.CompositeValue{
+						Constraints: []*types.Constraint{{
+							Type:  types.ConstraintType_CONSTRAINT_TYPE_MIN,
+							Value: &types.Constraint_MinValue{MinValue: "5000"},
+						}, {
+							Type:  types.ConstraintType_CONSTRAINT_TYPE_IN_SET,
+							Value: &types.Constraint_SetValue{SetValue: &types.SetValue{Values: []string{"1", "2"}}},
+						}},
+					}},
+				},
+			}},
+		}},
+	}
+
+	explanation, err := engine.Explain(policy)
+	require.NoError(t, err)
+	require.Equal(t, "explained", explanation.PolicyID)
+	require.Equal(t, "at most 2 txs per 1h0m0s", explanation.RateLimit)
+	require.Len(t, explanation.Resources, 2)
+
+	send := explanation.Resources[0]
+	require.Equal(t, "allow", send.Effect)
+	require.Equal(t, "ethereum.eth.transfer", send.Resource)
+	require.Equal(t, "ethereum", send.Chain)
+	require.Equal(t, "eth", send.Protocol)
+	require.Equal(t, "transfer", send.Function)
+	require.Equal(t, "magic_constant", send.Target.Type)
+	require.Equal(t, vault, send.Target.Address)
+	require.Equal(t, "THORCHAIN_VAULT (currently "+vault+")", send.Target.Description)
+	require.Equal(t, []ParameterExplanation{{
+		Name:        "amount",
+		Type:        "max",
+		Period:      "day",
+		Description: "at most 1000 per day",
+	}}, send.Parameters)
+
+	deny := explanation.Resources[1]
+	require.Equal(t, "deny", deny.Effect)
+	require.Equal(t, "Deny erc20.transfer on ethereum", deny.Description)
+	require.Equal(t, "(at least 5000) or (one of [1, 2])", deny.Parameters[0].Description)
+
+	text := explanation.String()
+	require.Contains(t, text, "Policy explained (at most 2 txs per 1h0m0s):")
+	require.Contains(t, text, "- Allow eth.transfer on ethereum\n")
+	require.Contains(t, text, "    amount: at most 1000 per day\n")
+
+	raw, err := json.Marshal(explanation)
+	require.NoError(t, err)
+
+	var decoded PolicyExplanation
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	require.Equal(t, *explanation, decoded)
+}
+
+func TestExplain_UnresolvedMagicConstant(t *testing.T) {
+	engine, err := NewEngine(WithResolverRegistry(&resolver.MagicConstantRegistry{}))
+	require.NoError(t, err)
+
+	explanation, err := engine.Explain(&types.Policy{
+		Rules: []*types.Rule{{
+			Resource: "ethereum.eth.transfer",
+			Effect:   types.Effect_EFFECT_ALLOW,
+			Target: &types.Target{
+				TargetType: types.TargetType_TARGET_TYPE_MAGIC_CONSTANT,
+				Target: &types.Target_MagicConstant{
+					MagicConstant: types.MagicConstant_THORCHAIN_VAULT,
+				},
+			},
+		}},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, explanation.Resources[0].Target.ResolveError)
+	require.Contains(t, explanation.Resources[0].Target.Description, "THORCHAIN_VAULT (unresolved:")
+}
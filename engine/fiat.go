@@ -0,0 +1,241 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/vultisig/recipes/engine/compare"
+	"github.com/vultisig/recipes/engine/price"
+	"github.com/vultisig/recipes/engine/units"
+	"github.com/vultisig/recipes/types"
+	"github.com/vultisig/vultisig-go/common"
+)
+
+// fiatDecimals of fiat amounts counted against period limits, i.e. amounts are recorded in millionths of the currency
+const fiatDecimals = 6
+
+// hasFiatConstraints reports whether any constraint of the rule is denominated in a fiat currency
+func hasFiatConstraints(rule *types.Rule) bool {
+	for _, pc := range rule.GetParameterConstraints() {
+		if price.IsFiat(pc.GetConstraint().GetDenominatedIn()) {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutFiatConstraints returns the copy of the rule checked by the chain engine: fiat-denominated constraints
+// are relaxed to ANY, the engine checks them against the value of the amount in the currency
+func withoutFiatConstraints(rule *types.Rule) *types.Rule {
+	if !hasFiatConstraints(rule) {
+		return rule
+	}
+
+	out := proto.Clone(rule).(*types.Rule)
+	for _, pc := range out.GetParameterConstraints() {
+		if !price.IsFiat(pc.GetConstraint().GetDenominatedIn()) {
+			continue
+		}
+		pc.Constraint = &types.Constraint{
+			Type:     types.ConstraintType_CONSTRAINT_TYPE_ANY,
+			Required: pc.GetConstraint().GetRequired(),
+		}
+	}
+	return out
+}
+
+// matchRule reports whether the tx matches the deny rule, nil error means match.
+// An error which isn't a mismatch (see compare.IsMismatch) means the rule can't be evaluated,
+// e.g. the tx can't be decoded or a magic constant can't be resolved, and the tx must be rejected.
+// Fiat constraints which can't be evaluated (e.g. the price is stale) match, so the tx is denied
+func (e *Engine) matchRule(
+	ctx context.Context,
+	chain common.Chain,
+	chainEngine ChainEngine,
+	rule *types.Rule,
+	txBytes []byte,
+) error {
+	err := chainEngine.Match(ctx, withoutFiatConstraints(rule), txBytes)
+	if err != nil {
+		return err
+	}
+
+	err = e.assertDenominations(chain, rule, chainEngine, txBytes)
+	if err != nil {
+		return err
+	}
+
+	err = e.assertFiatLimits(ctx, chain, rule, chainEngine, txBytes)
+	if compare.IsMismatch(err) {
+		return err
+	}
+	if err != nil {
+		e.logger.Printf("Failed to evaluate fiat constraints of deny rule %s, treating as matched: %v", rule.GetId(), err)
+	}
+	return nil
+}
+
+// evaluateRule checks the tx against the allow rule, denominations of its amounts, its fiat and period-limited constraints
+func (e *Engine) evaluateRule(
+	ctx context.Context,
+	chain common.Chain,
+	chainEngine ChainEngine,
+	rule *types.Rule,
+	txBytes []byte,
+) error {
+	err := chainEngine.Evaluate(ctx, withoutFiatConstraints(rule), txBytes)
+	if err != nil {
+		return err
+	}
+
+	err = e.assertDenominations(chain, rule, chainEngine, txBytes)
+	if err != nil {
+		return err
+	}
+
+	err = e.assertFiatLimits(ctx, chain, rule, chainEngine, txBytes)
+	if err != nil {
+		return err
+	}
+	return e.assertSpendLimits(ctx, chain, rule, chainEngine, txBytes)
+}
+
+// assertFiatLimits compares the value of amounts in the currency of fiat-denominated constraints
+// with their min, max or range values
+func (e *Engine) assertFiatLimits(
+	ctx context.Context,
+	chain common.Chain,
+	rule *types.Rule,
+	chainEngine ChainEngine,
+	txBytes []byte,
+) error {
+	for _, pc := range rule.GetParameterConstraints() {
+		c := pc.GetConstraint()
+		currency := c.GetDenominatedIn()
+		if !price.IsFiat(currency) || c.GetPeriod() != "" {
+			// period-limited constraints are checked with amounts spent within the period
+			continue
+		}
+
+		var lo, hi string
+		switch c.GetType() {
+		case types.ConstraintType_CONSTRAINT_TYPE_MIN:
+			lo = c.GetMinValue()
+		case types.ConstraintType_CONSTRAINT_TYPE_MAX:
+			hi = c.GetMaxValue()
+		case types.ConstraintType_CONSTRAINT_TYPE_RANGE:
+			lo, hi = c.GetRangeValue().GetMin(), c.GetRangeValue().GetMax()
+		default:
+			return fmt.Errorf("fiat denomination is only supported for min, max and range constraints: parameter=%s, type=%s",
+				pc.GetParameterName(), c.GetType().String())
+		}
+
+		value, err := e.fiatValue(ctx, chain, rule, chainEngine, txBytes, pc.GetParameterName(), currency)
+		if err != nil {
+			return err
+		}
+
+		for _, bound := range []struct {
+			limit string
+			cmp   int
+		}{{lo, -1}, {hi, 1}} {
+			if bound.limit == "" {
+				continue
+			}
+			limit, ok := new(big.Rat).SetString(bound.limit)
+			if !ok {
+				return fmt.Errorf("failed to parse fiat limit: %s", bound.limit)
+			}
+			if value.Cmp(limit) != bound.cmp {
+				continue
+			}
+			return &compare.ConstraintError{
+				Parameter: pc.GetParameterName(),
+				Type:      c.GetType().String(),
+				Expected:  bound.limit + " " + currency,
+				Actual:    value.FloatString(2) + " " + currency,
+				Reason: fmt.Sprintf(
+					"fiat limit exceeded: parameter=%s, limit=%s %s, actual=%s %s",
+					pc.GetParameterName(),
+					bound.limit,
+					currency,
+					value.FloatString(2),
+					currency,
+				),
+			}
+		}
+	}
+	return nil
+}
+
+// fiatValue returns the value of the amount parameter in the currency at the oracle price
+func (e *Engine) fiatValue(
+	ctx context.Context,
+	chain common.Chain,
+	rule *types.Rule,
+	chainEngine ChainEngine,
+	txBytes []byte,
+	parameter string,
+	currency string,
+) (*big.Rat, error) {
+	if e.prices == nil {
+		return nil, errors.New("no price oracle to evaluate fiat-denominated constraints")
+	}
+
+	valuer, ok := chainEngine.(ParameterValuer)
+	if !ok {
+		return nil, fmt.Errorf("chain engine doesn't support fiat-denominated constraints: %T", chainEngine)
+	}
+	assets, ok := chainEngine.(ParameterAssetResolver)
+	if !ok {
+		return nil, fmt.Errorf("chain engine doesn't support fiat-denominated constraints: %T", chainEngine)
+	}
+
+	// the chain engine reads the rule as it's evaluated, e.g. to locate unordered UTXO outputs
+	chainRule := withoutFiatConstraints(rule)
+	amount, err := valuer.ParameterValue(chainRule, txBytes, parameter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parameter value: parameter=%s, error=%w", parameter, err)
+	}
+	asset, err := assets.ParameterAsset(chainRule, txBytes, parameter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parameter asset: parameter=%s, error=%w", parameter, err)
+	}
+
+	var decimals int
+	if asset == "" {
+		decimals, err = units.NativeDecimals(chain)
+	} else {
+		decimals, err = e.units.Decimals(chain, asset)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get decimals: %w", err)
+	}
+
+	quote, err := e.prices.Price(ctx, chain, asset, currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price: %w", err)
+	}
+	if quote.Price == nil {
+		return nil, fmt.Errorf("failed to get price: no %s price of %s", currency, parameter)
+	}
+	err = price.AssertFresh(quote, e.now(), e.maxPriceAge)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price of %s: %w", parameter, err)
+	}
+	return price.Value(amount, decimals, quote), nil
+}
+
+// fiatUnits converts the fiat value to millionths of the currency, rounded up
+func fiatUnits(value *big.Rat) *big.Int {
+	scaled := new(big.Rat).Mul(value, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(fiatDecimals), nil)))
+	q, r := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	if r.Sign() > 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	return q
+}
@@ -0,0 +1,175 @@
+package engine
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+	"github.com/vultisig/vultisig-go/common"
+
+	"github.com/vultisig/recipes/engine/price"
+	"github.com/vultisig/recipes/sdk/evm/codegen/erc20"
+	"github.com/vultisig/recipes/types"
+)
+
+const usdcAddress = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+
+func fiatAmountRule(resource, target string, constraint *types.Constraint) *types.Rule {
+	rule := &types.Rule{
+		Id:       resource,
+		Resource: resource,
+		Effect:   types.Effect_EFFECT_ALLOW,
+		Target: &types.Target{
+			TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+			Target:     &types.Target_Address{Address: target},
+		},
+		ParameterConstraints: []*types.ParameterConstraint{{
+			ParameterName: "amount",
+			Constraint:    constraint,
+		}},
+	}
+	if resource == "ethereum.erc20.transfer" {
+		rule.ParameterConstraints = append(rule.ParameterConstraints, &types.ParameterConstraint{
+			ParameterName: "recipient",
+			Constraint:    &types.Constraint{Type: types.ConstraintType_CONSTRAINT_TYPE_ANY},
+		})
+	}
+	return rule
+}
+
+func maxUSD(value, period string) *types.Constraint {
+	return &types.Constraint{
+		Type:          types.ConstraintType_CONSTRAINT_TYPE_MAX,
+		Value:         &types.Constraint_MaxValue{MaxValue: value},
+		DenominatedIn: "USD",
+		Period:        period,
+	}
+}
+
+func ether(milli int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(milli), big.NewInt(1_000_000_000_000_000))
+}
+
+func TestEvaluate_FiatLimit(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	oracle := price.NewStaticOracle()
+	require.NoError(t, oracle.Set(common.Ethereum, "", "USD", "2500", now))
+	require.NoError(t, oracle.Set(common.Ethereum, usdcAddress, "USD", "0.9998", now))
+
+	engine, err := NewEngine(WithPriceOracle(oracle))
+	require.NoError(t, err)
+	engine.now = func() time.Time {
+		return now
+	}
+
+	recipient := ecommon.HexToAddress("0xcf0475d9B0a29975bc5132A3066010eC898d8CaB")
+	policy := &types.Policy{
+		Id: "fiat-policy",
+		Rules: []*types.Rule{
+			fiatAmountRule("ethereum.eth.transfer", recipient.Hex(), maxUSD("500", "")),
+			fiatAmountRule("ethereum.erc20.transfer", usdcAddress, maxUSD("100", "")),
+		},
+	}
+	usdcTransfer := func(amount int64) []byte {
+		return buildUnsignedTx(
+			ecommon.HexToAddress(usdcAddress),
+			erc20.NewErc20().PackTransfer(recipient, big.NewInt(amount)),
+			big.NewInt(0),
+		)
+	}
+
+	// 0.2 ETH = 500 USD
+	_, err = engine.Evaluate(policy, common.Ethereum, buildUnsignedTx(recipient, nil, ether(200)))
+	require.NoError(t, err)
+
+	_, err = engine.Evaluate(policy, common.Ethereum, buildUnsignedTx(recipient, nil, ether(201)))
+	require.ErrorContains(t, err, "fiat limit exceeded: parameter=amount, limit=500 USD, actual=502.50 USD")
+
+	// 100 USDC = 99.98 USD
+	_, err = engine.Evaluate(policy, common.Ethereum, usdcTransfer(100_000_000))
+	require.NoError(t, err)
+
+	_, err = engine.Evaluate(policy, common.Ethereum, usdcTransfer(100_100_000))
+	require.ErrorContains(t, err, "fiat limit exceeded")
+
+	now = now.Add(price.DefaultMaxAge + time.Second)
+	_, err = engine.Evaluate(policy, common.Ethereum, buildUnsignedTx(recipient, nil, ether(1)))
+	require.ErrorContains(t, err, "price is stale")
+
+	noOracle, err := NewEngine()
+	require.NoError(t, err)
+	_, err = noOracle.Evaluate(policy, common.Ethereum, buildUnsignedTx(recipient, nil, ether(1)))
+	require.ErrorContains(t, err, "no price oracle")
+}
+
+func TestEvaluate_FiatPeriodLimit(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	oracle := price.NewStaticOracle()
+	require.NoError(t, oracle.Set(common.Ethereum, "", "USD", "2500", now))
+
+	engine, err := NewEngine(WithPriceOracle(oracle), WithMaxPriceAge(time.Hour))
+	require.NoError(t, err)
+	engine.now = func() time.Time {
+		return now
+	}
+
+	recipient := ecommon.HexToAddress("0xcf0475d9B0a29975bc5132A3066010eC898d8CaB")
+	policy := &types.Policy{
+		Id:    "fiat-period-policy",
+		Rules: []*types.Rule{fiatAmountRule("ethereum.eth.transfer", recipient.Hex(), maxUSD("400.5", "day"))},
+	}
+
+	// 0.1 ETH = 250 USD
+	txBytes := buildUnsignedTx(recipient, nil, ether(100))
+	ctx := WithPolicyInstance(context.Background(), "vault-1")
+	rule, err := engine.EvaluateContext(ctx, policy, common.Ethereum, txBytes)
+	require.NoError(t, err)
+	require.NoError(t, engine.RecordSpend(ctx, rule, common.Ethereum, txBytes))
+
+	_, err = engine.EvaluateContext(ctx, policy, common.Ethereum, txBytes)
+	require.ErrorContains(t, err, "period limit exceeded")
+
+	// the price drops, 0.1 ETH = 150 USD fits the rest of the budget
+	require.NoError(t, oracle.Set(common.Ethereum, "", "USD", "1500", now))
+	_, err = engine.EvaluateContext(ctx, policy, common.Ethereum, txBytes)
+	require.NoError(t, err)
+}
+
+func TestEvaluate_FiatDenyFailsClosed(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	oracle := price.NewStaticOracle()
+	require.NoError(t, oracle.Set(common.Ethereum, "", "USD", "2500", now.Add(-time.Hour)))
+
+	engine, err := NewEngine(WithPriceOracle(oracle))
+	require.NoError(t, err)
+	engine.now = func() time.Time {
+		return now
+	}
+
+	recipient := ecommon.HexToAddress("0xcf0475d9B0a29975bc5132A3066010eC898d8CaB")
+	deny := fiatAmountRule("ethereum.eth.transfer", recipient.Hex(), &types.Constraint{
+		Type:          types.ConstraintType_CONSTRAINT_TYPE_MIN,
+		Value:         &types.Constraint_MinValue{MinValue: "10000"},
+		DenominatedIn: "USD",
+	})
+	deny.Id = "deny-large"
+	deny.Effect = types.Effect_EFFECT_DENY
+	allow := fiatAmountRule("ethereum.eth.transfer", recipient.Hex(), &types.Constraint{
+		Type: types.ConstraintType_CONSTRAINT_TYPE_ANY,
+	})
+	policy := &types.Policy{Id: "fiat-deny-policy", Rules: []*types.Rule{deny, allow}}
+
+	txBytes := buildUnsignedTx(recipient, nil, ether(1))
+	_, err = engine.Evaluate(policy, common.Ethereum, txBytes)
+	require.ErrorContains(t, err, "tx denied by rule: id=deny-large")
+
+	require.NoError(t, oracle.Set(common.Ethereum, "", "USD", "2500", now))
+	_, err = engine.Evaluate(policy, common.Ethereum, txBytes)
+	require.NoError(t, err)
+
+	_, err = engine.Evaluate(policy, common.Ethereum, buildUnsignedTx(recipient, nil, ether(4000)))
+	require.ErrorContains(t, err, "tx denied by rule: id=deny-large")
+}
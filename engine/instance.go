@@ -0,0 +1,39 @@
+package engine
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vultisig/recipes/types"
+)
+
+type policyInstanceKey struct{}
+
+// WithPolicyInstance returns the context carrying the identity of the policy instance, e.g. the plugin
+// installation of the vault. Policy.id may be shared by every installation of the plugin policy,
+// so rate and spend limits are counted per instance. Rate limits of the context without the instance
+// are counted per policy id, spend limits can't be checked or recorded without it
+func WithPolicyInstance(ctx context.Context, instanceID string) context.Context {
+	return context.WithValue(ctx, policyInstanceKey{}, instanceID)
+}
+
+// rateLimitInstance returns the policy instance identity of the context, or the policy id if it's not set
+func rateLimitInstance(ctx context.Context, policy *types.Policy) (string, error) {
+	instanceID, _ := ctx.Value(policyInstanceKey{}).(string)
+	if instanceID != "" {
+		return instanceID, nil
+	}
+	if policy.GetId() == "" {
+		return "", errors.New("policy id or policy instance is required to count the rate limit, use WithPolicyInstance")
+	}
+	return policy.GetId(), nil
+}
+
+// policyInstance returns the policy instance identity of the context, spend limits are counted per instance
+func policyInstance(ctx context.Context) (string, error) {
+	instanceID, _ := ctx.Value(policyInstanceKey{}).(string)
+	if instanceID == "" {
+		return "", errors.New("policy instance is required to count the spend limits, use WithPolicyInstance")
+	}
+	return instanceID, nil
+}
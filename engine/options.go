@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"log"
+	"time"
+
+	"github.com/vultisig/recipes/engine/evm"
+	"github.com/vultisig/recipes/engine/price"
+	"github.com/vultisig/recipes/engine/units"
+	"github.com/vultisig/recipes/resolver"
+	"github.com/vultisig/vultisig-go/common"
+)
+
+// Option configures the Engine created by NewEngine
+type Option func(*options)
+
+type options struct {
+	logger         *log.Logger
+	chains         []common.Chain
+	engines        map[common.Chain]ChainEngine
+	resolvers      *resolver.MagicConstantRegistry
+	resolverConfig resolver.RegistryConfig
+	abis           *evm.ABIRegistry
+	deployments    *evm.DeploymentRegistry
+	units          *units.Registry
+	prices         price.Oracle
+	maxPriceAge    time.Duration
+	approvalSlack  uint64
+	swapInputs     map[string]evm.SwapInput
+}
+
+// WithLogger sets the logger, evaluation logs are discarded by default
+func WithLogger(logger *log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithChains limits the engine to the given chains, built-in engines are created for them.
+// Any EVM chain known to vultisig-go/common is supported, not only SupportedEVMChains
+func WithChains(chains ...common.Chain) Option {
+	return func(o *options) {
+		o.chains = chains
+	}
+}
+
+// WithChainEngine registers a custom engine for the chain, replacing the built-in one
+// or adding a chain which has no built-in engine. The engine is used as configured,
+// the shared registries and the approval slack are only set on built-in engines
+func WithChainEngine(chain common.Chain, engine ChainEngine) Option {
+	return func(o *options) {
+		if o.engines == nil {
+			o.engines = make(map[common.Chain]ChainEngine)
+		}
+		o.engines[chain] = engine
+	}
+}
+
+// WithResolverRegistry sets the magic constant registry shared by all chain engines.
+// The registry is used as is, wrap it with MagicConstantRegistry.WithCache to cache resolutions
+func WithResolverRegistry(registry *resolver.MagicConstantRegistry) Option {
+	return func(o *options) {
+		o.resolvers = registry
+	}
+}
+
+// WithResolverConfig sets the HTTP client and base URLs (e.g. a local THORNode) of the default
+// cached magic constant registry. Ignored if WithResolverRegistry is set
+func WithResolverConfig(config resolver.RegistryConfig) Option {
+	return func(o *options) {
+		o.resolverConfig = config
+	}
+}
+
+// WithABIRegistry sets the ABI registry shared by all EVM chain engines,
+// by default a registry with the ABIs embedded in chain/evm/abi is created
+func WithABIRegistry(registry *evm.ABIRegistry) Option {
+	return func(o *options) {
+		o.abis = registry
+	}
+}
+
+// WithDeploymentRegistry makes EVM chain engines reject txs calling a protocol at an address
+// which is not a known deployment of it, e.g. evm.NewDeploymentRegistry(). Disabled by default
+func WithDeploymentRegistry(registry *evm.DeploymentRegistry) Option {
+	return func(o *options) {
+		o.deployments = registry
+	}
+}
+
+// WithTokenRegistry sets the registry resolving denominations of constraint amounts to decimals,
+// by default tokens of the list embedded in chain/evm/ethereum are known
+func WithTokenRegistry(registry *units.Registry) Option {
+	return func(o *options) {
+		o.units = registry
+	}
+}
+
+// WithPriceOracle sets the oracle converting amounts to fiat currencies, it's required
+// to evaluate constraints denominated in fiat, e.g. max 500 USD per swap
+func WithPriceOracle(oracle price.Oracle) Option {
+	return func(o *options) {
+		o.prices = oracle
+	}
+}
+
+// WithMaxPriceAge sets the max age of oracle prices, constraints can't be evaluated with older prices.
+// Defaults to price.DefaultMaxAge
+func WithMaxPriceAge(maxAge time.Duration) Option {
+	return func(o *options) {
+		o.maxPriceAge = maxAge
+	}
+}
+
+// WithApprovalSlack lets ERC20 approvals in bundles exceed the amount of the swap they approve
+// by up to bps basis points, by default the approval must be the exact swap amount
+func WithApprovalSlack(bps uint64) Option {
+	return func(o *options) {
+		o.approvalSlack = bps
+	}
+}
+
+// WithSwapInput sets the args of the protocol method holding the token and the amount the swap spends,
+// so ERC20 approvals in bundles can be matched with swaps of routers unknown to the engine, e.g. LiFi
+func WithSwapInput(protocolID, functionID string, input evm.SwapInput) Option {
+	return func(o *options) {
+		if o.swapInputs == nil {
+			o.swapInputs = make(map[string]evm.SwapInput)
+		}
+		o.swapInputs[protocolID+"."+functionID] = input
+	}
+}
@@ -0,0 +1,244 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+	"github.com/vultisig/vultisig-go/common"
+
+	"github.com/vultisig/recipes/engine/evm"
+	"github.com/vultisig/recipes/resolver"
+	"github.com/vultisig/recipes/sdk/evm/codegen/uniswapv2_router"
+	"github.com/vultisig/recipes/types"
+)
+
+func TestNewEngine_WithResolverConfig(t *testing.T) {
+	vault := ecommon.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		_ = json.NewEncoder(w).Encode([]resolver.InboundAddress{{
+			Chain:   "ETH",
+			Address: vault.Hex(),
+		}})
+	}))
+	defer srv.Close()
+
+	engine, err := NewEngine(WithResolverConfig(resolver.RegistryConfig{
+		THORChainBaseURL: srv.URL,
+	}))
+	require.NoError(t, err)
+
+	policy := &types.Policy{
+		Rules: []*types.Rule{{
+			Id:       "eth to thorchain vault",
+			Resource: "ethereum.eth.transfer",
+			Effect:   types.Effect_EFFECT_ALLOW,
+			Target: &types.Target{
+				TargetType: types.TargetType_TARGET_TYPE_MAGIC_CONSTANT,
+				Target: &types.Target_MagicConstant{
+					MagicConstant: types.MagicConstant_THORCHAIN_VAULT,
+				},
+			},
+			ParameterConstraints: []*types.ParameterConstraint{{
+				ParameterName: "amount",
+				Constraint: &types.Constraint{
+					Type: types.ConstraintType_CONSTRAINT_TYPE_ANY,
+				},
+			}},
+		}},
+	}
+	txBytes := buildUnsignedTx(vault, nil, big.NewInt(1))
+
+	for i := 0; i < 3; i++ {
+		rule, err := engine.Evaluate(policy, common.Ethereum, txBytes)
+		require.NoError(t, err)
+		require.Equal(t, "eth to thorchain vault", rule.GetId())
+	}
+	require.EqualValues(t, 1, hits.Load(), "vault must be resolved once and cached")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	uncached, err := NewEngine(WithResolverConfig(resolver.RegistryConfig{
+		THORChainBaseURL: srv.URL,
+	}))
+	require.NoError(t, err)
+	_, err = uncached.EvaluateContext(ctx, policy, common.Ethereum, txBytes)
+	require.ErrorContains(t, err, context.Canceled.Error())
+}
+
+// fixedVault is a context-free resolver registered through resolver.FromLegacy
+type fixedVault struct {
+	address string
+}
+
+func (f *fixedVault) Supports(constant types.MagicConstant) bool {
+	return constant == types.MagicConstant_THORCHAIN_VAULT
+}
+
+func (f *fixedVault) Resolve(_ types.MagicConstant, _, _ string) (string, string, error) {
+	return f.address, "", nil
+}
+
+func TestNewEngine_WithResolverRegistry(t *testing.T) {
+	vault := ecommon.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	registry := &resolver.MagicConstantRegistry{}
+	registry.Register(resolver.FromLegacy(&fixedVault{address: vault.Hex()}))
+
+	engine, err := NewEngine(WithResolverRegistry(registry))
+	require.NoError(t, err)
+
+	rule, err := engine.Evaluate(&types.Policy{
+		Rules: []*types.Rule{{
+			Id:       "eth to vault",
+			Resource: "ethereum.eth.transfer",
+			Effect:   types.Effect_EFFECT_ALLOW,
+			Target: &types.Target{
+				TargetType: types.TargetType_TARGET_TYPE_MAGIC_CONSTANT,
+				Target: &types.Target_MagicConstant{
+					MagicConstant: types.MagicConstant_THORCHAIN_VAULT,
+				},
+			},
+			ParameterConstraints: []*types.ParameterConstraint{{
+				ParameterName: "amount",
+				Constraint: &types.Constraint{
+					Type: types.ConstraintType_CONSTRAINT_TYPE_ANY,
+				},
+			}},
+		}},
+	}, common.Ethereum, buildUnsignedTx(vault, nil, big.NewInt(1)))
+	require.NoError(t, err)
+	require.Equal(t, "eth to vault", rule.GetId())
+}
+
+func TestNewEngine_WithChains(t *testing.T) {
+	engine, err := NewEngine(WithChains(common.Ethereum, common.Mantle))
+	require.NoError(t, err)
+
+	require.Equal(t, []common.Chain{common.Ethereum, common.Mantle}, engine.registry.Chains())
+
+	_, err = engine.registry.GetEngine(common.Mantle)
+	require.NoError(t, err)
+
+	_, err = engine.registry.GetEngine(common.Bitcoin)
+	require.Error(t, err)
+}
+
+func TestNewEngine_WithChainEngine(t *testing.T) {
+	stub := &stubEngine{chain: common.Ethereum}
+
+	engine, err := NewEngine(WithChainEngine(common.Ethereum, stub))
+	require.NoError(t, err)
+
+	rule, err := engine.Evaluate(&types.Policy{
+		Rules: []*types.Rule{{
+			Id:       "stubbed",
+			Resource: "ethereum.eth.transfer",
+			Effect:   types.Effect_EFFECT_ALLOW,
+		}},
+	}, common.Ethereum, []byte{0x01})
+	require.NoError(t, err)
+	require.Equal(t, "stubbed", rule.GetId())
+	require.Equal(t, 1, stub.calls)
+
+	_, err = NewEngine(WithChainEngine(common.Ethereum, &stubEngine{chain: common.Bitcoin}))
+	require.Error(t, err)
+}
+
+func TestNewEngine_WithChainEngineKeepsRegistries(t *testing.T) {
+	custom, err := evm.NewEvm("ETH")
+	require.NoError(t, err)
+	abis, err := evm.NewABIRegistry()
+	require.NoError(t, err)
+	custom.SetABIRegistry(abis)
+
+	engine, err := NewEngine(WithChainEngine(common.Ethereum, custom), WithChains(common.Ethereum, common.Arbitrum))
+	require.NoError(t, err)
+
+	ethereum, err := engine.registry.GetEngine(common.Ethereum)
+	require.NoError(t, err)
+	require.Same(t, custom, ethereum)
+	require.Same(t, abis, custom.ABIRegistry())
+	require.NotSame(t, engine.ABIRegistry(), custom.ABIRegistry())
+
+	// built-in engines still share the engine registries
+	arbitrum, err := engine.registry.GetEngine(common.Arbitrum)
+	require.NoError(t, err)
+	require.Same(t, engine.ABIRegistry(), arbitrum.(*evm.Evm).ABIRegistry())
+}
+
+func TestNewEngine_WithLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	engine, err := NewEngine(WithLogger(log.New(&buf, "", 0)))
+	require.NoError(t, err)
+
+	_, err = engine.Evaluate(&types.Policy{
+		Rules: []*types.Rule{{
+			Id:       "bitcoin only",
+			Resource: "bitcoin.btc.transfer",
+			Effect:   types.Effect_EFFECT_ALLOW,
+		}},
+	}, common.Ethereum, []byte{0x01})
+	require.Error(t, err)
+	require.NotEmpty(t, buf.String())
+}
+
+func TestNewEngine_WithABIRegistry(t *testing.T) {
+	abis, err := evm.NewABIRegistry()
+	require.NoError(t, err)
+
+	engine, err := NewEngine(WithABIRegistry(abis), WithChains(common.Ethereum, common.Arbitrum))
+	require.NoError(t, err)
+	require.Same(t, abis, engine.ABIRegistry())
+
+	for _, chain := range []common.Chain{common.Ethereum, common.Arbitrum} {
+		chainEngine, err := engine.registry.GetEngine(chain)
+		require.NoError(t, err)
+		require.Same(t, abis, chainEngine.(*evm.Evm).ABIRegistry(), chain.String())
+	}
+
+	// the default registry is shared by all EVM chains too
+	engine, err = NewEngine(WithChains(common.Ethereum, common.Arbitrum))
+	require.NoError(t, err)
+	arbitrum, err := engine.registry.GetEngine(common.Arbitrum)
+	require.NoError(t, err)
+	require.Same(t, engine.ABIRegistry(), arbitrum.(*evm.Evm).ABIRegistry())
+}
+
+func TestNewEngine_WithDeploymentRegistry(t *testing.T) {
+	engine, err := NewEngine(WithDeploymentRegistry(evm.NewDeploymentRegistry()))
+	require.NoError(t, err)
+
+	rule, err := engine.Evaluate(bundlePolicy(), common.Ethereum, swapTx(0, 1000))
+	require.NoError(t, err)
+	require.Equal(t, "swap", rule.GetId())
+
+	// a lookalike router sharing the selector is rejected even if the rule targets it
+	lookalike := ecommon.HexToAddress("0x6666666666666666666666666666666666666666")
+	policy := bundlePolicy()
+	policy.Rules[1].Target = &types.Target{
+		TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+		Target:     &types.Target_Address{Address: lookalike.Hex()},
+	}
+	data := uniswapv2_router.NewUniswapv2Router().PackSwapExactTokensForTokens(
+		big.NewInt(1000),
+		big.NewInt(1),
+		[]ecommon.Address{ecommon.HexToAddress(bundleToken), ecommon.HexToAddress(bundleRouter)},
+		lookalike,
+		big.NewInt(1700000000),
+	)
+	_, err = engine.Evaluate(policy, common.Ethereum, buildUnsignedTx(lookalike, data, big.NewInt(0)))
+	require.ErrorContains(t, err, "tx target is not a known deployment of uniswapV2_router")
+}
@@ -0,0 +1,121 @@
+package price
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vultisig/vultisig-go/common"
+)
+
+// DefaultMaxAge is the max age of a price used to evaluate fiat-denominated constraints
+const DefaultMaxAge = 5 * time.Minute
+
+// currencies are fiat currencies constraints can be denominated in
+var currencies = map[string]bool{
+	"USD": true,
+	"EUR": true,
+	"GBP": true,
+	"JPY": true,
+	"CHF": true,
+	"CAD": true,
+	"AUD": true,
+	"SGD": true,
+}
+
+// IsFiat reports whether the denomination is a fiat currency code, e.g. USD
+func IsFiat(denomination string) bool {
+	return currencies[strings.ToUpper(denomination)]
+}
+
+// Quote is the price of one whole unit of the asset (e.g. 1 ETH, not 1 wei) in the currency
+type Quote struct {
+	Price     *big.Rat
+	UpdatedAt time.Time
+}
+
+// Oracle returns prices of chain assets. The asset is empty for the chain native asset,
+// otherwise the token identifier as reported by the chain engine (e.g. lowercase EVM token address)
+type Oracle interface {
+	Price(ctx context.Context, chain common.Chain, asset, currency string) (Quote, error)
+}
+
+type key struct {
+	chain    common.Chain
+	asset    string
+	currency string
+}
+
+// StaticOracle is the Oracle with prices set by the caller, e.g. test fixtures or a snapshot of a price feed
+type StaticOracle struct {
+	mu     sync.RWMutex
+	quotes map[key]Quote
+}
+
+// NewStaticOracle creates the oracle without prices
+func NewStaticOracle() *StaticOracle {
+	return &StaticOracle{
+		quotes: make(map[key]Quote),
+	}
+}
+
+// Set sets the decimal price of the asset, e.g. "3150.25"
+func (o *StaticOracle) Set(chain common.Chain, asset, currency, price string, updatedAt time.Time) error {
+	p, ok := new(big.Rat).SetString(price)
+	if !ok || p.Sign() < 0 {
+		return fmt.Errorf("invalid price: %s", price)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.quotes[newKey(chain, asset, currency)] = Quote{Price: p, UpdatedAt: updatedAt}
+	return nil
+}
+
+// Price returns the price set for the asset
+func (o *StaticOracle) Price(_ context.Context, chain common.Chain, asset, currency string) (Quote, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	q, ok := o.quotes[newKey(chain, asset, currency)]
+	if !ok {
+		return Quote{}, fmt.Errorf("no %s price of %s on %s", currency, assetName(asset), chain.String())
+	}
+	return q, nil
+}
+
+func newKey(chain common.Chain, asset, currency string) key {
+	return key{
+		chain:    chain,
+		asset:    strings.ToLower(asset),
+		currency: strings.ToUpper(currency),
+	}
+}
+
+// Value converts the amount in base units of the asset with the decimals to the quote currency
+func Value(amount *big.Int, decimals int, quote Quote) *big.Rat {
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	whole := new(big.Rat).SetFrac(amount, unit)
+	return whole.Mul(whole, quote.Price)
+}
+
+// AssertFresh checks the quote is not older than maxAge at now
+func AssertFresh(quote Quote, now time.Time, maxAge time.Duration) error {
+	age := now.Sub(quote.UpdatedAt)
+	if age > maxAge {
+		return fmt.Errorf("price is stale: updated_at=%s, age=%s, max_age=%s",
+			quote.UpdatedAt.UTC().Format(time.RFC3339), age.Round(time.Second), maxAge)
+	}
+	return nil
+}
+
+func assetName(asset string) string {
+	if asset == "" {
+		return "native asset"
+	}
+	return asset
+}
@@ -0,0 +1,53 @@
+package price
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vultisig/vultisig-go/common"
+)
+
+func TestStaticOracle(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	o := NewStaticOracle()
+	require.NoError(t, o.Set(common.Ethereum, "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", "usd", "0.9998", now))
+	require.Error(t, o.Set(common.Ethereum, "", "USD", "-1", now))
+	require.Error(t, o.Set(common.Ethereum, "", "USD", "abc", now))
+
+	q, err := o.Price(context.Background(), common.Ethereum, "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", "USD")
+	require.NoError(t, err)
+	require.Equal(t, "0.9998", q.Price.FloatString(4))
+	require.Equal(t, now, q.UpdatedAt)
+
+	_, err = o.Price(context.Background(), common.Ethereum, "", "USD")
+	require.ErrorContains(t, err, "no USD price of native asset on Ethereum")
+}
+
+func TestValue(t *testing.T) {
+	quote := Quote{Price: big.NewRat(250050, 100)}
+
+	// 0.2 ETH at 2500.50
+	v := Value(big.NewInt(200_000_000_000_000_000), 18, quote)
+	require.Equal(t, "500.10", v.FloatString(2))
+
+	// 1.5 BTC
+	v = Value(big.NewInt(150_000_000), 8, quote)
+	require.Equal(t, "3750.75", v.FloatString(2))
+}
+
+func TestAssertFresh(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, AssertFresh(Quote{UpdatedAt: now.Add(-DefaultMaxAge)}, now, DefaultMaxAge))
+	require.ErrorContains(t, AssertFresh(Quote{UpdatedAt: now.Add(-time.Hour)}, now, DefaultMaxAge), "price is stale")
+}
+
+func TestIsFiat(t *testing.T) {
+	require.True(t, IsFiat("USD"))
+	require.True(t, IsFiat("eur"))
+	require.False(t, IsFiat("USDC"))
+	require.False(t, IsFiat(""))
+}
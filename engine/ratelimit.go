@@ -0,0 +1,215 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vultisig/recipes/types"
+)
+
+// RateLimitWindow is the current batch window of a policy instance
+type RateLimitWindow struct {
+	Start time.Time
+	Count uint32
+}
+
+// RateLimitStore persists batch windows of policy instances. Implementations must be safe for concurrent use.
+type RateLimitStore interface {
+	// GetWindow returns the current window of the policy instance, nil if no txs were executed yet
+	GetWindow(instanceID string) (*RateLimitWindow, error)
+	// AddToWindow atomically counts n txs in the window of the policy instance if the window has room for them.
+	// The window which isn't started or is over by now is replaced with the new one starting now.
+	// Returns the resulting window, or the current one and false if the txs don't fit maxTxs
+	AddToWindow(instanceID string, n, maxTxs uint32, length time.Duration, now time.Time) (RateLimitWindow, bool, error)
+}
+
+// RateLimitError is returned when the policy instance exhausted max_txs_per_window for the current window
+type RateLimitError struct {
+	PolicyID   string
+	InstanceID string
+	MaxTxs     uint32
+	Window     time.Duration
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf(
+		"rate limit exceeded: policy_id=%s, instance_id=%s, max_txs_per_window=%d, window=%s, retry_after=%s",
+		e.PolicyID,
+		e.InstanceID,
+		e.MaxTxs,
+		e.Window,
+		e.RetryAfter,
+	)
+}
+
+// RateLimiter enforces Policy.rate_limit_window and Policy.max_txs_per_window per policy instance.
+// Batch window starts with the first tx executed after the previous window is over,
+// up to max_txs_per_window txs can be executed until the window ends.
+type RateLimiter struct {
+	store RateLimitStore
+	now   func() time.Time
+}
+
+// NewRateLimiter creates rate limiter, now is the clock and defaults to time.Now if nil
+func NewRateLimiter(store RateLimitStore, now func() time.Time) *RateLimiter {
+	if now == nil {
+		now = time.Now
+	}
+	return &RateLimiter{
+		store: store,
+		now:   now,
+	}
+}
+
+func policyWindow(policy *types.Policy) (time.Duration, uint32) {
+	maxTxs := policy.GetMaxTxsPerWindow()
+	if policy.MaxTxsPerWindow == nil {
+		maxTxs = 1
+	}
+	return time.Duration(policy.GetRateLimitWindow()) * time.Second, maxTxs
+}
+
+func isRateLimited(policy *types.Policy) bool {
+	return policy.RateLimitWindow != nil && policy.GetRateLimitWindow() != 0
+}
+
+// Check returns *RateLimitError if one more tx of the policy instance doesn't fit the current window
+func (r *RateLimiter) Check(instanceID string, policy *types.Policy) error {
+	return r.CheckN(instanceID, policy, 1)
+}
+
+// CheckN returns *RateLimitError if n more txs of the policy instance don't fit the current window
+func (r *RateLimiter) CheckN(instanceID string, policy *types.Policy, n uint32) error {
+	if !isRateLimited(policy) {
+		return nil
+	}
+	if instanceID == "" {
+		return errors.New("empty policy instance id")
+	}
+	window, maxTxs := policyWindow(policy)
+
+	if n > maxTxs {
+		return fmt.Errorf("batch of %d txs exceeds max_txs_per_window=%d", n, maxTxs)
+	}
+
+	current, err := r.store.GetWindow(instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to get rate limit window: %w", err)
+	}
+
+	now := r.now()
+	if current == nil || !now.Before(current.Start.Add(window)) {
+		return nil
+	}
+	if current.Count+n <= maxTxs {
+		return nil
+	}
+	return r.limitError(instanceID, policy, *current, now)
+}
+
+// Record counts executed txs of the policy instance, call it after txs were signed.
+// The window is checked and counted atomically: if concurrent txs exhausted it after Check,
+// Record returns *RateLimitError and doesn't count the txs, which must not be broadcast then
+func (r *RateLimiter) Record(instanceID string, policy *types.Policy, n uint32) error {
+	if !isRateLimited(policy) {
+		return nil
+	}
+	if instanceID == "" {
+		return errors.New("empty policy instance id")
+	}
+	window, maxTxs := policyWindow(policy)
+
+	now := r.now()
+	current, ok, err := r.store.AddToWindow(instanceID, n, maxTxs, window, now)
+	if err != nil {
+		return fmt.Errorf("failed to add to rate limit window: %w", err)
+	}
+	if !ok {
+		return r.limitError(instanceID, policy, current, now)
+	}
+	return nil
+}
+
+func (r *RateLimiter) limitError(instanceID string, policy *types.Policy, current RateLimitWindow, now time.Time) error {
+	window, maxTxs := policyWindow(policy)
+	return &RateLimitError{
+		PolicyID:   policy.GetId(),
+		InstanceID: instanceID,
+		MaxTxs:     maxTxs,
+		Window:     window,
+		RetryAfter: current.Start.Add(window).Sub(now),
+	}
+}
+
+// checkRateLimit checks n more txs of the policy instance of the context, or of the policy, against the rate limit
+func (e *Engine) checkRateLimit(ctx context.Context, policy *types.Policy, n uint32) error {
+	if !isRateLimited(policy) {
+		return nil
+	}
+	instanceID, err := rateLimitInstance(ctx, policy)
+	if err != nil {
+		return err
+	}
+	return e.rateLimiter.CheckN(instanceID, policy, n)
+}
+
+// recordExecution counts n executed txs of the policy instance of the context, or of the policy
+func (e *Engine) recordExecution(ctx context.Context, policy *types.Policy, n uint32) error {
+	if !isRateLimited(policy) {
+		return nil
+	}
+	instanceID, err := rateLimitInstance(ctx, policy)
+	if err != nil {
+		return err
+	}
+	return e.rateLimiter.Record(instanceID, policy, n)
+}
+
+// MemoryRateLimitStore is an in-process RateLimitStore
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	windows map[string]RateLimitWindow
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		windows: make(map[string]RateLimitWindow),
+	}
+}
+
+func (s *MemoryRateLimitStore) GetWindow(instanceID string) (*RateLimitWindow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.windows[instanceID]
+	if !ok {
+		return nil, nil
+	}
+	return &w, nil
+}
+
+func (s *MemoryRateLimitStore) AddToWindow(
+	instanceID string,
+	n, maxTxs uint32,
+	length time.Duration,
+	now time.Time,
+) (RateLimitWindow, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.windows[instanceID]
+	if !ok || !now.Before(w.Start.Add(length)) {
+		w = RateLimitWindow{Start: now}
+	}
+	if w.Count+n > maxTxs {
+		return w, false, nil
+	}
+
+	w.Count += n
+	s.windows[instanceID] = w
+	return w, true, nil
+}
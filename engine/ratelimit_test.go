@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+	"github.com/vultisig/recipes/sdk/evm/codegen/erc20"
+	"github.com/vultisig/recipes/types"
+	"github.com/vultisig/vultisig-go/common"
+)
+
+func uint32Ptr(v uint32) *uint32 {
+	return &v
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(NewMemoryRateLimitStore(), func() time.Time {
+		return now
+	})
+
+	policy := &types.Policy{
+		Id:              "policy-id",
+		RateLimitWindow: uint32Ptr(60),
+		MaxTxsPerWindow: uint32Ptr(2),
+	}
+
+	require.NoError(t, limiter.Check("instance", policy))
+	require.NoError(t, limiter.Record("instance", policy, 1))
+
+	now = now.Add(10 * time.Second)
+	require.NoError(t, limiter.Check("instance", policy))
+	require.NoError(t, limiter.Record("instance", policy, 1))
+
+	now = now.Add(20 * time.Second)
+	err := limiter.Check("instance", policy)
+	var rateErr *RateLimitError
+	require.True(t, errors.As(err, &rateErr))
+	require.Equal(t, 30*time.Second, rateErr.RetryAfter)
+
+	// new window starts once the previous one is over
+	now = now.Add(30 * time.Second)
+	require.NoError(t, limiter.CheckN("instance", policy, 2))
+	require.NoError(t, limiter.Record("instance", policy, 2))
+	require.Error(t, limiter.Check("instance", policy))
+
+	require.ErrorContains(t, limiter.CheckN("instance", policy, 3), "exceeds max_txs_per_window")
+
+	// other instances of the policy have their own windows
+	require.NoError(t, limiter.CheckN("other-instance", policy, 2))
+	require.NoError(t, limiter.Record("other-instance", policy, 2))
+}
+
+func TestRateLimiter_RecordIsAtomic(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(NewMemoryRateLimitStore(), func() time.Time {
+		return now
+	})
+
+	policy := &types.Policy{
+		Id:              "policy-id",
+		RateLimitWindow: uint32Ptr(60),
+		MaxTxsPerWindow: uint32Ptr(2),
+	}
+
+	// both txs were checked before either was recorded
+	require.NoError(t, limiter.CheckN("instance", policy, 2))
+	require.NoError(t, limiter.CheckN("instance", policy, 2))
+
+	require.NoError(t, limiter.Record("instance", policy, 2))
+	err := limiter.Record("instance", policy, 2)
+	var rateErr *RateLimitError
+	require.True(t, errors.As(err, &rateErr))
+	require.Equal(t, "instance", rateErr.InstanceID)
+	require.Equal(t, 60*time.Second, rateErr.RetryAfter)
+
+	// the rejected txs weren't counted
+	now = now.Add(time.Minute)
+	require.NoError(t, limiter.Record("instance", policy, 2))
+
+	require.ErrorContains(t, limiter.Record("", policy, 1), "empty policy instance id")
+}
+
+func TestRateLimiter_Defaults(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(NewMemoryRateLimitStore(), func() time.Time {
+		return now
+	})
+
+	// no window: unlimited
+	unlimited := &types.Policy{Id: "unlimited"}
+	for i := 0; i < 3; i++ {
+		require.NoError(t, limiter.Check("instance", unlimited))
+		require.NoError(t, limiter.Record("instance", unlimited, 1))
+	}
+
+	// window without max_txs_per_window: single tx per window
+	single := &types.Policy{
+		Id:              "single",
+		RateLimitWindow: uint32Ptr(60),
+	}
+	require.NoError(t, limiter.Check("instance", single))
+	require.NoError(t, limiter.Record("instance", single, 1))
+	require.Error(t, limiter.Check("instance", single))
+}
+
+func TestEvaluate_RateLimit(t *testing.T) {
+	engine, err := NewEngine()
+	require.NoError(t, err)
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	engine.SetRateLimiter(NewRateLimiter(NewMemoryRateLimitStore(), func() time.Time {
+		return now
+	}))
+
+	policy := &types.Policy{
+		Id:              "policy-id",
+		RateLimitWindow: uint32Ptr(3600),
+		MaxTxsPerWindow: uint32Ptr(1),
+		Rules: []*types.Rule{
+			erc20TransferRule(types.Effect_EFFECT_ALLOW, &types.Constraint{
+				Type: types.ConstraintType_CONSTRAINT_TYPE_ANY,
+			}),
+		},
+	}
+	txBytes := buildUnsignedTx(
+		ecommon.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"),
+		erc20.NewErc20().PackTransfer(ecommon.HexToAddress("0xcf0475d9B0a29975bc5132A3066010eC898d8CaB"), big.NewInt(1)),
+		big.NewInt(0),
+	)
+
+	// without the instance the rate limit is counted per policy id
+	_, err = engine.Evaluate(policy, common.Ethereum, txBytes)
+	require.NoError(t, err)
+	require.NoError(t, engine.RecordExecution(context.Background(), policy))
+	_, err = engine.Evaluate(policy, common.Ethereum, txBytes)
+	var rateErr *RateLimitError
+	require.True(t, errors.As(err, &rateErr))
+	require.Equal(t, "policy-id", rateErr.InstanceID)
+
+	ctx := WithPolicyInstance(context.Background(), "vault-1")
+	_, err = engine.EvaluateContext(ctx, policy, common.Ethereum, txBytes)
+	require.NoError(t, err)
+	require.NoError(t, engine.RecordExecution(ctx, policy))
+
+	_, err = engine.EvaluateContext(ctx, policy, common.Ethereum, txBytes)
+	require.True(t, errors.As(err, &rateErr))
+	require.Equal(t, time.Hour, rateErr.RetryAfter)
+
+	// another installation of the same plugin policy
+	_, err = engine.EvaluateContext(WithPolicyInstance(context.Background(), "vault-2"), policy, common.Ethereum, txBytes)
+	require.NoError(t, err)
+
+	now = now.Add(time.Hour)
+	_, err = engine.EvaluateContext(ctx, policy, common.Ethereum, txBytes)
+	require.NoError(t, err)
+}
@@ -1,7 +1,11 @@
 package engine
 
 import (
+	"context"
 	"fmt"
+	"math/big"
+	"slices"
+	"sync"
 
 	"github.com/vultisig/recipes/engine/cosmos/gaia"
 	"github.com/vultisig/recipes/engine/cosmos/maya"
@@ -16,106 +20,286 @@ import (
 	"github.com/vultisig/recipes/engine/utxo/litecoin"
 	"github.com/vultisig/recipes/engine/utxo/zcash"
 	"github.com/vultisig/recipes/engine/xrpl"
+	"github.com/vultisig/recipes/resolver"
 	"github.com/vultisig/recipes/types"
 	"github.com/vultisig/vultisig-go/common"
 )
 
 // ChainEngine defines the interface that all chain-specific engines must implement
 type ChainEngine interface {
-	Evaluate(rule *types.Rule, txBytes []byte) error
+	// Evaluate validates the tx against an allow rule
+	Evaluate(ctx context.Context, rule *types.Rule, txBytes []byte) error
+	// Match reports whether the tx matches the rule regardless of its effect,
+	// nil error means match. Used to apply deny rules
+	Match(ctx context.Context, rule *types.Rule, txBytes []byte) error
 	Supports(chain common.Chain) bool
 	ExtractTxBytes(txData string) ([]byte, error)
 }
 
-// SupportedEVMChains is the list of all EVM chains that have engines registered.
-// To add a new EVM chain, simply add it to this list and ensure it has a valid
-// NativeSymbol() implementation in the vultisig-go/common package.
+// ParameterValuer is implemented by chain engines which can report the numeric value
+// of a rule parameter in the tx, it's required to enforce period-limited constraints
+type ParameterValuer interface {
+	ParameterValue(rule *types.Rule, txBytes []byte, name string) (*big.Int, error)
+}
+
+// ParameterAssetResolver is implemented by chain engines which can report the asset of a numeric
+// rule parameter in the tx, "" for the native asset. It's required to enforce fiat-denominated constraints
+type ParameterAssetResolver interface {
+	ParameterAsset(rule *types.Rule, txBytes []byte, name string) (string, error)
+}
+
+// PSBTExtractor is implemented by UTXO chain engines. Unlike ExtractTxBytes, which returns the unsigned tx,
+// it keeps the whole PSBT, so input, fee and sighash constraints can be evaluated
+type PSBTExtractor interface {
+	ExtractPSBTBytes(txData string) ([]byte, error)
+}
+
+// BundleValidator is implemented by chain engines which can check relations between txs
+// signed together, e.g. sequential nonces or the approval matching the swap
+type BundleValidator interface {
+	ValidateBundle(rules []*types.Rule, txs [][]byte) error
+}
+
+// ApprovalSlackSetting is implemented by bundle validators which bound approvals to the amount of the approved swap
+type ApprovalSlackSetting interface {
+	SetApprovalSlack(bps uint64)
+}
+
+// SwapInputRegistering is implemented by bundle validators which match approvals with the swap args
+// holding the spent token and amount
+type SwapInputRegistering interface {
+	RegisterSwapInput(protocolID, functionID string, input evm.SwapInput)
+}
+
+// ABIRegistering is implemented by chain engines which resolve resource protocols to ABIs,
+// it lets the engine share a single registry, with ABIs registered at runtime, across them
+type ABIRegistering interface {
+	SetABIRegistry(registry *evm.ABIRegistry)
+}
+
+// DeploymentChecking is implemented by chain engines which can check the tx calls
+// a known deployment of the resource protocol
+type DeploymentChecking interface {
+	SetDeploymentRegistry(registry *evm.DeploymentRegistry)
+}
+
+// TypedDataEvaluator is implemented by chain engines which can evaluate off-chain signed messages,
+// e.g. EIP-712 permits granting token allowances without a tx
+type TypedDataEvaluator interface {
+	// EvaluateTypedData validates the typed data against an allow rule
+	EvaluateTypedData(ctx context.Context, rule *types.Rule, data []byte) error
+	// MatchTypedData reports whether the typed data matches the rule regardless of its effect
+	MatchTypedData(ctx context.Context, rule *types.Rule, data []byte) error
+}
+
+// MagicConstantResolving is implemented by chain engines which resolve magic constants,
+// it lets the engine share a single (cached) registry across them
+type MagicConstantResolving interface {
+	SetMagicConstantRegistry(registry *resolver.MagicConstantRegistry)
+}
+
+// SupportedEVMChains is the list of all EVM chains that have engines registered by default.
+// Any other EVM chain with a valid NativeSymbol() implementation in the vultisig-go/common
+// package can be enabled with WithChains without editing this list.
 var SupportedEVMChains = []common.Chain{
 	common.Ethereum, common.BscChain, common.Arbitrum, common.Avalanche,
 	common.Base, common.Blast, common.CronosChain, common.Optimism,
 	common.Polygon, common.Zksync,
 }
 
-// ChainEngineRegistry manages chain-specific engines
+// SupportedNonEVMChains is the list of all non-EVM chains that have engines registered by default
+var SupportedNonEVMChains = []common.Chain{
+	common.Bitcoin, common.BitcoinCash, common.Dogecoin, common.Litecoin,
+	common.XRP, common.THORChain, common.Zcash, common.Dash,
+	common.Solana, common.GaiaChain, common.MayaChain, common.Tron,
+}
+
+// DefaultChains returns all chains registered by NewChainEngineRegistry
+func DefaultChains() []common.Chain {
+	chains := make([]common.Chain, 0, len(SupportedEVMChains)+len(SupportedNonEVMChains))
+	chains = append(chains, SupportedEVMChains...)
+	return append(chains, SupportedNonEVMChains...)
+}
+
+// ChainEngineRegistry manages chain-specific engines, one engine per chain
 type ChainEngineRegistry struct {
-	engines []ChainEngine
+	engines map[common.Chain]ChainEngine
+	mu      sync.RWMutex
 }
 
-// NewChainEngineRegistry creates a new engine registry with all engines registered
+// NewChainEngineRegistry creates a new engine registry with engines for all DefaultChains registered
 func NewChainEngineRegistry() (*ChainEngineRegistry, error) {
+	return NewChainEngineRegistryForChains(DefaultChains())
+}
+
+// NewChainEngineRegistryForChains creates a new engine registry with the built-in engine
+// registered for each of the given chains
+func NewChainEngineRegistryForChains(chains []common.Chain) (*ChainEngineRegistry, error) {
 	registry := &ChainEngineRegistry{
-		engines: make([]ChainEngine, 0),
+		engines: make(map[common.Chain]ChainEngine, len(chains)),
 	}
 
-	// Register EVM engines - one for each supported EVM chain
-	for _, chain := range SupportedEVMChains {
-		nativeSymbol, err := chain.NativeSymbol()
+	for _, chain := range chains {
+		engine, err := NewDefaultChainEngine(chain)
+		if err != nil {
+			return nil, err
+		}
+
+		err = registry.Register(chain, engine)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get native symbol for %s: %w", chain.String(), err)
+			return nil, err
 		}
+	}
+
+	return registry, nil
+}
 
-		evmEngine, err := evm.NewEvm(nativeSymbol)
+// NewDefaultChainEngine creates the built-in engine for the chain
+func NewDefaultChainEngine(chain common.Chain) (ChainEngine, error) {
+	if chain.IsEvm() {
+		evmEngine, err := evm.NewEvmForChain(chain)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create evm engine for %s: %w", chain.String(), err)
 		}
+		return evmEngine, nil
+	}
 
-		registry.Register(evmEngine)
+	switch chain {
+	case common.Bitcoin:
+		return bitcoin.NewBtc(), nil
+	case common.BitcoinCash:
+		return bitcoincash.NewBitcoinCash(), nil
+	case common.Dogecoin:
+		return dogecoin.NewDogecoin(), nil
+	case common.Litecoin:
+		return litecoin.NewLitecoin(), nil
+	case common.XRP:
+		return xrpl.NewXRPL(), nil
+	case common.THORChain:
+		return thorchain.NewThorchain(), nil
+	case common.Zcash:
+		return zcash.NewZcash(), nil
+	case common.Dash:
+		return dash.NewDash(), nil
+	case common.Solana:
+		solEng, err := solana.NewSolana()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create solana engine: %w", err)
+		}
+		return solEng, nil
+	case common.GaiaChain:
+		return gaia.NewGaia(), nil
+	case common.MayaChain:
+		return maya.NewMaya(), nil
+	case common.Tron:
+		return tron.NewTron(), nil
+	default:
+		return nil, fmt.Errorf("no built-in engine for chain: %s", chain.String())
 	}
+}
 
-	// Register Bitcoin engine
-	registry.Register(bitcoin.NewBtc())
+// Register sets the engine for the chain, replacing the engine registered before
+func (r *ChainEngineRegistry) Register(chain common.Chain, engine ChainEngine) error {
+	if !engine.Supports(chain) {
+		return fmt.Errorf("engine %T doesn't support chain: %s", engine, chain.String())
+	}
 
-	// Register Bitcoin Cash engine
-	registry.Register(bitcoincash.NewBitcoinCash())
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	// Register Dogecoin engine
-	registry.Register(dogecoin.NewDogecoin())
+	r.engines[chain] = engine
+	return nil
+}
 
-	// Register Litecoin engine
-	registry.Register(litecoin.NewLitecoin())
+// Unregister removes the engine of the chain
+func (r *ChainEngineRegistry) Unregister(chain common.Chain) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	// Register XRPL engine
-	registry.Register(xrpl.NewXRPL())
+	delete(r.engines, chain)
+}
 
-	// Register Thorchain engine
-	registry.Register(thorchain.NewThorchain())
+// Chains returns all chains with a registered engine, sorted
+func (r *ChainEngineRegistry) Chains() []common.Chain {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	// Register Zcash engine
-	registry.Register(zcash.NewZcash())
+	chains := make([]common.Chain, 0, len(r.engines))
+	for chain := range r.engines {
+		chains = append(chains, chain)
+	}
+	slices.Sort(chains)
+	return chains
+}
 
-	// Register Dash engine
-	registry.Register(dash.NewDash())
+// SetMagicConstantRegistry sets the registry on every registered engine resolving magic constants
+func (r *ChainEngineRegistry) SetMagicConstantRegistry(registry *resolver.MagicConstantRegistry) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	// Register Solana engine
-	solEng, err := solana.NewSolana()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create solana engine: %s", err)
+	for _, engine := range r.engines {
+		if e, ok := engine.(MagicConstantResolving); ok {
+			e.SetMagicConstantRegistry(registry)
+		}
 	}
-	registry.Register(solEng)
+}
 
-	// Register Gaia (Cosmos) engine
-	registry.Register(gaia.NewGaia())
+// SetABIRegistry sets the registry on every registered engine resolving ABIs
+func (r *ChainEngineRegistry) SetABIRegistry(registry *evm.ABIRegistry) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	// Register Maya engine
-	registry.Register(maya.NewMaya())
+	for _, engine := range r.engines {
+		if e, ok := engine.(ABIRegistering); ok {
+			e.SetABIRegistry(registry)
+		}
+	}
+}
 
-	// Register Tron engine
-	registry.Register(tron.NewTron())
+// SetDeploymentRegistry sets the registry on every registered engine checking protocol deployments
+func (r *ChainEngineRegistry) SetDeploymentRegistry(registry *evm.DeploymentRegistry) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	return registry, nil
+	for _, engine := range r.engines {
+		if e, ok := engine.(DeploymentChecking); ok {
+			e.SetDeploymentRegistry(registry)
+		}
+	}
 }
 
-// Register adds an engine to the registry
-func (r *ChainEngineRegistry) Register(engine ChainEngine) {
-	r.engines = append(r.engines, engine)
+// SetApprovalSlack sets the approval slack on every registered engine bounding approvals in bundles
+func (r *ChainEngineRegistry) SetApprovalSlack(bps uint64) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, engine := range r.engines {
+		if e, ok := engine.(ApprovalSlackSetting); ok {
+			e.SetApprovalSlack(bps)
+		}
+	}
 }
 
-// GetEngine returns the first engine that supports the given chain
-func (r *ChainEngineRegistry) GetEngine(chain common.Chain) (ChainEngine, error) {
+// RegisterSwapInput sets the swap input of the protocol method on every registered engine matching approvals with swaps
+func (r *ChainEngineRegistry) RegisterSwapInput(protocolID, functionID string, input evm.SwapInput) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	for _, engine := range r.engines {
-		if engine.Supports(chain) {
-			return engine, nil
+		if e, ok := engine.(SwapInputRegistering); ok {
+			e.RegisterSwapInput(protocolID, functionID, input)
 		}
 	}
-	return nil, fmt.Errorf("no engine found for chain: %v", chain)
+}
+
+// GetEngine returns the engine registered for the chain
+func (r *ChainEngineRegistry) GetEngine(chain common.Chain) (ChainEngine, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	engine, ok := r.engines[chain]
+	if !ok {
+		return nil, fmt.Errorf("no engine found for chain: %v", chain)
+	}
+	return engine, nil
 }
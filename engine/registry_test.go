@@ -1,9 +1,15 @@
 package engine
 
 import (
+	"bytes"
+	"context"
+	"strings"
 	"testing"
 
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/wire"
 	"github.com/stretchr/testify/require"
+	"github.com/vultisig/recipes/types"
 	"github.com/vultisig/vultisig-go/common"
 )
 
@@ -137,3 +143,137 @@ func TestChainEngineInterface(t *testing.T) {
 		})
 	}
 }
+
+func TestChainEngineMatch_IgnoresEffect(t *testing.T) {
+	registry, err := NewChainEngineRegistry()
+	require.NoError(t, err)
+
+	chains := []common.Chain{
+		common.Ethereum, common.BscChain, common.Arbitrum,
+		common.Bitcoin, common.BitcoinCash, common.Dogecoin, common.Litecoin, common.Dash,
+		common.Zcash, common.XRP, common.Solana, common.THORChain, common.GaiaChain,
+		common.MayaChain, common.Tron,
+	}
+
+	for _, chain := range chains {
+		t.Run(chain.String(), func(t *testing.T) {
+			engine, err := registry.GetEngine(chain)
+			require.NoError(t, err)
+
+			rule := &types.Rule{
+				Resource: strings.ToLower(chain.String()) + ".send",
+				Effect:   types.Effect_EFFECT_DENY,
+			}
+
+			err = engine.Evaluate(context.Background(), rule, []byte("invalid-tx-data"))
+			require.ErrorContains(t, err, "only allow rules supported")
+
+			// Match must get past the effect check and fail on the tx itself
+			err = engine.Match(context.Background(), rule, []byte("invalid-tx-data"))
+			require.Error(t, err)
+			require.NotContains(t, err.Error(), "only allow rules supported")
+		})
+	}
+}
+
+func TestChainEngineRegistry_MagicConstantRegistry(t *testing.T) {
+	registry, err := NewChainEngineRegistry()
+	require.NoError(t, err)
+
+	for _, engine := range registry.engines {
+		_, ok := engine.(MagicConstantResolving)
+		require.True(t, ok, "%T must accept the shared magic constant registry", engine)
+	}
+}
+
+type stubEngine struct {
+	chain common.Chain
+	calls int
+}
+
+func (s *stubEngine) Evaluate(_ context.Context, _ *types.Rule, _ []byte) error {
+	s.calls++
+	return nil
+}
+
+func (s *stubEngine) Match(_ context.Context, _ *types.Rule, _ []byte) error {
+	s.calls++
+	return nil
+}
+
+func (s *stubEngine) Supports(chain common.Chain) bool {
+	return chain == s.chain
+}
+
+func (s *stubEngine) ExtractTxBytes(txData string) ([]byte, error) {
+	return []byte(txData), nil
+}
+
+func TestChainEngineRegistry_ForChains(t *testing.T) {
+	registry, err := NewChainEngineRegistryForChains([]common.Chain{common.Mantle, common.Bitcoin})
+	require.NoError(t, err)
+
+	require.Equal(t, []common.Chain{common.Bitcoin, common.Mantle}, registry.Chains())
+
+	engine, err := registry.GetEngine(common.Mantle)
+	require.NoError(t, err)
+	require.True(t, engine.Supports(common.Mantle))
+
+	_, err = registry.GetEngine(common.Ethereum)
+	require.Error(t, err)
+}
+
+func TestChainEngineRegistry_Register(t *testing.T) {
+	registry, err := NewChainEngineRegistryForChains(nil)
+	require.NoError(t, err)
+	require.Empty(t, registry.Chains())
+
+	err = registry.Register(common.Ethereum, &stubEngine{chain: common.Bitcoin})
+	require.Error(t, err)
+
+	stub := &stubEngine{chain: common.Ethereum}
+	require.NoError(t, registry.Register(common.Ethereum, stub))
+
+	engine, err := registry.GetEngine(common.Ethereum)
+	require.NoError(t, err)
+	require.Same(t, stub, engine)
+
+	registry.Unregister(common.Ethereum)
+	_, err = registry.GetEngine(common.Ethereum)
+	require.Error(t, err)
+}
+
+func TestEngine_ExtractPSBTBytes(t *testing.T) {
+	engine, err := NewEngine()
+	require.NoError(t, err)
+
+	// p2wpkh script
+	script := append([]byte{0x00, 0x14}, bytes.Repeat([]byte{0x01}, 20)...)
+	packet, err := psbt.New(
+		[]*wire.OutPoint{{Index: 0}},
+		[]*wire.TxOut{wire.NewTxOut(1_000_000, script)},
+		2,
+		0,
+		[]uint32{wire.MaxTxInSequenceNum},
+	)
+	require.NoError(t, err)
+	packet.Inputs[0].WitnessUtxo = wire.NewTxOut(2_000_000, script)
+	encoded, err := packet.B64Encode()
+	require.NoError(t, err)
+
+	var want bytes.Buffer
+	require.NoError(t, packet.Serialize(&want))
+	psbtBytes, err := engine.ExtractPSBTBytes(common.Bitcoin, encoded)
+	require.NoError(t, err)
+	require.Equal(t, want.Bytes(), psbtBytes)
+
+	// ExtractTxBytes returns the unsigned tx without input utxos
+	var unsigned bytes.Buffer
+	require.NoError(t, packet.UnsignedTx.Serialize(&unsigned))
+	txBytes, err := engine.ExtractTxBytes(common.Bitcoin, encoded)
+	require.NoError(t, err)
+	require.Equal(t, unsigned.Bytes(), txBytes)
+
+	_, err = engine.ExtractPSBTBytes(common.Ethereum, encoded)
+	require.ErrorContains(t, err, "chain Ethereum doesn't support PSBTs")
+}
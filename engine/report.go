@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"errors"
+
+	"github.com/vultisig/recipes/engine/compare"
+	"github.com/vultisig/recipes/types"
+)
+
+// RuleOutcome is the result of a single rule evaluation
+type RuleOutcome string
+
+const (
+	// RuleOutcomeSkipped rule doesn't apply to the tx chain or is malformed, see SkipReason
+	RuleOutcomeSkipped RuleOutcome = "skipped"
+	// RuleOutcomeMatched allow rule passed or deny rule matched the tx
+	RuleOutcomeMatched RuleOutcome = "matched"
+	// RuleOutcomeNotMatched allow rule failed or deny rule didn't match the tx
+	RuleOutcomeNotMatched RuleOutcome = "not_matched"
+	// RuleOutcomeNotEvaluated rule wasn't evaluated because the decision was already made
+	RuleOutcomeNotEvaluated RuleOutcome = "not_evaluated"
+	// RuleOutcomeError deny rule couldn't be evaluated against the tx, so the tx was rejected
+	RuleOutcomeError RuleOutcome = "error"
+)
+
+// SkipReason explains why the rule was skipped
+type SkipReason string
+
+const (
+	SkipReasonInvalidResource SkipReason = "invalid_resource"
+	SkipReasonWrongChain      SkipReason = "wrong_chain"
+	SkipReasonNoEngine        SkipReason = "no_engine"
+	SkipReasonInvalidAmount   SkipReason = "invalid_amount"
+)
+
+// RuleReport describes the evaluation of a single rule, meta-rules are reported
+// per expanded rule
+type RuleReport struct {
+	RuleID     string      `json:"rule_id"`
+	Resource   string      `json:"resource"`
+	Effect     string      `json:"effect"`
+	Outcome    RuleOutcome `json:"outcome"`
+	SkipReason SkipReason  `json:"skip_reason,omitempty"`
+	// Error is the reason the rule didn't match
+	Error string `json:"error,omitempty"`
+	// Violation is set if the rule didn't match because of a parameter constraint
+	Violation *compare.ConstraintError `json:"violation,omitempty"`
+}
+
+// EvaluationReport describes how the policy was evaluated against the tx
+type EvaluationReport struct {
+	PolicyID string        `json:"policy_id"`
+	Chain    string        `json:"chain"`
+	Rules    []*RuleReport `json:"rules"`
+	// MatchedRuleID is the ID of the allow rule which allowed the tx
+	MatchedRuleID string `json:"matched_rule_id,omitempty"`
+	// DeniedByRuleID is the ID of the deny rule which rejected the tx
+	DeniedByRuleID string `json:"denied_by_rule_id,omitempty"`
+	// RateLimit is set if the tx was rejected by the policy rate limit
+	RateLimit *RateLimitError `json:"rate_limit,omitempty"`
+
+	MatchedRule *types.Rule `json:"-"`
+}
+
+func (r *EvaluationReport) add(rule *types.Rule, outcome RuleOutcome) *RuleReport {
+	rr := &RuleReport{
+		RuleID:   rule.GetId(),
+		Resource: rule.GetResource(),
+		Effect:   rule.GetEffect().String(),
+		Outcome:  outcome,
+	}
+	r.Rules = append(r.Rules, rr)
+	return rr
+}
+
+func (r *EvaluationReport) skip(rule *types.Rule, reason SkipReason, err error) {
+	rr := r.add(rule, RuleOutcomeSkipped)
+	rr.SkipReason = reason
+	if err != nil {
+		rr.Error = err.Error()
+	}
+}
+
+func (r *EvaluationReport) notMatched(rule *types.Rule, err error) {
+	rr := r.add(rule, RuleOutcomeNotMatched)
+	rr.Error = err.Error()
+
+	var violation *compare.ConstraintError
+	if errors.As(err, &violation) {
+		rr.Violation = violation
+	}
+}
+
+func (r *EvaluationReport) failed(rule *types.Rule, err error) {
+	rr := r.add(rule, RuleOutcomeError)
+	rr.Error = err.Error()
+}
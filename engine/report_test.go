@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+	"github.com/vultisig/recipes/sdk/evm/codegen/erc20"
+	"github.com/vultisig/vultisig-go/common"
+
+	"github.com/vultisig/recipes/types"
+)
+
+func TestEvaluateWithReport(t *testing.T) {
+	engine, err := NewEngine()
+	require.NoError(t, err)
+
+	expected := ecommon.HexToAddress("0xcf0475d9B0a29975bc5132A3066010eC898d8CaB")
+	actual := ecommon.HexToAddress("0x000000000000000000000000000000000000dEaD")
+
+	allowExpected := erc20TransferRule(types.Effect_EFFECT_ALLOW, &types.Constraint{
+		Type:  types.ConstraintType_CONSTRAINT_TYPE_FIXED,
+		Value: &types.Constraint_FixedValue{FixedValue: expected.Hex()},
+	})
+	allowExpected.Id = "allow expected"
+	wrongChain := &types.Rule{
+		Id:       "btc send",
+		Resource: "bitcoin.btc.transfer",
+		Effect:   types.Effect_EFFECT_ALLOW,
+	}
+
+	txBytes := buildUnsignedTx(
+		ecommon.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"),
+		erc20.NewErc20().PackTransfer(actual, big.NewInt(1000000)),
+		big.NewInt(0),
+	)
+
+	policy := &types.Policy{
+		Id:    "policy",
+		Rules: []*types.Rule{wrongChain, allowExpected},
+	}
+
+	report, err := engine.EvaluateWithReport(context.Background(), policy, common.Ethereum, txBytes)
+	require.ErrorContains(t, err, "failed to evaluate tx")
+	require.NotNil(t, report)
+	require.Equal(t, "policy", report.PolicyID)
+	require.Empty(t, report.MatchedRuleID)
+	require.Nil(t, report.MatchedRule)
+	require.Len(t, report.Rules, 2)
+
+	require.Equal(t, RuleOutcomeSkipped, report.Rules[0].Outcome)
+	require.Equal(t, SkipReasonWrongChain, report.Rules[0].SkipReason)
+
+	failed := report.Rules[1]
+	require.Equal(t, "allow expected", failed.RuleID)
+	require.Equal(t, RuleOutcomeNotMatched, failed.Outcome)
+	require.NotNil(t, failed.Violation)
+	require.Equal(t, "recipient", failed.Violation.Parameter)
+	require.Equal(t, types.ConstraintType_CONSTRAINT_TYPE_FIXED.String(), failed.Violation.Type)
+	require.True(t, strings.EqualFold(expected.Hex(), failed.Violation.Expected))
+	require.True(t, strings.EqualFold(actual.Hex(), failed.Violation.Actual))
+
+	_, err = json.Marshal(report)
+	require.NoError(t, err)
+}
+
+func TestEvaluateWithReport_Matched(t *testing.T) {
+	engine, err := NewEngine()
+	require.NoError(t, err)
+
+	to := ecommon.HexToAddress("0xcf0475d9B0a29975bc5132A3066010eC898d8CaB")
+	first := erc20TransferRule(types.Effect_EFFECT_ALLOW, &types.Constraint{
+		Type: types.ConstraintType_CONSTRAINT_TYPE_ANY,
+	})
+	first.Id = "first"
+	second := erc20TransferRule(types.Effect_EFFECT_ALLOW, &types.Constraint{
+		Type: types.ConstraintType_CONSTRAINT_TYPE_ANY,
+	})
+	second.Id = "second"
+
+	txBytes := buildUnsignedTx(
+		ecommon.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"),
+		erc20.NewErc20().PackTransfer(to, big.NewInt(1000000)),
+		big.NewInt(0),
+	)
+
+	report, err := engine.EvaluateWithReport(context.Background(), &types.Policy{Rules: []*types.Rule{first, second}}, common.Ethereum, txBytes)
+	require.NoError(t, err)
+	require.Equal(t, "first", report.MatchedRuleID)
+	require.Equal(t, first, report.MatchedRule)
+	require.Len(t, report.Rules, 2)
+	require.Equal(t, RuleOutcomeMatched, report.Rules[0].Outcome)
+	require.Equal(t, RuleOutcomeNotEvaluated, report.Rules[1].Outcome)
+}
@@ -2,6 +2,7 @@ package solana
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 
 	bin "github.com/gagliardetto/binary"
@@ -13,7 +14,8 @@ import (
 	"github.com/vultisig/vultisig-go/common"
 )
 
-func assertTarget(
+func (s *Solana) assertTarget(
+	ctx context.Context,
 	resource *types.ResourcePath,
 	targetRule *types.Target,
 	actual solana.PublicKey,
@@ -32,7 +34,7 @@ func assertTarget(
 		}
 
 		if !actual.Equals(expectedRuleTarget) {
-			return fmt.Errorf(
+			return compare.NewMismatchError(
 				"tx target is wrong: tx_to=%s, rule_target_address=%s",
 				actual.String(),
 				expectedRuleTarget.String(),
@@ -41,7 +43,7 @@ func assertTarget(
 		return nil
 
 	case types.TargetType_TARGET_TYPE_MAGIC_CONSTANT:
-		resolve, er := resolver.NewMagicConstantRegistry().GetResolver(targetRule.GetMagicConstant())
+		resolve, er := s.resolvers.GetResolver(targetRule.GetMagicConstant())
 		if er != nil {
 			return fmt.Errorf(
 				"failed to get resolver: magic_const=%s",
@@ -50,6 +52,7 @@ func assertTarget(
 		}
 
 		resolvedAddr, _, er := resolve.Resolve(
+			ctx,
 			targetRule.GetMagicConstant(),
 			resource.ChainId,
 			"default",
@@ -67,7 +70,7 @@ func assertTarget(
 		}
 
 		if !actual.Equals(resolvedTarget) {
-			return fmt.Errorf(
+			return compare.NewMismatchError(
 				"tx target is wrong: tx_to=%s, rule_magic_const_resolved=%s",
 				actual.String(),
 				resolvedTarget.String(),
@@ -86,7 +89,7 @@ func assertFuncSelector(expected []byte, data solana.Base58) error {
 	}
 
 	if len(data) < len(expected) {
-		return fmt.Errorf(
+		return compare.NewMismatchError(
 			"instruction data too short: expected at least %d bytes for discriminator, got %d",
 			len(expected),
 			len(data),
@@ -95,21 +98,26 @@ func assertFuncSelector(expected []byte, data solana.Base58) error {
 
 	actual := []byte(data[:len(expected)])
 	if !bytes.Equal(expected, actual) {
-		return fmt.Errorf("function discriminator mismatch: expected %x, got %x", expected, actual)
+		return compare.NewMismatchError("function discriminator mismatch: expected %x, got %x", expected, actual)
 	}
 
 	return nil
 }
 
-func assertAccounts(constraints []*types.ParameterConstraint, msg solana.Message, accs []idlAccount) error {
+func (s *Solana) assertAccounts(
+	ctx context.Context,
+	constraints []*types.ParameterConstraint,
+	msg solana.Message,
+	inst solana.CompiledInstruction,
+	accs []idlAccount,
+) error {
 	const constraintPrefix = "account_"
 
-	inst := msg.Instructions[0]
 	idlAccountCount := len(accs)
 	actualAccountCount := len(inst.Accounts)
 
 	if actualAccountCount < idlAccountCount {
-		return fmt.Errorf(
+		return compare.NewMismatchError(
 			"not enough accounts: IDL requires %d accounts, tx has %d accounts",
 			idlAccountCount,
 			actualAccountCount,
@@ -125,6 +133,8 @@ func assertAccounts(constraints []*types.ParameterConstraint, msg solana.Message
 		}
 
 		err = compare.AssertArg(
+			ctx,
+			s.resolvers,
 			common.Solana.String(),
 			constraints,
 			name,
@@ -139,7 +149,8 @@ func assertAccounts(constraints []*types.ParameterConstraint, msg solana.Message
 	return nil
 }
 
-func assertArgs(
+func (s *Solana) assertArgs(
+	ctx context.Context,
 	constraints []*types.ParameterConstraint,
 	data solana.Base58,
 	args []idlArgument,
@@ -161,13 +172,14 @@ func assertArgs(
 	}
 
 	if firstComplexIdx == -1 {
-		return assertArgsSequential(constraints, data, args, discriminator, constraintPrefix)
+		return s.assertArgsSequential(ctx, constraints, data, args, discriminator, constraintPrefix)
 	}
 
-	return assertArgsWithComplex(constraints, data, args, discriminator, constraintPrefix, firstComplexIdx)
+	return s.assertArgsWithComplex(ctx, constraints, data, args, discriminator, constraintPrefix, firstComplexIdx)
 }
 
-func assertArgsSequential(
+func (s *Solana) assertArgsSequential(
+	ctx context.Context,
 	constraints []*types.ParameterConstraint,
 	data solana.Base58,
 	args []idlArgument,
@@ -180,31 +192,31 @@ func assertArgsSequential(
 
 		switch arg.Type {
 		case argU8:
-			er := decodeAndAssert(decoder, constraints, name, compare.NewUint8)
+			er := decodeAndAssert(ctx, s.resolvers, decoder, constraints, name, compare.NewUint8)
 			if er != nil {
 				return fmt.Errorf("failed to decode & assert: %w", er)
 			}
 
 		case argU16:
-			er := decodeAndAssert(decoder, constraints, name, compare.NewUint16)
+			er := decodeAndAssert(ctx, s.resolvers, decoder, constraints, name, compare.NewUint16)
 			if er != nil {
 				return fmt.Errorf("failed to decode & assert: %w", er)
 			}
 
 		case argU64:
-			er := decodeAndAssert(decoder, constraints, name, compare.NewUint64)
+			er := decodeAndAssert(ctx, s.resolvers, decoder, constraints, name, compare.NewUint64)
 			if er != nil {
 				return fmt.Errorf("failed to decode & assert: %w", er)
 			}
 
 		case argBool:
-			er := decodeAndAssert(decoder, constraints, name, compare.NewBool)
+			er := decodeAndAssert(ctx, s.resolvers, decoder, constraints, name, compare.NewBool)
 			if er != nil {
 				return fmt.Errorf("failed to decode & assert: %w", er)
 			}
 
 		case argPublicKey:
-			er := decodeAndAssert(decoder, constraints, name, solcmp.NewPubKey)
+			er := decodeAndAssert(ctx, s.resolvers, decoder, constraints, name, solcmp.NewPubKey)
 			if er != nil {
 				return fmt.Errorf("failed to decode & assert: %w", er)
 			}
@@ -217,6 +229,8 @@ func assertArgsSequential(
 			}
 
 			er = compare.AssertArg(
+				ctx,
+				s.resolvers,
 				common.Solana.String(),
 				constraints,
 				name,
@@ -234,7 +248,8 @@ func assertArgsSequential(
 	return nil
 }
 
-func assertArgsWithComplex(
+func (s *Solana) assertArgsWithComplex(
+	ctx context.Context,
 	constraints []*types.ParameterConstraint,
 	data solana.Base58,
 	args []idlArgument,
@@ -250,25 +265,25 @@ func assertArgsWithComplex(
 
 		switch arg.Type {
 		case argU8:
-			er := decodeAndAssert(decoder, constraints, name, compare.NewUint8)
+			er := decodeAndAssert(ctx, s.resolvers, decoder, constraints, name, compare.NewUint8)
 			if er != nil {
 				return fmt.Errorf("failed to decode & assert: %w", er)
 			}
 
 		case argU16:
-			er := decodeAndAssert(decoder, constraints, name, compare.NewUint16)
+			er := decodeAndAssert(ctx, s.resolvers, decoder, constraints, name, compare.NewUint16)
 			if er != nil {
 				return fmt.Errorf("failed to decode & assert: %w", er)
 			}
 
 		case argU64:
-			er := decodeAndAssert(decoder, constraints, name, compare.NewUint64)
+			er := decodeAndAssert(ctx, s.resolvers, decoder, constraints, name, compare.NewUint64)
 			if er != nil {
 				return fmt.Errorf("failed to decode & assert: %w", er)
 			}
 
 		case argPublicKey:
-			er := decodeAndAssert(decoder, constraints, name, solcmp.NewPubKey)
+			er := decodeAndAssert(ctx, s.resolvers, decoder, constraints, name, solcmp.NewPubKey)
 			if er != nil {
 				return fmt.Errorf("failed to decode & assert: %w", er)
 			}
@@ -292,6 +307,8 @@ func assertArgsWithComplex(
 		name := constraintPrefix + arg.Name
 
 		er := compare.AssertArg(
+			ctx,
+			s.resolvers,
 			common.Solana.String(),
 			constraints,
 			name,
@@ -333,25 +350,25 @@ func assertArgsWithComplex(
 
 		switch arg.Type {
 		case argU8:
-			er := decodeAndAssert(suffixDecoder, constraints, name, compare.NewUint8)
+			er := decodeAndAssert(ctx, s.resolvers, suffixDecoder, constraints, name, compare.NewUint8)
 			if er != nil {
 				return fmt.Errorf("failed to decode & assert: %w", er)
 			}
 
 		case argU16:
-			er := decodeAndAssert(suffixDecoder, constraints, name, compare.NewUint16)
+			er := decodeAndAssert(ctx, s.resolvers, suffixDecoder, constraints, name, compare.NewUint16)
 			if er != nil {
 				return fmt.Errorf("failed to decode & assert: %w", er)
 			}
 
 		case argU64:
-			er := decodeAndAssert(suffixDecoder, constraints, name, compare.NewUint64)
+			er := decodeAndAssert(ctx, s.resolvers, suffixDecoder, constraints, name, compare.NewUint64)
 			if er != nil {
 				return fmt.Errorf("failed to decode & assert: %w", er)
 			}
 
 		case argPublicKey:
-			er := decodeAndAssert(suffixDecoder, constraints, name, solcmp.NewPubKey)
+			er := decodeAndAssert(ctx, s.resolvers, suffixDecoder, constraints, name, solcmp.NewPubKey)
 			if er != nil {
 				return fmt.Errorf("failed to decode & assert: %w", er)
 			}
@@ -365,6 +382,8 @@ func assertArgsWithComplex(
 }
 
 func decodeAndAssert[T any](
+	ctx context.Context,
+	registry *resolver.MagicConstantRegistry,
 	decoder *bin.Decoder,
 	expectedList []*types.ParameterConstraint,
 	expectedName string,
@@ -378,6 +397,8 @@ func decodeAndAssert[T any](
 	}
 
 	err = compare.AssertArg(
+		ctx,
+		registry,
 		common.Solana.String(),
 		expectedList,
 		expectedName,
@@ -0,0 +1,109 @@
+package solana
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/vultisig/recipes/engine/compare"
+	"github.com/vultisig/recipes/types"
+	"github.com/vultisig/vultisig-go/common"
+)
+
+// ComputeBudget instruction discriminators
+const (
+	requestHeapFrame               = 1
+	setComputeUnitLimit            = 2
+	setComputeUnitPrice            = 3
+	setLoadedAccountsDataSizeLimit = 4
+)
+
+// ComputeBudgetLimits are the max values of ComputeBudget instructions of rules without compute_unit_* constraints
+type ComputeBudgetLimits struct {
+	// MaxUnitLimit is the max compute unit limit
+	MaxUnitLimit uint64
+	// MaxUnitPrice is the max compute unit price in micro-lamports, the priority fee is the price times the limit
+	MaxUnitPrice uint64
+}
+
+// DefaultComputeBudgetLimits allow the max compute unit limit of a tx, with the priority fee up to 0.014 SOL
+func DefaultComputeBudgetLimits() ComputeBudgetLimits {
+	return ComputeBudgetLimits{
+		MaxUnitLimit: 1_400_000,
+		MaxUnitPrice: 10_000_000,
+	}
+}
+
+// assertComputeBudget checks ComputeBudget instructions of the tx against compute_unit_* constraints,
+// or the engine limits if the rule has none. Returns indexes of the other instructions
+func (s *Solana) assertComputeBudget(
+	ctx context.Context,
+	params map[string]*types.ParameterConstraint,
+	msg solana.Message,
+) ([]int, error) {
+	var instructions []int
+	seen := make(map[byte]bool)
+	for i, inst := range msg.Instructions {
+		programID, err := msg.Program(inst.ProgramIDIndex)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve program id: %w", err)
+		}
+		if !programID.Equals(solana.ComputeBudget) {
+			instructions = append(instructions, i)
+			continue
+		}
+
+		if len(inst.Data) == 0 {
+			return nil, fmt.Errorf("instruction %d: empty compute budget instruction", i)
+		}
+		kind := inst.Data[0]
+		if seen[kind] {
+			return nil, fmt.Errorf("instruction %d: duplicate compute budget instruction %d", i, kind)
+		}
+		seen[kind] = true
+
+		switch kind {
+		case requestHeapFrame, setLoadedAccountsDataSizeLimit:
+			if len(inst.Data) != 5 {
+				return nil, fmt.Errorf("instruction %d: invalid compute budget instruction %d", i, kind)
+			}
+		case setComputeUnitLimit:
+			if len(inst.Data) != 5 {
+				return nil, fmt.Errorf("instruction %d: invalid compute unit limit", i)
+			}
+			limit := uint64(binary.LittleEndian.Uint32(inst.Data[1:]))
+			err = s.assertComputeBudgetValue(ctx, params[ComputeUnitLimit], limit, s.budget.MaxUnitLimit)
+			if err != nil {
+				return nil, fmt.Errorf("instruction %d: compute unit limit: %w", i, err)
+			}
+		case setComputeUnitPrice:
+			if len(inst.Data) != 9 {
+				return nil, fmt.Errorf("instruction %d: invalid compute unit price", i)
+			}
+			price := binary.LittleEndian.Uint64(inst.Data[1:])
+			err = s.assertComputeBudgetValue(ctx, params[ComputeUnitPrice], price, s.budget.MaxUnitPrice)
+			if err != nil {
+				return nil, fmt.Errorf("instruction %d: compute unit price: %w", i, err)
+			}
+		default:
+			return nil, fmt.Errorf("instruction %d: unsupported compute budget instruction %d", i, kind)
+		}
+	}
+	return instructions, nil
+}
+
+func (s *Solana) assertComputeBudgetValue(
+	ctx context.Context,
+	constraint *types.ParameterConstraint,
+	actual uint64,
+	limit uint64,
+) error {
+	if constraint != nil {
+		return compare.AssertConstraint(ctx, s.resolvers, common.Solana.String(), constraint, actual, compare.NewUint64)
+	}
+	if actual > limit {
+		return fmt.Errorf("value %d exceeds the limit %d", actual, limit)
+	}
+	return nil
+}
@@ -0,0 +1,373 @@
+package solana
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/vultisig/recipes/types"
+	"github.com/vultisig/recipes/util"
+)
+
+// Tx-level parameters of Solana rules, checked in addition to the account_* and arg_* constraints
+// of the rule's own instruction
+const (
+	// InstructionOrder selects how instructions are matched to instruction patterns, FIXED to one of:
+	//   - InstructionOrderStrict (default): instructions follow the order of pattern indexes
+	//   - InstructionOrderAny: instructions may be in any order
+	InstructionOrder = "instruction_order"
+	// InstructionIndex is the index of the rule's own instruction among instruction patterns, 0 by default
+	InstructionIndex = "instruction_index"
+	// ComputeUnitLimit constrains the SetComputeUnitLimit instruction, in compute units
+	ComputeUnitLimit = "compute_unit_limit"
+	// ComputeUnitPrice constrains the SetComputeUnitPrice instruction, in micro-lamports per compute unit
+	ComputeUnitPrice = "compute_unit_price"
+)
+
+const (
+	InstructionOrderStrict = "strict"
+	InstructionOrderAny    = "any"
+)
+
+// instructionPrefix of instruction pattern parameters, instruction_N.resource, instruction_N.program,
+// instruction_N.account_* and instruction_N.arg_*. The resource, e.g. FIXED "associated_token_account.create",
+// is required, it's a required pattern if the resource constraint is required, otherwise the instruction may be absent.
+// The program defaults to the well-known program of the protocol
+const instructionPrefix = "instruction_"
+
+// programs are program ids of protocols with embedded IDLs
+var programs = map[protocolID]solana.PublicKey{
+	"system":                   solana.SystemProgramID,
+	"token":                    solana.TokenProgramID,
+	"associated_token_account": solana.SPLAssociatedTokenAccountProgramID,
+	"jupiter_aggregatorv6":     solana.MustPublicKeyFromBase58("JUP6LkbZbjS1jKKwapdHNy74zcZ3tLUZoi5QNyVTaV4"),
+}
+
+// instructionPattern is an instruction allowed by the rule
+type instructionPattern struct {
+	index       int
+	primary     bool
+	required    bool
+	resource    *types.ResourcePath
+	target      *types.Target
+	constraints []*types.ParameterConstraint
+}
+
+func (p instructionPattern) String() string {
+	return fmt.Sprintf("%d (%s.%s)", p.index, p.resource.ProtocolId, p.resource.FunctionId)
+}
+
+func isTxParameter(name string) bool {
+	switch name {
+	case InstructionOrder, InstructionIndex, ComputeUnitLimit, ComputeUnitPrice:
+		return true
+	default:
+		return false
+	}
+}
+
+// instructionPatterns returns the rule's own instruction and instruction_N patterns sorted by index,
+// and tx-level parameters by name
+func instructionPatterns(rule *types.Rule) ([]instructionPattern, map[string]*types.ParameterConstraint, error) {
+	r, err := util.ParseResource(rule.GetResource())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse rule resource: %w", err)
+	}
+
+	params := make(map[string]*types.ParameterConstraint)
+	byIndex := make(map[int][]*types.ParameterConstraint)
+	primary := instructionPattern{
+		primary:  true,
+		required: true,
+		resource: r,
+		target:   rule.GetTarget(),
+	}
+	for _, pc := range rule.GetParameterConstraints() {
+		name := pc.GetParameterName()
+		if isTxParameter(name) {
+			params[name] = pc
+			continue
+		}
+
+		index, field, ok := parsePatternParameter(name)
+		if !ok {
+			primary.constraints = append(primary.constraints, pc)
+			continue
+		}
+		byIndex[index] = append(byIndex[index], &types.ParameterConstraint{
+			ParameterName: field,
+			Constraint:    pc.GetConstraint(),
+		})
+	}
+
+	if c, ok := params[InstructionIndex]; ok {
+		if c.GetConstraint().GetType() != types.ConstraintType_CONSTRAINT_TYPE_FIXED {
+			return nil, nil, fmt.Errorf("instruction index must be a fixed value, got: %s", c.GetConstraint().GetType().String())
+		}
+		primary.index, err = strconv.Atoi(c.GetConstraint().GetFixedValue())
+		if err != nil || primary.index < 0 {
+			return nil, nil, fmt.Errorf("invalid instruction index: %s", c.GetConstraint().GetFixedValue())
+		}
+	}
+
+	patterns := []instructionPattern{primary}
+	for index, constraints := range byIndex {
+		if index == primary.index {
+			return nil, nil, fmt.Errorf("instruction %d is the rule's own instruction", index)
+		}
+		p, er := newInstructionPattern(index, r.ChainId, constraints)
+		if er != nil {
+			return nil, nil, fmt.Errorf("invalid instruction %d: %w", index, er)
+		}
+		patterns = append(patterns, p)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		return patterns[i].index < patterns[j].index
+	})
+	return patterns, params, nil
+}
+
+// parsePatternParameter splits instruction_N.field into N and field
+func parsePatternParameter(name string) (int, string, bool) {
+	if !strings.HasPrefix(name, instructionPrefix) {
+		return 0, "", false
+	}
+	indexStr, field, ok := strings.Cut(strings.TrimPrefix(name, instructionPrefix), ".")
+	if !ok {
+		return 0, "", false
+	}
+	index, err := strconv.Atoi(indexStr)
+	if err != nil || index < 0 {
+		return 0, "", false
+	}
+	return index, field, true
+}
+
+func newInstructionPattern(index int, chainID string, constraints []*types.ParameterConstraint) (instructionPattern, error) {
+	p := instructionPattern{index: index}
+
+	var program *types.ParameterConstraint
+	for _, pc := range constraints {
+		switch pc.GetParameterName() {
+		case "resource":
+			if pc.GetConstraint().GetType() != types.ConstraintType_CONSTRAINT_TYPE_FIXED {
+				return p, fmt.Errorf("resource must be a fixed value, got: %s", pc.GetConstraint().GetType().String())
+			}
+			r, err := util.ParseResource(chainID + "." + pc.GetConstraint().GetFixedValue())
+			if err != nil || r.FunctionId == "" {
+				return p, fmt.Errorf("invalid resource: %s", pc.GetConstraint().GetFixedValue())
+			}
+			p.resource = r
+			p.required = pc.GetConstraint().GetRequired()
+		case "program":
+			program = pc
+		default:
+			p.constraints = append(p.constraints, pc)
+		}
+	}
+	if p.resource == nil {
+		return p, fmt.Errorf("missing %s%d.resource", instructionPrefix, index)
+	}
+
+	switch {
+	case program == nil:
+		programID, ok := programs[p.resource.ProtocolId]
+		if !ok {
+			return p, fmt.Errorf("unknown program of %s, %s%d.program is required", p.resource.ProtocolId, instructionPrefix, index)
+		}
+		p.target = &types.Target{
+			TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+			Target:     &types.Target_Address{Address: programID.String()},
+		}
+	case program.GetConstraint().GetType() == types.ConstraintType_CONSTRAINT_TYPE_FIXED:
+		p.target = &types.Target{
+			TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+			Target:     &types.Target_Address{Address: program.GetConstraint().GetFixedValue()},
+		}
+	case program.GetConstraint().GetType() == types.ConstraintType_CONSTRAINT_TYPE_MAGIC_CONSTANT:
+		p.target = &types.Target{
+			TargetType: types.TargetType_TARGET_TYPE_MAGIC_CONSTANT,
+			Target:     &types.Target_MagicConstant{MagicConstant: program.GetConstraint().GetMagicConstantValue()},
+		}
+	default:
+		return p, fmt.Errorf("program must be a fixed value or a magic constant, got: %s",
+			program.GetConstraint().GetType().String())
+	}
+	return p, nil
+}
+
+func instructionOrder(c *types.ParameterConstraint) (string, error) {
+	if c == nil {
+		return InstructionOrderStrict, nil
+	}
+	if c.GetConstraint().GetType() != types.ConstraintType_CONSTRAINT_TYPE_FIXED {
+		return "", fmt.Errorf("instruction order must be a fixed value, got: %s", c.GetConstraint().GetType().String())
+	}
+
+	order := c.GetConstraint().GetFixedValue()
+	switch order {
+	case InstructionOrderStrict, InstructionOrderAny:
+		return order, nil
+	default:
+		return "", fmt.Errorf("unsupported instruction order: %s", order)
+	}
+}
+
+// matchInstructionsInOrder matches the instructions to patterns with increasing indexes, skipping patterns
+// which are not required
+func (s *Solana) matchInstructionsInOrder(
+	ctx context.Context,
+	patterns []instructionPattern,
+	tx *solana.Transaction,
+	instructions []int,
+) error {
+	next := 0
+	for _, i := range instructions {
+		inst := tx.Message.Instructions[i]
+
+		var mismatches []string
+		matched := false
+		for next < len(patterns) {
+			p := patterns[next]
+			next++
+
+			err := s.matchInstruction(ctx, p, tx, inst, false)
+			if err == nil {
+				matched = true
+				break
+			}
+			if len(patterns) == 1 && len(instructions) == 1 {
+				return err
+			}
+			mismatches = append(mismatches, fmt.Sprintf("instruction %s: %s", p, err.Error()))
+			if p.required {
+				return fmt.Errorf("instruction %d doesn't match required instruction %s: %s", i, p, strings.Join(mismatches, "; "))
+			}
+		}
+		if !matched {
+			if len(mismatches) == 0 {
+				return fmt.Errorf("instruction %d matches no allowed instruction: all allowed instructions precede it", i)
+			}
+			return fmt.Errorf("instruction %d matches no allowed instruction: %s", i, strings.Join(mismatches, "; "))
+		}
+	}
+
+	for ; next < len(patterns); next++ {
+		if patterns[next].required {
+			return fmt.Errorf("missing required instruction %s", patterns[next])
+		}
+	}
+	return nil
+}
+
+// assignInstructions finds a one-to-one assignment of the instructions to patterns in any order,
+// every required pattern must be assigned
+func (s *Solana) assignInstructions(
+	ctx context.Context,
+	patterns []instructionPattern,
+	tx *solana.Transaction,
+	instructions []int,
+) error {
+	// candidates[k] are patterns matching instructions[k]
+	candidates := make([][]int, len(instructions))
+	mismatches := make([][]string, len(instructions))
+	for k, i := range instructions {
+		for n, p := range patterns {
+			err := s.matchInstruction(ctx, p, tx, tx.Message.Instructions[i], false)
+			if err != nil {
+				mismatches[k] = append(mismatches[k], fmt.Sprintf("instruction %s: %s", p, err.Error()))
+				continue
+			}
+			candidates[k] = append(candidates[k], n)
+		}
+	}
+
+	// assigned[n] is the instruction assigned to patterns[n] and pattern[k] is the pattern assigned to instructions[k], or -1
+	assigned := make([]int, len(patterns))
+	for n := range assigned {
+		assigned[n] = -1
+	}
+	pattern := make([]int, len(instructions))
+	for k := range pattern {
+		pattern[k] = -1
+	}
+	link := func(k, n int) {
+		assigned[n] = k
+		pattern[k] = n
+	}
+
+	// augmenting path search of the bipartite matching from either side,
+	// extending the matching keeps assigned instructions and patterns assigned
+	var fromInstruction func(k int, visited []bool) bool
+	fromInstruction = func(k int, visited []bool) bool {
+		for _, n := range candidates[k] {
+			if visited[n] {
+				continue
+			}
+			visited[n] = true
+			if assigned[n] == -1 || fromInstruction(assigned[n], visited) {
+				link(k, n)
+				return true
+			}
+		}
+		return false
+	}
+	var fromPattern func(n int, visited []bool) bool
+	fromPattern = func(n int, visited []bool) bool {
+		for k := range instructions {
+			if visited[k] || !contains(candidates[k], n) {
+				continue
+			}
+			visited[k] = true
+			if pattern[k] == -1 || fromPattern(pattern[k], visited) {
+				link(k, n)
+				return true
+			}
+		}
+		return false
+	}
+
+	// required patterns first, then every other instruction
+	for n, p := range patterns {
+		if !p.required || fromPattern(n, make([]bool, len(instructions))) {
+			continue
+		}
+		if len(patterns) == 1 && len(instructions) == 1 {
+			return errors.New(mismatches[0][0])
+		}
+		return fmt.Errorf("missing required instruction %s", p)
+	}
+
+	for k, i := range instructions {
+		if pattern[k] != -1 || fromInstruction(k, make([]bool, len(patterns))) {
+			continue
+		}
+		if len(candidates[k]) == 0 {
+			return fmt.Errorf("instruction %d matches no allowed instruction: %s", i, strings.Join(mismatches[k], "; "))
+		}
+		return fmt.Errorf("instruction %d only matches instructions %v, which are assigned to other instructions",
+			i, patternIndexes(patterns, candidates[k]))
+	}
+	return nil
+}
+
+func contains(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func patternIndexes(patterns []instructionPattern, positions []int) []int {
+	out := make([]int, 0, len(positions))
+	for _, n := range positions {
+		out = append(out, patterns[n].index)
+	}
+	return out
+}
@@ -1,18 +1,25 @@
 package solana
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
+	"slices"
+	"strings"
 
+	"github.com/gagliardetto/solana-go"
 	chainsolana "github.com/vultisig/recipes/chain/solana"
+	"github.com/vultisig/recipes/engine/compare"
+	"github.com/vultisig/recipes/resolver"
 	"github.com/vultisig/recipes/types"
-	"github.com/vultisig/recipes/util"
 	"github.com/vultisig/vultisig-go/common"
 )
 
 type Solana struct {
-	chain *chainsolana.Chain
-	idl   map[protocolID]idl
+	chain     *chainsolana.Chain
+	idl       map[protocolID]idl
+	resolvers *resolver.MagicConstantRegistry
+	budget    ComputeBudgetLimits
 }
 
 func NewSolana() (*Solana, error) {
@@ -22,56 +29,147 @@ func NewSolana() (*Solana, error) {
 	}
 
 	return &Solana{
-		chain: chainsolana.NewChain(),
-		idl:   idls,
+		chain:     chainsolana.NewChain(),
+		idl:       idls,
+		resolvers: resolver.NewMagicConstantRegistry(),
+		budget:    DefaultComputeBudgetLimits(),
 	}, nil
 }
 
+// SetMagicConstantRegistry sets the registry used to resolve magic constants
+func (s *Solana) SetMagicConstantRegistry(registry *resolver.MagicConstantRegistry) {
+	s.resolvers = registry
+}
+
+// SetComputeBudgetLimits sets the limits of ComputeBudget instructions of rules without compute_unit_* constraints
+func (s *Solana) SetComputeBudgetLimits(limits ComputeBudgetLimits) {
+	s.budget = limits
+}
+
 func (s *Solana) Supports(chain common.Chain) bool {
 	return chain == common.Solana
 }
 
-func (s *Solana) Evaluate(rule *types.Rule, txBytes []byte) error {
+// Evaluate validates the tx against the allow rule: every instruction must match a distinct instruction pattern
+// of the rule, ComputeBudget instructions aside, and the rule's own instruction must be present
+func (s *Solana) Evaluate(ctx context.Context, rule *types.Rule, txBytes []byte) error {
 	if rule.GetEffect().String() != types.Effect_EFFECT_ALLOW.String() {
 		return fmt.Errorf("only allow rules supported, got: %s", rule.GetEffect().String())
 	}
 
-	r, err := util.ParseResource(rule.GetResource())
+	tx, err := s.parseTx(txBytes)
 	if err != nil {
-		return fmt.Errorf("failed to parse rule resource: %w", err)
+		return err
 	}
 
-	// Use chain package to parse transaction (using bytes directly)
-	parsedTx, err := s.chain.ParseTransactionBytes(txBytes)
+	patterns, params, err := instructionPatterns(rule)
+	if err != nil {
+		return fmt.Errorf("failed to parse instruction patterns: %w", err)
+	}
+
+	instructions, err := s.assertComputeBudget(ctx, params, tx.Message)
+	if err != nil {
+		return fmt.Errorf("failed to assert compute budget: %w", err)
+	}
+
+	order, err := instructionOrder(params[InstructionOrder])
+	if err != nil {
+		return err
+	}
+	if order == InstructionOrderAny {
+		return s.assignInstructions(ctx, patterns, tx, instructions)
+	}
+	return s.matchInstructionsInOrder(ctx, patterns, tx, instructions)
+}
+
+// Match reports whether any instruction of the tx, ComputeBudget instructions aside, matches the rule's own
+// instruction. Instruction patterns of the rule are ignored and args and accounts without constraints match
+// any value, so a deny rule applies wherever the instruction with the constrained values is
+func (s *Solana) Match(ctx context.Context, rule *types.Rule, txBytes []byte) error {
+	tx, err := s.parseTx(txBytes)
 	if err != nil {
-		return fmt.Errorf("failed to decode tx payload: %w", err)
+		return err
 	}
 
-	tx := parsedTx.GetTransaction()
-	if len(tx.Message.Instructions) != 1 {
-		return fmt.Errorf(
-			"only single instruction transactions are allowed, got %d instructions",
-			len(tx.Message.Instructions),
-		)
+	patterns, _, err := instructionPatterns(rule)
+	if err != nil {
+		return fmt.Errorf("failed to parse instruction patterns: %w", err)
+	}
+	primary := patterns[0]
+	for _, p := range patterns {
+		if p.primary {
+			primary = p
+		}
 	}
-	inst := tx.Message.Instructions[0]
 
+	var mismatches []string
+	for i, inst := range tx.Message.Instructions {
+		programID, er := tx.ResolveProgramIDIndex(inst.ProgramIDIndex)
+		if er != nil {
+			return fmt.Errorf("failed to resolve program id: %w", er)
+		}
+		if programID.Equals(solana.ComputeBudget) {
+			continue
+		}
+
+		er = s.matchInstruction(ctx, primary, tx, inst, true)
+		if er == nil {
+			return nil
+		}
+		if len(tx.Message.Instructions) == 1 || !compare.IsMismatch(er) {
+			return er
+		}
+		mismatches = append(mismatches, fmt.Sprintf("instruction %d: %s", i, er.Error()))
+	}
+	return compare.NewMismatchError("no instruction matches the rule: %s", strings.Join(mismatches, "; "))
+}
+
+func (s *Solana) parseTx(txBytes []byte) (*solana.Transaction, error) {
+	// Use chain package to parse transaction (using bytes directly)
+	parsedTx, err := s.chain.ParseTransactionBytes(txBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode tx payload: %w", err)
+	}
+	return parsedTx.GetTransaction(), nil
+}
+
+// matchInstruction checks the instruction against the program, accounts and args of the pattern.
+// Every arg and account must be constrained, partial matching only checks the constrained ones
+func (s *Solana) matchInstruction(
+	ctx context.Context,
+	p instructionPattern,
+	tx *solana.Transaction,
+	inst solana.CompiledInstruction,
+	partial bool,
+) error {
 	programID, err := tx.ResolveProgramIDIndex(inst.ProgramIDIndex)
 	if err != nil {
 		return fmt.Errorf("failed to resolve program id: %w", err)
 	}
 
-	idlProtocolSchema, ok := s.idl[r.ProtocolId]
+	idlProtocolSchema, ok := s.idl[p.resource.ProtocolId]
 	if !ok {
-		return fmt.Errorf("unknown protocol id: %s", r.ProtocolId)
+		return fmt.Errorf("unknown protocol id: %s", p.resource.ProtocolId)
 	}
-	idlInstSchema, err := findInstruction(idlProtocolSchema.Instructions, r.FunctionId)
+	idlInstSchema, err := findInstruction(idlProtocolSchema.Instructions, p.resource.FunctionId)
 	if err != nil {
 		return fmt.Errorf("failed to find instruction: %w", err)
 	}
 
-	err = assertArgs(
-		rule.GetParameterConstraints(),
+	constraints := p.constraints
+	if partial {
+		constraints = withAnyConstraints(constraints, idlInstSchema)
+	}
+
+	// the program is checked first, args of other programs' instructions may not decode
+	err = s.assertTarget(ctx, p.resource, p.target, programID)
+	if err != nil {
+		return fmt.Errorf("failed to assert target: %w", err)
+	}
+
+	err = s.assertArgs(
+		ctx,
+		constraints,
 		inst.Data,
 		idlInstSchema.Args,
 		idlInstSchema.Metadata.Discriminator,
@@ -80,18 +178,40 @@ func (s *Solana) Evaluate(rule *types.Rule, txBytes []byte) error {
 		return fmt.Errorf("failed to assert args: %w", err)
 	}
 
-	err = assertTarget(r, rule.GetTarget(), programID)
-	if err != nil {
-		return fmt.Errorf("failed to assert target: %w", err)
-	}
-
-	err = assertAccounts(rule.GetParameterConstraints(), tx.Message, idlInstSchema.Accounts)
+	err = s.assertAccounts(ctx, constraints, tx.Message, inst, idlInstSchema.Accounts)
 	if err != nil {
 		return fmt.Errorf("failed to assert accounts: %w", err)
 	}
 	return nil
 }
 
+// withAnyConstraints appends ANY constraints for args and accounts of the instruction which have none
+func withAnyConstraints(constraints []*types.ParameterConstraint, inst idlInstruction) []*types.ParameterConstraint {
+	names := make([]string, 0, len(inst.Args)+len(inst.Accounts))
+	for _, arg := range inst.Args {
+		names = append(names, "arg_"+arg.Name)
+	}
+	for _, acc := range inst.Accounts {
+		names = append(names, "account_"+acc.Name)
+	}
+
+	out := slices.Clone(constraints)
+	for _, name := range names {
+		if slices.ContainsFunc(constraints, func(c *types.ParameterConstraint) bool {
+			return c.GetParameterName() == name
+		}) {
+			continue
+		}
+		out = append(out, &types.ParameterConstraint{
+			ParameterName: name,
+			Constraint: &types.Constraint{
+				Type: types.ConstraintType_CONSTRAINT_TYPE_ANY,
+			},
+		})
+	}
+	return out
+}
+
 func findInstruction(insts []idlInstruction, name string) (idlInstruction, error) {
 	for _, inst := range insts {
 		if inst.Name == name {
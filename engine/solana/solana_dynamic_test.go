@@ -2,6 +2,7 @@ package solana
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"strconv"
 	"testing"
@@ -43,7 +44,7 @@ func runTestSuite(t *testing.T, engine *Solana, protocolName string, instruction
 		txBytes, er := genTx(instruction, accs, args, programID)
 		assert.NoErrorf(t, er, "Failed to generate transaction: %v", er)
 
-		er = engine.Evaluate(rule, txBytes)
+		er = engine.Evaluate(context.Background(), rule, txBytes)
 		assert.NoError(t, er, "Positive case should pass")
 	})
 
@@ -54,7 +55,7 @@ func runTestSuite(t *testing.T, engine *Solana, protocolName string, instruction
 			txBytes, er := genTx(instruction, accs, args, programID)
 			assert.NoErrorf(t, er, "Failed to generate transaction: %v", er)
 
-			er = engine.Evaluate(rule, txBytes)
+			er = engine.Evaluate(context.Background(), rule, txBytes)
 			assert.Error(t, er, "Should fail with wrong program ID")
 		})
 
@@ -67,7 +68,7 @@ func runTestSuite(t *testing.T, engine *Solana, protocolName string, instruction
 				txBytes, er := genTx(instruction, accs, args, programID)
 				assert.NoErrorf(t, er, "Failed to generate transaction: %v", er)
 
-				er = engine.Evaluate(rule, txBytes)
+				er = engine.Evaluate(context.Background(), rule, txBytes)
 				assert.Error(t, er, "Should fail with wrong account")
 			})
 		}
@@ -101,7 +102,7 @@ func runTestSuite(t *testing.T, engine *Solana, protocolName string, instruction
 					txBytes, er := genTx(instruction, accs, args, programID)
 					assert.NoErrorf(t, er, "Failed to generate transaction: %v", er)
 
-					er = engine.Evaluate(rule, txBytes)
+					er = engine.Evaluate(context.Background(), rule, txBytes)
 					assert.NoError(t, er, "Min constraint should pass when value is above minimum")
 				})
 
@@ -110,7 +111,7 @@ func runTestSuite(t *testing.T, engine *Solana, protocolName string, instruction
 					txBytes, er := genTx(instruction, accs, args, programID)
 					assert.NoErrorf(t, er, "Failed to generate transaction: %v", er)
 
-					er = engine.Evaluate(rule, txBytes)
+					er = engine.Evaluate(context.Background(), rule, txBytes)
 					assert.NoError(t, er, "Max constraint should pass when value is below maximum")
 				})
 
@@ -119,7 +120,7 @@ func runTestSuite(t *testing.T, engine *Solana, protocolName string, instruction
 					txBytes, er := genTx(instruction, accs, args, programID)
 					assert.NoErrorf(t, er, "Failed to generate transaction: %v", er)
 
-					er = engine.Evaluate(rule, txBytes)
+					er = engine.Evaluate(context.Background(), rule, txBytes)
 					assert.Error(t, er, "Min constraint should fail when value is below minimum")
 				})
 
@@ -128,7 +129,7 @@ func runTestSuite(t *testing.T, engine *Solana, protocolName string, instruction
 					txBytes, er := genTx(instruction, accs, args, programID)
 					assert.NoErrorf(t, er, "Failed to generate transaction: %v", er)
 
-					er = engine.Evaluate(rule, txBytes)
+					er = engine.Evaluate(context.Background(), rule, txBytes)
 					assert.Error(t, er, "Max constraint should fail when value is above maximum")
 				})
 			}
@@ -1,14 +1,19 @@
 package solana
 
 import (
+	"context"
 	"testing"
 
 	"github.com/gagliardetto/solana-go"
+	associatedtokenaccount "github.com/gagliardetto/solana-go/programs/associated-token-account"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
 	"github.com/gagliardetto/solana-go/programs/system"
 	"github.com/gagliardetto/solana-go/programs/token"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vultisig/recipes/engine/compare"
 	"github.com/vultisig/recipes/types"
+	"google.golang.org/protobuf/proto"
 )
 
 func buildMockSystemTransferTx(from, to solana.PublicKey, amount uint64) []byte {
@@ -114,7 +119,7 @@ func TestEvaluate_SOLTransfer(t *testing.T) {
 			},
 		},
 	}
-	err = engine.Evaluate(rule, txBytes)
+	err = engine.Evaluate(context.Background(), rule, txBytes)
 	assert.NoError(t, err)
 }
 
@@ -170,7 +175,7 @@ func TestEvaluate_SOLTransfer_InvalidAmount(t *testing.T) {
 		},
 	}
 
-	err = engine.Evaluate(rule, txBytes)
+	err = engine.Evaluate(context.Background(), rule, txBytes)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to assert: name=arg_lamports")
 }
@@ -228,7 +233,7 @@ func TestEvaluate_SOLTransfer_InvalidRecipient(t *testing.T) {
 		},
 	}
 
-	err = engine.Evaluate(rule, txBytes)
+	err = engine.Evaluate(context.Background(), rule, txBytes)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to assert: name=account_to")
 }
@@ -285,7 +290,7 @@ func TestEvaluate_SOLTransfer_MinAmount(t *testing.T) {
 		},
 	}
 
-	err = engine.Evaluate(rule, txBytes)
+	err = engine.Evaluate(context.Background(), rule, txBytes)
 	assert.NoError(t, err)
 }
 
@@ -341,7 +346,7 @@ func TestEvaluate_SOLTransfer_MinAmount_Invalid(t *testing.T) {
 		},
 	}
 
-	err = engine.Evaluate(rule, txBytes)
+	err = engine.Evaluate(context.Background(), rule, txBytes)
 	assert.Error(t, err)
 	assert.Contains(
 		t,
@@ -402,7 +407,7 @@ func TestEvaluate_SOLTransfer_MaxAmount(t *testing.T) {
 		},
 	}
 
-	err = engine.Evaluate(rule, txBytes)
+	err = engine.Evaluate(context.Background(), rule, txBytes)
 	assert.NoError(t, err)
 }
 
@@ -458,7 +463,7 @@ func TestEvaluate_SOLTransfer_MaxAmount_Invalid(t *testing.T) {
 		},
 	}
 
-	err = engine.Evaluate(rule, txBytes)
+	err = engine.Evaluate(context.Background(), rule, txBytes)
 	assert.Error(t, err)
 	assert.Contains(
 		t,
@@ -518,9 +523,9 @@ func TestEvaluate_MultipleInstructions(t *testing.T) {
 	}
 
 	multiTxBytes := buildMockMultiInstructionTx(fromKey.PublicKey(), toKey.PublicKey(), lamports)
-	err = engine.Evaluate(rule, multiTxBytes)
+	err = engine.Evaluate(context.Background(), rule, multiTxBytes)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "only single instruction transactions are allowed")
+	assert.Contains(t, err.Error(), "instruction 1 matches no allowed instruction")
 }
 
 func TestEvaluate_SPLTokenTransfer(t *testing.T) {
@@ -594,7 +599,7 @@ func TestEvaluate_SPLTokenTransfer(t *testing.T) {
 		},
 	}
 
-	err = engine.Evaluate(rule, txBytes)
+	err = engine.Evaluate(context.Background(), rule, txBytes)
 	assert.NoError(t, err)
 }
 
@@ -669,7 +674,7 @@ func TestEvaluate_SPLTokenTransfer_InvalidAmount(t *testing.T) {
 		},
 	}
 
-	err = engine.Evaluate(rule, txBytes)
+	err = engine.Evaluate(context.Background(), rule, txBytes)
 	assert.Error(t, err)
 	assert.Contains(
 		t,
@@ -751,7 +756,7 @@ func TestEvaluate_SPLTokenTransfer_InvalidRecipient(t *testing.T) {
 		},
 	}
 
-	err = engine.Evaluate(rule, txBytes)
+	err = engine.Evaluate(context.Background(), rule, txBytes)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to assert accounts: failed to assert: name=account_destination")
 }
@@ -827,7 +832,7 @@ func TestEvaluate_SPLTokenTransfer_MinAmount(t *testing.T) {
 		},
 	}
 
-	err = engine.Evaluate(rule, txBytes)
+	err = engine.Evaluate(context.Background(), rule, txBytes)
 	assert.NoError(t, err)
 }
 
@@ -902,7 +907,7 @@ func TestEvaluate_SPLTokenTransfer_MinAmount_Invalid(t *testing.T) {
 		},
 	}
 
-	err = engine.Evaluate(rule, txBytes)
+	err = engine.Evaluate(context.Background(), rule, txBytes)
 	assert.Error(t, err)
 	assert.Contains(
 		t,
@@ -982,7 +987,7 @@ func TestEvaluate_SPLTokenTransfer_MaxAmount(t *testing.T) {
 		},
 	}
 
-	err = engine.Evaluate(rule, txBytes)
+	err = engine.Evaluate(context.Background(), rule, txBytes)
 	assert.NoError(t, err)
 }
 
@@ -1057,7 +1062,7 @@ func TestEvaluate_SPLTokenTransfer_MaxAmount_Invalid(t *testing.T) {
 		},
 	}
 
-	err = engine.Evaluate(rule, txBytes)
+	err = engine.Evaluate(context.Background(), rule, txBytes)
 	assert.Error(t, err)
 	assert.Contains(
 		t,
@@ -1172,7 +1177,214 @@ func TestEvaluate_SPLTokenTransfer_InvalidProgram(t *testing.T) {
 		},
 	}
 
-	err = engine.Evaluate(rule, txBytes)
+	err = engine.Evaluate(context.Background(), rule, txBytes)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to assert target: tx target is wrong")
 }
+
+func buildMockTx(payer solana.PublicKey, insts ...solana.Instruction) []byte {
+	tx, err := solana.NewTransaction(insts, solana.Hash{}, solana.TransactionPayer(payer))
+	if err != nil {
+		panic(err)
+	}
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func paramConstraint(name string, typ types.ConstraintType, value string, required bool) *types.ParameterConstraint {
+	c := &types.Constraint{Type: typ, Required: required}
+	if typ == types.ConstraintType_CONSTRAINT_TYPE_FIXED {
+		c.Value = &types.Constraint_FixedValue{FixedValue: value}
+	}
+	return &types.ParameterConstraint{ParameterName: name, Constraint: c}
+}
+
+func TestEvaluate_ComputeBudget(t *testing.T) {
+	const lamports = uint64(1000000)
+	from := solana.NewWallet().PublicKey()
+	to := solana.NewWallet().PublicKey()
+	engine, err := NewSolana()
+	require.NoError(t, err)
+
+	rule := func(extra ...*types.ParameterConstraint) *types.Rule {
+		return &types.Rule{
+			Effect:   types.Effect_EFFECT_ALLOW,
+			Resource: "solana.system.transfer",
+			Target: &types.Target{
+				TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+				Target:     &types.Target_Address{Address: solana.SystemProgramID.String()},
+			},
+			ParameterConstraints: append([]*types.ParameterConstraint{
+				paramConstraint("account_from", types.ConstraintType_CONSTRAINT_TYPE_FIXED, from.String(), true),
+				paramConstraint("account_to", types.ConstraintType_CONSTRAINT_TYPE_FIXED, to.String(), true),
+				paramConstraint("arg_lamports", types.ConstraintType_CONSTRAINT_TYPE_FIXED, "1000000", true),
+			}, extra...),
+		}
+	}
+	tx := func(price uint64) []byte {
+		return buildMockTx(from,
+			computebudget.NewSetComputeUnitLimitInstruction(200_000).Build(),
+			computebudget.NewSetComputeUnitPriceInstruction(price).Build(),
+			system.NewTransferInstruction(lamports, from, to).Build(),
+		)
+	}
+	ctx := context.Background()
+
+	assert.NoError(t, engine.Evaluate(ctx, rule(), tx(50_000)))
+
+	// over the default limit
+	err = engine.Evaluate(ctx, rule(), tx(20_000_000))
+	assert.ErrorContains(t, err, "compute unit price: value 20000000 exceeds the limit 10000000")
+
+	// the rule constraint replaces the default limit
+	maxPrice := &types.ParameterConstraint{
+		ParameterName: ComputeUnitPrice,
+		Constraint: &types.Constraint{
+			Type:  types.ConstraintType_CONSTRAINT_TYPE_MAX,
+			Value: &types.Constraint_MaxValue{MaxValue: "10000"},
+		},
+	}
+	err = engine.Evaluate(ctx, rule(maxPrice), tx(50_000))
+	assert.ErrorContains(t, err, "compute unit price")
+	assert.NoError(t, engine.Evaluate(ctx, rule(maxPrice), tx(10_000)))
+
+	engine.SetComputeBudgetLimits(ComputeBudgetLimits{MaxUnitLimit: 100_000, MaxUnitPrice: 10_000_000})
+	err = engine.Evaluate(ctx, rule(), tx(50_000))
+	assert.ErrorContains(t, err, "compute unit limit: value 200000 exceeds the limit 100000")
+
+	// duplicate compute budget instructions
+	txBytes := buildMockTx(from,
+		computebudget.NewSetComputeUnitPriceInstruction(1).Build(),
+		computebudget.NewSetComputeUnitPriceInstruction(2).Build(),
+		system.NewTransferInstruction(lamports, from, to).Build(),
+	)
+	err = engine.Evaluate(ctx, rule(), txBytes)
+	assert.ErrorContains(t, err, "duplicate compute budget instruction")
+}
+
+func TestEvaluate_InstructionPatterns(t *testing.T) {
+	const amount = uint64(500000)
+	authority := solana.NewWallet().PublicKey()
+	recipient := solana.NewWallet().PublicKey()
+	mint := solana.NewWallet().PublicKey()
+	source, _, err := solana.FindAssociatedTokenAddress(authority, mint)
+	require.NoError(t, err)
+	destination, _, err := solana.FindAssociatedTokenAddress(recipient, mint)
+	require.NoError(t, err)
+	engine, err := NewSolana()
+	require.NoError(t, err)
+
+	createATA := associatedtokenaccount.NewCreateInstruction(authority, recipient, mint).Build()
+	transfer := token.NewTransferInstruction(amount, source, destination, authority, nil).Build()
+	extra := system.NewTransferInstruction(1, authority, recipient).Build()
+
+	rule := func(ataRequired bool, params ...*types.ParameterConstraint) *types.Rule {
+		return &types.Rule{
+			Effect:   types.Effect_EFFECT_ALLOW,
+			Resource: "solana.token.transfer",
+			Target: &types.Target{
+				TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+				Target:     &types.Target_Address{Address: token.ProgramID.String()},
+			},
+			ParameterConstraints: append([]*types.ParameterConstraint{
+				paramConstraint("account_source", types.ConstraintType_CONSTRAINT_TYPE_FIXED, source.String(), true),
+				paramConstraint("account_destination", types.ConstraintType_CONSTRAINT_TYPE_FIXED, destination.String(), true),
+				paramConstraint("account_authority", types.ConstraintType_CONSTRAINT_TYPE_FIXED, authority.String(), true),
+				paramConstraint("arg_amount", types.ConstraintType_CONSTRAINT_TYPE_FIXED, "500000", true),
+				paramConstraint(InstructionIndex, types.ConstraintType_CONSTRAINT_TYPE_FIXED, "1", true),
+				paramConstraint("instruction_0.resource", types.ConstraintType_CONSTRAINT_TYPE_FIXED, "associated_token_account.create", ataRequired),
+				paramConstraint("instruction_0.account_payer", types.ConstraintType_CONSTRAINT_TYPE_FIXED, authority.String(), true),
+				paramConstraint("instruction_0.account_associated_token_account", types.ConstraintType_CONSTRAINT_TYPE_FIXED, destination.String(), true),
+				paramConstraint("instruction_0.account_owner", types.ConstraintType_CONSTRAINT_TYPE_FIXED, recipient.String(), true),
+				paramConstraint("instruction_0.account_mint", types.ConstraintType_CONSTRAINT_TYPE_FIXED, mint.String(), true),
+				paramConstraint("instruction_0.account_system_program", types.ConstraintType_CONSTRAINT_TYPE_ANY, "", false),
+				paramConstraint("instruction_0.account_token_program", types.ConstraintType_CONSTRAINT_TYPE_ANY, "", false),
+			}, params...),
+		}
+	}
+	ctx := context.Background()
+
+	// recipient token account creation followed by the transfer
+	txBytes := buildMockTx(authority,
+		computebudget.NewSetComputeUnitPriceInstruction(1000).Build(),
+		createATA,
+		transfer,
+	)
+	assert.NoError(t, engine.Evaluate(ctx, rule(true), txBytes))
+
+	// optional instruction may be absent, the required one may not
+	txBytes = buildMockTx(authority, transfer)
+	assert.NoError(t, engine.Evaluate(ctx, rule(false), txBytes))
+	err = engine.Evaluate(ctx, rule(true), txBytes)
+	assert.ErrorContains(t, err, "doesn't match required instruction 0 (associated_token_account.create)")
+
+	// out of order
+	txBytes = buildMockTx(authority, transfer, createATA)
+	err = engine.Evaluate(ctx, rule(true), txBytes)
+	assert.Error(t, err)
+
+	anyOrder := paramConstraint(InstructionOrder, types.ConstraintType_CONSTRAINT_TYPE_FIXED, InstructionOrderAny, true)
+	assert.NoError(t, engine.Evaluate(ctx, rule(true, anyOrder), txBytes))
+
+	// unmatched instruction
+	txBytes = buildMockTx(authority, createATA, transfer, extra)
+	err = engine.Evaluate(ctx, rule(true), txBytes)
+	assert.ErrorContains(t, err, "instruction 2 matches no allowed instruction")
+	err = engine.Evaluate(ctx, rule(true, anyOrder), txBytes)
+	assert.ErrorContains(t, err, "instruction 2 matches no allowed instruction")
+
+	// each pattern matches a single instruction
+	txBytes = buildMockTx(authority, createATA, transfer, transfer)
+	err = engine.Evaluate(ctx, rule(true, anyOrder), txBytes)
+	assert.ErrorContains(t, err, "instruction 2 only matches instructions [1], which are assigned to other instructions")
+
+	// the deny rule matches the instruction at any position
+	deny := rule(true)
+	deny.Effect = types.Effect_EFFECT_DENY
+	txBytes = buildMockTx(authority, createATA, transfer, extra)
+	assert.NoError(t, engine.Match(ctx, deny, txBytes))
+	txBytes = buildMockTx(authority, createATA, extra)
+	err = engine.Match(ctx, deny, txBytes)
+	assert.ErrorContains(t, err, "no instruction matches the rule")
+}
+
+func TestMatch_PartialConstraints(t *testing.T) {
+	from := solana.NewWallet().PublicKey()
+	blocked := solana.NewWallet().PublicKey()
+	other := solana.NewWallet().PublicKey()
+	engine, err := NewSolana()
+	require.NoError(t, err)
+
+	// deny any transfer to the blocked account, whoever sends it and whatever the amount
+	deny := &types.Rule{
+		Effect:   types.Effect_EFFECT_DENY,
+		Resource: "solana.system.transfer",
+		Target: &types.Target{
+			TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+			Target:     &types.Target_Address{Address: solana.SystemProgramID.String()},
+		},
+		ParameterConstraints: []*types.ParameterConstraint{
+			paramConstraint("account_to", types.ConstraintType_CONSTRAINT_TYPE_FIXED, blocked.String(), true),
+		},
+	}
+	ctx := context.Background()
+
+	txBytes := buildMockTx(from,
+		system.NewTransferInstruction(1, from, other).Build(),
+		system.NewTransferInstruction(1000000, from, blocked).Build(),
+	)
+	assert.NoError(t, engine.Match(ctx, deny, txBytes))
+
+	txBytes = buildMockTx(from, system.NewTransferInstruction(1000000, from, other).Build())
+	err = engine.Match(ctx, deny, txBytes)
+	assert.True(t, compare.IsMismatch(err), err)
+
+	// allow rules still constrain every account and arg
+	allow := proto.Clone(deny).(*types.Rule)
+	allow.Effect = types.Effect_EFFECT_ALLOW
+	txBytes = buildMockTx(from, system.NewTransferInstruction(1000000, from, blocked).Build())
+	assert.Error(t, engine.Evaluate(ctx, allow, txBytes))
+}
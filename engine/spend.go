@@ -0,0 +1,253 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/vultisig/recipes/engine/compare"
+	"github.com/vultisig/recipes/engine/price"
+	"github.com/vultisig/recipes/engine/spend"
+	"github.com/vultisig/recipes/engine/units"
+	"github.com/vultisig/recipes/types"
+	"github.com/vultisig/vultisig-go/common"
+)
+
+type spendLimit struct {
+	key       string
+	parameter string
+	max       *big.Int
+	period    string
+	// currency of fiat-denominated limits, amounts are counted in millionths of it
+	currency string
+}
+
+// spendLimits collects period-limited constraints of the rule, only MAX constraints can be period-limited.
+// Spent amounts are counted per policy instance of the context
+func spendLimits(ctx context.Context, rule *types.Rule) ([]spendLimit, error) {
+	var limits []spendLimit
+	for _, pc := range rule.GetParameterConstraints() {
+		c := pc.GetConstraint()
+		if c.GetPeriod() == "" {
+			continue
+		}
+
+		if c.GetType() != types.ConstraintType_CONSTRAINT_TYPE_MAX {
+			return nil, fmt.Errorf(
+				"period is only supported for max constraints: parameter=%s, type=%s",
+				pc.GetParameterName(),
+				c.GetType().String(),
+			)
+		}
+
+		instanceID, err := policyInstance(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var currency string
+		maxValue, ok := new(big.Int).SetString(c.GetMaxValue(), 10)
+		if price.IsFiat(c.GetDenominatedIn()) {
+			currency = c.GetDenominatedIn()
+			fiatMax, err := units.ParseAmount(c.GetMaxValue(), fiatDecimals)
+			maxValue, ok = fiatMax, err == nil
+		}
+		if !ok {
+			return nil, fmt.Errorf("failed to parse max value: %s", c.GetMaxValue())
+		}
+
+		limits = append(limits, spendLimit{
+			key: spend.Key(
+				instanceID,
+				rule.GetId(),
+				pc.GetParameterName(),
+				c.GetPeriod(),
+				c.GetDenominatedIn(),
+			),
+			parameter: pc.GetParameterName(),
+			max:       maxValue,
+			period:    c.GetPeriod(),
+			currency:  currency,
+		})
+	}
+	return limits, nil
+}
+
+// assertSpendLimits checks that the tx amount together with amounts already spent
+// within the constraint period doesn't exceed the max value
+func (e *Engine) assertSpendLimits(
+	ctx context.Context,
+	chain common.Chain,
+	rule *types.Rule,
+	chainEngine ChainEngine,
+	txBytes []byte,
+) error {
+	return e.assertPendingSpendLimits(ctx, chain, rule, chainEngine, txBytes, nil)
+}
+
+// assertBundleSpendLimits checks spend limits of the bundle txs matched by the rules,
+// amounts of all txs counted against the same limit are added up
+func (e *Engine) assertBundleSpendLimits(
+	ctx context.Context,
+	chain common.Chain,
+	rules []*types.Rule,
+	chainEngine ChainEngine,
+	txs [][]byte,
+) error {
+	pending := make(map[string]*big.Int)
+	for i, rule := range rules {
+		err := e.assertPendingSpendLimits(ctx, chain, rule, chainEngine, txs[i], pending)
+		if err != nil {
+			return fmt.Errorf("tx %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// assertPendingSpendLimits checks the tx amount together with amounts already spent and pending amounts
+// of other txs signed with it, by limit key. The tx amount is added to pending amounts if they're not nil
+func (e *Engine) assertPendingSpendLimits(
+	ctx context.Context,
+	chain common.Chain,
+	rule *types.Rule,
+	chainEngine ChainEngine,
+	txBytes []byte,
+	pending map[string]*big.Int,
+) error {
+	limits, err := spendLimits(ctx, rule)
+	if err != nil {
+		return err
+	}
+	if len(limits) == 0 {
+		return nil
+	}
+
+	valuer, ok := chainEngine.(ParameterValuer)
+	if !ok {
+		return fmt.Errorf("chain engine doesn't support period-limited constraints: %T", chainEngine)
+	}
+
+	now := e.now()
+	for _, limit := range limits {
+		period, er := spend.ParsePeriod(limit.period)
+		if er != nil {
+			return fmt.Errorf("failed to parse period: %w", er)
+		}
+
+		actual, er := e.spendAmount(ctx, chain, rule, chainEngine, valuer, txBytes, limit)
+		if er != nil {
+			return er
+		}
+
+		spent, er := e.spendStore.Spent(limit.key, now.Add(-period))
+		if er != nil {
+			return fmt.Errorf("failed to get spent amount: %w", er)
+		}
+
+		if p, ok := pending[limit.key]; ok {
+			spent = new(big.Int).Add(spent, p)
+		}
+
+		if new(big.Int).Add(spent, actual).Cmp(limit.max) > 0 {
+			return limit.exceeded(spent, actual)
+		}
+		if pending != nil {
+			p, ok := pending[limit.key]
+			if !ok {
+				p = new(big.Int)
+			}
+			pending[limit.key] = new(big.Int).Add(p, actual)
+		}
+	}
+	return nil
+}
+
+// exceeded returns the error of the amount which doesn't fit the limit together with the spent amount
+func (l spendLimit) exceeded(spent, actual *big.Int) error {
+	return &compare.ConstraintError{
+		Parameter: l.parameter,
+		Type:      types.ConstraintType_CONSTRAINT_TYPE_MAX.String(),
+		Expected:  l.max.String(),
+		Actual:    new(big.Int).Add(spent, actual).String(),
+		Reason: fmt.Sprintf(
+			"period limit exceeded: parameter=%s, period=%s, max=%s, spent=%s, actual=%s",
+			l.parameter,
+			l.period,
+			l.max.String(),
+			spent.String(),
+			actual.String(),
+		),
+	}
+}
+
+// RecordSpend records amounts of period-limited constraints of the rule matched by Evaluate
+// for the policy instance of the context, see WithPolicyInstance.
+// Call it only after the tx was signed, evaluated but not signed txs must not consume the limit.
+// Limits are checked and counted atomically: if concurrent txs exhausted a limit after Evaluate,
+// RecordSpend returns the period limit error and doesn't count the amount, the tx must not be broadcast then.
+// Limits of the rule recorded before the exhausted one stay counted
+func (e *Engine) RecordSpend(ctx context.Context, rule *types.Rule, chain common.Chain, txBytes []byte) error {
+	limits, err := spendLimits(ctx, rule)
+	if err != nil {
+		return err
+	}
+	if len(limits) == 0 {
+		return nil
+	}
+
+	chainEngine, err := e.registry.GetEngine(chain)
+	if err != nil {
+		return fmt.Errorf("failed to get engine for chain %s: %w", chain.String(), err)
+	}
+	valuer, ok := chainEngine.(ParameterValuer)
+	if !ok {
+		return fmt.Errorf("chain engine doesn't support period-limited constraints: %T", chainEngine)
+	}
+
+	now := e.now()
+	for _, limit := range limits {
+		period, er := spend.ParsePeriod(limit.period)
+		if er != nil {
+			return fmt.Errorf("failed to parse period: %w", er)
+		}
+
+		actual, er := e.spendAmount(ctx, chain, rule, chainEngine, valuer, txBytes, limit)
+		if er != nil {
+			return er
+		}
+
+		spent, ok, er := e.spendStore.AddWithin(limit.key, actual, limit.max, now.Add(-period), now)
+		if er != nil {
+			return fmt.Errorf("failed to record spent amount: %w", er)
+		}
+		if !ok {
+			return limit.exceeded(spent, actual)
+		}
+	}
+	return nil
+}
+
+// spendAmount returns the amount of the tx counted against the limit, in millionths of the currency for fiat limits
+func (e *Engine) spendAmount(
+	ctx context.Context,
+	chain common.Chain,
+	rule *types.Rule,
+	chainEngine ChainEngine,
+	valuer ParameterValuer,
+	txBytes []byte,
+	limit spendLimit,
+) (*big.Int, error) {
+	if limit.currency != "" {
+		value, err := e.fiatValue(ctx, chain, rule, chainEngine, txBytes, limit.parameter, limit.currency)
+		if err != nil {
+			return nil, err
+		}
+		return fiatUnits(value), nil
+	}
+
+	actual, err := valuer.ParameterValue(withoutFiatConstraints(rule), txBytes, limit.parameter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parameter value: parameter=%s, error=%w", limit.parameter, err)
+	}
+	return actual, nil
+}
@@ -0,0 +1,90 @@
+package spend
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+type record struct {
+	amount *big.Int
+	at     time.Time
+}
+
+// MemoryStore is an in-process Store, spent amounts are lost on restart.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string][]record
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records: make(map[string][]record),
+	}
+}
+
+// Spent returns the total amount recorded for the key after since.
+// Older records can't be counted anymore for the key and are dropped.
+func (s *MemoryStore) Spent(key string, since time.Time) (*big.Int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.spent(key, since), nil
+}
+
+func (s *MemoryStore) spent(key string, since time.Time) *big.Int {
+	total := new(big.Int)
+	kept := s.records[key][:0]
+	for _, r := range s.records[key] {
+		if !r.at.After(since) {
+			continue
+		}
+		total.Add(total, r.amount)
+		kept = append(kept, r)
+	}
+	if len(kept) == 0 {
+		delete(s.records, key)
+	} else {
+		s.records[key] = kept
+	}
+	return total
+}
+
+// Record adds the amount spent for the key at the given time.
+func (s *MemoryStore) Record(key string, amount *big.Int, at time.Time) error {
+	if amount == nil || amount.Sign() < 0 {
+		return fmt.Errorf("invalid amount: %v", amount)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = append(s.records[key], record{
+		amount: new(big.Int).Set(amount),
+		at:     at,
+	})
+	return nil
+}
+
+// AddWithin adds the amount spent for the key at the given time if it fits max together with
+// the amount recorded after since.
+func (s *MemoryStore) AddWithin(key string, amount, max *big.Int, since, at time.Time) (*big.Int, bool, error) {
+	if amount == nil || amount.Sign() < 0 {
+		return nil, false, fmt.Errorf("invalid amount: %v", amount)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	spent := s.spent(key, since)
+	if new(big.Int).Add(spent, amount).Cmp(max) > 0 {
+		return spent, false, nil
+	}
+	s.records[key] = append(s.records[key], record{
+		amount: new(big.Int).Set(amount),
+		at:     at,
+	})
+	return spent, true, nil
+}
@@ -0,0 +1,56 @@
+// Package spend tracks amounts spent under period-limited constraints,
+// e.g. "max 1 ETH per day" across many transactions.
+package spend
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Store persists spent amounts. Implementations must be safe for concurrent use.
+type Store interface {
+	// Spent returns the total amount recorded for the key after the given time.
+	Spent(key string, since time.Time) (*big.Int, error)
+
+	// Record adds the amount spent for the key at the given time.
+	Record(key string, amount *big.Int, at time.Time) error
+
+	// AddWithin atomically adds the amount spent for the key at the given time if the total amount
+	// recorded after since together with it doesn't exceed max. Returns the total amount recorded
+	// after since without the amount, and false if the amount doesn't fit and wasn't added.
+	AddWithin(key string, amount, max *big.Int, since, at time.Time) (*big.Int, bool, error)
+}
+
+// ParsePeriod converts Constraint.period to a duration. Named periods (hour, day, week, month)
+// and Go durations (e.g. "12h") are supported, a month is 30 days.
+func ParsePeriod(period string) (time.Duration, error) {
+	const day = 24 * time.Hour
+
+	switch strings.ToLower(strings.TrimSpace(period)) {
+	case "hour", "hourly":
+		return time.Hour, nil
+	case "day", "daily":
+		return day, nil
+	case "week", "weekly":
+		return 7 * day, nil
+	case "month", "monthly":
+		return 30 * day, nil
+	}
+
+	d, err := time.ParseDuration(period)
+	if err != nil {
+		return 0, fmt.Errorf("invalid period: %s", period)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("period must be positive: %s", period)
+	}
+	return d, nil
+}
+
+// Key builds the store key for the constraint of the rule of the policy instance. Period and denomination
+// are part of the key, so the changed constraint starts with a new budget.
+func Key(instanceID, ruleID, parameterName, period, denominatedIn string) string {
+	return strings.Join([]string{instanceID, ruleID, parameterName, period, denominatedIn}, "|")
+}
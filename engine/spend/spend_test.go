@@ -0,0 +1,115 @@
+package spend
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePeriod(t *testing.T) {
+	tests := []struct {
+		period  string
+		want    time.Duration
+		wantErr bool
+	}{
+		{period: "hour", want: time.Hour},
+		{period: "day", want: 24 * time.Hour},
+		{period: "Weekly", want: 7 * 24 * time.Hour},
+		{period: "month", want: 30 * 24 * time.Hour},
+		{period: "36h", want: 36 * time.Hour},
+		{period: "-1h", wantErr: true},
+		{period: "fortnight", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.period, func(t *testing.T) {
+			got, err := ParsePeriod(tt.period)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	key := Key("policy", "rule", "amount", "day", "wei")
+
+	spent, err := store.Spent(key, start)
+	require.NoError(t, err)
+	require.Zero(t, spent.Sign())
+
+	require.NoError(t, store.Record(key, big.NewInt(10), start.Add(time.Hour)))
+	require.NoError(t, store.Record(key, big.NewInt(5), start.Add(2*time.Hour)))
+	require.NoError(t, store.Record(Key("policy", "other", "amount", "day", "wei"), big.NewInt(100), start.Add(time.Hour)))
+	require.Error(t, store.Record(key, big.NewInt(-1), start))
+
+	spent, err = store.Spent(key, start)
+	require.NoError(t, err)
+	require.Equal(t, int64(15), spent.Int64())
+
+	spent, err = store.Spent(key, start.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, int64(5), spent.Int64())
+
+	spent, err = store.Spent(key, start.Add(3*time.Hour))
+	require.NoError(t, err)
+	require.Zero(t, spent.Sign())
+}
+
+func TestMemoryStore_AddWithin(t *testing.T) {
+	store := NewMemoryStore()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	key := Key("policy", "rule", "amount", "day", "wei")
+	limit := big.NewInt(5)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	added := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, ok, err := store.AddWithin(key, big.NewInt(1), limit, start, start.Add(time.Hour))
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+			}
+			if ok {
+				added++
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Empty(t, errs)
+
+	// concurrent amounts can't exceed the limit together
+	require.Equal(t, 5, added)
+	spent, err := store.Spent(key, start)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), spent.Int64())
+
+	spent, ok, err := store.AddWithin(key, big.NewInt(1), limit, start, start.Add(time.Hour))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Equal(t, int64(5), spent.Int64())
+
+	// amounts recorded before since don't count
+	spent, ok, err = store.AddWithin(key, big.NewInt(5), limit, start.Add(2*time.Hour), start.Add(3*time.Hour))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Zero(t, spent.Sign())
+
+	_, _, err = store.AddWithin(key, big.NewInt(-1), limit, start, start)
+	require.Error(t, err)
+}
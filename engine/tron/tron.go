@@ -1,6 +1,7 @@
 package tron
 
 import (
+	"context"
 	cryptoSha256 "crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
@@ -18,27 +19,38 @@ import (
 
 // Tron represents the TRON engine implementation
 type Tron struct {
-	chain *chaintron.Chain
+	chain     *chaintron.Chain
+	resolvers *resolver.MagicConstantRegistry
 }
 
 // NewTron creates a new Tron engine instance
 func NewTron() *Tron {
 	return &Tron{
-		chain: chaintron.NewChain(),
+		chain:     chaintron.NewChain(),
+		resolvers: resolver.NewMagicConstantRegistry(),
 	}
 }
 
+// SetMagicConstantRegistry sets the registry used to resolve magic constants
+func (t *Tron) SetMagicConstantRegistry(registry *resolver.MagicConstantRegistry) {
+	t.resolvers = registry
+}
+
 // Supports returns true if this engine supports the given chain
 func (t *Tron) Supports(chain common.Chain) bool {
 	return chain == common.Tron
 }
 
 // Evaluate validates a TRON transaction against policy rules
-func (t *Tron) Evaluate(rule *types.Rule, txBytes []byte) error {
+func (t *Tron) Evaluate(ctx context.Context, rule *types.Rule, txBytes []byte) error {
 	if rule.GetEffect().String() != types.Effect_EFFECT_ALLOW.String() {
 		return fmt.Errorf("only allow rules supported, got: %s", rule.GetEffect().String())
 	}
+	return t.Match(ctx, rule, txBytes)
+}
 
+// Match validates a TRON transaction against the rule regardless of its effect
+func (t *Tron) Match(ctx context.Context, rule *types.Rule, txBytes []byte) error {
 	r, err := util.ParseResource(rule.GetResource())
 	if err != nil {
 		return fmt.Errorf("failed to parse rule resource: %w", err)
@@ -71,19 +83,19 @@ func (t *Tron) Evaluate(rule *types.Rule, txBytes []byte) error {
 	switch contract.Type {
 	case "TransferContract":
 		if r.GetProtocolId() != "trx" {
-			return fmt.Errorf("unexpected protocol for TransferContract: %s", r.GetProtocolId())
+			return stdcompare.NewMismatchError("unexpected protocol for TransferContract: %s", r.GetProtocolId())
 		}
-		if err := t.validateTarget(r, rule.GetTarget(), parsedTx); err != nil {
+		if err := t.validateTarget(ctx, r, rule.GetTarget(), parsedTx); err != nil {
 			return fmt.Errorf("failed to validate target: %w", err)
 		}
-		if err := t.validateParameterConstraints(r, rule.GetParameterConstraints(), parsedTx); err != nil {
+		if err := t.validateParameterConstraints(ctx, r, rule.GetParameterConstraints(), parsedTx); err != nil {
 			return fmt.Errorf("failed to validate parameter constraints: %w", err)
 		}
 	case "TriggerSmartContract":
 		if r.GetProtocolId() != "trc20" {
-			return fmt.Errorf("unexpected protocol for TriggerSmartContract: %s", r.GetProtocolId())
+			return stdcompare.NewMismatchError("unexpected protocol for TriggerSmartContract: %s", r.GetProtocolId())
 		}
-		if err := t.validateTRC20Transfer(r, rule, parsedTx); err != nil {
+		if err := t.validateTRC20Transfer(ctx, r, rule, parsedTx); err != nil {
 			return fmt.Errorf("failed to validate TRC-20 transfer: %w", err)
 		}
 	default:
@@ -93,8 +105,66 @@ func (t *Tron) Evaluate(rule *types.Rule, txBytes []byte) error {
 	return nil
 }
 
+// ParameterValue returns the numeric value of the named rule parameter in the transaction
+func (t *Tron) ParameterValue(_ *types.Rule, txBytes []byte, name string) (*big.Int, error) {
+	if name != "amount" {
+		return nil, fmt.Errorf("unsupported numeric parameter: %s", name)
+	}
+
+	decodedTx, err := t.chain.ParseTransactionBytes(txBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TRON transaction: %w", err)
+	}
+
+	parsedTx, ok := decodedTx.(*chaintron.ParsedTronTransaction)
+	if !ok {
+		return nil, fmt.Errorf("unexpected transaction type: %T", decodedTx)
+	}
+
+	rawData := parsedTx.GetRawData()
+	if rawData == nil || len(rawData.Contract) != 1 {
+		return nil, fmt.Errorf("only single-contract transactions supported")
+	}
+
+	switch rawData.Contract[0].Type {
+	case "TransferContract":
+		return parsedTx.GetAmount(), nil
+	case "TriggerSmartContract":
+		return decodeTRC20Amount(parsedTx.GetCallData())
+	default:
+		return nil, fmt.Errorf("unsupported contract type: %s", rawData.Contract[0].Type)
+	}
+}
+
+// ParameterAsset returns the asset of the named numeric rule parameter: "" for TRX transfers,
+// the token contract address for TRC20 transfers
+func (t *Tron) ParameterAsset(_ *types.Rule, txBytes []byte, name string) (string, error) {
+	if name != "amount" {
+		return "", fmt.Errorf("unsupported numeric parameter: %s", name)
+	}
+
+	decodedTx, err := t.chain.ParseTransactionBytes(txBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse TRON transaction: %w", err)
+	}
+
+	parsedTx, ok := decodedTx.(*chaintron.ParsedTronTransaction)
+	if !ok {
+		return "", fmt.Errorf("unexpected transaction type: %T", decodedTx)
+	}
+
+	switch parsedTx.GetContractType() {
+	case "TransferContract":
+		return "", nil
+	case "TriggerSmartContract":
+		return parsedTx.GetContractAddress(), nil
+	default:
+		return "", fmt.Errorf("unsupported contract type: %s", parsedTx.GetContractType())
+	}
+}
+
 // validateTarget validates the transaction target against the rule target
-func (t *Tron) validateTarget(resource *types.ResourcePath, target *types.Target, tx *chaintron.ParsedTronTransaction) error {
+func (t *Tron) validateTarget(ctx context.Context, resource *types.ResourcePath, target *types.Target, tx *chaintron.ParsedTronTransaction) error {
 	if target == nil || target.GetTargetType() == types.TargetType_TARGET_TYPE_UNSPECIFIED {
 		return nil
 	}
@@ -108,12 +178,12 @@ func (t *Tron) validateTarget(resource *types.ResourcePath, target *types.Target
 			return fmt.Errorf("target address cannot be empty")
 		}
 		if actualDestination != expectedAddress {
-			return fmt.Errorf("target address mismatch: expected=%s, actual=%s",
+			return stdcompare.NewMismatchError("target address mismatch: expected=%s, actual=%s",
 				expectedAddress, actualDestination)
 		}
 
 	case types.TargetType_TARGET_TYPE_MAGIC_CONSTANT:
-		resolve, err := resolver.NewMagicConstantRegistry().GetResolver(target.GetMagicConstant())
+		resolve, err := t.resolvers.GetResolver(target.GetMagicConstant())
 		if err != nil {
 			return fmt.Errorf(
 				"failed to get resolver: magic_const=%s",
@@ -122,6 +192,7 @@ func (t *Tron) validateTarget(resource *types.ResourcePath, target *types.Target
 		}
 
 		resolvedAddr, _, err := resolve.Resolve(
+			ctx,
 			target.GetMagicConstant(),
 			resource.ChainId,
 			"default",
@@ -135,7 +206,7 @@ func (t *Tron) validateTarget(resource *types.ResourcePath, target *types.Target
 		}
 
 		if actualDestination != resolvedAddr {
-			return fmt.Errorf(
+			return stdcompare.NewMismatchError(
 				"tx target is wrong: tx_to=%s, rule_magic_const_resolved=%s",
 				actualDestination,
 				resolvedAddr,
@@ -150,7 +221,7 @@ func (t *Tron) validateTarget(resource *types.ResourcePath, target *types.Target
 }
 
 // validateParameterConstraints validates all parameter constraints
-func (t *Tron) validateParameterConstraints(resource *types.ResourcePath, constraints []*types.ParameterConstraint, tx *chaintron.ParsedTronTransaction) error {
+func (t *Tron) validateParameterConstraints(ctx context.Context, resource *types.ResourcePath, constraints []*types.ParameterConstraint, tx *chaintron.ParsedTronTransaction) error {
 	for _, constraint := range constraints {
 		paramName := constraint.GetParameterName()
 
@@ -159,7 +230,7 @@ func (t *Tron) validateParameterConstraints(resource *types.ResourcePath, constr
 			return fmt.Errorf("failed to extract parameter %s: %w", paramName, err)
 		}
 
-		if err := t.assertArgsByType(resource.ChainId, paramName, value, constraints); err != nil {
+		if err := t.assertArgsByType(ctx, resource.ChainId, paramName, value, constraints); err != nil {
 			return fmt.Errorf("constraint validation failed for parameter %s: %w", paramName, err)
 		}
 	}
@@ -181,10 +252,12 @@ func (t *Tron) extractParameterValue(paramName string, tx *chaintron.ParsedTronT
 }
 
 // assertArgsByType validates constraints using the appropriate comparator based on Go type
-func (t *Tron) assertArgsByType(chainId, inputName string, arg interface{}, constraints []*types.ParameterConstraint) error {
+func (t *Tron) assertArgsByType(ctx context.Context, chainId, inputName string, arg interface{}, constraints []*types.ParameterConstraint) error {
 	switch actual := arg.(type) {
 	case string:
 		err := stdcompare.AssertArg(
+			ctx,
+			t.resolvers,
 			chainId,
 			constraints,
 			inputName,
@@ -197,6 +270,8 @@ func (t *Tron) assertArgsByType(chainId, inputName string, arg interface{}, cons
 
 	case *big.Int:
 		err := stdcompare.AssertArg(
+			ctx,
+			t.resolvers,
 			chainId,
 			constraints,
 			inputName,
@@ -214,7 +289,7 @@ func (t *Tron) assertArgsByType(chainId, inputName string, arg interface{}, cons
 }
 
 // validateTRC20Transfer validates a TRC-20 token transfer (TriggerSmartContract)
-func (t *Tron) validateTRC20Transfer(resource *types.ResourcePath, rule *types.Rule, tx *chaintron.ParsedTronTransaction) error {
+func (t *Tron) validateTRC20Transfer(ctx context.Context, resource *types.ResourcePath, rule *types.Rule, tx *chaintron.ParsedTronTransaction) error {
 	callData := tx.GetCallData()
 	if callData == "" {
 		return fmt.Errorf("TRC-20 transfer missing call data")
@@ -226,7 +301,7 @@ func (t *Tron) validateTRC20Transfer(resource *types.ResourcePath, rule *types.R
 
 	funcSelector := callData[:8]
 	if funcSelector != "a9059cbb" {
-		return fmt.Errorf("invalid function selector, expected transfer(address,uint256), got: %s", funcSelector)
+		return stdcompare.NewMismatchError("invalid function selector, expected transfer(address,uint256), got: %s", funcSelector)
 	}
 
 	if len(callData) < 136 {
@@ -239,10 +314,9 @@ func (t *Tron) validateTRC20Transfer(resource *types.ResourcePath, rule *types.R
 		return fmt.Errorf("failed to decode recipient address: %w", err)
 	}
 
-	amountHex := callData[72:136]
-	amount := new(big.Int)
-	if _, ok := amount.SetString(amountHex, 16); !ok {
-		return fmt.Errorf("invalid amount hex: %s", amountHex)
+	amount, err := decodeTRC20Amount(callData)
+	if err != nil {
+		return err
 	}
 
 	contractAddr := tx.GetContractAddress()
@@ -252,15 +326,16 @@ func (t *Tron) validateTRC20Transfer(resource *types.ResourcePath, rule *types.R
 		case "recipient":
 			expectedRecipient := constraint.GetConstraint().GetFixedValue()
 			if expectedRecipient != "" && !strings.EqualFold(recipientAddr, expectedRecipient) {
-				return fmt.Errorf("recipient mismatch: expected %s, got %s", expectedRecipient, recipientAddr)
+				return stdcompare.NewMismatchError("recipient mismatch: expected %s, got %s", expectedRecipient, recipientAddr)
 			}
 			magicConst := constraint.GetConstraint().GetMagicConstantValue()
 			if magicConst != types.MagicConstant_UNSPECIFIED {
-				resolve, err := resolver.NewMagicConstantRegistry().GetResolver(magicConst)
+				resolve, err := t.resolvers.GetResolver(magicConst)
 				if err != nil {
 					return fmt.Errorf("failed to get resolver: %w", err)
 				}
 				resolvedAddr, _, err := resolve.Resolve(
+					ctx,
 					magicConst,
 					resource.ChainId,
 					"default",
@@ -269,12 +344,14 @@ func (t *Tron) validateTRC20Transfer(resource *types.ResourcePath, rule *types.R
 					return fmt.Errorf("failed to resolve magic constant: %w", err)
 				}
 				if !strings.EqualFold(recipientAddr, resolvedAddr) {
-					return fmt.Errorf("recipient mismatch: expected %s (resolved), got %s", resolvedAddr, recipientAddr)
+					return stdcompare.NewMismatchError("recipient mismatch: expected %s (resolved), got %s", resolvedAddr, recipientAddr)
 				}
 			}
 
 		case "amount":
 			err := stdcompare.AssertArg(
+				ctx,
+				t.resolvers,
 				resource.ChainId,
 				rule.GetParameterConstraints(),
 				"amount",
@@ -288,12 +365,14 @@ func (t *Tron) validateTRC20Transfer(resource *types.ResourcePath, rule *types.R
 		case "from_asset":
 			expectedContract := constraint.GetConstraint().GetFixedValue()
 			if expectedContract != "" && !strings.EqualFold(contractAddr, expectedContract) {
-				return fmt.Errorf("contract address mismatch: expected %s, got %s", expectedContract, contractAddr)
+				return stdcompare.NewMismatchError("contract address mismatch: expected %s, got %s", expectedContract, contractAddr)
 			}
 
 		case "memo":
 			memo := tx.GetMemo()
 			err := stdcompare.AssertArg(
+				ctx,
+				t.resolvers,
 				resource.ChainId,
 				rule.GetParameterConstraints(),
 				"memo",
@@ -309,6 +388,20 @@ func (t *Tron) validateTRC20Transfer(resource *types.ResourcePath, rule *types.R
 	return nil
 }
 
+// decodeTRC20Amount decodes the amount arg of transfer(address,uint256) call data
+func decodeTRC20Amount(callData string) (*big.Int, error) {
+	if len(callData) < 136 {
+		return nil, fmt.Errorf("call data incomplete for transfer: need 136 chars (4+32+32 bytes as hex), got %d", len(callData))
+	}
+
+	amountHex := callData[72:136]
+	amount := new(big.Int)
+	if _, ok := amount.SetString(amountHex, 16); !ok {
+		return nil, fmt.Errorf("invalid amount hex: %s", amountHex)
+	}
+	return amount, nil
+}
+
 // hexToTronAddress converts a hex-encoded address (with leading zeros) to TRON base58 address
 func (t *Tron) hexToTronAddress(hexAddr string) (string, error) {
 	hexAddr = strings.TrimPrefix(hexAddr, "0x")
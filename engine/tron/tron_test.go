@@ -1,6 +1,7 @@
 package tron
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"testing"
@@ -8,6 +9,7 @@ import (
 	"github.com/btcsuite/btcd/btcutil/base58"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vultisig/recipes/engine/compare"
 	"github.com/vultisig/recipes/types"
 	"github.com/vultisig/vultisig-go/common"
 )
@@ -73,7 +75,7 @@ func TestTron_Evaluate_DenyRule(t *testing.T) {
 		Effect: types.Effect_EFFECT_DENY,
 	}
 
-	err := tron.Evaluate(rule, []byte("any-data"))
+	err := tron.Evaluate(context.Background(), rule, []byte("any-data"))
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "only allow rules supported")
 }
@@ -85,7 +87,7 @@ func TestTron_Evaluate_InvalidResource(t *testing.T) {
 		Resource: "invalid-resource",
 	}
 
-	err := tron.Evaluate(rule, []byte("any-data"))
+	err := tron.Evaluate(context.Background(), rule, []byte("any-data"))
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to parse rule resource")
 }
@@ -97,7 +99,7 @@ func TestTron_Evaluate_InvalidTransactionData(t *testing.T) {
 		Resource: "tron.trx.transfer",
 	}
 
-	err := tron.Evaluate(rule, []byte("invalid-tx-data"))
+	err := tron.Evaluate(context.Background(), rule, []byte("invalid-tx-data"))
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to parse TRON transaction")
 }
@@ -109,7 +111,7 @@ func TestTron_Evaluate_EmptyTransaction(t *testing.T) {
 		Resource: "tron.trx.transfer",
 	}
 
-	err := tron.Evaluate(rule, []byte{})
+	err := tron.Evaluate(context.Background(), rule, []byte{})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "empty transaction data")
 }
@@ -218,7 +220,7 @@ func TestTron_Evaluate_Success_ValidTransfer(t *testing.T) {
 		Resource: "tron.trx.transfer",
 	}
 
-	err := tron.Evaluate(rule, txBytes)
+	err := tron.Evaluate(context.Background(), rule, txBytes)
 	require.NoError(t, err)
 }
 
@@ -242,7 +244,7 @@ func TestTron_Evaluate_Success_WithTargetAddress(t *testing.T) {
 		},
 	}
 
-	err := tron.Evaluate(rule, txBytes)
+	err := tron.Evaluate(context.Background(), rule, txBytes)
 	require.NoError(t, err)
 }
 
@@ -267,7 +269,7 @@ func TestTron_Evaluate_Failure_TargetMismatch(t *testing.T) {
 		},
 	}
 
-	err := tron.Evaluate(rule, txBytes)
+	err := tron.Evaluate(context.Background(), rule, txBytes)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "target address mismatch")
 }
@@ -297,7 +299,7 @@ func TestTron_Evaluate_Success_WithAmountConstraint(t *testing.T) {
 		},
 	}
 
-	err := tron.Evaluate(rule, txBytes)
+	err := tron.Evaluate(context.Background(), rule, txBytes)
 	require.NoError(t, err)
 }
 
@@ -327,7 +329,7 @@ func TestTron_Evaluate_Failure_AmountConstraintViolation(t *testing.T) {
 		},
 	}
 
-	err := tron.Evaluate(rule, txBytes)
+	err := tron.Evaluate(context.Background(), rule, txBytes)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to validate parameter constraints")
 }
@@ -357,7 +359,7 @@ func TestTron_Evaluate_Success_WithMaxAmountConstraint(t *testing.T) {
 		},
 	}
 
-	err := tron.Evaluate(rule, txBytes)
+	err := tron.Evaluate(context.Background(), rule, txBytes)
 	require.NoError(t, err)
 }
 
@@ -386,7 +388,7 @@ func TestTron_Evaluate_Failure_MaxAmountExceeded(t *testing.T) {
 		},
 	}
 
-	err := tron.Evaluate(rule, txBytes)
+	err := tron.Evaluate(context.Background(), rule, txBytes)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to validate parameter constraints")
 }
@@ -416,7 +418,7 @@ func TestTron_Evaluate_Success_WithRecipientConstraint(t *testing.T) {
 		},
 	}
 
-	err := tron.Evaluate(rule, txBytes)
+	err := tron.Evaluate(context.Background(), rule, txBytes)
 	require.NoError(t, err)
 }
 
@@ -446,7 +448,39 @@ func TestTron_Evaluate_Failure_RecipientConstraintViolation(t *testing.T) {
 		},
 	}
 
-	err := tron.Evaluate(rule, txBytes)
+	err := tron.Evaluate(context.Background(), rule, txBytes)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to validate parameter constraints")
 }
+
+func TestTron_Match_DenyRecipient(t *testing.T) {
+	tron := NewTron()
+
+	ownerAddr, _ := hex.DecodeString("41a614f803b6fd780986a42c78ec9c7f77e6ded13c")
+	toAddr, _ := hex.DecodeString("41b614f803b6fd780986a42c78ec9c7f77e6ded13d")
+	otherAddr, _ := hex.DecodeString("41c614f803b6fd780986a42c78ec9c7f77e6ded13e")
+
+	// deny any transfer to the recipient, whatever the amount
+	rule := &types.Rule{
+		Effect:   types.Effect_EFFECT_DENY,
+		Resource: "tron.trx.transfer",
+		ParameterConstraints: []*types.ParameterConstraint{
+			{
+				ParameterName: "recipient",
+				Constraint: &types.Constraint{
+					Type: types.ConstraintType_CONSTRAINT_TYPE_FIXED,
+					Value: &types.Constraint_FixedValue{
+						FixedValue: hexToBase58(hex.EncodeToString(toAddr)),
+					},
+				},
+			},
+		},
+	}
+
+	err := tron.Match(context.Background(), rule, buildTronTransferTx(ownerAddr, toAddr, 1000000))
+	require.NoError(t, err)
+
+	err = tron.Match(context.Background(), rule, buildTronTransferTx(ownerAddr, otherAddr, 1000000))
+	require.Error(t, err)
+	assert.True(t, compare.IsMismatch(err), err)
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vultisig/recipes/engine/compare"
+	"github.com/vultisig/recipes/types"
+	"github.com/vultisig/vultisig-go/common"
+)
+
+// EvaluateTypedData finds the rule which allows signing the EIP-712 typed data (eth_signTypedData_v4 JSON),
+// e.g. an EIP-2612 or Permit2 permit. Deny rules are checked first, same as for txs.
+// Rate and spend limits are not applied: a permit only grants the allowance,
+// the tx spending it is evaluated and counted against the limits
+func (e *Engine) EvaluateTypedData(policy *types.Policy, chain common.Chain, data []byte) (*types.Rule, error) {
+	return e.EvaluateTypedDataContext(context.Background(), policy, chain, data)
+}
+
+// EvaluateTypedDataContext is EvaluateTypedData with a context, which is passed down to chain engines
+func (e *Engine) EvaluateTypedDataContext(
+	ctx context.Context,
+	policy *types.Policy,
+	chain common.Chain,
+	data []byte,
+) (*types.Rule, error) {
+	rules, err := e.chainRules(policy, chain, &EvaluationReport{})
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, errors.New("no matching rule")
+	}
+
+	chainEngine, err := e.registry.GetEngine(chain)
+	if err != nil {
+		return nil, fmt.Errorf("no engine available for chain %s: %w", chain.String(), err)
+	}
+	evaluator, ok := chainEngine.(TypedDataEvaluator)
+	if !ok {
+		return nil, fmt.Errorf("typed data is not supported for chain %s", chain.String())
+	}
+
+	for _, rule := range rules {
+		if rule.GetEffect() != types.Effect_EFFECT_DENY {
+			continue
+		}
+
+		// fiat constraints can't be evaluated for typed data, deny rules with them match regardless of the amount
+		er := evaluator.MatchTypedData(ctx, withoutFiatConstraints(rule), data)
+		if compare.IsMismatch(er) {
+			e.logger.Printf("Deny rule %s not matched typed data for %s: %v", rule.GetId(), chain.String(), er)
+			continue
+		}
+		if er != nil {
+			return nil, fmt.Errorf("failed to evaluate deny rule: id=%s, resource=%s: %w", rule.GetId(), rule.GetResource(), er)
+		}
+		return nil, fmt.Errorf("typed data denied by rule: id=%s, resource=%s", rule.GetId(), rule.GetResource())
+	}
+
+	var errStrs []string
+	for _, rule := range rules {
+		if rule.GetEffect() == types.Effect_EFFECT_DENY {
+			continue
+		}
+
+		er := evaluator.EvaluateTypedData(ctx, rule, data)
+		if er == nil && hasFiatConstraints(rule) {
+			er = errors.New("fiat-denominated constraints are not supported for typed data")
+		}
+		if er != nil {
+			errStrs = append(errStrs, fmt.Sprintf("%s(%s)", rule.GetResource(), er.Error()))
+			e.logger.Printf("Failed to evaluate typed data for %s: %v", chain.String(), er)
+			continue
+		}
+
+		e.logger.Printf("Typed data validated for %s", chain.String())
+		return rule, nil
+	}
+	if len(errStrs) == 0 {
+		return nil, errors.New("no matching rule")
+	}
+	return nil, fmt.Errorf("failed to evaluate typed data: %s", strings.Join(errStrs, " "))
+}
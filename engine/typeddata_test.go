@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vultisig/vultisig-go/common"
+
+	"github.com/vultisig/recipes/types"
+)
+
+func permitTypedData(spender, value string) []byte {
+	return []byte(fmt.Sprintf(`{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Permit": [
+			{"name": "owner", "type": "address"},
+			{"name": "spender", "type": "address"},
+			{"name": "value", "type": "uint256"},
+			{"name": "nonce", "type": "uint256"},
+			{"name": "deadline", "type": "uint256"}
+		]
+	},
+	"primaryType": "Permit",
+	"domain": {
+		"name": "USD Coin",
+		"version": "2",
+		"chainId": 1,
+		"verifyingContract": "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	},
+	"message": {
+		"owner": "0x1111111111111111111111111111111111111111",
+		"spender": "%s",
+		"value": "%s",
+		"nonce": "0",
+		"deadline": "1900000000"
+	}
+}`, spender, value))
+}
+
+func TestEvaluateTypedData(t *testing.T) {
+	const (
+		spender = "0x2222222222222222222222222222222222222222"
+		blocked = "0x3333333333333333333333333333333333333333"
+	)
+
+	engine, err := NewEngine()
+	require.NoError(t, err)
+
+	constraint := func(name string, c *types.Constraint) *types.ParameterConstraint {
+		return &types.ParameterConstraint{ParameterName: name, Constraint: c}
+	}
+	anyValue := &types.Constraint{Type: types.ConstraintType_CONSTRAINT_TYPE_ANY}
+	target := &types.Target{
+		TargetType: types.TargetType_TARGET_TYPE_ADDRESS,
+		Target:     &types.Target_Address{Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"},
+	}
+
+	policy := &types.Policy{
+		Id: "permit-policy",
+		Rules: []*types.Rule{
+			{
+				Id:       "deny-blocked-spender",
+				Effect:   types.Effect_EFFECT_DENY,
+				Resource: "ethereum.erc20.permit",
+				Target:   target,
+				ParameterConstraints: []*types.ParameterConstraint{
+					constraint("owner", anyValue),
+					constraint("spender", &types.Constraint{
+						Type:  types.ConstraintType_CONSTRAINT_TYPE_FIXED,
+						Value: &types.Constraint_FixedValue{FixedValue: blocked},
+					}),
+					constraint("value", anyValue),
+					constraint("nonce", anyValue),
+					constraint("deadline", anyValue),
+				},
+			},
+			{
+				Id:       "allow-permit",
+				Effect:   types.Effect_EFFECT_ALLOW,
+				Resource: "ethereum.erc20.permit",
+				Target:   target,
+				ParameterConstraints: []*types.ParameterConstraint{
+					constraint("owner", anyValue),
+					constraint("spender", anyValue),
+					constraint("value", &types.Constraint{
+						Type:  types.ConstraintType_CONSTRAINT_TYPE_MAX,
+						Value: &types.Constraint_MaxValue{MaxValue: "1000000"},
+					}),
+					constraint("nonce", anyValue),
+					constraint("deadline", anyValue),
+				},
+			},
+		},
+	}
+
+	rule, err := engine.EvaluateTypedData(policy, common.Ethereum, permitTypedData(spender, "1000000"))
+	require.NoError(t, err)
+	require.Equal(t, "allow-permit", rule.GetId())
+
+	_, err = engine.EvaluateTypedData(policy, common.Ethereum, permitTypedData(spender, "1000001"))
+	require.ErrorContains(t, err, "failed to evaluate typed data")
+
+	_, err = engine.EvaluateTypedData(policy, common.Ethereum, permitTypedData(blocked, "1"))
+	require.ErrorContains(t, err, "typed data denied by rule: id=deny-blocked-spender")
+
+	_, err = engine.EvaluateTypedData(policy, common.Bitcoin, permitTypedData(spender, "1"))
+	require.ErrorContains(t, err, "no matching rule")
+}
@@ -0,0 +1,365 @@
+package units
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/vultisig/recipes/chain/evm/ethereum"
+	"github.com/vultisig/recipes/engine/price"
+	"github.com/vultisig/recipes/types"
+	"github.com/vultisig/vultisig-go/common"
+	"google.golang.org/protobuf/proto"
+)
+
+// nativeDecimals of chains which are not EVM, EVM chains have 18
+var nativeDecimals = map[common.Chain]int{
+	common.Bitcoin:     8,
+	common.BitcoinCash: 8,
+	common.Litecoin:    8,
+	common.Dogecoin:    8,
+	common.Dash:        8,
+	common.Zcash:       8,
+	common.THORChain:   8,
+	common.MayaChain:   10,
+	common.GaiaChain:   6,
+	common.Solana:      9,
+	common.XRP:         6,
+	common.Tron:        6,
+}
+
+// evmUnits are denominations of EVM native amounts other than the native symbol and wei
+var evmUnits = map[string]int{
+	"gwei": 9,
+}
+
+// baseUnits are labels of base units of chains which are not EVM, EVM chains use wei
+var baseUnits = map[common.Chain][]string{
+	common.Bitcoin:     {"satoshi", "satoshis", "sat", "sats"},
+	common.BitcoinCash: {"satoshi", "satoshis", "sat", "sats"},
+	common.Litecoin:    {"satoshi", "satoshis", "litoshi", "litoshis", "sat", "sats"},
+	common.Dogecoin:    {"satoshi", "satoshis", "koinu", "sat", "sats"},
+	common.Dash:        {"satoshi", "satoshis", "duff", "duffs", "sat", "sats"},
+	common.Zcash:       {"zatoshi", "zatoshis", "zat", "zats"},
+	common.GaiaChain:   {"uatom"},
+	common.Solana:      {"lamport", "lamports"},
+	common.XRP:         {"drop", "drops"},
+	common.Tron:        {"sun"},
+}
+
+// IsBaseUnits reports whether amounts of the denomination are in base units of the chain, e.g. satoshis.
+// Policies denominate token amounts in base units too, so such amounts are not bound to the native asset
+func IsBaseUnits(chain common.Chain, denomination string) bool {
+	if chain.IsEvm() {
+		return strings.EqualFold(denomination, "wei")
+	}
+	for _, unit := range baseUnits[chain] {
+		if strings.EqualFold(denomination, unit) {
+			return true
+		}
+	}
+	return false
+}
+
+// isNativeSymbol reports whether the denomination is the native symbol of the chain, e.g. ETH
+func isNativeSymbol(chain common.Chain, denomination string) bool {
+	nativeSymbol, err := chain.NativeSymbol()
+	return err == nil && strings.EqualFold(denomination, nativeSymbol)
+}
+
+// NativeDecimals returns the number of decimals of the chain native asset
+func NativeDecimals(chain common.Chain) (int, error) {
+	if chain.IsEvm() {
+		return 18, nil
+	}
+
+	decimals, ok := nativeDecimals[chain]
+	if !ok {
+		return 0, fmt.Errorf("unknown native decimals of %s", chain.String())
+	}
+	return decimals, nil
+}
+
+type token struct {
+	chainID int
+	key     string
+}
+
+type tokenInfo struct {
+	address  string
+	decimals int
+}
+
+// Registry resolves denominations of constraint amounts (native symbols, EVM units
+// and tokens by symbol or address) to assets and decimals
+type Registry struct {
+	mu     sync.RWMutex
+	tokens map[token]tokenInfo
+}
+
+// NewRegistry creates the registry with tokens of the lists
+func NewRegistry(lists ...*ethereum.TokenList) *Registry {
+	r := &Registry{
+		tokens: make(map[token]tokenInfo),
+	}
+	for _, list := range lists {
+		r.AddTokenList(list)
+	}
+	return r
+}
+
+// DefaultRegistry creates the registry with tokens of the token list embedded in chain/evm/ethereum
+func DefaultRegistry() (*Registry, error) {
+	list, err := ethereum.DefaultTokenList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load default token list: %w", err)
+	}
+	return NewRegistry(list), nil
+}
+
+// AddTokenList adds tokens of the list, tokens are keyed by the EIP-155 chain ID
+func (r *Registry) AddTokenList(list *ethereum.TokenList) {
+	for _, t := range list.Tokens {
+		r.AddToken(t)
+	}
+}
+
+// AddToken adds the token, it can be referenced by symbol or address (case-insensitive)
+func (r *Registry) AddToken(t ethereum.Token) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	info := tokenInfo{address: strings.ToLower(t.Address), decimals: t.Decimals}
+	r.tokens[token{chainID: t.ChainId, key: strings.ToLower(t.Symbol)}] = info
+	r.tokens[token{chainID: t.ChainId, key: info.address}] = info
+}
+
+// Decimals returns the number of decimals of amounts denominated in the native symbol,
+// base units (satoshis, lamports, wei, ...), gwei or the token symbol or address on the chain
+func (r *Registry) Decimals(chain common.Chain, denomination string) (int, error) {
+	_, decimals, err := r.lookup(chain, denomination)
+	return decimals, err
+}
+
+// Asset returns the asset of amounts denominated in the native symbol, EVM unit or token on the chain:
+// "" for the native asset, the lowercase token address for tokens, as reported by chain engines for tx amounts
+func (r *Registry) Asset(chain common.Chain, denomination string) (string, error) {
+	asset, _, err := r.lookup(chain, denomination)
+	return asset, err
+}
+
+func (r *Registry) lookup(chain common.Chain, denomination string) (string, int, error) {
+	key := strings.ToLower(denomination)
+
+	if isNativeSymbol(chain, denomination) {
+		decimals, err := NativeDecimals(chain)
+		return "", decimals, err
+	}
+	if IsBaseUnits(chain, denomination) {
+		return "", 0, nil
+	}
+
+	if !chain.IsEvm() {
+		return "", 0, fmt.Errorf("unknown token %s on %s", denomination, chain.String())
+	}
+
+	decimals, ok := evmUnits[key]
+	if ok {
+		return "", decimals, nil
+	}
+
+	chainID, err := chain.EvmID()
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to get chain id of %s: %w", chain.String(), err)
+	}
+
+	r.mu.RLock()
+	info, ok := r.tokens[token{chainID: int(chainID.Int64()), key: key}]
+	r.mu.RUnlock()
+	if !ok {
+		return "", 0, fmt.Errorf("unknown token %s on %s", denomination, chain.String())
+	}
+	return info.address, info.decimals, nil
+}
+
+// ParseAmount converts the decimal amount, e.g. "100.5", to base units.
+// Amounts more precise than the decimals are rejected rather than rounded
+func ParseAmount(value string, decimals int) (*big.Int, error) {
+	whole, frac, _ := strings.Cut(strings.TrimSpace(value), ".")
+	if whole == "" && frac == "" {
+		return nil, fmt.Errorf("invalid amount: %q", value)
+	}
+	if len(strings.TrimRight(frac, "0")) > decimals {
+		return nil, fmt.Errorf("amount %s has more than %d decimals", value, decimals)
+	}
+	if len(frac) > decimals {
+		frac = frac[:decimals]
+	}
+	frac += strings.Repeat("0", decimals-len(frac))
+
+	digits := whole + frac
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return nil, fmt.Errorf("invalid amount: %q", value)
+		}
+	}
+
+	amount, ok := new(big.Int).SetString("0"+digits, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid amount: %q", value)
+	}
+	return amount, nil
+}
+
+// NormalizeRule returns the copy of the rule with amounts of denominated constraints converted to base units,
+// e.g. max 100.5 denominated in usdc becomes 100500000. The rule is returned as is if no constraint is denominated
+// The denomination is kept, the asset of the tx amount must be checked against it with Asset
+func (r *Registry) NormalizeRule(chain common.Chain, rule *types.Rule) (*types.Rule, error) {
+	denominated := false
+	for _, pc := range rule.GetParameterConstraints() {
+		if isDenominated(pc.GetConstraint()) {
+			denominated = true
+			break
+		}
+	}
+	if !denominated {
+		return rule, nil
+	}
+
+	out := proto.Clone(rule).(*types.Rule)
+	out.ParameterConstraints = make([]*types.ParameterConstraint, 0, len(rule.GetParameterConstraints()))
+	for _, pc := range rule.GetParameterConstraints() {
+		c, err := r.normalizeConstraint(chain, pc.GetConstraint(), "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to normalize %s: %w", pc.GetParameterName(), err)
+		}
+		out.ParameterConstraints = append(out.ParameterConstraints, &types.ParameterConstraint{
+			ParameterName: pc.GetParameterName(),
+			Constraint:    c,
+		})
+	}
+	return out, nil
+}
+
+func isDenominated(c *types.Constraint) bool {
+	if c.GetDenominatedIn() != "" {
+		return true
+	}
+	for _, nested := range c.GetCompositeValue().GetConstraints() {
+		if isDenominated(nested) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeConstraint converts amounts of the constraint, nested constraints inherit the denomination of the parent.
+// Fiat amounts are left as is, the engine compares them with the value of the amount in the currency
+func (r *Registry) normalizeConstraint(chain common.Chain, c *types.Constraint, parent string) (*types.Constraint, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	denomination := c.GetDenominatedIn()
+	if denomination == "" {
+		denomination = parent
+	}
+	if price.IsFiat(denomination) {
+		if parent != "" || c.GetCompositeValue() != nil {
+			return nil, fmt.Errorf("fiat denomination is only supported for min, max and range constraints")
+		}
+		return proto.Clone(c).(*types.Constraint), nil
+	}
+
+	out := &types.Constraint{
+		Type:          c.GetType(),
+		DenominatedIn: c.GetDenominatedIn(),
+		Period:        c.GetPeriod(),
+		Required:      c.GetRequired(),
+	}
+
+	if composite := c.GetCompositeValue(); composite != nil {
+		nested := make([]*types.Constraint, 0, len(composite.GetConstraints()))
+		for _, n := range composite.GetConstraints() {
+			nc, err := r.normalizeConstraint(chain, n, denomination)
+			if err != nil {
+				return nil, err
+			}
+			nested = append(nested, nc)
+		}
+		out.Value = &types.Constraint_CompositeValue{CompositeValue: &types.CompositeValue{Constraints: nested}}
+		return out, nil
+	}
+
+	if denomination == "" {
+		return proto.Clone(c).(*types.Constraint), nil
+	}
+
+	decimals, err := r.Decimals(chain, denomination)
+	if err != nil {
+		return nil, err
+	}
+	// policies written before denominations were converted denominate base unit amounts in the native symbol,
+	// so native amounts without a decimal point are kept as base units
+	native := isNativeSymbol(chain, denomination)
+	convert := func(value string) (string, error) {
+		if value == "" {
+			return "", nil
+		}
+		d := decimals
+		if native && !strings.Contains(value, ".") {
+			d = 0
+		}
+		amount, er := ParseAmount(value, d)
+		if er != nil {
+			return "", er
+		}
+		return amount.String(), nil
+	}
+
+	switch v := c.GetValue().(type) {
+	case *types.Constraint_FixedValue:
+		value, er := convert(v.FixedValue)
+		if er != nil {
+			return nil, er
+		}
+		out.Value = &types.Constraint_FixedValue{FixedValue: value}
+	case *types.Constraint_MaxValue:
+		value, er := convert(v.MaxValue)
+		if er != nil {
+			return nil, er
+		}
+		out.Value = &types.Constraint_MaxValue{MaxValue: value}
+	case *types.Constraint_MinValue:
+		value, er := convert(v.MinValue)
+		if er != nil {
+			return nil, er
+		}
+		out.Value = &types.Constraint_MinValue{MinValue: value}
+	case *types.Constraint_RangeValue:
+		lo, er := convert(v.RangeValue.GetMin())
+		if er != nil {
+			return nil, er
+		}
+		hi, er := convert(v.RangeValue.GetMax())
+		if er != nil {
+			return nil, er
+		}
+		out.Value = &types.Constraint_RangeValue{RangeValue: &types.RangeValue{Min: lo, Max: hi}}
+	case *types.Constraint_SetValue:
+		values := make([]string, 0, len(v.SetValue.GetValues()))
+		for _, s := range v.SetValue.GetValues() {
+			value, er := convert(s)
+			if er != nil {
+				return nil, er
+			}
+			values = append(values, value)
+		}
+		out.Value = &types.Constraint_SetValue{SetValue: &types.SetValue{Values: values}}
+	case nil:
+	default:
+		return nil, fmt.Errorf("constraint type %s can't be denominated", c.GetType().String())
+	}
+	return out, nil
+}
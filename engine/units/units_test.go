@@ -0,0 +1,245 @@
+package units
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vultisig/vultisig-go/common"
+
+	"github.com/vultisig/recipes/chain/evm/ethereum"
+	"github.com/vultisig/recipes/types"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		value    string
+		decimals int
+		want     string
+		wantErr  bool
+	}{
+		{value: "100.5", decimals: 6, want: "100500000"},
+		{value: "100", decimals: 6, want: "100000000"},
+		{value: ".25", decimals: 2, want: "25"},
+		{value: "1.500", decimals: 1, want: "15"},
+		{value: "1000", decimals: 0, want: "1000"},
+		{value: "0.0000001", decimals: 6, wantErr: true},
+		{value: "1.5", decimals: 0, wantErr: true},
+		{value: "-1", decimals: 6, wantErr: true},
+		{value: "1e6", decimals: 6, wantErr: true},
+		{value: "", decimals: 6, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseAmount(tt.value, tt.decimals)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			want, _ := new(big.Int).SetString(tt.want, 10)
+			require.Equal(t, want, got)
+		})
+	}
+}
+
+func TestRegistry_Decimals(t *testing.T) {
+	r, err := DefaultRegistry()
+	require.NoError(t, err)
+
+	tests := []struct {
+		chain        common.Chain
+		denomination string
+		want         int
+		wantErr      string
+	}{
+		{chain: common.Ethereum, denomination: "USDC", want: 6},
+		{chain: common.Ethereum, denomination: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", want: 6},
+		{chain: common.Ethereum, denomination: "eth", want: 18},
+		{chain: common.Ethereum, denomination: "gwei", want: 9},
+		{chain: common.Ethereum, denomination: "wei", want: 0},
+		{chain: common.Bitcoin, denomination: "BTC", want: 8},
+		{chain: common.Solana, denomination: "SOL", want: 9},
+		{chain: common.Arbitrum, denomination: "usdc", wantErr: "unknown token usdc on Arbitrum"},
+		{chain: common.Ethereum, denomination: "NOPE", wantErr: "unknown token NOPE on Ethereum"},
+		{chain: common.Bitcoin, denomination: "wei", wantErr: "unknown token wei on Bitcoin"},
+		{chain: common.Bitcoin, denomination: "satoshis", want: 0},
+		{chain: common.Solana, denomination: "Lamports", want: 0},
+		{chain: common.XRP, denomination: "drops", want: 0},
+		{chain: common.GaiaChain, denomination: "uatom", want: 0},
+		{chain: common.Ethereum, denomination: "satoshis", wantErr: "unknown token satoshis on Ethereum"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.chain.String()+"/"+tt.denomination, func(t *testing.T) {
+			got, err := r.Decimals(tt.chain, tt.denomination)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	r.AddToken(ethereum.Token{ChainId: 42161, Symbol: "USDC", Address: "0xaf88d065e77c8cC2239327C5EDb3A432268e5831", Decimals: 6})
+	got, err := r.Decimals(common.Arbitrum, "usdc")
+	require.NoError(t, err)
+	require.Equal(t, 6, got)
+}
+
+func TestRegistry_Asset(t *testing.T) {
+	r, err := DefaultRegistry()
+	require.NoError(t, err)
+
+	tests := []struct {
+		chain        common.Chain
+		denomination string
+		want         string
+		wantErr      string
+	}{
+		{chain: common.Ethereum, denomination: "USDC", want: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"},
+		{chain: common.Ethereum, denomination: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", want: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"},
+		{chain: common.Ethereum, denomination: "eth", want: ""},
+		{chain: common.Ethereum, denomination: "gwei", want: ""},
+		{chain: common.Bitcoin, denomination: "BTC", want: ""},
+		{chain: common.Bitcoin, denomination: "sats", want: ""},
+		{chain: common.Ethereum, denomination: "NOPE", wantErr: "unknown token NOPE on Ethereum"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.chain.String()+"/"+tt.denomination, func(t *testing.T) {
+			got, err := r.Asset(tt.chain, tt.denomination)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRegistry_NormalizeRule(t *testing.T) {
+	r, err := DefaultRegistry()
+	require.NoError(t, err)
+
+	plain := &types.Rule{
+		Resource: "ethereum.erc20.transfer",
+		ParameterConstraints: []*types.ParameterConstraint{{
+			ParameterName: "amount",
+			Constraint: &types.Constraint{
+				Type:  types.ConstraintType_CONSTRAINT_TYPE_MAX,
+				Value: &types.Constraint_MaxValue{MaxValue: "100"},
+			},
+		}},
+	}
+	out, err := r.NormalizeRule(common.Ethereum, plain)
+	require.NoError(t, err)
+	require.Same(t, plain, out)
+
+	rule := &types.Rule{
+		Resource: "ethereum.erc20.transfer",
+		ParameterConstraints: []*types.ParameterConstraint{{
+			ParameterName: "amount",
+			Constraint: &types.Constraint{
+				Type:          types.ConstraintType_CONSTRAINT_TYPE_MAX,
+				Value:         &types.Constraint_MaxValue{MaxValue: "100.5"},
+				DenominatedIn: "USDC",
+				Period:        "day",
+			},
+		}, {
+			ParameterName: "tx.max_fee_per_gas",
+			Constraint: &types.Constraint{
+				Type: types.ConstraintType_CONSTRAINT_TYPE_ANY_OF,
+				Value: &types.Constraint_CompositeValue{CompositeValue: &types.CompositeValue{
+					Constraints: []*types.Constraint{{
+						Type:  types.ConstraintType_CONSTRAINT_TYPE_RANGE,
+						Value: &types.Constraint_RangeValue{RangeValue: &types.RangeValue{Min: "1", Max: "30.5"}},
+					}},
+				}},
+				DenominatedIn: "gwei",
+			},
+		}},
+	}
+	out, err = r.NormalizeRule(common.Ethereum, rule)
+	require.NoError(t, err)
+
+	amount := out.GetParameterConstraints()[0].GetConstraint()
+	require.Equal(t, "100500000", amount.GetMaxValue())
+	require.Equal(t, "USDC", amount.GetDenominatedIn())
+	require.Equal(t, "day", amount.GetPeriod())
+	rng := out.GetParameterConstraints()[1].GetConstraint().GetCompositeValue().GetConstraints()[0].GetRangeValue()
+	require.Equal(t, "1000000000", rng.GetMin())
+	require.Equal(t, "30500000000", rng.GetMax())
+	require.Equal(t, "100.5", rule.GetParameterConstraints()[0].GetConstraint().GetMaxValue(), "source rule is not modified")
+
+	_, err = r.NormalizeRule(common.Arbitrum, rule)
+	require.ErrorContains(t, err, "failed to normalize amount: unknown token USDC on Arbitrum")
+
+	fiat := &types.Rule{
+		ParameterConstraints: []*types.ParameterConstraint{{
+			ParameterName: "amount",
+			Constraint: &types.Constraint{
+				Type:          types.ConstraintType_CONSTRAINT_TYPE_MAX,
+				Value:         &types.Constraint_MaxValue{MaxValue: "500.25"},
+				DenominatedIn: "USD",
+			},
+		}},
+	}
+	out, err = r.NormalizeRule(common.Ethereum, fiat)
+	require.NoError(t, err)
+	require.Equal(t, "500.25", out.GetParameterConstraints()[0].GetConstraint().GetMaxValue(), "fiat amounts are left as is")
+
+	regexp := &types.Rule{
+		ParameterConstraints: []*types.ParameterConstraint{{
+			ParameterName: "amount",
+			Constraint: &types.Constraint{
+				Type:          types.ConstraintType_CONSTRAINT_TYPE_REGEXP,
+				Value:         &types.Constraint_RegexpValue{RegexpValue: "^1"},
+				DenominatedIn: "usdc",
+			},
+		}},
+	}
+	_, err = r.NormalizeRule(common.Ethereum, regexp)
+	require.ErrorContains(t, err, "can't be denominated")
+}
+
+func TestRegistry_NormalizeRule_NativeAmounts(t *testing.T) {
+	r, err := DefaultRegistry()
+	require.NoError(t, err)
+
+	rule := func(value, denomination string) *types.Rule {
+		return &types.Rule{
+			Resource: "bitcoin.btc.transfer",
+			ParameterConstraints: []*types.ParameterConstraint{{
+				ParameterName: "amount",
+				Constraint: &types.Constraint{
+					Type:          types.ConstraintType_CONSTRAINT_TYPE_MAX,
+					Value:         &types.Constraint_MaxValue{MaxValue: value},
+					DenominatedIn: denomination,
+				},
+			}},
+		}
+	}
+
+	tests := []struct {
+		value        string
+		denomination string
+		want         string
+	}{
+		{value: "10000000", denomination: "satoshis", want: "10000000"},
+		{value: "10000000", denomination: "BTC", want: "10000000"},
+		{value: "0.1", denomination: "BTC", want: "10000000"},
+		{value: "1.0", denomination: "btc", want: "100000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value+" "+tt.denomination, func(t *testing.T) {
+			out, err := r.NormalizeRule(common.Bitcoin, rule(tt.value, tt.denomination))
+			require.NoError(t, err)
+			require.Equal(t, tt.want, out.GetParameterConstraints()[0].GetConstraint().GetMaxValue())
+		})
+	}
+}
@@ -1,9 +1,13 @@
 package bitcoin
 
 import (
+	"context"
+	"math/big"
+
 	"github.com/btcsuite/btcd/chaincfg"
 
 	"github.com/vultisig/recipes/engine/utxo"
+	"github.com/vultisig/recipes/resolver"
 	"github.com/vultisig/recipes/types"
 	"github.com/vultisig/vultisig-go/common"
 )
@@ -30,11 +34,36 @@ func (b *Btc) Supports(chain common.Chain) bool {
 }
 
 // Evaluate validates a transaction against the given rule.
-func (b *Btc) Evaluate(rule *types.Rule, txBytes []byte) error {
-	return b.engine.Evaluate(rule, txBytes)
+func (b *Btc) Evaluate(ctx context.Context, rule *types.Rule, txBytes []byte) error {
+	return b.engine.Evaluate(ctx, rule, txBytes)
+}
+
+// Match checks the transaction against the given rule regardless of its effect.
+func (b *Btc) Match(ctx context.Context, rule *types.Rule, txBytes []byte) error {
+	return b.engine.Match(ctx, rule, txBytes)
+}
+
+// ParameterValue returns the numeric value of the named rule parameter in the transaction.
+func (b *Btc) ParameterValue(rule *types.Rule, txBytes []byte, name string) (*big.Int, error) {
+	return b.engine.ParameterValue(rule, txBytes, name)
+}
+
+// ParameterAsset returns the asset of the named numeric rule parameter, "" for the native asset.
+func (b *Btc) ParameterAsset(rule *types.Rule, txBytes []byte, name string) (string, error) {
+	return b.engine.ParameterAsset(rule, txBytes, name)
+}
+
+// SetMagicConstantRegistry sets the registry used to resolve magic constants.
+func (b *Btc) SetMagicConstantRegistry(registry *resolver.MagicConstantRegistry) {
+	b.engine.SetMagicConstantRegistry(registry)
 }
 
 // ExtractTxBytes extracts transaction bytes from a PSBT string.
 func (b *Btc) ExtractTxBytes(txData string) ([]byte, error) {
 	return b.engine.ExtractTxBytes(txData)
 }
+
+// ExtractPSBTBytes extracts the serialized PSBT, with input UTXOs and sighash types, from a PSBT string.
+func (b *Btc) ExtractPSBTBytes(txData string) ([]byte, error) {
+	return b.engine.ExtractPSBTBytes(txData)
+}
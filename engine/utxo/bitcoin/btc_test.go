@@ -2,13 +2,25 @@ package bitcoin
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/stretchr/testify/assert"
+	"github.com/vultisig/recipes/engine/compare"
+	"github.com/vultisig/recipes/engine/utxo"
+	"github.com/vultisig/recipes/resolver"
 	"github.com/vultisig/recipes/types"
 )
 
@@ -98,7 +110,7 @@ func TestBtc_Evaluate_Fixed(t *testing.T) {
 		"4772191",
 	)...)
 
-	err = NewBtc().Evaluate(&types.Rule{
+	err = NewBtc().Evaluate(context.Background(), &types.Rule{
 		Resource:             "bitcoin.btc.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -122,7 +134,7 @@ func TestBtc_Evaluate_MinConstraints(t *testing.T) {
 		"4000000",
 	)...) // min 4M, actual 4.77M
 
-	err = NewBtc().Evaluate(&types.Rule{
+	err = NewBtc().Evaluate(context.Background(), &types.Rule{
 		Resource:             "bitcoin.btc.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -146,13 +158,13 @@ func TestBtc_Evaluate_MinConstraints_ShouldFail(t *testing.T) {
 		"4772191",
 	)...)
 
-	err = NewBtc().Evaluate(&types.Rule{
+	err = NewBtc().Evaluate(context.Background(), &types.Rule{
 		Resource:             "bitcoin.btc.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
 	}, txBytes)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "min value constraint failed")
+	assert.Contains(t, err.Error(), "failed to compare min values")
 }
 
 func TestBtc_Evaluate_MaxConstraints(t *testing.T) {
@@ -171,7 +183,7 @@ func TestBtc_Evaluate_MaxConstraints(t *testing.T) {
 		"5000000",
 	)...) // max 5M, actual 4.77M
 
-	err = NewBtc().Evaluate(&types.Rule{
+	err = NewBtc().Evaluate(context.Background(), &types.Rule{
 		Resource:             "bitcoin.btc.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -195,13 +207,13 @@ func TestBtc_Evaluate_MaxConstraints_ShouldFail(t *testing.T) {
 		"4772191",
 	)...)
 
-	err = NewBtc().Evaluate(&types.Rule{
+	err = NewBtc().Evaluate(context.Background(), &types.Rule{
 		Resource:             "bitcoin.btc.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
 	}, txBytes)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "max value constraint failed")
+	assert.Contains(t, err.Error(), "failed to compare max values")
 }
 
 func TestBtc_Evaluate_WrongAddresses_ShouldFail(t *testing.T) {
@@ -222,13 +234,13 @@ func TestBtc_Evaluate_WrongAddresses_ShouldFail(t *testing.T) {
 		"4772191",
 	)...)
 
-	err = NewBtc().Evaluate(&types.Rule{
+	err = NewBtc().Evaluate(context.Background(), &types.Rule{
 		Resource:             "bitcoin.btc.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
 	}, txBytes)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "fixed value constraint failed")
+	assert.Contains(t, err.Error(), "failed to compare fixed values")
 }
 
 func TestBtc_Evaluate_WrongValues_ShouldFail(t *testing.T) {
@@ -249,13 +261,13 @@ func TestBtc_Evaluate_WrongValues_ShouldFail(t *testing.T) {
 		"4772191",
 	)...)
 
-	err = NewBtc().Evaluate(&types.Rule{
+	err = NewBtc().Evaluate(context.Background(), &types.Rule{
 		Resource:             "bitcoin.btc.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
 	}, txBytes)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "fixed value constraint failed")
+	assert.Contains(t, err.Error(), "failed to compare fixed values")
 }
 
 func TestBtc_Evaluate_MismatchedOutputCounts_ShouldFail(t *testing.T) {
@@ -271,7 +283,7 @@ func TestBtc_Evaluate_MismatchedOutputCounts_ShouldFail(t *testing.T) {
 		"1000000",
 	)...)
 
-	err = NewBtc().Evaluate(&types.Rule{
+	err = NewBtc().Evaluate(context.Background(), &types.Rule{
 		Resource:             "bitcoin.btc.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -290,7 +302,7 @@ func TestBtc_Evaluate_InvalidTxBytes_ShouldFail(t *testing.T) {
 		"447175",
 	)...)
 
-	err := NewBtc().Evaluate(&types.Rule{
+	err := NewBtc().Evaluate(context.Background(), &types.Rule{
 		Resource:             "bitcoin.btc.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -337,7 +349,7 @@ func TestBtc_Evaluate_RegexpConstraints(t *testing.T) {
 		"4772191",
 	)...)
 
-	err = NewBtc().Evaluate(&types.Rule{
+	err = NewBtc().Evaluate(context.Background(), &types.Rule{
 		Resource:             "bitcoin.btc.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -361,7 +373,7 @@ func TestBtc_Evaluate_RegexpConstraints_ShouldFail(t *testing.T) {
 		"4772191",
 	)...)
 
-	err = NewBtc().Evaluate(&types.Rule{
+	err = NewBtc().Evaluate(context.Background(), &types.Rule{
 		Resource:             "bitcoin.btc.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -392,7 +404,7 @@ func TestBtc_Evaluate_DataRegexpConstraints(t *testing.T) {
 		"^=:e:0x86d526d6624AbC0178cF7296cD538Ecc080A95F1:.",
 	)...)
 
-	err := NewBtc().Evaluate(&types.Rule{
+	err := NewBtc().Evaluate(context.Background(), &types.Rule{
 		Resource:             "bitcoin.btc.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -409,7 +421,7 @@ func TestBtc_Evaluate_DataRegexpConstraints_ShouldFail(t *testing.T) {
 		"^=:e:0x86d526d6624AbC0178cF7296cD538Ecc08088888:.",
 	)...)
 
-	err := NewBtc().Evaluate(&types.Rule{
+	err := NewBtc().Evaluate(context.Background(), &types.Rule{
 		Resource:             "bitcoin.btc.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -449,3 +461,559 @@ func createOpReturnTransaction(t *testing.T, data string) []byte {
 
 	return buf.Bytes()
 }
+
+func TestBtc_ParameterValue(t *testing.T) {
+	txBytes, err := hex.DecodeString(testTxHex)
+	assert.NoError(t, err)
+
+	v, err := NewBtc().ParameterValue(nil, txBytes, "output_value_1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4772191), v.Int64())
+
+	_, err = NewBtc().ParameterValue(nil, txBytes, "output_address_1")
+	assert.Error(t, err)
+
+	_, err = NewBtc().ParameterValue(nil, txBytes, "output_value_2")
+	assert.Error(t, err)
+}
+
+type psbtOutput struct {
+	address string
+	value   int64
+}
+
+// buildPSBT builds the serialized PSBT spending witness UTXOs of the inputs to the outputs
+func buildPSBT(t *testing.T, inputs, outputs []psbtOutput) []byte {
+	t.Helper()
+	return serializePSBT(t, buildPacket(t, inputs, outputs))
+}
+
+func buildPacket(t *testing.T, inputs, outputs []psbtOutput) *psbt.Packet {
+	t.Helper()
+
+	script := func(addr string) []byte {
+		decoded, err := btcutil.DecodeAddress(addr, &chaincfg.MainNetParams)
+		assert.NoError(t, err)
+		pkScript, err := txscript.PayToAddrScript(decoded)
+		assert.NoError(t, err)
+		return pkScript
+	}
+
+	outPoints := make([]*wire.OutPoint, 0, len(inputs))
+	sequences := make([]uint32, 0, len(inputs))
+	for i := range inputs {
+		outPoints = append(outPoints, wire.NewOutPoint(&chainhash.Hash{byte(i + 1)}, uint32(i)))
+		sequences = append(sequences, wire.MaxTxInSequenceNum)
+	}
+	txOuts := make([]*wire.TxOut, 0, len(outputs))
+	for _, out := range outputs {
+		txOuts = append(txOuts, wire.NewTxOut(out.value, script(out.address)))
+	}
+
+	packet, err := psbt.New(outPoints, txOuts, 2, 0, sequences)
+	assert.NoError(t, err)
+	for i, in := range inputs {
+		packet.Inputs[i].WitnessUtxo = wire.NewTxOut(in.value, script(in.address))
+	}
+	return packet
+}
+
+func serializePSBT(t *testing.T, packet *psbt.Packet) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	assert.NoError(t, packet.Serialize(&buf))
+	return buf.Bytes()
+}
+
+func paramConstraint(name string, typ types.ConstraintType, value string) *types.ParameterConstraint {
+	c := &types.Constraint{Type: typ}
+	switch typ {
+	case types.ConstraintType_CONSTRAINT_TYPE_FIXED:
+		c.Value = &types.Constraint_FixedValue{FixedValue: value}
+	case types.ConstraintType_CONSTRAINT_TYPE_MAX:
+		c.Value = &types.Constraint_MaxValue{MaxValue: value}
+	case types.ConstraintType_CONSTRAINT_TYPE_IN_SET:
+		c.Value = &types.Constraint_SetValue{SetValue: &types.SetValue{Values: strings.Split(value, ",")}}
+	}
+	return &types.ParameterConstraint{ParameterName: name, Constraint: c}
+}
+
+func TestBtc_Evaluate_InputsAndChange(t *testing.T) {
+	const (
+		vault     = "bc1ql5624ufxtk67zlkr42rzh4pqlkfqpgfh220msa"
+		recipient = "bc1qw5alzf5pu2hlnmn429jqq54qd9dvf2a2jjvvv0"
+		foreign   = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+	)
+
+	rule := func(extra ...*types.ParameterConstraint) *types.Rule {
+		params := newFixed(0, recipient, "1000000")
+		params = append(params,
+			paramConstraint(utxo.ChangeAddress, types.ConstraintType_CONSTRAINT_TYPE_FIXED, vault),
+			paramConstraint(utxo.InputAddress, types.ConstraintType_CONSTRAINT_TYPE_FIXED, vault),
+			paramConstraint(utxo.InputCount, types.ConstraintType_CONSTRAINT_TYPE_MAX, "2"),
+			paramConstraint(utxo.Fee, types.ConstraintType_CONSTRAINT_TYPE_MAX, "5000"),
+		)
+		return &types.Rule{
+			Resource:             "bitcoin.btc.transfer",
+			Effect:               types.Effect_EFFECT_ALLOW,
+			ParameterConstraints: append(params, extra...),
+		}
+	}
+	ctx := context.Background()
+
+	// change at any position, with any value
+	txBytes := buildPSBT(t,
+		[]psbtOutput{{vault, 3_000_000}},
+		[]psbtOutput{{recipient, 1_000_000}, {vault, 1_996_000}},
+	)
+	assert.NoError(t, NewBtc().Evaluate(ctx, rule(), txBytes))
+
+	fee, err := NewBtc().ParameterValue(nil, txBytes, utxo.Fee)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4000), fee.Int64())
+
+	// no change output
+	txBytes = buildPSBT(t,
+		[]psbtOutput{{vault, 1_004_000}},
+		[]psbtOutput{{recipient, 1_000_000}},
+	)
+	assert.NoError(t, NewBtc().Evaluate(ctx, rule(), txBytes))
+
+	// unconstrained output which is not change
+	txBytes = buildPSBT(t,
+		[]psbtOutput{{vault, 3_000_000}},
+		[]psbtOutput{{recipient, 1_000_000}, {foreign, 1_996_000}},
+	)
+	err = NewBtc().Evaluate(ctx, rule(), txBytes)
+	assert.ErrorContains(t, err, "missing constraints for output 1")
+
+	// foreign input
+	txBytes = buildPSBT(t,
+		[]psbtOutput{{vault, 1_000_000}, {foreign, 2_000_000}},
+		[]psbtOutput{{recipient, 1_000_000}, {vault, 1_996_000}},
+	)
+	err = NewBtc().Evaluate(ctx, rule(), txBytes)
+	assert.ErrorContains(t, err, "input 1 address validation failed")
+
+	// too many inputs
+	txBytes = buildPSBT(t,
+		[]psbtOutput{{vault, 1_000_000}, {vault, 1_000_000}, {vault, 1_000_000}},
+		[]psbtOutput{{recipient, 1_000_000}, {vault, 1_996_000}},
+	)
+	err = NewBtc().Evaluate(ctx, rule(), txBytes)
+	assert.ErrorContains(t, err, "input count validation failed")
+
+	// fee over the ceiling
+	txBytes = buildPSBT(t,
+		[]psbtOutput{{vault, 3_000_000}},
+		[]psbtOutput{{recipient, 1_000_000}, {vault, 1_990_000}},
+	)
+	err = NewBtc().Evaluate(ctx, rule(), txBytes)
+	assert.ErrorContains(t, err, "fee validation failed")
+
+	// fee can't be computed without input utxos
+	raw, err := hex.DecodeString(testTxHex)
+	assert.NoError(t, err)
+	err = NewBtc().Evaluate(ctx, rule(), raw)
+	assert.ErrorContains(t, err, "input utxos are only known for PSBTs")
+}
+
+func TestBtc_Evaluate_PSBT(t *testing.T) {
+	const (
+		vault     = "bc1ql5624ufxtk67zlkr42rzh4pqlkfqpgfh220msa"
+		recipient = "bc1qw5alzf5pu2hlnmn429jqq54qd9dvf2a2jjvvv0"
+	)
+
+	rule := func(extra ...*types.ParameterConstraint) *types.Rule {
+		params := newFixed(0, recipient, "1000000")
+		params = append(params,
+			paramConstraint(utxo.ChangeAddress, types.ConstraintType_CONSTRAINT_TYPE_FIXED, vault),
+			paramConstraint(utxo.Fee, types.ConstraintType_CONSTRAINT_TYPE_MAX, "5000"),
+		)
+		return &types.Rule{
+			Resource:             "bitcoin.btc.transfer",
+			Effect:               types.Effect_EFFECT_ALLOW,
+			ParameterConstraints: append(params, extra...),
+		}
+	}
+	packet := func() *psbt.Packet {
+		return buildPacket(t,
+			[]psbtOutput{{vault, 3_000_000}},
+			[]psbtOutput{{recipient, 1_000_000}, {vault, 1_996_000}},
+		)
+	}
+	ctx := context.Background()
+
+	// fee 4000 sat, estimated vsize 141 vB
+	txBytes := serializePSBT(t, packet())
+	rate, err := NewBtc().ParameterValue(nil, txBytes, utxo.FeeRate)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(28), rate.Int64())
+
+	feeRate := paramConstraint(utxo.FeeRate, types.ConstraintType_CONSTRAINT_TYPE_MAX, "30")
+	assert.NoError(t, NewBtc().Evaluate(ctx, rule(feeRate), txBytes))
+
+	feeRate = paramConstraint(utxo.FeeRate, types.ConstraintType_CONSTRAINT_TYPE_MAX, "20")
+	err = NewBtc().Evaluate(ctx, rule(feeRate), txBytes)
+	assert.ErrorContains(t, err, "fee rate validation failed")
+
+	// sighash all is allowed by default
+	p := packet()
+	p.Inputs[0].SighashType = txscript.SigHashAll
+	assert.NoError(t, NewBtc().Evaluate(ctx, rule(), serializePSBT(t, p)))
+
+	// sighash types signing part of the tx are rejected by default
+	p = packet()
+	p.Inputs[0].SighashType = txscript.SigHashNone
+	err = NewBtc().Evaluate(ctx, rule(), serializePSBT(t, p))
+	assert.ErrorContains(t, err, "input 0 sighash type is not allowed: none")
+
+	p = packet()
+	p.Inputs[0].SighashType = txscript.SigHashAll | txscript.SigHashAnyOneCanPay
+	txBytes = serializePSBT(t, p)
+	err = NewBtc().Evaluate(ctx, rule(), txBytes)
+	assert.ErrorContains(t, err, "input 0 sighash type is not allowed: all|anyonecanpay")
+
+	// unless allowed by the constraint
+	sighash := paramConstraint(utxo.SighashType, types.ConstraintType_CONSTRAINT_TYPE_IN_SET, "all,all|anyonecanpay")
+	assert.NoError(t, NewBtc().Evaluate(ctx, rule(sighash), txBytes))
+
+	// partial signatures are checked too
+	key, err := btcec.NewPrivateKey()
+	assert.NoError(t, err)
+	sig := ecdsa.Sign(key, chainhash.DoubleHashB([]byte("tx")))
+	p = packet()
+	p.Inputs[0].PartialSigs = []*psbt.PartialSig{{
+		PubKey:    key.PubKey().SerializeCompressed(),
+		Signature: append(sig.Serialize(), byte(txscript.SigHashSingle)),
+	}}
+	err = NewBtc().Evaluate(ctx, rule(sighash), serializePSBT(t, p))
+	assert.ErrorContains(t, err, "input 0 sighash type validation failed")
+
+	// the witness utxo amount must match the previous tx
+	prevTx := wire.NewMsgTx(2)
+	prevTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{0xff}, 0), nil, nil))
+	prevTx.AddTxOut(wire.NewTxOut(2_000_000, p.Inputs[0].WitnessUtxo.PkScript))
+	p = packet()
+	p.UnsignedTx.TxIn[0].PreviousOutPoint = *wire.NewOutPoint(ptr(prevTx.TxHash()), 0)
+	p.Inputs[0].NonWitnessUtxo = prevTx
+	err = NewBtc().Evaluate(ctx, rule(), serializePSBT(t, p))
+	assert.ErrorContains(t, err, "input 0 witness utxo doesn't match the previous tx")
+
+	// the witness utxo alone isn't trusted for legacy inputs
+	const legacy = "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"
+	p = buildPacket(t,
+		[]psbtOutput{{legacy, 3_000_000}},
+		[]psbtOutput{{recipient, 1_000_000}, {vault, 1_996_000}},
+	)
+	_, err = NewBtc().ParameterValue(nil, serializePSBT(t, p), utxo.Fee)
+	assert.ErrorContains(t, err, "input 0 doesn't spend a witness output, its previous tx is required")
+
+	prevTx = wire.NewMsgTx(2)
+	prevTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{0xff}, 0), nil, nil))
+	prevTx.AddTxOut(wire.NewTxOut(3_000_000, p.Inputs[0].WitnessUtxo.PkScript))
+	p.UnsignedTx.TxIn[0].PreviousOutPoint = *wire.NewOutPoint(ptr(prevTx.TxHash()), 0)
+	p.Inputs[0].NonWitnessUtxo = prevTx
+	fee, err := NewBtc().ParameterValue(nil, serializePSBT(t, p), utxo.Fee)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4000), fee.Int64())
+
+	// sighash types are only known for PSBTs
+	raw, err := hex.DecodeString(testTxHex)
+	assert.NoError(t, err)
+	err = NewBtc().Evaluate(ctx, &types.Rule{
+		Resource: "bitcoin.btc.transfer",
+		Effect:   types.Effect_EFFECT_ALLOW,
+		ParameterConstraints: append(newFixed(0, recipient, "1000000"),
+			paramConstraint(utxo.ChangeAddress, types.ConstraintType_CONSTRAINT_TYPE_FIXED, vault),
+			sighash,
+		),
+	}, raw)
+	assert.ErrorContains(t, err, "sighash types are only known for PSBTs")
+}
+
+func TestBtc_ExtractTxBytes(t *testing.T) {
+	const (
+		vault     = "bc1ql5624ufxtk67zlkr42rzh4pqlkfqpgfh220msa"
+		recipient = "bc1qw5alzf5pu2hlnmn429jqq54qd9dvf2a2jjvvv0"
+	)
+
+	packet := buildPacket(t,
+		[]psbtOutput{{vault, 3_000_000}},
+		[]psbtOutput{{recipient, 1_000_000}, {vault, 1_996_000}},
+	)
+	encoded, err := packet.B64Encode()
+	assert.NoError(t, err)
+
+	// the unsigned tx, input utxos are unknown
+	txBytes, err := NewBtc().ExtractTxBytes(encoded)
+	assert.NoError(t, err)
+	var unsigned bytes.Buffer
+	assert.NoError(t, packet.UnsignedTx.Serialize(&unsigned))
+	assert.Equal(t, unsigned.Bytes(), txBytes)
+
+	_, err = NewBtc().ParameterValue(nil, txBytes, utxo.Fee)
+	assert.ErrorContains(t, err, "input utxos are only known for PSBTs")
+
+	// the whole PSBT
+	psbtBytes, err := NewBtc().ExtractPSBTBytes(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, serializePSBT(t, packet), psbtBytes)
+
+	fee, err := NewBtc().ParameterValue(nil, psbtBytes, utxo.Fee)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4000), fee.Int64())
+}
+
+type failingResolver struct{}
+
+func (failingResolver) Supports(types.MagicConstant) bool {
+	return true
+}
+
+func (failingResolver) Resolve(context.Context, types.MagicConstant, string, string) (string, string, error) {
+	return "", "", errors.New("resolver is down")
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestBtc_Evaluate_UnorderedOutputs(t *testing.T) {
+	const (
+		vault     = "bc1ql5624ufxtk67zlkr42rzh4pqlkfqpgfh220msa"
+		recipient = "bc1qw5alzf5pu2hlnmn429jqq54qd9dvf2a2jjvvv0"
+		foreign   = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+	)
+
+	rule := func(order string, outputs ...[]*types.ParameterConstraint) *types.Rule {
+		params := []*types.ParameterConstraint{
+			paramConstraint(utxo.ChangeAddress, types.ConstraintType_CONSTRAINT_TYPE_FIXED, vault),
+			paramConstraint(utxo.OutputOrder, types.ConstraintType_CONSTRAINT_TYPE_FIXED, order),
+		}
+		for _, output := range outputs {
+			params = append(params, output...)
+		}
+		return &types.Rule{
+			Resource:             "bitcoin.btc.transfer",
+			Effect:               types.Effect_EFFECT_ALLOW,
+			ParameterConstraints: params,
+		}
+	}
+	// memo in place of the first output
+	withMemo := func(outputs []psbtOutput) []byte {
+		packet := buildPacket(t, []psbtOutput{{vault, 3_000_000}}, outputs)
+		memo, err := txscript.NullDataScript([]byte("=:ETH.ETH:0x1234"))
+		assert.NoError(t, err)
+		packet.UnsignedTx.TxOut[0].PkScript = memo
+		packet.UnsignedTx.TxOut[0].Value = 0
+		return serializePSBT(t, packet)
+	}
+	ctx := context.Background()
+
+	// BIP69-like order: memo, change, recipient
+	txBytes := withMemo([]psbtOutput{{vault, 0}, {vault, 1_996_000}, {recipient, 1_000_000}})
+	swap := rule(utxo.OutputOrderAny, newFixed(0, recipient, "1000000"), newDataRegexp(1, "^=:ETH\\.ETH:"))
+	assert.NoError(t, NewBtc().Evaluate(ctx, swap, txBytes))
+
+	value, err := NewBtc().ParameterValue(swap, txBytes, "output_value_0")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1_000_000), value.Int64())
+
+	err = NewBtc().Evaluate(ctx, rule(utxo.OutputOrderStrict, newFixed(0, recipient, "1000000"), newDataRegexp(1, "^=:ETH\\.ETH:")), txBytes)
+	assert.ErrorContains(t, err, "missing constraints for output 2")
+
+	// no output for the expected one
+	err = NewBtc().Evaluate(ctx, rule(utxo.OutputOrderAny, newFixed(0, recipient, "2000000"), newDataRegexp(1, "^=:ETH\\.ETH:")), txBytes)
+	assert.ErrorContains(t, err, "no output matches expected output 0")
+	assert.ErrorContains(t, err, "output 2 value validation failed")
+
+	// two expected outputs with a single matching tx output
+	err = NewBtc().Evaluate(ctx, rule(utxo.OutputOrderAny, newFixed(0, recipient, "1000000"), newFixed(1, recipient, "1000000")), txBytes)
+	assert.ErrorContains(t, err, "expected output 1 only matches outputs [2], which are assigned to other expected outputs")
+
+	// output which is neither expected nor change
+	txBytes = withMemo([]psbtOutput{{vault, 0}, {foreign, 1_996_000}, {recipient, 1_000_000}})
+	err = NewBtc().Evaluate(ctx, swap, txBytes)
+	assert.ErrorContains(t, err, "outputs [1] match no expected output and are not change")
+
+	// the expected output is assigned to the tx output it matches, not to change
+	txBytes = buildPSBT(t,
+		[]psbtOutput{{vault, 3_000_000}},
+		[]psbtOutput{{vault, 1_000_000}, {vault, 1_996_000}},
+	)
+	consolidation := rule(utxo.OutputOrderAny, newFixed(0, vault, "1996000"))
+	assert.NoError(t, NewBtc().Evaluate(ctx, consolidation, txBytes))
+	value, err = NewBtc().ParameterValue(consolidation, txBytes, "output_value_0")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1_996_000), value.Int64())
+
+	err = NewBtc().Evaluate(ctx, rule("sorted", newFixed(0, vault, "1996000")), txBytes)
+	assert.ErrorContains(t, err, "unsupported output order: sorted")
+}
+
+func TestBtc_Evaluate_ScriptTypes(t *testing.T) {
+	const (
+		vault     = "bc1ql5624ufxtk67zlkr42rzh4pqlkfqpgfh220msa"
+		recipient = "bc1qw5alzf5pu2hlnmn429jqq54qd9dvf2a2jjvvv0"
+		// BIP86 test vector
+		taproot = "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"
+	)
+
+	rule := func(params ...*types.ParameterConstraint) *types.Rule {
+		return &types.Rule{
+			Resource: "bitcoin.btc.transfer",
+			Effect:   types.Effect_EFFECT_ALLOW,
+			ParameterConstraints: append(params,
+				paramConstraint(utxo.ChangeAddress, types.ConstraintType_CONSTRAINT_TYPE_FIXED, vault),
+			),
+		}
+	}
+	// recipient output paying to the script instead
+	withScript := func(script []byte) []byte {
+		packet := buildPacket(t,
+			[]psbtOutput{{vault, 3_000_000}},
+			[]psbtOutput{{recipient, 1_000_000}, {vault, 1_996_000}},
+		)
+		packet.UnsignedTx.TxOut[0].PkScript = script
+		return serializePSBT(t, packet)
+	}
+	outputType := func(typ types.ConstraintType, value string) *types.ParameterConstraint {
+		return paramConstraint("output_type_0", typ, value)
+	}
+	ctx := context.Background()
+
+	// taproot
+	txBytes := buildPSBT(t,
+		[]psbtOutput{{vault, 3_000_000}},
+		[]psbtOutput{{taproot, 1_000_000}, {vault, 1_996_000}},
+	)
+	params := append(newFixed(0, taproot, "1000000"), outputType(types.ConstraintType_CONSTRAINT_TYPE_FIXED, utxo.ScriptP2TR))
+	assert.NoError(t, NewBtc().Evaluate(ctx, rule(params...), txBytes))
+
+	params = append(newFixed(0, taproot, "1000000"), outputType(types.ConstraintType_CONSTRAINT_TYPE_FIXED, utxo.ScriptP2WPKH))
+	err := NewBtc().Evaluate(ctx, rule(params...), txBytes)
+	assert.ErrorContains(t, err, "output 0 type validation failed")
+
+	// bare multisig isn't a payment to the address of its first key
+	key, err := btcec.NewPrivateKey()
+	assert.NoError(t, err)
+	pubKey, err := btcutil.NewAddressPubKey(key.PubKey().SerializeCompressed(), &chaincfg.MainNetParams)
+	assert.NoError(t, err)
+	multisig, err := txscript.MultiSigScript([]*btcutil.AddressPubKey{pubKey}, 1)
+	assert.NoError(t, err)
+	txBytes = withScript(multisig)
+
+	err = NewBtc().Evaluate(ctx, rule(newFixed(0, pubKey.AddressPubKeyHash().EncodeAddress(), "1000000")...), txBytes)
+	assert.ErrorContains(t, err, "multisig script has no address")
+
+	// unless the rule explicitly allows the script type
+	params = append(newFixed(0, recipient, "1000000")[1:], outputType(types.ConstraintType_CONSTRAINT_TYPE_FIXED, utxo.ScriptMultisig))
+	assert.NoError(t, NewBtc().Evaluate(ctx, rule(params...), txBytes))
+
+	// nor change
+	err = NewBtc().Evaluate(ctx, &types.Rule{
+		Resource: "bitcoin.btc.transfer",
+		Effect:   types.Effect_EFFECT_ALLOW,
+		ParameterConstraints: append(newFixed(1, vault, "1996000"),
+			paramConstraint(utxo.ChangeAddress, types.ConstraintType_CONSTRAINT_TYPE_FIXED, pubKey.AddressPubKeyHash().EncodeAddress()),
+		),
+	}, txBytes)
+	assert.ErrorContains(t, err, "missing constraints for output 0")
+
+	// unknown witness versions and non-standard scripts are rejected
+	witnessV2, err := txscript.NewScriptBuilder().AddOp(txscript.OP_2).AddData(make([]byte, 32)).Script()
+	assert.NoError(t, err)
+	err = NewBtc().Evaluate(ctx, rule(newFixed(0, recipient, "1000000")...), withScript(witnessV2))
+	assert.ErrorContains(t, err, "witness_unknown script has no address")
+
+	anyoneCanSpend := []byte{txscript.OP_TRUE}
+	err = NewBtc().Evaluate(ctx, rule(newFixed(0, recipient, "1000000")...), withScript(anyoneCanSpend))
+	assert.ErrorContains(t, err, "nonstandard script has no address")
+
+	params = append(newFixed(0, recipient, "1000000")[1:],
+		outputType(types.ConstraintType_CONSTRAINT_TYPE_IN_SET, "multisig,p2pk"))
+	err = NewBtc().Evaluate(ctx, rule(params...), withScript(anyoneCanSpend))
+	assert.ErrorContains(t, err, "output 0 type validation failed")
+
+	// outputs of address types need the address, the type and value would allow any recipient
+	params = append(newFixed(0, recipient, "1000000")[1:],
+		outputType(types.ConstraintType_CONSTRAINT_TYPE_IN_SET, "p2wpkh,p2tr"))
+	err = NewBtc().Evaluate(ctx, rule(params...), withScript(anyoneCanSpend))
+	assert.ErrorContains(t, err, "output 0 type allows scripts with an address, output_address_0 is required")
+
+	params = append(newFixed(0, recipient, "1000000")[1:],
+		outputType(types.ConstraintType_CONSTRAINT_TYPE_FIXED, utxo.ScriptP2WPKH))
+	err = NewBtc().Evaluate(ctx, rule(params...), withScript(nil))
+	assert.ErrorContains(t, err, "output_address_0 is required")
+
+	params = append(newFixed(0, recipient, "1000000")[1:],
+		outputType(types.ConstraintType_CONSTRAINT_TYPE_ANY, ""))
+	err = NewBtc().Evaluate(ctx, rule(params...), txBytes)
+	assert.ErrorContains(t, err, "output_address_0 is required")
+}
+
+func TestBtc_Match_PartialOutputs(t *testing.T) {
+	const (
+		vault   = "bc1ql5624ufxtk67zlkr42rzh4pqlkfqpgfh220msa"
+		blocked = "bc1qw5alzf5pu2hlnmn429jqq54qd9dvf2a2jjvvv0"
+		other   = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+	)
+
+	deny := func(params ...*types.ParameterConstraint) *types.Rule {
+		return &types.Rule{
+			Resource:             "bitcoin.btc.transfer",
+			Effect:               types.Effect_EFFECT_DENY,
+			ParameterConstraints: params,
+		}
+	}
+	anyOutputTo := func(address string) *types.Rule {
+		return deny(
+			paramConstraint(utxo.OutputOrder, types.ConstraintType_CONSTRAINT_TYPE_FIXED, utxo.OutputOrderAny),
+			paramConstraint("output_address_0", types.ConstraintType_CONSTRAINT_TYPE_FIXED, address),
+		)
+	}
+	ctx := context.Background()
+
+	// recipient, change and a foreign output, none of which is constrained by the rule but the one
+	txBytes := buildPSBT(t,
+		[]psbtOutput{{vault, 3_000_000}},
+		[]psbtOutput{{other, 500_000}, {vault, 1_496_000}, {blocked, 1_000_000}},
+	)
+
+	assert.NoError(t, NewBtc().Match(ctx, anyOutputTo(blocked), txBytes))
+	assert.NoError(t, NewBtc().Match(ctx, deny(paramConstraint("output_address_2", types.ConstraintType_CONSTRAINT_TYPE_FIXED, blocked)), txBytes))
+	assert.NoError(t, NewBtc().Match(ctx, deny(paramConstraint("output_value_0", types.ConstraintType_CONSTRAINT_TYPE_MAX, "600000")), txBytes))
+
+	err := NewBtc().Match(ctx, anyOutputTo("bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"), txBytes)
+	assert.ErrorContains(t, err, "no output matches expected output 0")
+	assert.True(t, compare.IsMismatch(err))
+
+	err = NewBtc().Match(ctx, deny(paramConstraint("output_address_3", types.ConstraintType_CONSTRAINT_TYPE_FIXED, blocked)), txBytes)
+	assert.ErrorContains(t, err, "rule has constraints for output 3, tx has 3 outputs")
+	assert.True(t, compare.IsMismatch(err))
+
+	// an output address which can't be resolved fails the deny rule rather than missing it
+	registry := &resolver.MagicConstantRegistry{}
+	registry.Register(failingResolver{})
+	btc := NewBtc()
+	btc.SetMagicConstantRegistry(registry)
+	err = btc.Match(ctx, deny(
+		paramConstraint(utxo.OutputOrder, types.ConstraintType_CONSTRAINT_TYPE_FIXED, utxo.OutputOrderAny),
+		&types.ParameterConstraint{
+			ParameterName: "output_address_0",
+			Constraint: &types.Constraint{
+				Type:  types.ConstraintType_CONSTRAINT_TYPE_MAGIC_CONSTANT,
+				Value: &types.Constraint_MagicConstantValue{MagicConstantValue: types.MagicConstant_THORCHAIN_VAULT},
+			},
+		},
+	), txBytes)
+	assert.ErrorContains(t, err, "failed to resolve magic const")
+	assert.False(t, compare.IsMismatch(err))
+
+	// allow rules must still constrain every output
+	allow := anyOutputTo(blocked)
+	allow.Effect = types.Effect_EFFECT_ALLOW
+	assert.ErrorContains(t, NewBtc().Evaluate(ctx, allow, txBytes), "output 0 must have either data constraint or both address and value constraints")
+}
@@ -2,6 +2,7 @@ package bitcoincash
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"testing"
 
@@ -165,7 +166,7 @@ func TestBitcoinCash_Evaluate_Fixed(t *testing.T) {
 	params = append(params, newFixed(0, "qpmmlusvvrjj9ha2jyltqy3ldvllwcxskqny0z09tk", "1000000")...)
 	params = append(params, newFixed(1, "qzyvaccaw8mr8c9m5f8vzg6w038wsu0pcyaz4fjwuk", "500000")...)
 
-	err := NewBitcoinCash().Evaluate(&types.Rule{
+	err := NewBitcoinCash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "bitcoin-cash.bch.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -184,7 +185,7 @@ func TestBitcoinCash_Evaluate_MaxConstraints(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newMax(0, "qpmmlusvvrjj9ha2jyltqy3ldvllwcxskqny0z09tk", "2000000")...) // max 2M, actual 1M
 
-	err := NewBitcoinCash().Evaluate(&types.Rule{
+	err := NewBitcoinCash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "bitcoin-cash.bch.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -203,13 +204,13 @@ func TestBitcoinCash_Evaluate_MaxConstraints_ShouldFail(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newMax(0, "qpmmlusvvrjj9ha2jyltqy3ldvllwcxskqny0z09tk", "500000")...) // max 500k, actual 1M - should fail
 
-	err := NewBitcoinCash().Evaluate(&types.Rule{
+	err := NewBitcoinCash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "bitcoin-cash.bch.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
 	}, txBytes)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "max value constraint failed")
+	assert.Contains(t, err.Error(), "failed to compare max values")
 }
 
 func TestBitcoinCash_Evaluate_MinConstraints(t *testing.T) {
@@ -223,7 +224,7 @@ func TestBitcoinCash_Evaluate_MinConstraints(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newMin(0, "qpmmlusvvrjj9ha2jyltqy3ldvllwcxskqny0z09tk", "500000")...) // min 500k, actual 1M
 
-	err := NewBitcoinCash().Evaluate(&types.Rule{
+	err := NewBitcoinCash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "bitcoin-cash.bch.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -242,13 +243,13 @@ func TestBitcoinCash_Evaluate_MinConstraints_ShouldFail(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newMin(0, "qpmmlusvvrjj9ha2jyltqy3ldvllwcxskqny0z09tk", "2000000")...) // min 2M, actual 1M - should fail
 
-	err := NewBitcoinCash().Evaluate(&types.Rule{
+	err := NewBitcoinCash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "bitcoin-cash.bch.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
 	}, txBytes)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "min value constraint failed")
+	assert.Contains(t, err.Error(), "failed to compare min values")
 }
 
 func TestBitcoinCash_Evaluate_WrongAddress_ShouldFail(t *testing.T) {
@@ -262,13 +263,13 @@ func TestBitcoinCash_Evaluate_WrongAddress_ShouldFail(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newFixed(0, "qwrongaddressxxxxxxxxxxxxxxxxxxxxxxxxx", "1000000")...)
 
-	err := NewBitcoinCash().Evaluate(&types.Rule{
+	err := NewBitcoinCash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "bitcoin-cash.bch.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
 	}, txBytes)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "fixed value constraint failed")
+	assert.Contains(t, err.Error(), "failed to compare fixed values")
 }
 
 func TestBitcoinCash_Evaluate_MismatchedOutputCounts_ShouldFail(t *testing.T) {
@@ -284,7 +285,7 @@ func TestBitcoinCash_Evaluate_MismatchedOutputCounts_ShouldFail(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newFixed(0, "qpmmlusvvrjj9ha2jyltqy3ldvllwcxskqny0z09tk", "1000000")...)
 
-	err := NewBitcoinCash().Evaluate(&types.Rule{
+	err := NewBitcoinCash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "bitcoin-cash.bch.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -299,7 +300,7 @@ func TestBitcoinCash_Evaluate_InvalidTxBytes_ShouldFail(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newFixed(0, "qpmmlusvvrjj9ha2jyltqy3ldvllwcxskqny0z09tk", "1000000")...)
 
-	err := NewBitcoinCash().Evaluate(&types.Rule{
+	err := NewBitcoinCash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "bitcoin-cash.bch.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -364,7 +365,7 @@ func TestBitcoinCash_Evaluate_DataRegexpConstraints(t *testing.T) {
 		"^=:e:0x86d526d6624AbC0178cF7296cD538Ecc080A95F1:.",
 	)...)
 
-	err := NewBitcoinCash().Evaluate(&types.Rule{
+	err := NewBitcoinCash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "bitcoin-cash.bch.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -381,7 +382,7 @@ func TestBitcoinCash_Evaluate_DataRegexpConstraints_ShouldFail(t *testing.T) {
 		"^=:e:0x86d526d6624AbC0178cF7296cD538Ecc08088888:.",
 	)...)
 
-	err := NewBitcoinCash().Evaluate(&types.Rule{
+	err := NewBitcoinCash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "bitcoin-cash.bch.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
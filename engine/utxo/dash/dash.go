@@ -1,8 +1,12 @@
 package dash
 
 import (
+	"context"
+	"math/big"
+
 	chaindash "github.com/vultisig/recipes/chain/utxo/dash"
 	"github.com/vultisig/recipes/engine/utxo"
+	"github.com/vultisig/recipes/resolver"
 	"github.com/vultisig/recipes/types"
 	"github.com/vultisig/vultisig-go/common"
 )
@@ -29,11 +33,36 @@ func (d *Dash) Supports(chain common.Chain) bool {
 }
 
 // Evaluate validates a transaction against the given rule.
-func (d *Dash) Evaluate(rule *types.Rule, txBytes []byte) error {
-	return d.engine.Evaluate(rule, txBytes)
+func (d *Dash) Evaluate(ctx context.Context, rule *types.Rule, txBytes []byte) error {
+	return d.engine.Evaluate(ctx, rule, txBytes)
+}
+
+// Match checks the transaction against the given rule regardless of its effect.
+func (d *Dash) Match(ctx context.Context, rule *types.Rule, txBytes []byte) error {
+	return d.engine.Match(ctx, rule, txBytes)
+}
+
+// ParameterValue returns the numeric value of the named rule parameter in the transaction.
+func (d *Dash) ParameterValue(rule *types.Rule, txBytes []byte, name string) (*big.Int, error) {
+	return d.engine.ParameterValue(rule, txBytes, name)
+}
+
+// ParameterAsset returns the asset of the named numeric rule parameter, "" for the native asset.
+func (d *Dash) ParameterAsset(rule *types.Rule, txBytes []byte, name string) (string, error) {
+	return d.engine.ParameterAsset(rule, txBytes, name)
+}
+
+// SetMagicConstantRegistry sets the registry used to resolve magic constants.
+func (d *Dash) SetMagicConstantRegistry(registry *resolver.MagicConstantRegistry) {
+	d.engine.SetMagicConstantRegistry(registry)
 }
 
 // ExtractTxBytes extracts transaction bytes from a PSBT string.
 func (d *Dash) ExtractTxBytes(txData string) ([]byte, error) {
 	return d.engine.ExtractTxBytes(txData)
 }
+
+// ExtractPSBTBytes extracts the serialized PSBT, with input UTXOs and sighash types, from a PSBT string.
+func (d *Dash) ExtractPSBTBytes(txData string) ([]byte, error) {
+	return d.engine.ExtractPSBTBytes(txData)
+}
@@ -2,6 +2,7 @@ package dogecoin
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"testing"
 
@@ -162,7 +163,7 @@ func TestDogecoin_Evaluate_Fixed(t *testing.T) {
 	params = append(params, newFixed(0, "DG4GthBCBJQwRtGGcQFuSy7EznJsJxU53W", "100000000000")...)
 	params = append(params, newFixed(1, "DHcUH7uKpNCHeiquAXtcYmCPmVX2JNE1qp", "50000000000")...)
 
-	err := NewDogecoin().Evaluate(&types.Rule{
+	err := NewDogecoin().Evaluate(context.Background(), &types.Rule{
 		Resource:             "dogecoin.doge.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -181,7 +182,7 @@ func TestDogecoin_Evaluate_MaxConstraints(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newMax(0, "DG4GthBCBJQwRtGGcQFuSy7EznJsJxU53W", "200000000000")...) // max 2000 DOGE
 
-	err := NewDogecoin().Evaluate(&types.Rule{
+	err := NewDogecoin().Evaluate(context.Background(), &types.Rule{
 		Resource:             "dogecoin.doge.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -200,13 +201,13 @@ func TestDogecoin_Evaluate_MaxConstraints_ShouldFail(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newMax(0, "DG4GthBCBJQwRtGGcQFuSy7EznJsJxU53W", "50000000000")...) // max 500 DOGE - should fail
 
-	err := NewDogecoin().Evaluate(&types.Rule{
+	err := NewDogecoin().Evaluate(context.Background(), &types.Rule{
 		Resource:             "dogecoin.doge.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
 	}, txBytes)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "max value constraint failed")
+	assert.Contains(t, err.Error(), "failed to compare max values")
 }
 
 func TestDogecoin_Evaluate_MinConstraints(t *testing.T) {
@@ -220,7 +221,7 @@ func TestDogecoin_Evaluate_MinConstraints(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newMin(0, "DG4GthBCBJQwRtGGcQFuSy7EznJsJxU53W", "50000000000")...) // min 500 DOGE
 
-	err := NewDogecoin().Evaluate(&types.Rule{
+	err := NewDogecoin().Evaluate(context.Background(), &types.Rule{
 		Resource:             "dogecoin.doge.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -239,13 +240,13 @@ func TestDogecoin_Evaluate_MinConstraints_ShouldFail(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newMin(0, "DG4GthBCBJQwRtGGcQFuSy7EznJsJxU53W", "200000000000")...) // min 2000 DOGE - should fail
 
-	err := NewDogecoin().Evaluate(&types.Rule{
+	err := NewDogecoin().Evaluate(context.Background(), &types.Rule{
 		Resource:             "dogecoin.doge.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
 	}, txBytes)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "min value constraint failed")
+	assert.Contains(t, err.Error(), "failed to compare min values")
 }
 
 func TestDogecoin_Evaluate_WrongAddress_ShouldFail(t *testing.T) {
@@ -259,13 +260,13 @@ func TestDogecoin_Evaluate_WrongAddress_ShouldFail(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newFixed(0, "DWrongAddressXXXXXXXXXXXXXXXXX", "100000000000")...)
 
-	err := NewDogecoin().Evaluate(&types.Rule{
+	err := NewDogecoin().Evaluate(context.Background(), &types.Rule{
 		Resource:             "dogecoin.doge.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
 	}, txBytes)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "fixed value constraint failed")
+	assert.Contains(t, err.Error(), "failed to compare fixed values")
 }
 
 func TestDogecoin_Evaluate_MismatchedOutputCounts_ShouldFail(t *testing.T) {
@@ -281,7 +282,7 @@ func TestDogecoin_Evaluate_MismatchedOutputCounts_ShouldFail(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newFixed(0, "DG4GthBCBJQwRtGGcQFuSy7EznJsJxU53W", "100000000000")...)
 
-	err := NewDogecoin().Evaluate(&types.Rule{
+	err := NewDogecoin().Evaluate(context.Background(), &types.Rule{
 		Resource:             "dogecoin.doge.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -296,7 +297,7 @@ func TestDogecoin_Evaluate_InvalidTxBytes_ShouldFail(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newFixed(0, "DG4GthBCBJQwRtGGcQFuSy7EznJsJxU53W", "100000000000")...)
 
-	err := NewDogecoin().Evaluate(&types.Rule{
+	err := NewDogecoin().Evaluate(context.Background(), &types.Rule{
 		Resource:             "dogecoin.doge.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -361,7 +362,7 @@ func TestDogecoin_Evaluate_DataRegexpConstraints(t *testing.T) {
 		"^=:e:0x86d526d6624AbC0178cF7296cD538Ecc080A95F1:.",
 	)...)
 
-	err := NewDogecoin().Evaluate(&types.Rule{
+	err := NewDogecoin().Evaluate(context.Background(), &types.Rule{
 		Resource:             "dogecoin.doge.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -378,7 +379,7 @@ func TestDogecoin_Evaluate_DataRegexpConstraints_ShouldFail(t *testing.T) {
 		"^=:e:0x86d526d6624AbC0178cF7296cD538Ecc08088888:.",
 	)...)
 
-	err := NewDogecoin().Evaluate(&types.Rule{
+	err := NewDogecoin().Evaluate(context.Background(), &types.Rule{
 		Resource:             "dogecoin.doge.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
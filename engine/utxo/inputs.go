@@ -0,0 +1,190 @@
+package utxo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/vultisig/recipes/engine/compare"
+	"github.com/vultisig/recipes/types"
+)
+
+// Tx-level parameters, checked in addition to the output_* constraints
+const (
+	// InputAddress constrains the source address of every input, e.g. FIXED to the vault address
+	// rejects txs spending foreign inputs. Requires a PSBT with input UTXOs
+	InputAddress = "input_address"
+	// InputCount constrains the number of inputs
+	InputCount = "input_count"
+	// ChangeAddress marks outputs paying to the address as change back to the vault: they need no
+	// output_* constraints and are accepted at any position with any value
+	ChangeAddress = "change_address"
+	// Fee constrains the total fee, inputs minus outputs. Requires a PSBT with input UTXOs
+	Fee = "fee"
+)
+
+var psbtMagic = []byte{0x70, 0x73, 0x62, 0x74, 0xff}
+
+func isTxParameter(name string) bool {
+	switch name {
+	case InputAddress, InputCount, ChangeAddress, Fee, SighashType, FeeRate, OutputOrder:
+		return true
+	default:
+		return false
+	}
+}
+
+// txParameters returns tx-level constraints of the rule by parameter name
+func txParameters(rule *types.Rule) map[string]*types.ParameterConstraint {
+	txConstraints := make(map[string]*types.ParameterConstraint)
+	for _, constraint := range rule.GetParameterConstraints() {
+		if isTxParameter(constraint.GetParameterName()) {
+			txConstraints[constraint.GetParameterName()] = constraint
+		}
+	}
+	return txConstraints
+}
+
+// parse decodes the raw tx or the PSBT, the packet is nil for raw txs
+func (e *Engine) parse(txBytes []byte) (*wire.MsgTx, *psbt.Packet, error) {
+	if !bytes.HasPrefix(txBytes, psbtMagic) {
+		tx, err := e.parseTx(txBytes)
+		return tx, nil, err
+	}
+
+	packet, err := psbt.NewFromRawBytes(bytes.NewReader(txBytes), false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse PSBT: %w", err)
+	}
+	return packet.UnsignedTx, packet, nil
+}
+
+// inputUtxo returns the output spent by the PSBT input, from the full previous tx or the witness UTXO.
+// If the PSBT has both they must match, so the signer can't be tricked into a higher fee by a fake witness amount.
+// The witness UTXO alone is only trusted for witness inputs, legacy inputs don't commit to the spent amount
+func inputUtxo(packet *psbt.Packet, i int) (*wire.TxOut, error) {
+	if packet == nil {
+		return nil, fmt.Errorf("input utxos are only known for PSBTs")
+	}
+
+	in := packet.Inputs[i]
+	prevOut := packet.UnsignedTx.TxIn[i].PreviousOutPoint
+	if in.NonWitnessUtxo != nil {
+		if in.NonWitnessUtxo.TxHash() != prevOut.Hash {
+			return nil, fmt.Errorf("input %d previous tx doesn't match the outpoint", i)
+		}
+		if int(prevOut.Index) >= len(in.NonWitnessUtxo.TxOut) {
+			return nil, fmt.Errorf("input %d previous output index out of range: %d", i, prevOut.Index)
+		}
+
+		utxo := in.NonWitnessUtxo.TxOut[prevOut.Index]
+		if in.WitnessUtxo != nil &&
+			(in.WitnessUtxo.Value != utxo.Value || !bytes.Equal(in.WitnessUtxo.PkScript, utxo.PkScript)) {
+			return nil, fmt.Errorf("input %d witness utxo doesn't match the previous tx: witness_value=%d, value=%d",
+				i, in.WitnessUtxo.Value, utxo.Value)
+		}
+		return utxo, nil
+	}
+	if in.WitnessUtxo != nil {
+		if !isWitnessInput(in) {
+			return nil, fmt.Errorf("input %d doesn't spend a witness output, its previous tx is required", i)
+		}
+		return in.WitnessUtxo, nil
+	}
+	return nil, fmt.Errorf("input %d has no utxo in the PSBT", i)
+}
+
+// isWitnessInput reports whether the witness UTXO of the input is a witness program or a P2SH-wrapped one
+func isWitnessInput(in psbt.PInput) bool {
+	script := in.WitnessUtxo.PkScript
+	if txscript.IsWitnessProgram(script) {
+		return true
+	}
+	if !txscript.IsPayToScriptHash(script) || !txscript.IsWitnessProgram(in.RedeemScript) {
+		return false
+	}
+	// OP_HASH160 <20-byte hash> OP_EQUAL
+	return bytes.Equal(script[2:22], btcutil.Hash160(in.RedeemScript))
+}
+
+// fee returns inputs minus outputs of the PSBT
+func fee(tx *wire.MsgTx, packet *psbt.Packet) (*big.Int, error) {
+	var total int64
+	for i := range tx.TxIn {
+		utxo, err := inputUtxo(packet, i)
+		if err != nil {
+			return nil, err
+		}
+		total += utxo.Value
+	}
+	for _, out := range tx.TxOut {
+		total -= out.Value
+	}
+	if total < 0 {
+		return nil, fmt.Errorf("outputs exceed inputs by %d", -total)
+	}
+	return big.NewInt(total), nil
+}
+
+// validateTxConstraints checks the input, fee and PSBT constraints of the rule
+func (e *Engine) validateTxConstraints(
+	ctx context.Context,
+	constraints map[string]*types.ParameterConstraint,
+	tx *wire.MsgTx,
+	packet *psbt.Packet,
+	partial bool,
+) error {
+	if c, ok := constraints[InputCount]; ok {
+		err := compare.AssertConstraint(ctx, e.resolvers, e.config.ChainID, c, big.NewInt(int64(len(tx.TxIn))), compare.NewBigInt)
+		if err != nil {
+			return fmt.Errorf("input count validation failed: %w", err)
+		}
+	}
+
+	if c, ok := constraints[InputAddress]; ok {
+		for i := range tx.TxIn {
+			utxo, err := inputUtxo(packet, i)
+			if err != nil {
+				return err
+			}
+			addr, err := e.extractAddress(utxo)
+			if err != nil {
+				return fmt.Errorf("failed to extract address from input %d: %w", i, err)
+			}
+			err = compare.AssertConstraint(ctx, e.resolvers, e.config.ChainID, c, addr, compare.NewString)
+			if err != nil {
+				return fmt.Errorf("input %d address validation failed: %w", i, err)
+			}
+		}
+	}
+
+	if c, ok := constraints[Fee]; ok {
+		actual, err := fee(tx, packet)
+		if err != nil {
+			return fmt.Errorf("failed to compute fee: %w", err)
+		}
+		err = compare.AssertConstraint(ctx, e.resolvers, e.config.ChainID, c, actual, compare.NewBigInt)
+		if err != nil {
+			return fmt.Errorf("fee validation failed: %w", err)
+		}
+	}
+	return e.validatePSBT(ctx, constraints, tx, packet, partial)
+}
+
+// isChange reports whether the output pays to the change address of the rule
+func (e *Engine) isChange(ctx context.Context, change *types.ParameterConstraint, txOut *wire.TxOut) bool {
+	if change == nil {
+		return false
+	}
+	addr, err := e.extractAddress(txOut)
+	if err != nil {
+		return false
+	}
+	return compare.AssertConstraint(ctx, e.resolvers, e.config.ChainID, change, addr, compare.NewString) == nil
+}
@@ -1,9 +1,13 @@
 package litecoin
 
 import (
+	"context"
+	"math/big"
+
 	"github.com/btcsuite/btcd/chaincfg"
 
 	"github.com/vultisig/recipes/engine/utxo"
+	"github.com/vultisig/recipes/resolver"
 	"github.com/vultisig/recipes/types"
 	"github.com/vultisig/vultisig-go/common"
 )
@@ -37,8 +41,28 @@ func (l *Litecoin) Supports(chain common.Chain) bool {
 }
 
 // Evaluate validates a transaction against the given rule.
-func (l *Litecoin) Evaluate(rule *types.Rule, txBytes []byte) error {
-	return l.engine.Evaluate(rule, txBytes)
+func (l *Litecoin) Evaluate(ctx context.Context, rule *types.Rule, txBytes []byte) error {
+	return l.engine.Evaluate(ctx, rule, txBytes)
+}
+
+// Match checks the transaction against the given rule regardless of its effect.
+func (l *Litecoin) Match(ctx context.Context, rule *types.Rule, txBytes []byte) error {
+	return l.engine.Match(ctx, rule, txBytes)
+}
+
+// ParameterValue returns the numeric value of the named rule parameter in the transaction.
+func (l *Litecoin) ParameterValue(rule *types.Rule, txBytes []byte, name string) (*big.Int, error) {
+	return l.engine.ParameterValue(rule, txBytes, name)
+}
+
+// ParameterAsset returns the asset of the named numeric rule parameter, "" for the native asset.
+func (l *Litecoin) ParameterAsset(rule *types.Rule, txBytes []byte, name string) (string, error) {
+	return l.engine.ParameterAsset(rule, txBytes, name)
+}
+
+// SetMagicConstantRegistry sets the registry used to resolve magic constants.
+func (l *Litecoin) SetMagicConstantRegistry(registry *resolver.MagicConstantRegistry) {
+	l.engine.SetMagicConstantRegistry(registry)
 }
 
 // ExtractTxBytes extracts transaction bytes from a PSBT string.
@@ -46,3 +70,8 @@ func (l *Litecoin) ExtractTxBytes(txData string) ([]byte, error) {
 	return l.engine.ExtractTxBytes(txData)
 }
 
+// ExtractPSBTBytes extracts the serialized PSBT, with input UTXOs and sighash types, from a PSBT string.
+func (l *Litecoin) ExtractPSBTBytes(txData string) ([]byte, error) {
+	return l.engine.ExtractPSBTBytes(txData)
+}
+
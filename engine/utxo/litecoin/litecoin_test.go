@@ -2,6 +2,7 @@ package litecoin
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"testing"
 
@@ -163,7 +164,7 @@ func TestLitecoin_Evaluate_Fixed(t *testing.T) {
 	params = append(params, newFixed(0, "ltc1qw7llyrrqu53dl25386cpy0mt8lmkp59sstm02h", "10000000")...)
 	params = append(params, newFixed(1, "ltc1q3r8wx8t37ce7pwazfmqjxnnufm58rcwpjnnsq7", "5000000")...)
 
-	err := NewLitecoin().Evaluate(&types.Rule{
+	err := NewLitecoin().Evaluate(context.Background(), &types.Rule{
 		Resource:             "litecoin.ltc.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -182,7 +183,7 @@ func TestLitecoin_Evaluate_MaxConstraints(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newMax(0, "ltc1qw7llyrrqu53dl25386cpy0mt8lmkp59sstm02h", "20000000")...) // max 0.2 LTC
 
-	err := NewLitecoin().Evaluate(&types.Rule{
+	err := NewLitecoin().Evaluate(context.Background(), &types.Rule{
 		Resource:             "litecoin.ltc.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -201,13 +202,13 @@ func TestLitecoin_Evaluate_MaxConstraints_ShouldFail(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newMax(0, "ltc1qw7llyrrqu53dl25386cpy0mt8lmkp59sstm02h", "5000000")...) // max 0.05 LTC - should fail
 
-	err := NewLitecoin().Evaluate(&types.Rule{
+	err := NewLitecoin().Evaluate(context.Background(), &types.Rule{
 		Resource:             "litecoin.ltc.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
 	}, txBytes)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "max value constraint failed")
+	assert.Contains(t, err.Error(), "failed to compare max values")
 }
 
 func TestLitecoin_Evaluate_MinConstraints(t *testing.T) {
@@ -221,7 +222,7 @@ func TestLitecoin_Evaluate_MinConstraints(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newMin(0, "ltc1qw7llyrrqu53dl25386cpy0mt8lmkp59sstm02h", "5000000")...) // min 0.05 LTC
 
-	err := NewLitecoin().Evaluate(&types.Rule{
+	err := NewLitecoin().Evaluate(context.Background(), &types.Rule{
 		Resource:             "litecoin.ltc.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -240,13 +241,13 @@ func TestLitecoin_Evaluate_MinConstraints_ShouldFail(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newMin(0, "ltc1qw7llyrrqu53dl25386cpy0mt8lmkp59sstm02h", "20000000")...) // min 0.2 LTC - should fail
 
-	err := NewLitecoin().Evaluate(&types.Rule{
+	err := NewLitecoin().Evaluate(context.Background(), &types.Rule{
 		Resource:             "litecoin.ltc.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
 	}, txBytes)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "min value constraint failed")
+	assert.Contains(t, err.Error(), "failed to compare min values")
 }
 
 func TestLitecoin_Evaluate_WrongAddress_ShouldFail(t *testing.T) {
@@ -260,13 +261,13 @@ func TestLitecoin_Evaluate_WrongAddress_ShouldFail(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newFixed(0, "ltc1qwrongaddressxxxxxxxxxxxxxxxxxxxxx", "10000000")...)
 
-	err := NewLitecoin().Evaluate(&types.Rule{
+	err := NewLitecoin().Evaluate(context.Background(), &types.Rule{
 		Resource:             "litecoin.ltc.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
 	}, txBytes)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "fixed value constraint failed")
+	assert.Contains(t, err.Error(), "failed to compare fixed values")
 }
 
 func TestLitecoin_Evaluate_MismatchedOutputCounts_ShouldFail(t *testing.T) {
@@ -282,7 +283,7 @@ func TestLitecoin_Evaluate_MismatchedOutputCounts_ShouldFail(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newFixed(0, "ltc1qw7llyrrqu53dl25386cpy0mt8lmkp59sstm02h", "10000000")...)
 
-	err := NewLitecoin().Evaluate(&types.Rule{
+	err := NewLitecoin().Evaluate(context.Background(), &types.Rule{
 		Resource:             "litecoin.ltc.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -297,7 +298,7 @@ func TestLitecoin_Evaluate_InvalidTxBytes_ShouldFail(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newFixed(0, "ltc1qw7llyrrqu53dl25386cpy0mt8lmkp59sstm02h", "10000000")...)
 
-	err := NewLitecoin().Evaluate(&types.Rule{
+	err := NewLitecoin().Evaluate(context.Background(), &types.Rule{
 		Resource:             "litecoin.ltc.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -362,7 +363,7 @@ func TestLitecoin_Evaluate_DataRegexpConstraints(t *testing.T) {
 		"^=:e:0x86d526d6624AbC0178cF7296cD538Ecc080A95F1:.",
 	)...)
 
-	err := NewLitecoin().Evaluate(&types.Rule{
+	err := NewLitecoin().Evaluate(context.Background(), &types.Rule{
 		Resource:             "litecoin.ltc.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -379,7 +380,7 @@ func TestLitecoin_Evaluate_DataRegexpConstraints_ShouldFail(t *testing.T) {
 		"^=:e:0x86d526d6624AbC0178cF7296cD538Ecc08088888:.",
 	)...)
 
-	err := NewLitecoin().Evaluate(&types.Rule{
+	err := NewLitecoin().Evaluate(context.Background(), &types.Rule{
 		Resource:             "litecoin.ltc.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
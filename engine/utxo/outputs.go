@@ -0,0 +1,190 @@
+package utxo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/vultisig/recipes/engine/compare"
+	"github.com/vultisig/recipes/types"
+)
+
+// OutputOrder selects how output_* constraints are matched to tx outputs, FIXED to one of:
+//   - OutputOrderStrict (default): output_*_N constrains the tx output at index N
+//   - OutputOrderAny: output_*_N constrains one of the tx outputs, at any position, e.g. for BIP69-sorted txs.
+//     Every expected output is assigned to a distinct tx output, tx outputs left over must be change
+const OutputOrder = "output_order"
+
+const (
+	OutputOrderStrict = "strict"
+	OutputOrderAny    = "any"
+)
+
+func outputOrder(c *types.ParameterConstraint) (string, error) {
+	if c == nil {
+		return OutputOrderStrict, nil
+	}
+	if c.GetConstraint().GetType() != types.ConstraintType_CONSTRAINT_TYPE_FIXED {
+		return "", fmt.Errorf("output order must be a fixed value, got: %s", c.GetConstraint().GetType().String())
+	}
+
+	order := c.GetConstraint().GetFixedValue()
+	switch order {
+	case OutputOrderStrict, OutputOrderAny:
+		return order, nil
+	default:
+		return "", fmt.Errorf("unsupported output order: %s", order)
+	}
+}
+
+// assignOutputs finds a one-to-one assignment of expected outputs (output_*_N constraints) to tx outputs,
+// tx outputs which are not assigned must pay to the change address unless the rule is matched partially.
+// Returns tx output indexes by expected output index
+func (e *Engine) assignOutputs(
+	ctx context.Context,
+	outputConstraints map[int]*outputConstraints,
+	tx *wire.MsgTx,
+	change *types.ParameterConstraint,
+	partial bool,
+) (map[int]int, error) {
+	expected := make([]int, 0, len(outputConstraints))
+	for i, constraints := range outputConstraints {
+		if !partial {
+			err := validateOutputConstraintKinds(i, constraints)
+			if err != nil {
+				return nil, err
+			}
+		}
+		expected = append(expected, i)
+	}
+	sort.Ints(expected)
+
+	if len(expected) > len(tx.TxOut) {
+		return nil, compare.NewMismatchError("output count mismatch: rule has %d outputs, tx has %d outputs", len(expected), len(tx.TxOut))
+	}
+	if !partial && change == nil && len(expected) != len(tx.TxOut) {
+		return nil, compare.NewMismatchError("output count mismatch: rule has %d outputs, tx has %d outputs", len(expected), len(tx.TxOut))
+	}
+	if !partial && change != nil {
+		err := validateChangeConstraint(change)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// candidates of expected outputs, followed by a change slot per tx output left over,
+	// which is filled by any change output. Outputs left over are ignored by partial matching
+	slots := len(tx.TxOut)
+	if partial {
+		slots = len(expected)
+	}
+	candidates := make([][]int, slots)
+	mismatches := make([][]string, len(expected))
+	for k, i := range expected {
+		for j, txOut := range tx.TxOut {
+			err := e.validateOutput(ctx, j, outputConstraints[i], txOut, partial)
+			if err != nil {
+				if !compare.IsMismatch(err) {
+					// e.g. a magic constant which can't be resolved, the output may match
+					return nil, err
+				}
+				mismatches[k] = append(mismatches[k], err.Error())
+				continue
+			}
+			candidates[k] = append(candidates[k], j)
+		}
+	}
+	var changeOutputs []int
+	for j, txOut := range tx.TxOut {
+		if e.isChange(ctx, change, txOut) {
+			changeOutputs = append(changeOutputs, j)
+		}
+	}
+	for k := len(expected); k < slots; k++ {
+		candidates[k] = changeOutputs
+	}
+
+	// slots by tx output index
+	assigned := make([]int, len(tx.TxOut))
+	for j := range assigned {
+		assigned[j] = -1
+	}
+
+	// augmenting path search of the bipartite matching
+	var assign func(k int, visited []bool) bool
+	assign = func(k int, visited []bool) bool {
+		for _, j := range candidates[k] {
+			if visited[j] {
+				continue
+			}
+			visited[j] = true
+			if assigned[j] == -1 || assign(assigned[j], visited) {
+				assigned[j] = k
+				return true
+			}
+		}
+		return false
+	}
+
+	for k := range candidates {
+		if assign(k, make([]bool, len(tx.TxOut))) {
+			continue
+		}
+
+		if k >= len(expected) {
+			var left []int
+			for j, slot := range assigned {
+				if slot == -1 {
+					left = append(left, j)
+				}
+			}
+			return nil, compare.NewMismatchError("outputs %v match no expected output and are not change", left)
+		}
+		if len(candidates[k]) == 0 {
+			return nil, compare.NewMismatchError("no output matches expected output %d: %s", expected[k], strings.Join(mismatches[k], "; "))
+		}
+		return nil, compare.NewMismatchError("expected output %d only matches outputs %v, which are assigned to other expected outputs",
+			expected[k], candidates[k])
+	}
+
+	assignment := make(map[int]int, len(expected))
+	for j, k := range assigned {
+		// tx outputs left over by partial matching are not assigned
+		if k >= 0 && k < len(expected) {
+			assignment[expected[k]] = j
+		}
+	}
+	return assignment, nil
+}
+
+// outputIndex returns the index of the tx output constrained by output_*_N parameters of the rule,
+// outputs of deny rules are assigned the same way as by Match
+func (e *Engine) outputIndex(ctx context.Context, rule *types.Rule, tx *wire.MsgTx, index int) (int, error) {
+	txConstraints := txParameters(rule)
+
+	order, err := outputOrder(txConstraints[OutputOrder])
+	if err != nil {
+		return 0, err
+	}
+	if order == OutputOrderStrict {
+		return index, nil
+	}
+
+	outputs, err := e.collectOutputConstraints(rule)
+	if err != nil {
+		return 0, err
+	}
+	partial := rule.GetEffect() == types.Effect_EFFECT_DENY
+	assignment, err := e.assignOutputs(ctx, outputs, tx, txConstraints[ChangeAddress], partial)
+	if err != nil {
+		return 0, fmt.Errorf("failed to assign outputs: %w", err)
+	}
+	j, ok := assignment[index]
+	if !ok {
+		return 0, fmt.Errorf("rule has no constraints for output %d", index)
+	}
+	return j, nil
+}
@@ -0,0 +1,199 @@
+package utxo
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/vultisig/recipes/engine/compare"
+	"github.com/vultisig/recipes/types"
+)
+
+// PSBT parameters
+const (
+	// SighashType constrains the sighash type of every input, e.g. IN_SET of "all" and "all|anyonecanpay".
+	// Without the constraint only "all" and "default" (Taproot), with the fork id on Bitcoin Cash, are allowed
+	SighashType = "sighash_type"
+	// FeeRate constrains the fee rate in sat/vB, the signed tx size is estimated from the scripts spent by the inputs
+	FeeRate = "fee_rate"
+)
+
+const sighashForkID txscript.SigHashType = 0x40
+
+// sighashName returns the name of the sighash type, e.g. "all", "none|anyonecanpay", "all|forkid"
+func sighashName(t txscript.SigHashType) string {
+	var name string
+	switch t & 0x1f {
+	case txscript.SigHashDefault:
+		if t != txscript.SigHashDefault {
+			return fmt.Sprintf("unknown(%#x)", uint32(t))
+		}
+		return "default"
+	case txscript.SigHashAll:
+		name = "all"
+	case txscript.SigHashNone:
+		name = "none"
+	case txscript.SigHashSingle:
+		name = "single"
+	default:
+		return fmt.Sprintf("unknown(%#x)", uint32(t))
+	}
+
+	if t&sighashForkID != 0 {
+		name += "|forkid"
+	}
+	if t&txscript.SigHashAnyOneCanPay != 0 {
+		name += "|anyonecanpay"
+	}
+	if t&^(0x1f|sighashForkID|txscript.SigHashAnyOneCanPay) != 0 {
+		return fmt.Sprintf("unknown(%#x)", uint32(t))
+	}
+	return name
+}
+
+// inputSighashTypes returns sighash types the input requests or is already signed with
+func inputSighashTypes(in psbt.PInput) []txscript.SigHashType {
+	var out []txscript.SigHashType
+	if in.SighashType != 0 {
+		out = append(out, in.SighashType)
+	}
+	for _, sig := range in.PartialSigs {
+		if len(sig.Signature) > 0 {
+			out = append(out, txscript.SigHashType(sig.Signature[len(sig.Signature)-1]))
+		}
+	}
+	if len(in.TaprootKeySpendSig) == 65 {
+		out = append(out, txscript.SigHashType(in.TaprootKeySpendSig[64]))
+	}
+	return out
+}
+
+// validateSighashTypes rejects inputs signing only part of the tx (none, single, anyonecanpay)
+// unless the sighash type constraint allows them. Partial matching only checks the constraint
+func (e *Engine) validateSighashTypes(
+	ctx context.Context,
+	constraint *types.ParameterConstraint,
+	packet *psbt.Packet,
+	partial bool,
+) error {
+	if packet == nil {
+		if constraint != nil {
+			return fmt.Errorf("sighash types are only known for PSBTs")
+		}
+		return nil
+	}
+	if partial && constraint == nil {
+		return nil
+	}
+
+	for i, in := range packet.Inputs {
+		for _, t := range inputSighashTypes(in) {
+			name := sighashName(t)
+			if constraint != nil {
+				err := compare.AssertConstraint(ctx, e.resolvers, e.config.ChainID, constraint, name, compare.NewString)
+				if err != nil {
+					return fmt.Errorf("input %d sighash type validation failed: %w", i, err)
+				}
+				continue
+			}
+
+			switch name {
+			case "all", "all|forkid", "default":
+			default:
+				return fmt.Errorf("input %d sighash type is not allowed: %s", i, name)
+			}
+		}
+	}
+	return nil
+}
+
+// inputWeight estimates the weight the input adds to the unsigned tx once signed, assuming single-key spends
+func inputWeight(pkScript []byte) (int64, error) {
+	const (
+		// ecdsa signature with the sighash byte and the compressed public key, with their push opcodes
+		sigPubKey = 1 + 72 + 1 + 33
+		// witness items count
+		items = 1
+	)
+
+	switch txscript.GetScriptClass(pkScript) {
+	case txscript.PubKeyHashTy:
+		return 4 * sigPubKey, nil
+	case txscript.WitnessV0PubKeyHashTy:
+		return items + sigPubKey, nil
+	case txscript.ScriptHashTy:
+		// nested P2WPKH: the redeem script push in the script sig and the witness
+		return 4*23 + items + sigPubKey, nil
+	case txscript.WitnessV1TaprootTy:
+		// key path spend: schnorr signature with the sighash byte
+		return items + 1 + 65, nil
+	default:
+		return 0, fmt.Errorf("can't estimate the size of the %s input", txscript.GetScriptClass(pkScript).String())
+	}
+}
+
+// vsize estimates the virtual size of the signed tx
+func vsize(tx *wire.MsgTx, packet *psbt.Packet) (int64, error) {
+	weight := int64(tx.SerializeSizeStripped()) * 4
+	segwit := false
+	for i := range tx.TxIn {
+		utxo, err := inputUtxo(packet, i)
+		if err != nil {
+			return 0, err
+		}
+		w, err := inputWeight(utxo.PkScript)
+		if err != nil {
+			return 0, fmt.Errorf("input %d: %w", i, err)
+		}
+		weight += w
+		segwit = segwit || txscript.IsWitnessProgram(utxo.PkScript) || txscript.IsPayToScriptHash(utxo.PkScript)
+	}
+	if segwit {
+		// marker and flag
+		weight += 2
+	}
+	return (weight + 3) / 4, nil
+}
+
+// feeRate returns the fee rate in sat/vB, rounded down
+func feeRate(tx *wire.MsgTx, packet *psbt.Packet) (*big.Int, error) {
+	total, err := fee(tx, packet)
+	if err != nil {
+		return nil, err
+	}
+	size, err := vsize(tx, packet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate tx size: %w", err)
+	}
+	return total.Div(total, big.NewInt(size)), nil
+}
+
+// validatePSBT checks sighash types of the inputs and the fee rate
+func (e *Engine) validatePSBT(
+	ctx context.Context,
+	constraints map[string]*types.ParameterConstraint,
+	tx *wire.MsgTx,
+	packet *psbt.Packet,
+	partial bool,
+) error {
+	err := e.validateSighashTypes(ctx, constraints[SighashType], packet, partial)
+	if err != nil {
+		return err
+	}
+
+	if c, ok := constraints[FeeRate]; ok {
+		actual, er := feeRate(tx, packet)
+		if er != nil {
+			return fmt.Errorf("failed to compute fee rate: %w", er)
+		}
+		er = compare.AssertConstraint(ctx, e.resolvers, e.config.ChainID, c, actual, compare.NewBigInt)
+		if er != nil {
+			return fmt.Errorf("fee rate validation failed: %w", er)
+		}
+	}
+	return nil
+}
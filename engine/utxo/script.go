@@ -0,0 +1,64 @@
+package utxo
+
+import (
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// Output script types, the values of output_type_N parameters
+const (
+	ScriptP2PKH          = "p2pkh"
+	ScriptP2SH           = "p2sh"
+	ScriptP2WPKH         = "p2wpkh"
+	ScriptP2WSH          = "p2wsh"
+	ScriptP2TR           = "p2tr"
+	ScriptOpReturn       = "op_return"
+	ScriptP2PK           = "p2pk"
+	ScriptMultisig       = "multisig"
+	ScriptWitnessUnknown = "witness_unknown"
+	ScriptNonStandard    = "nonstandard"
+)
+
+// scriptType classifies the output script. Any script starting with OP_RETURN is op_return, it's provably
+// unspendable and carries data only, even if larger than the standard null data script
+func scriptType(pkScript []byte) string {
+	if len(pkScript) > 0 && pkScript[0] == txscript.OP_RETURN {
+		return ScriptOpReturn
+	}
+
+	switch txscript.GetScriptClass(pkScript) {
+	case txscript.PubKeyHashTy:
+		return ScriptP2PKH
+	case txscript.ScriptHashTy:
+		return ScriptP2SH
+	case txscript.WitnessV0PubKeyHashTy:
+		return ScriptP2WPKH
+	case txscript.WitnessV0ScriptHashTy:
+		return ScriptP2WSH
+	case txscript.WitnessV1TaprootTy:
+		return ScriptP2TR
+	case txscript.PubKeyTy:
+		return ScriptP2PK
+	case txscript.MultiSigTy:
+		return ScriptMultisig
+	case txscript.WitnessUnknownTy:
+		return ScriptWitnessUnknown
+	default:
+		// btcd only classifies some future witness versions
+		if txscript.IsWitnessProgram(pkScript) {
+			return ScriptWitnessUnknown
+		}
+		return ScriptNonStandard
+	}
+}
+
+// isAddressScript reports whether outputs of the script type pay to a single address.
+// Other types (p2pk, bare multisig, unknown witness versions, non-standard scripts) have no address,
+// so they're rejected unless an output_type_N constraint explicitly allows them
+func isAddressScript(t string) bool {
+	switch t {
+	case ScriptP2PKH, ScriptP2SH, ScriptP2WPKH, ScriptP2WSH, ScriptP2TR:
+		return true
+	default:
+		return false
+	}
+}
@@ -3,9 +3,9 @@ package utxo
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"math/big"
-	"regexp"
 	"strconv"
 	"strings"
 
@@ -43,12 +43,21 @@ type Config struct {
 
 // Engine is a generic UTXO engine that can be configured for different chains.
 type Engine struct {
-	config Config
+	config    Config
+	resolvers *resolver.MagicConstantRegistry
 }
 
 // NewEngine creates a new UTXO engine with the given configuration.
 func NewEngine(config Config) *Engine {
-	return &Engine{config: config}
+	return &Engine{
+		config:    config,
+		resolvers: resolver.NewMagicConstantRegistry(),
+	}
+}
+
+// SetMagicConstantRegistry sets the registry used to resolve magic constants
+func (e *Engine) SetMagicConstantRegistry(registry *resolver.MagicConstantRegistry) {
+	e.resolvers = registry
 }
 
 // Supports returns true if this engine supports the given chain.
@@ -61,7 +70,7 @@ func (e *Engine) Supports(chain common.Chain) bool {
 	return false
 }
 
-// ExtractTxBytes extracts transaction bytes from a PSBT string.
+// ExtractTxBytes extracts the unsigned transaction bytes from a PSBT string.
 func (e *Engine) ExtractTxBytes(txData string) ([]byte, error) {
 	p, err := psbt.NewFromRawBytes(strings.NewReader(txData), true)
 	if err != nil {
@@ -74,27 +83,111 @@ func (e *Engine) ExtractTxBytes(txData string) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// ExtractPSBTBytes extracts the serialized PSBT from a base64 PSBT string.
+// Unlike ExtractTxBytes, input UTXOs and sighash types are kept, so they're evaluated along with the unsigned tx.
+func (e *Engine) ExtractPSBTBytes(txData string) ([]byte, error) {
+	p, err := psbt.NewFromRawBytes(strings.NewReader(txData), true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PSBT: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := p.Serialize(&buf); err != nil {
+		return nil, fmt.Errorf("failed to serialize PSBT: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
 // Evaluate validates a transaction against the given rule.
-func (e *Engine) Evaluate(rule *types.Rule, txBytes []byte) error {
+func (e *Engine) Evaluate(ctx context.Context, rule *types.Rule, txBytes []byte) error {
 	if rule.GetEffect().String() != types.Effect_EFFECT_ALLOW.String() {
 		return fmt.Errorf("only allow rules supported, got: %s", rule.GetEffect().String())
 	}
+	return e.match(ctx, rule, txBytes, false)
+}
+
+// Match validates the transaction outputs, inputs and fee against the rule without looking at
+// the rule effect. A nil error means the rule matches the transaction.
+// txBytes is the raw transaction or the serialized PSBT, input addresses and the fee are only known for PSBTs.
+// Unlike Evaluate, outputs without constraints are ignored and need not be change, and sighash types are only
+// checked against the sighash_type constraint, so a deny rule applies to any tx having the constrained outputs
+func (e *Engine) Match(ctx context.Context, rule *types.Rule, txBytes []byte) error {
+	return e.match(ctx, rule, txBytes, true)
+}
+
+// match checks the tx against the rule, allow rules must cover every output.
+// Partial matching checks only the constrained outputs
+func (e *Engine) match(ctx context.Context, rule *types.Rule, txBytes []byte, partial bool) error {
 	if rule.GetTarget().GetTargetType() != types.TargetType_TARGET_TYPE_UNSPECIFIED {
 		return fmt.Errorf("target type must be nil for %s, got: %s", e.config.ChainID, rule.GetTarget().GetTargetType().String())
 	}
 
-	tx, err := e.parseTx(txBytes)
+	tx, packet, err := e.parse(txBytes)
 	if err != nil {
 		return fmt.Errorf("failed to parse %s transaction: %w", e.config.ChainID, err)
 	}
 
-	if err := e.validateOutputs(rule, tx); err != nil {
+	txConstraints := txParameters(rule)
+
+	if err := e.validateOutputs(ctx, rule, tx, txConstraints, partial); err != nil {
 		return fmt.Errorf("failed to validate outputs: %w", err)
 	}
 
+	if err := e.validateTxConstraints(ctx, txConstraints, tx, packet, partial); err != nil {
+		return fmt.Errorf("failed to validate inputs: %w", err)
+	}
+
 	return nil
 }
 
+// ParameterValue returns the value of the output referenced by an output_value_N parameter,
+// the fee, the fee rate or the input count.
+func (e *Engine) ParameterValue(rule *types.Rule, txBytes []byte, name string) (*big.Int, error) {
+	tx, packet, err := e.parse(txBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s transaction: %w", e.config.ChainID, err)
+	}
+
+	switch name {
+	case Fee:
+		return fee(tx, packet)
+	case FeeRate:
+		return feeRate(tx, packet)
+	case InputCount:
+		return big.NewInt(int64(len(tx.TxIn))), nil
+	}
+
+	index, cType, err := e.parseConstraintName(name)
+	if err != nil {
+		return nil, err
+	}
+	if cType != value {
+		return nil, fmt.Errorf("parameter is not numeric: %s", name)
+	}
+	index, err = e.outputIndex(context.Background(), rule, tx, index)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(tx.TxOut) {
+		return nil, fmt.Errorf("output index out of range: %d", index)
+	}
+	return big.NewInt(tx.TxOut[index].Value), nil
+}
+
+// ParameterAsset returns the asset of the output_value_N or fee parameter, always the native asset.
+func (e *Engine) ParameterAsset(_ *types.Rule, _ []byte, name string) (string, error) {
+	if name == Fee {
+		return "", nil
+	}
+	_, cType, err := e.parseConstraintName(name)
+	if err != nil {
+		return "", err
+	}
+	if cType != value {
+		return "", fmt.Errorf("parameter is not numeric: %s", name)
+	}
+	return "", nil
+}
+
 func (e *Engine) parseTx(txBytes []byte) (*wire.MsgTx, error) {
 	if e.config.ParseTx != nil {
 		return e.config.ParseTx(txBytes)
@@ -112,26 +205,71 @@ type outputConstraints struct {
 	address *types.ParameterConstraint
 	value   *types.ParameterConstraint
 	data    *types.ParameterConstraint
+	// outputType constrains the script type, e.g. p2wpkh
+	outputType *types.ParameterConstraint
 }
 
-func (e *Engine) validateOutputs(rule *types.Rule, tx *wire.MsgTx) error {
+func (e *Engine) validateOutputs(
+	ctx context.Context,
+	rule *types.Rule,
+	tx *wire.MsgTx,
+	txConstraints map[string]*types.ParameterConstraint,
+	partial bool,
+) error {
+	outputs, err := e.collectOutputConstraints(rule)
+	if err != nil {
+		return err
+	}
+
+	order, err := outputOrder(txConstraints[OutputOrder])
+	if err != nil {
+		return err
+	}
+
+	change := txConstraints[ChangeAddress]
+	if order == OutputOrderAny {
+		_, err = e.assignOutputs(ctx, outputs, tx, change, partial)
+		return err
+	}
+
+	if partial {
+		for i := range outputs {
+			if i < 0 || i >= len(tx.TxOut) {
+				return compare.NewMismatchError("rule has constraints for output %d, tx has %d outputs", i, len(tx.TxOut))
+			}
+		}
+		return e.validateOutputConstraints(ctx, outputs, tx, partial)
+	}
+
+	if change != nil {
+		err = e.validateChangeOutputs(ctx, outputs, tx, change)
+		if err != nil {
+			return err
+		}
+	} else if err = e.validateOutputConstraintCounts(outputs, tx); err != nil {
+		return fmt.Errorf("failed to validate output constraint counts: %w", err)
+	}
+
+	return e.validateOutputConstraints(ctx, outputs, tx, partial)
+}
+
+// collectOutputConstraints groups output_* constraints of the rule by output index
+func (e *Engine) collectOutputConstraints(rule *types.Rule) (map[int]*outputConstraints, error) {
 	outputs := make(map[int]*outputConstraints)
 
 	for _, constraint := range rule.GetParameterConstraints() {
 		name := constraint.GetParameterName()
+		if isTxParameter(name) {
+			continue
+		}
 
 		if index, constrType, err := e.parseConstraintName(name); err != nil {
-			return fmt.Errorf("failed to parse constraint name: %w", err)
+			return nil, fmt.Errorf("failed to parse constraint name: %w", err)
 		} else {
 			e.setConstraint(outputs, index, constraint, constrType)
 		}
 	}
-
-	if err := e.validateOutputConstraintCounts(outputs, tx); err != nil {
-		return fmt.Errorf("failed to validate output constraint counts: %w", err)
-	}
-
-	return e.validateOutputConstraints(outputs, tx)
+	return outputs, nil
 }
 
 type constraintType string
@@ -140,6 +278,8 @@ const (
 	address constraintType = "address"
 	value   constraintType = "value"
 	data    constraintType = "data"
+	// outputType is the script type of the output
+	outputType constraintType = "type"
 )
 
 func (e *Engine) parseConstraintName(name string) (index int, cType constraintType, err error) {
@@ -170,6 +310,15 @@ func (e *Engine) parseConstraintName(name string) (index int, cType constraintTy
 		return ind, data, nil
 	}
 
+	if strings.HasPrefix(name, "output_type_") {
+		indexStr := strings.TrimPrefix(name, "output_type_")
+		ind, er := strconv.Atoi(indexStr)
+		if er != nil {
+			return 0, "", fmt.Errorf("invalid constraint name: %s", name)
+		}
+		return ind, outputType, nil
+	}
+
 	return 0, "", fmt.Errorf("unsupported constraint parameter name (only output_* supported): %s", name)
 }
 
@@ -190,210 +339,232 @@ func (e *Engine) setConstraint(
 		constraints[index].value = constraint
 	case data:
 		constraints[index].data = constraint
+	case outputType:
+		constraints[index].outputType = constraint
 	}
 }
 
 func (e *Engine) validateOutputConstraintCounts(outputConstraints map[int]*outputConstraints, tx *wire.MsgTx) error {
 	if len(outputConstraints) != len(tx.TxOut) {
-		return fmt.Errorf("output count mismatch: rule has %d outputs, tx has %d outputs", len(outputConstraints), len(tx.TxOut))
+		return compare.NewMismatchError("output count mismatch: rule has %d outputs, tx has %d outputs", len(outputConstraints), len(tx.TxOut))
 	}
 
 	for i := 0; i < len(tx.TxOut); i += 1 {
 		constraints, exists := outputConstraints[i]
 		if !exists {
-			return fmt.Errorf("missing constraints for output %d", i)
-		}
-
-		// Exclusivity logic: output must be either data OR (address+value), but not both
-		hasData := constraints.data != nil
-		hasAddressValue := constraints.address != nil && constraints.value != nil
-
-		if hasData && hasAddressValue {
-			return fmt.Errorf("output %d cannot have both data and address+value constraints", i)
+			return compare.NewMismatchError("missing constraints for output %d", i)
 		}
 
-		if !hasData && !hasAddressValue {
-			return fmt.Errorf("output %d must have either data constraint or both address and value constraints", i)
-		}
-
-		// If using address+value format, both must be present
-		if !hasData && (constraints.address == nil || constraints.value == nil) {
-			return fmt.Errorf("output %d with non-data format must have both address and value constraints", i)
+		err := validateOutputConstraintKinds(i, constraints)
+		if err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-func (e *Engine) validateOutputConstraints(outputConstraints map[int]*outputConstraints, tx *wire.MsgTx) error {
-	for i, txOut := range tx.TxOut {
-		constraints := outputConstraints[i]
+// validateChangeOutputs checks every output is either constrained or change, change outputs
+// may be at any position. Constrained outputs paying to the change address are checked as usual
+func (e *Engine) validateChangeOutputs(
+	ctx context.Context,
+	outputConstraints map[int]*outputConstraints,
+	tx *wire.MsgTx,
+	change *types.ParameterConstraint,
+) error {
+	err := validateChangeConstraint(change)
+	if err != nil {
+		return err
+	}
+
+	for i := range outputConstraints {
+		if i < 0 || i >= len(tx.TxOut) {
+			return compare.NewMismatchError("rule has constraints for output %d, tx has %d outputs", i, len(tx.TxOut))
+		}
+	}
 
-		if constraints.data != nil {
-			// Data constraint validation - validate against OP_RETURN data
-			if len(txOut.PkScript) < 2 || txOut.PkScript[0] != txscript.OP_RETURN {
-				return fmt.Errorf("output %d is not an OP_RETURN script", i)
+	for i, txOut := range tx.TxOut {
+		constraints, exists := outputConstraints[i]
+		if !exists {
+			if e.isChange(ctx, change, txOut) {
+				continue
 			}
+			return compare.NewMismatchError("missing constraints for output %d", i)
+		}
 
-		// Extract data from OP_RETURN script using txscript.PushedData
-		// which handles all PUSHDATA variants (OP_DATA_1-75, OP_PUSHDATA1/2/4)
-		pushedData, err := txscript.PushedData(txOut.PkScript[1:]) // Skip OP_RETURN
+		err = validateOutputConstraintKinds(i, constraints)
 		if err != nil {
-			return fmt.Errorf("output %d failed to parse OP_RETURN data: %w", i, err)
+			return err
 		}
-			var dataBytes []byte
-			if len(pushedData) > 0 {
-				dataBytes = pushedData[0]
-			}
-
-			// Use raw bytes as string for regexp matching (ASCII data)
-			dataStr := string(dataBytes)
+	}
+	return nil
+}
 
-			if er := validateConstraint(e.config.ChainID, constraints.data, dataStr, compare.NewString); er != nil {
-				return fmt.Errorf("output %d data validation failed: %w", i, er)
-			}
-		} else {
-			// Address+value constraint validation
-			outputAddress, err := e.extractAddress(txOut)
-			if err != nil {
-				return fmt.Errorf("failed to extract address from output %d: %w", i, err)
-			}
+// validateChangeConstraint requires the change address to be a single known address
+func validateChangeConstraint(change *types.ParameterConstraint) error {
+	switch change.GetConstraint().GetType() {
+	case types.ConstraintType_CONSTRAINT_TYPE_FIXED, types.ConstraintType_CONSTRAINT_TYPE_MAGIC_CONSTANT:
+		return nil
+	default:
+		return fmt.Errorf("change address must be a fixed or magic constant, got: %s", change.GetConstraint().GetType().String())
+	}
+}
 
-			outputAmount := big.NewInt(txOut.Value)
+func validateOutputConstraintKinds(i int, constraints *outputConstraints) error {
+	// Exclusivity logic: output must be either data OR (address+value), but not both.
+	// Outputs without an address (e.g. bare multisig) are constrained by type+value instead of address+value,
+	// the type must not allow scripts having an address
+	hasData := constraints.data != nil
+	hasAddress := constraints.address != nil || allowsOnlyAddresslessScripts(constraints.outputType)
+	hasAddressValue := hasAddress && constraints.value != nil
 
-			if er := validateConstraint(e.config.ChainID, constraints.address, outputAddress, compare.NewString); er != nil {
-				return fmt.Errorf("output %d address validation failed: %w", i, er)
-			}
+	if hasData && hasAddressValue {
+		return fmt.Errorf("output %d cannot have both data and address+value constraints", i)
+	}
 
-			if er := validateConstraint(e.config.ChainID, constraints.value, outputAmount, compare.NewBigInt); er != nil {
-				return fmt.Errorf("output %d value validation failed: %w", i, er)
-			}
+	if !hasData && !hasAddressValue {
+		if constraints.address == nil && constraints.outputType != nil && constraints.value != nil {
+			return fmt.Errorf("output %d type allows scripts with an address, output_address_%d is required", i, i)
 		}
+		return fmt.Errorf("output %d must have either data constraint or both address and value constraints", i)
 	}
 	return nil
 }
 
-func (e *Engine) extractAddress(txOut *wire.TxOut) (string, error) {
-	// Use custom address extractor if provided
-	if e.config.ExtractAddress != nil {
-		return e.config.ExtractAddress(txOut.PkScript)
+// allowsOnlyAddresslessScripts reports whether the output_type_N constraint allows only script types without
+// an address, outputs of other types must be constrained by output_address_N
+func allowsOnlyAddresslessScripts(c *types.ParameterConstraint) bool {
+	var allowed []string
+	switch c.GetConstraint().GetType() {
+	case types.ConstraintType_CONSTRAINT_TYPE_FIXED:
+		allowed = []string{c.GetConstraint().GetFixedValue()}
+	case types.ConstraintType_CONSTRAINT_TYPE_IN_SET:
+		allowed = c.GetConstraint().GetSetValue().GetValues()
 	}
-
-	// Default: use btcd address extraction with network params
-	_, addrs, _, err := txscript.ExtractPkScriptAddrs(txOut.PkScript, e.config.NetworkParams)
-	if err != nil {
-		return "", fmt.Errorf("failed to extract address from script: %w", err)
+	if len(allowed) == 0 {
+		return false
 	}
-
-	if len(addrs) == 0 {
-		return "", fmt.Errorf("no address found in script")
+	for _, t := range allowed {
+		if isAddressScript(t) {
+			return false
+		}
 	}
-
-	return addrs[0].EncodeAddress(), nil
+	return true
 }
 
-// validateConstraint is a package-level generic function for constraint validation
-func validateConstraint[T any](
-	chainID string,
-	constraint *types.ParameterConstraint,
-	actual T,
-	makeComparer compare.Constructor[T],
+func (e *Engine) validateOutputConstraints(
+	ctx context.Context,
+	outputConstraints map[int]*outputConstraints,
+	tx *wire.MsgTx,
+	partial bool,
 ) error {
-	kind := constraint.GetConstraint().GetType()
-
-	switch kind {
-	case types.ConstraintType_CONSTRAINT_TYPE_ANY:
-		return nil
+	for i, txOut := range tx.TxOut {
+		constraints := outputConstraints[i]
+		if constraints == nil {
+			// change output
+			continue
+		}
 
-	case types.ConstraintType_CONSTRAINT_TYPE_FIXED:
-		comparer, err := makeComparer(constraint.GetConstraint().GetFixedValue())
+		err := e.validateOutput(ctx, i, constraints, txOut, partial)
 		if err != nil {
-			return fmt.Errorf("failed to build fixed comparer: %w", err)
-		}
-		if comparer.Fixed(actual) {
-			return nil
+			return err
 		}
-		return fmt.Errorf("fixed value constraint failed: expected=%v, actual=%v",
-			constraint.GetConstraint().GetFixedValue(), actual)
+	}
+	return nil
+}
 
-	case types.ConstraintType_CONSTRAINT_TYPE_MIN:
-		comparer, err := makeComparer(constraint.GetConstraint().GetMinValue())
-		if err != nil {
-			return fmt.Errorf("failed to build min comparer: %w", err)
+// validateOutput checks the tx output at index i against the data or address and value constraints.
+// Outputs paying to an address must be constrained by the address unless the rule is matched partially
+func (e *Engine) validateOutput(
+	ctx context.Context,
+	i int,
+	constraints *outputConstraints,
+	txOut *wire.TxOut,
+	partial bool,
+) error {
+	t := scriptType(txOut.PkScript)
+	if constraints.outputType != nil {
+		if er := compare.AssertConstraint(ctx, e.resolvers, e.config.ChainID, constraints.outputType, t, compare.NewString); er != nil {
+			return fmt.Errorf("output %d type validation failed: %w", i, er)
 		}
-		if comparer.Min(actual) {
-			return nil
+	}
+	if !partial && constraints.data == nil && constraints.address == nil && isAddressScript(t) {
+		return compare.NewMismatchError("output %d pays to a %s address, but its address is not constrained", i, t)
+	}
+
+	if constraints.data != nil {
+		// Data constraint validation - validate against OP_RETURN data
+		if len(txOut.PkScript) < 2 || txOut.PkScript[0] != txscript.OP_RETURN {
+			return compare.NewMismatchError("output %d is not an OP_RETURN script", i)
 		}
-		return fmt.Errorf("min value constraint failed: expected>=%v, actual=%v",
-			constraint.GetConstraint().GetMinValue(), actual)
 
-	case types.ConstraintType_CONSTRAINT_TYPE_MAX:
-		comparer, err := makeComparer(constraint.GetConstraint().GetMaxValue())
+		// Extract data from OP_RETURN script using txscript.PushedData
+		// which handles all PUSHDATA variants (OP_DATA_1-75, OP_PUSHDATA1/2/4)
+		pushedData, err := txscript.PushedData(txOut.PkScript[1:]) // Skip OP_RETURN
 		if err != nil {
-			return fmt.Errorf("failed to build max comparer: %w", err)
+			return fmt.Errorf("output %d failed to parse OP_RETURN data: %w", i, err)
 		}
-		if comparer.Max(actual) {
-			return nil
+		var dataBytes []byte
+		if len(pushedData) > 0 {
+			dataBytes = pushedData[0]
 		}
-		return fmt.Errorf("max value constraint failed: expected<=%v, actual=%v",
-			constraint.GetConstraint().GetMaxValue(), actual)
 
-	case types.ConstraintType_CONSTRAINT_TYPE_MAGIC_CONSTANT:
-		resolve, err := resolver.NewMagicConstantRegistry().GetResolver(
-			constraint.GetConstraint().GetMagicConstantValue(),
-		)
-		if err != nil {
-			return fmt.Errorf(
-				"failed to get magic const resolver: magic_const=%s",
-				constraint.GetConstraint().GetMagicConstantValue().String(),
-			)
-		}
+		// Use raw bytes as string for regexp matching (ASCII data)
+		dataStr := string(dataBytes)
 
-		resolvedValue, _, err := resolve.Resolve(
-			constraint.GetConstraint().GetMagicConstantValue(),
-			chainID,
-			"default",
-		)
-		if err != nil {
-			return fmt.Errorf(
-				"failed to resolve magic const: magic_const=%s",
-				constraint.GetConstraint().GetMagicConstantValue().String(),
-			)
+		if er := compare.AssertConstraint(ctx, e.resolvers, e.config.ChainID, constraints.data, dataStr, compare.NewString); er != nil {
+			return fmt.Errorf("output %d data validation failed: %w", i, er)
 		}
+		return nil
+	}
 
-		comparer, err := makeComparer(resolvedValue)
-		if err != nil {
-			return fmt.Errorf(
-				"failed to build magic comparer: resolved=%s",
-				resolvedValue,
-			)
-		}
-		if comparer.Fixed(actual) {
-			return nil
-		}
-		return fmt.Errorf(
-			"magic value constraint failed: expected(resolved)=%v, actual=%v",
-			resolvedValue,
-			actual,
-		)
-
-	case types.ConstraintType_CONSTRAINT_TYPE_REGEXP:
-		strVal := fmt.Sprintf("%v", actual)
-		ok, err := regexp.MatchString(
-			constraint.GetConstraint().GetRegexpValue(),
-			strVal,
-		)
+	// Address+value constraint validation, type+value outputs are checked by the type
+	if constraints.address != nil {
+		outputAddress, err := e.extractAddress(txOut)
 		if err != nil {
-			return fmt.Errorf("regexp match failed: expected=%v, actual=%v",
-				constraint.GetConstraint().GetRegexpValue(), actual)
+			return fmt.Errorf("failed to extract address from output %d: %w", i, err)
 		}
-		if ok {
-			return nil
+
+		if er := compare.AssertConstraint(ctx, e.resolvers, e.config.ChainID, constraints.address, outputAddress, compare.NewString); er != nil {
+			return fmt.Errorf("output %d address validation failed: %w", i, er)
 		}
-		return fmt.Errorf("regexp value constraint failed: expected=%v, actual=%v",
-			constraint.GetConstraint().GetRegexpValue(), actual)
+	}
 
-	default:
-		return fmt.Errorf("unknown constraint type: %s", kind.String())
+	if constraints.value == nil {
+		// partially matched rules may constrain the address only
+		return nil
 	}
+	outputAmount := big.NewInt(txOut.Value)
+
+	if er := compare.AssertConstraint(ctx, e.resolvers, e.config.ChainID, constraints.value, outputAmount, compare.NewBigInt); er != nil {
+		return fmt.Errorf("output %d value validation failed: %w", i, er)
+	}
+	return nil
+}
+
+// extractAddress returns the address the output pays to. Only scripts paying to a single address
+// have one: taking the first key of a bare multisig or a p2pk script as the address would let the
+// output pass for a payment to that key
+func (e *Engine) extractAddress(txOut *wire.TxOut) (string, error) {
+	t := scriptType(txOut.PkScript)
+	if !isAddressScript(t) {
+		return "", compare.NewMismatchError("%s script has no address", t)
+	}
+
+	// Use custom address extractor if provided
+	if e.config.ExtractAddress != nil {
+		return e.config.ExtractAddress(txOut.PkScript)
+	}
+
+	// Default: use btcd address extraction with network params
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(txOut.PkScript, e.config.NetworkParams)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract address from script: %w", err)
+	}
+
+	if len(addrs) != 1 {
+		return "", compare.NewMismatchError("expected one address in %s script, got %d", t, len(addrs))
+	}
+
+	return addrs[0].EncodeAddress(), nil
 }
@@ -1,6 +1,7 @@
 package zcash
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"math/big"
@@ -10,15 +11,25 @@ import (
 	"github.com/btcsuite/btcd/txscript"
 	chainzcash "github.com/vultisig/recipes/chain/utxo/zcash"
 	stdcompare "github.com/vultisig/recipes/engine/compare"
+	"github.com/vultisig/recipes/resolver"
 	sdkzcash "github.com/vultisig/recipes/sdk/zcash"
 	"github.com/vultisig/recipes/types"
 	"github.com/vultisig/vultisig-go/common"
 )
 
-type Zcash struct{}
+type Zcash struct {
+	resolvers *resolver.MagicConstantRegistry
+}
 
 func NewZcash() *Zcash {
-	return &Zcash{}
+	return &Zcash{
+		resolvers: resolver.NewMagicConstantRegistry(),
+	}
+}
+
+// SetMagicConstantRegistry sets the registry used to resolve magic constants
+func (z *Zcash) SetMagicConstantRegistry(registry *resolver.MagicConstantRegistry) {
+	z.resolvers = registry
 }
 
 // Supports returns true if this engine supports the given chain
@@ -26,10 +37,21 @@ func (z *Zcash) Supports(chain common.Chain) bool {
 	return chain == common.Zcash
 }
 
-func (z *Zcash) Evaluate(rule *types.Rule, txBytes []byte) error {
+func (z *Zcash) Evaluate(ctx context.Context, rule *types.Rule, txBytes []byte) error {
 	if rule.GetEffect().String() != types.Effect_EFFECT_ALLOW.String() {
 		return fmt.Errorf("only allow rules supported, got: %s", rule.GetEffect().String())
 	}
+	return z.match(ctx, rule, txBytes, false)
+}
+
+// Match checks the transaction against the rule regardless of its effect.
+// A nil error means the rule matches the transaction. Unlike Evaluate, outputs
+// without constraints are ignored, so a deny rule applies to any tx having the constrained outputs
+func (z *Zcash) Match(ctx context.Context, rule *types.Rule, txBytes []byte) error {
+	return z.match(ctx, rule, txBytes, true)
+}
+
+func (z *Zcash) match(ctx context.Context, rule *types.Rule, txBytes []byte, partial bool) error {
 	if rule.GetTarget().GetTargetType() != types.TargetType_TARGET_TYPE_UNSPECIFIED {
 		return fmt.Errorf("target type must be unspecified for Zcash, got: %s", rule.GetTarget().GetTargetType().String())
 	}
@@ -39,7 +61,7 @@ func (z *Zcash) Evaluate(rule *types.Rule, txBytes []byte) error {
 		return fmt.Errorf("failed to parse zcash transaction: %w", err)
 	}
 
-	if err := z.validateOutputs(rule, tx); err != nil {
+	if err := z.validateOutputs(ctx, rule, tx, partial); err != nil {
 		return fmt.Errorf("failed to validate outputs: %w", err)
 	}
 
@@ -70,7 +92,9 @@ type outputConstraints struct {
 	data    *types.ParameterConstraint
 }
 
-func (z *Zcash) validateOutputs(rule *types.Rule, tx *chainzcash.ZcashTransaction) error {
+// validateOutputs checks the outputs against output_* constraints. Every output must be constrained,
+// partial matching only checks the constrained ones
+func (z *Zcash) validateOutputs(ctx context.Context, rule *types.Rule, tx *chainzcash.ZcashTransaction, partial bool) error {
 	outputs := make(map[int]*outputConstraints)
 
 	for _, constraint := range rule.GetParameterConstraints() {
@@ -83,11 +107,17 @@ func (z *Zcash) validateOutputs(rule *types.Rule, tx *chainzcash.ZcashTransactio
 		}
 	}
 
-	if err := z.validateOutputConstraintCounts(outputs, tx); err != nil {
+	if partial {
+		for i := range outputs {
+			if i < 0 || i >= len(tx.Outputs) {
+				return stdcompare.NewMismatchError("rule has constraints for output %d, tx has %d outputs", i, len(tx.Outputs))
+			}
+		}
+	} else if err := z.validateOutputConstraintCounts(outputs, tx); err != nil {
 		return fmt.Errorf("failed to validate output constraint counts: %w", err)
 	}
 
-	return z.validateOutputConstraints(rule.GetParameterConstraints(), outputs, tx)
+	return z.validateOutputConstraints(ctx, rule.GetParameterConstraints(), outputs, tx)
 }
 
 type constraintType string
@@ -151,13 +181,13 @@ func (z *Zcash) setConstraint(
 
 func (z *Zcash) validateOutputConstraintCounts(outputConstraints map[int]*outputConstraints, tx *chainzcash.ZcashTransaction) error {
 	if len(outputConstraints) != len(tx.Outputs) {
-		return fmt.Errorf("output count mismatch: rule has %d outputs, tx has %d outputs", len(outputConstraints), len(tx.Outputs))
+		return stdcompare.NewMismatchError("output count mismatch: rule has %d outputs, tx has %d outputs", len(outputConstraints), len(tx.Outputs))
 	}
 
 	for i := 0; i < len(tx.Outputs); i++ {
 		constraints, exists := outputConstraints[i]
 		if !exists {
-			return fmt.Errorf("missing constraints for output %d", i)
+			return stdcompare.NewMismatchError("missing constraints for output %d", i)
 		}
 
 		// Exclusivity logic: output must be either data OR (address+value), but not both
@@ -182,6 +212,7 @@ func (z *Zcash) validateOutputConstraintCounts(outputConstraints map[int]*output
 }
 
 func (z *Zcash) validateOutputConstraints(
+	ctx context.Context,
 	constraintList []*types.ParameterConstraint,
 	outputConstraints map[int]*outputConstraints,
 	tx *chainzcash.ZcashTransaction,
@@ -190,11 +221,15 @@ func (z *Zcash) validateOutputConstraints(
 
 	for i, txOut := range tx.Outputs {
 		constraints := outputConstraints[i]
+		if constraints == nil {
+			// not constrained by the partially matched rule
+			continue
+		}
 
 		if constraints.data != nil {
 			// Data constraint validation - validate against OP_RETURN data
 			if len(txOut.PkScript) < 2 || txOut.PkScript[0] != txscript.OP_RETURN {
-				return fmt.Errorf("output %d is not an OP_RETURN script", i)
+				return stdcompare.NewMismatchError("output %d is not an OP_RETURN script", i)
 			}
 
 			// Extract data from OP_RETURN script using txscript.PushedData
@@ -208,23 +243,28 @@ func (z *Zcash) validateOutputConstraints(
 			// Use raw bytes as string for regexp matching (ASCII data)
 			dataStr := string(dataBytes)
 
-			if er := stdcompare.AssertArg(chainID, constraintList, fmt.Sprintf("output_data_%d", i), dataStr, stdcompare.NewString); er != nil {
+			if er := stdcompare.AssertArg(ctx, z.resolvers, chainID, constraintList, fmt.Sprintf("output_data_%d", i), dataStr, stdcompare.NewString); er != nil {
 				return fmt.Errorf("output %d data validation failed: %w", i, er)
 			}
-		} else {
-			// Address+value constraint validation
+			continue
+		}
+
+		// Address+value constraint validation
+		if constraints.address != nil {
 			outputAddress, err := z.extractAddress(txOut)
 			if err != nil {
 				return fmt.Errorf("failed to extract address from output %d: %w", i, err)
 			}
 
-			outputAmount := big.NewInt(txOut.Value)
-
-			if er := stdcompare.AssertArg(chainID, constraintList, fmt.Sprintf("output_address_%d", i), outputAddress, stdcompare.NewString); er != nil {
+			if er := stdcompare.AssertArg(ctx, z.resolvers, chainID, constraintList, fmt.Sprintf("output_address_%d", i), outputAddress, stdcompare.NewString); er != nil {
 				return fmt.Errorf("output %d address validation failed: %w", i, er)
 			}
+		}
+
+		if constraints.value != nil {
+			outputAmount := big.NewInt(txOut.Value)
 
-			if er := stdcompare.AssertArg(chainID, constraintList, fmt.Sprintf("output_value_%d", i), outputAmount, stdcompare.NewBigInt); er != nil {
+			if er := stdcompare.AssertArg(ctx, z.resolvers, chainID, constraintList, fmt.Sprintf("output_value_%d", i), outputAmount, stdcompare.NewBigInt); er != nil {
 				return fmt.Errorf("output %d value validation failed: %w", i, er)
 			}
 		}
@@ -1,6 +1,7 @@
 package zcash
 
 import (
+	"context"
 	"encoding/hex"
 	"fmt"
 	"testing"
@@ -214,7 +215,7 @@ func TestZcash_Evaluate_Fixed(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newFixed(0, expectedAddr, "1000000")...)
 
-	err = NewZcash().Evaluate(&types.Rule{
+	err = NewZcash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "zcash.zec.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -240,7 +241,7 @@ func TestZcash_Evaluate_MaxConstraints(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newMax(0, expectedAddr, "2000000")...) // max 2M, actual 1M
 
-	err = NewZcash().Evaluate(&types.Rule{
+	err = NewZcash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "zcash.zec.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -266,7 +267,7 @@ func TestZcash_Evaluate_MaxConstraints_ShouldFail(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newMax(0, expectedAddr, "500000")...) // max 500k, actual 1M - should fail
 
-	err = NewZcash().Evaluate(&types.Rule{
+	err = NewZcash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "zcash.zec.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -293,7 +294,7 @@ func TestZcash_Evaluate_MinConstraints(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newMin(0, expectedAddr, "500000")...) // min 500k, actual 1M
 
-	err = NewZcash().Evaluate(&types.Rule{
+	err = NewZcash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "zcash.zec.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -319,7 +320,7 @@ func TestZcash_Evaluate_MinConstraints_ShouldFail(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newMin(0, expectedAddr, "2000000")...) // min 2M, actual 1M - should fail
 
-	err = NewZcash().Evaluate(&types.Rule{
+	err = NewZcash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "zcash.zec.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -343,7 +344,7 @@ func TestZcash_Evaluate_WrongAddress_ShouldFail(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newFixed(0, "t1WrongAddress", "1000000")...)
 
-	err := NewZcash().Evaluate(&types.Rule{
+	err := NewZcash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "zcash.zec.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -370,7 +371,7 @@ func TestZcash_Evaluate_WrongValue_ShouldFail(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newFixed(0, expectedAddr, "999999")...) // wrong value
 
-	err = NewZcash().Evaluate(&types.Rule{
+	err = NewZcash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "zcash.zec.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -402,7 +403,7 @@ func TestZcash_Evaluate_MismatchedOutputCounts_ShouldFail(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newFixed(0, expectedAddr, "1000000")...)
 
-	err = NewZcash().Evaluate(&types.Rule{
+	err = NewZcash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "zcash.zec.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -427,7 +428,7 @@ func TestZcash_Evaluate_DataRegexpConstraints(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newDataRegexp(0, "^=:ETH\\.ETH:0x[a-fA-F0-9]+$")...)
 
-	err := NewZcash().Evaluate(&types.Rule{
+	err := NewZcash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "zcash.zec.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -451,7 +452,7 @@ func TestZcash_Evaluate_DataRegexpConstraints_ShouldFail(t *testing.T) {
 	// Pattern that doesn't match the memo
 	params = append(params, newDataRegexp(0, "^=:BTC\\.BTC:bc1[a-z0-9]+$")...)
 
-	err := NewZcash().Evaluate(&types.Rule{
+	err := NewZcash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "zcash.zec.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -469,7 +470,7 @@ func TestZcash_Evaluate_InvalidTxBytes_ShouldFail(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newFixed(0, expectedAddr, "1000000")...)
 
-	err := NewZcash().Evaluate(&types.Rule{
+	err := NewZcash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "zcash.zec.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -496,7 +497,7 @@ func TestZcash_Evaluate_DenyEffect_ShouldFail(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newFixed(0, expectedAddr, "1000000")...)
 
-	err = NewZcash().Evaluate(&types.Rule{
+	err = NewZcash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "zcash.zec.transfer",
 		Effect:               types.Effect_EFFECT_DENY, // DENY effect should fail
 		ParameterConstraints: params,
@@ -523,7 +524,7 @@ func TestZcash_Evaluate_WithTarget_ShouldFail(t *testing.T) {
 	var params []*types.ParameterConstraint
 	params = append(params, newFixed(0, expectedAddr, "1000000")...)
 
-	err = NewZcash().Evaluate(&types.Rule{
+	err = NewZcash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "zcash.zec.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -567,7 +568,7 @@ func TestZcash_Evaluate_MayaChainSwapStructure(t *testing.T) {
 	// Output 1: OP_RETURN with swap memo pattern
 	params = append(params, newDataRegexp(1, "^=:ETH\\.ETH:0x[a-zA-Z0-9]+:.*")...)
 
-	err = NewZcash().Evaluate(&types.Rule{
+	err = NewZcash().Evaluate(context.Background(), &types.Rule{
 		Resource:             "zcash.zec.transfer",
 		Effect:               types.Effect_EFFECT_ALLOW,
 		ParameterConstraints: params,
@@ -1,6 +1,7 @@
 package xrpl
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"math/big"
@@ -17,16 +18,23 @@ import (
 
 // XRPL represents the XRP Ledger engine implementation
 type XRPL struct {
-	chain *chainxrpl.Chain
+	chain     *chainxrpl.Chain
+	resolvers *resolver.MagicConstantRegistry
 }
 
 // NewXRPL creates a new XRPL engine instance
 func NewXRPL() *XRPL {
 	return &XRPL{
-		chain: chainxrpl.NewChain(),
+		chain:     chainxrpl.NewChain(),
+		resolvers: resolver.NewMagicConstantRegistry(),
 	}
 }
 
+// SetMagicConstantRegistry sets the registry used to resolve magic constants
+func (x *XRPL) SetMagicConstantRegistry(registry *resolver.MagicConstantRegistry) {
+	x.resolvers = registry
+}
+
 // Supports returns true if this engine supports the given chain
 func (x *XRPL) Supports(chain common.Chain) bool {
 	return chain == common.XRP
@@ -34,12 +42,17 @@ func (x *XRPL) Supports(chain common.Chain) bool {
 
 // Evaluate validates an XRPL transaction against policy rules
 // This is the main entry point called by the main engine
-func (x *XRPL) Evaluate(rule *types.Rule, txBytes []byte) error {
+func (x *XRPL) Evaluate(ctx context.Context, rule *types.Rule, txBytes []byte) error {
 	// Validate rule effect is ALLOW (following existing pattern from BTC/EVM engines)
 	if rule.GetEffect().String() != types.Effect_EFFECT_ALLOW.String() {
 		return fmt.Errorf("only allow rules supported, got: %s", rule.GetEffect().String())
 	}
+	return x.Match(ctx, rule, txBytes)
+}
 
+// Match checks the transaction against the rule regardless of its effect.
+// A nil error means the rule matches the transaction.
+func (x *XRPL) Match(ctx context.Context, rule *types.Rule, txBytes []byte) error {
 	// Parse resource to extract protocol and function information
 	r, err := util.ParseResource(rule.GetResource())
 	if err != nil {
@@ -61,24 +74,55 @@ func (x *XRPL) Evaluate(rule *types.Rule, txBytes []byte) error {
 
 	// Validate it's a Payment transaction
 	if payment.TransactionType != transactions.PaymentTx {
-		return fmt.Errorf("only Payment transactions are supported, got: %s", payment.TransactionType)
+		return stdcompare.NewMismatchError("only Payment transactions are supported, got: %s", payment.TransactionType)
 	}
 
 	// Validate target if specified
-	if err := x.validateTarget(r, rule.GetTarget(), payment); err != nil {
+	if err := x.validateTarget(ctx, r, rule.GetTarget(), payment); err != nil {
 		return fmt.Errorf("failed to validate target: %w", err)
 	}
 
 	// Validate parameter constraints for XRP payments
-	if err := x.validateParameterConstraints(r, rule.GetParameterConstraints(), payment); err != nil {
+	if err := x.validateParameterConstraints(ctx, r, rule.GetParameterConstraints(), payment); err != nil {
 		return fmt.Errorf("failed to validate parameter constraints: %w", err)
 	}
 
 	return nil
 }
 
+// ParameterAsset returns the asset of the named numeric rule parameter, only XRP amounts are supported
+func (x *XRPL) ParameterAsset(_ *types.Rule, _ []byte, name string) (string, error) {
+	if name != "amount" {
+		return "", fmt.Errorf("unsupported numeric parameter: %s", name)
+	}
+	return "", nil
+}
+
+// ParameterValue returns the numeric value of the named rule parameter in the payment
+func (x *XRPL) ParameterValue(_ *types.Rule, txBytes []byte, name string) (*big.Int, error) {
+	decodedTx, err := x.chain.ParseTransactionBytes(txBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse XRPL transaction: %w", err)
+	}
+
+	parsedTx, ok := decodedTx.(*chainxrpl.ParsedXRPLTransaction)
+	if !ok {
+		return nil, fmt.Errorf("unexpected transaction type: %T", decodedTx)
+	}
+
+	value, err := x.extractParameterValue(name, parsedTx.GetPayment())
+	if err != nil {
+		return nil, err
+	}
+	amount, ok := value.(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("parameter %s is not numeric: %T", name, value)
+	}
+	return amount, nil
+}
+
 // validateTarget validates the transaction target against the rule target
-func (x *XRPL) validateTarget(resource *types.ResourcePath, target *types.Target, payment *transactions.Payment) error {
+func (x *XRPL) validateTarget(ctx context.Context, resource *types.ResourcePath, target *types.Target, payment *transactions.Payment) error {
 	if target == nil || target.GetTargetType() == types.TargetType_TARGET_TYPE_UNSPECIFIED {
 		return nil // No target validation required
 	}
@@ -92,11 +136,11 @@ func (x *XRPL) validateTarget(resource *types.ResourcePath, target *types.Target
 		// For XRPL, we validate against the Destination (recipient)
 		actualDestination := string(payment.Destination)
 		if actualDestination != expectedAddress {
-			return fmt.Errorf("target address mismatch: expected=%s, actual=%s",
+			return stdcompare.NewMismatchError("target address mismatch: expected=%s, actual=%s",
 				expectedAddress, actualDestination)
 		}
 	case types.TargetType_TARGET_TYPE_MAGIC_CONSTANT:
-		resolve, err := resolver.NewMagicConstantRegistry().GetResolver(target.GetMagicConstant())
+		resolve, err := x.resolvers.GetResolver(target.GetMagicConstant())
 		if err != nil {
 			return fmt.Errorf(
 				"failed to get resolver: magic_const=%s",
@@ -105,6 +149,7 @@ func (x *XRPL) validateTarget(resource *types.ResourcePath, target *types.Target
 		}
 
 		resolvedAddr, _, err := resolve.Resolve(
+			ctx,
 			target.GetMagicConstant(),
 			resource.ChainId,
 			"default",
@@ -118,7 +163,7 @@ func (x *XRPL) validateTarget(resource *types.ResourcePath, target *types.Target
 		}
 		actualDestination := string(payment.Destination)
 		if actualDestination != resolvedAddr {
-			return fmt.Errorf(
+			return stdcompare.NewMismatchError(
 				"tx target is wrong: tx_to=%s, rule_magic_const_resolved=%s",
 				actualDestination,
 				resolvedAddr,
@@ -134,7 +179,7 @@ func (x *XRPL) validateTarget(resource *types.ResourcePath, target *types.Target
 }
 
 // validateParameterConstraints validates all parameter constraints
-func (x *XRPL) validateParameterConstraints(resource *types.ResourcePath, constraints []*types.ParameterConstraint, payment *transactions.Payment) error {
+func (x *XRPL) validateParameterConstraints(ctx context.Context, resource *types.ResourcePath, constraints []*types.ParameterConstraint, payment *transactions.Payment) error {
 	for _, constraint := range constraints {
 		paramName := constraint.GetParameterName()
 
@@ -145,7 +190,7 @@ func (x *XRPL) validateParameterConstraints(resource *types.ResourcePath, constr
 		}
 
 		// Use type-based constraint validation
-		if err := x.assertArgsByType(resource.ChainId, paramName, value, constraints); err != nil {
+		if err := x.assertArgsByType(ctx, resource.ChainId, paramName, value, constraints); err != nil {
 			return fmt.Errorf("constraint validation failed for parameter %s: %w", paramName, err)
 		}
 	}
@@ -184,10 +229,12 @@ func (x *XRPL) extractCurrencyAmountAsBigInt(amount xrptypes.CurrencyAmount) (*b
 }
 
 // assertArgsByType validates constraints using the appropriate comparator based on Go type
-func (x *XRPL) assertArgsByType(chainId, inputName string, arg interface{}, constraints []*types.ParameterConstraint) error {
+func (x *XRPL) assertArgsByType(ctx context.Context, chainId, inputName string, arg interface{}, constraints []*types.ParameterConstraint) error {
 	switch actual := arg.(type) {
 	case string:
 		err := stdcompare.AssertArg(
+			ctx,
+			x.resolvers,
 			chainId,
 			constraints,
 			inputName,
@@ -200,6 +247,8 @@ func (x *XRPL) assertArgsByType(chainId, inputName string, arg interface{}, cons
 
 	case *big.Int:
 		err := stdcompare.AssertArg(
+			ctx,
+			x.resolvers,
 			chainId,
 			constraints,
 			inputName,
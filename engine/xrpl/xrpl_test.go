@@ -1,11 +1,13 @@
 package xrpl
 
 import (
+	"context"
 	"encoding/hex"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/vultisig/recipes/engine/compare"
 	"github.com/vultisig/recipes/types"
 	"github.com/xyield/xrpl-go/model/transactions"
 	xrptypes "github.com/xyield/xrpl-go/model/transactions/types"
@@ -22,7 +24,7 @@ func TestXRPL_Evaluate_DenyRule(t *testing.T) {
 		Effect: types.Effect_EFFECT_DENY,
 	}
 
-	err := xrpl.Evaluate(rule, []byte("any-data"))
+	err := xrpl.Evaluate(context.Background(), rule, []byte("any-data"))
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "only allow rules supported")
 }
@@ -34,7 +36,7 @@ func TestXRPL_Evaluate_InvalidResource(t *testing.T) {
 		Resource: "invalid-resource",
 	}
 
-	err := xrpl.Evaluate(rule, []byte("any-data"))
+	err := xrpl.Evaluate(context.Background(), rule, []byte("any-data"))
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to parse rule resource")
 }
@@ -46,7 +48,7 @@ func TestXRPL_Evaluate_WrongProtocol(t *testing.T) {
 		Resource: "ripple.send",
 	}
 
-	err := xrpl.Evaluate(rule, []byte("any-data"))
+	err := xrpl.Evaluate(context.Background(), rule, []byte("any-data"))
 	assert.Error(t, err)
 	// Since parsing happens first, we get a parsing error before protocol validation
 	assert.Contains(t, err.Error(), "failed to parse XRPL transaction")
@@ -59,7 +61,7 @@ func TestXRPL_Evaluate_UnsupportedFunction(t *testing.T) {
 		Resource: "ripple.swap",
 	}
 
-	err := xrpl.Evaluate(rule, []byte("any-data"))
+	err := xrpl.Evaluate(context.Background(), rule, []byte("any-data"))
 	assert.Error(t, err)
 	// Since parsing happens first, we get a parsing error before function validation
 	assert.Contains(t, err.Error(), "failed to parse XRPL transaction")
@@ -72,7 +74,7 @@ func TestXRPL_Evaluate_InvalidTransactionData(t *testing.T) {
 		Resource: "ripple.send",
 	}
 
-	err := xrpl.Evaluate(rule, []byte("invalid-tx-data"))
+	err := xrpl.Evaluate(context.Background(), rule, []byte("invalid-tx-data"))
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to parse XRPL transaction")
 }
@@ -96,7 +98,7 @@ func TestXRPL_ValidateTarget_Success(t *testing.T) {
 		},
 	}
 
-	err := xrpl.validateTarget(resource, target, payment)
+	err := xrpl.validateTarget(context.Background(), resource, target, payment)
 	assert.NoError(t, err)
 }
 
@@ -119,7 +121,7 @@ func TestXRPL_ValidateTarget_Mismatch(t *testing.T) {
 		},
 	}
 
-	err := xrpl.validateTarget(resource, target, payment)
+	err := xrpl.validateTarget(context.Background(), resource, target, payment)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "target address mismatch")
 }
@@ -162,7 +164,7 @@ func TestXRPL_ValidateParameterConstraints_Success(t *testing.T) {
 		},
 	}
 
-	err := xrpl.validateParameterConstraints(resource, constraints, payment)
+	err := xrpl.validateParameterConstraints(context.Background(), resource, constraints, payment)
 	assert.NoError(t, err)
 }
 
@@ -195,7 +197,7 @@ func TestXRPL_ValidateParameterConstraints_Failure(t *testing.T) {
 		},
 	}
 
-	err := xrpl.validateParameterConstraints(resource, constraints, payment)
+	err := xrpl.validateParameterConstraints(context.Background(), resource, constraints, payment)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to compare max values")
 }
@@ -246,7 +248,7 @@ func TestXRPL_Evaluate_Success(t *testing.T) {
 		},
 	}
 
-	err = xrpl.Evaluate(rule, txBytes)
+	err = xrpl.Evaluate(context.Background(), rule, txBytes)
 
 	assert.NoError(t, err, "Evaluation should succeed with valid Payment transaction and matching constraints")
 }
@@ -284,7 +286,7 @@ func TestXRPL_MagicConstant_THORChainVault(t *testing.T) {
 		},
 	}
 
-	err = xrpl.Evaluate(rule, txBytes)
+	err = xrpl.Evaluate(context.Background(), rule, txBytes)
 
 	// This should fail with target mismatch (not a resolution error),
 	// which means the magic constant resolution worked
@@ -344,7 +346,7 @@ func TestXRPL_Evaluate_Failure(t *testing.T) {
 		},
 	}
 
-	err = xrpl.Evaluate(rule, txBytes)
+	err = xrpl.Evaluate(context.Background(), rule, txBytes)
 
 	// Should fail due to target address mismatch (fails first)
 	assert.Error(t, err)
@@ -413,7 +415,7 @@ func TestXRPL_Evaluate_Failure_ParameterConstraints(t *testing.T) {
 		},
 	}
 
-	err = xrpl.Evaluate(rule, txBytes)
+	err = xrpl.Evaluate(context.Background(), rule, txBytes)
 
 	// Should fail due to recipient constraint failure (checked first in parameter validation)
 	assert.Error(t, err)
@@ -476,7 +478,7 @@ func TestXRPL_Evaluate_Swap_Success(t *testing.T) {
 		},
 	}
 
-	err = xrpl.Evaluate(rule, txBytes)
+	err = xrpl.Evaluate(context.Background(), rule, txBytes)
 	assert.NoError(t, err, "Swap should pass validation")
 }
 
@@ -512,7 +514,7 @@ func TestXRPL_Evaluate_Swap_WrongTarget(t *testing.T) {
 		},
 	}
 
-	err = xrpl.Evaluate(rule, txBytes)
+	err = xrpl.Evaluate(context.Background(), rule, txBytes)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "target address mismatch", "Should fail with wrong target address")
 }
@@ -549,7 +551,7 @@ func TestXRPL_Evaluate_Swap_WrongAsset(t *testing.T) {
 		},
 	}
 
-	err = xrpl.Evaluate(rule, txBytes)
+	err = xrpl.Evaluate(context.Background(), rule, txBytes)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "regexp value constraint failed", "Should fail with wrong asset constraint")
 }
@@ -586,7 +588,7 @@ func TestXRPL_Evaluate_Swap_AmountTooHigh(t *testing.T) {
 		},
 	}
 
-	err = xrpl.Evaluate(rule, txBytes)
+	err = xrpl.Evaluate(context.Background(), rule, txBytes)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to compare max values", "Should fail with amount too high")
 }
@@ -645,7 +647,7 @@ func TestXRPL_Evaluate_Swap_ShortformAsset_Success(t *testing.T) {
 		},
 	}
 
-	err = xrpl.Evaluate(rule, txBytes)
+	err = xrpl.Evaluate(context.Background(), rule, txBytes)
 	assert.NoError(t, err, "Swap should pass validation with flexible asset pattern that accepts both BTC.BTC and b")
 }
 
@@ -683,7 +685,7 @@ func TestXRPL_Send_Success_WithMemo(t *testing.T) {
 		},
 	}
 
-	err = xrpl.Evaluate(rule, txBytes)
+	err = xrpl.Evaluate(context.Background(), rule, txBytes)
 	assert.NoError(t, err, "Payment transaction with memo should pass validation")
 }
 
@@ -720,8 +722,41 @@ func TestXRPL_Send_Failure_WrongMemo(t *testing.T) {
 		},
 	}
 
-	err = xrpl.Evaluate(rule, txBytes)
+	err = xrpl.Evaluate(context.Background(), rule, txBytes)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to compare fixed values",
 		"Should fail with wrong memo constraint")
 }
+
+func TestXRPL_Match_DenyRecipient(t *testing.T) {
+	xrpl := NewXRPL()
+
+	// Destination: rw2ciyaNshpHe7bCHo4bRWq6pqqynnWKQg
+	realTxHex := "12000022000000002300000FA42405ACB00D2E68FC974D201B05E4846E61400000000000264168400000000000000C7321ED9A3DFF30C22A2848FF6EF4647F93091AB1DB2B14D2BB2A76CA777448968DF16174408158110FC627911D7556BE337B567DB8908E9C5730EBB2344A2B682D5FA9774787845597883249B66A7D23288737F051007DF302A56B84FA1917443106065807811438B8C86B89B8517B209A5F7290F5B13F72CA3B0583146914CB622B8E41E150DE431F48DA244A69809366"
+	txBytes, err := hex.DecodeString(realTxHex)
+	assert.NoError(t, err)
+
+	// deny any payment to the recipient, whatever the amount
+	rule := func(recipient string) *types.Rule {
+		return &types.Rule{
+			Effect:   types.Effect_EFFECT_DENY,
+			Resource: "ripple.send",
+			ParameterConstraints: []*types.ParameterConstraint{
+				{
+					ParameterName: "recipient",
+					Constraint: &types.Constraint{
+						Type:  types.ConstraintType_CONSTRAINT_TYPE_FIXED,
+						Value: &types.Constraint_FixedValue{FixedValue: recipient},
+					},
+				},
+			},
+		}
+	}
+
+	err = xrpl.Match(context.Background(), rule("rw2ciyaNshpHe7bCHo4bRWq6pqqynnWKQg"), txBytes)
+	assert.NoError(t, err)
+
+	err = xrpl.Match(context.Background(), rule("rN7n7otQDd6FczFgLdSqtcsAUxDkw6fzRH"), txBytes)
+	assert.Error(t, err)
+	assert.True(t, compare.IsMismatch(err), err)
+}
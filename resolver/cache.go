@@ -0,0 +1,157 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/vultisig/recipes/types"
+)
+
+const (
+	DefaultCacheTTL                  = time.Minute
+	DefaultCacheStaleWhileRevalidate = 30 * time.Second
+)
+
+// CacheConfig configures CachingResolver
+type CacheConfig struct {
+	// TTL is how long a resolution is served from cache without refreshing
+	TTL time.Duration
+	// StaleWhileRevalidate is how long after TTL an expired resolution is still served
+	// while it's refreshed in the background. Zero refreshes synchronously once TTL expires.
+	// Inbound addresses, which can be halted, are always refreshed synchronously
+	StaleWhileRevalidate time.Duration
+}
+
+// DefaultCacheConfig returns the cache config used by the engine
+func DefaultCacheConfig() CacheConfig {
+	return CacheConfig{
+		TTL:                  DefaultCacheTTL,
+		StaleWhileRevalidate: DefaultCacheStaleWhileRevalidate,
+	}
+}
+
+type cacheKey struct {
+	constant types.MagicConstant
+	chainID  string
+	assetID  string
+}
+
+type cacheEntry struct {
+	address    string
+	memo       string
+	resolvedAt time.Time
+	refreshing bool
+}
+
+// haltable are magic constants resolved to inbound addresses, which can be halted (ErrHalted)
+var haltable = map[types.MagicConstant]bool{
+	types.MagicConstant_THORCHAIN_VAULT:  true,
+	types.MagicConstant_THORCHAIN_ROUTER: true,
+	types.MagicConstant_MAYACHAIN_VAULT:  true,
+	types.MagicConstant_MAYACHAIN_ROUTER: true,
+}
+
+// CachingResolver caches resolutions of the wrapped resolver per magic constant, chain and asset.
+// Errors are never cached. Inbound addresses, which can be halted, are never served stale: once TTL
+// expires they're resolved synchronously, so a halt reported by the wrapped resolver (ErrHalted) stops
+// the resolution at once. Within TTL the halt isn't noticed, keep TTL short to bound that time
+type CachingResolver struct {
+	inner  Resolver
+	config CacheConfig
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[cacheKey]*cacheEntry
+}
+
+// NewCachingResolver wraps the resolver with a TTL cache
+func NewCachingResolver(inner Resolver, config CacheConfig) *CachingResolver {
+	return &CachingResolver{
+		inner:   inner,
+		config:  config,
+		now:     time.Now,
+		entries: make(map[cacheKey]*cacheEntry),
+	}
+}
+
+func (r *CachingResolver) Supports(constant types.MagicConstant) bool {
+	return r.inner.Supports(constant)
+}
+
+// Resolve returns the cached resolution if it's fresh, the stale one of the constant which can't be halted
+// while refreshing it in the background, or resolves it with the wrapped resolver otherwise
+func (r *CachingResolver) Resolve(
+	ctx context.Context,
+	constant types.MagicConstant,
+	chainID, assetID string,
+) (string, string, error) {
+	key := cacheKey{constant: constant, chainID: chainID, assetID: assetID}
+
+	r.mu.Lock()
+	entry, ok := r.entries[key]
+	if ok {
+		age := r.now().Sub(entry.resolvedAt)
+		if age < r.config.TTL {
+			r.mu.Unlock()
+			return entry.address, entry.memo, nil
+		}
+		if age < r.config.TTL+r.config.StaleWhileRevalidate && !haltable[constant] {
+			if !entry.refreshing {
+				entry.refreshing = true
+				// refresh must outlive the request which triggered it
+				go r.refresh(context.WithoutCancel(ctx), key)
+			}
+			r.mu.Unlock()
+			return entry.address, entry.memo, nil
+		}
+	}
+	r.mu.Unlock()
+
+	return r.resolve(ctx, key)
+}
+
+func (r *CachingResolver) refresh(ctx context.Context, key cacheKey) {
+	_, _, err := r.resolve(ctx, key)
+	if err == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if entry, ok := r.entries[key]; ok {
+		// keep serving stale until it expires, next call after that retries synchronously
+		entry.refreshing = false
+	}
+}
+
+func (r *CachingResolver) resolve(ctx context.Context, key cacheKey) (string, string, error) {
+	address, memo, err := r.inner.Resolve(ctx, key.constant, key.chainID, key.assetID)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		if errors.Is(err, ErrHalted) {
+			delete(r.entries, key)
+		}
+		return "", "", err
+	}
+	r.entries[key] = &cacheEntry{
+		address:    address,
+		memo:       memo,
+		resolvedAt: r.now(),
+	}
+	return address, memo, nil
+}
+
+// WithCache returns a registry with every registered resolver wrapped into CachingResolver
+func (r *MagicConstantRegistry) WithCache(config CacheConfig) *MagicConstantRegistry {
+	cached := &MagicConstantRegistry{
+		resolvers: make([]Resolver, 0, len(r.resolvers)),
+	}
+	for _, res := range r.resolvers {
+		cached.Register(NewCachingResolver(res, config))
+	}
+	return cached
+}
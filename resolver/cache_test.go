@@ -0,0 +1,263 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vultisig/recipes/types"
+)
+
+// inboundServer is an httptest stand-in for the THORChain inbound_addresses endpoint
+type inboundServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	addresses []InboundAddress
+	fail      bool
+	hits      atomic.Int32
+}
+
+func newInboundServer(t *testing.T, addresses []InboundAddress) *inboundServer {
+	s := &inboundServer{addresses: addresses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s.hits.Add(1)
+		require.Equal(t, "/thorchain/inbound_addresses", req.URL.Path)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(s.addresses)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *inboundServer) set(addresses []InboundAddress, fail bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addresses = addresses
+	s.fail = fail
+}
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newCachedVault(srv *inboundServer, config CacheConfig) (*CachingResolver, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	cached := NewCachingResolver(&THORChainVaultResolver{
+		client:  srv.Client(),
+		baseURL: srv.URL,
+	}, config)
+	cached.now = clock.Now
+	return cached, clock
+}
+
+// stubResolver resolves any constant to the address it's set to, or fails
+type stubResolver struct {
+	mu      sync.Mutex
+	address string
+	fail    bool
+	hits    atomic.Int32
+}
+
+func (r *stubResolver) Supports(types.MagicConstant) bool {
+	return true
+}
+
+func (r *stubResolver) Resolve(context.Context, types.MagicConstant, string, string) (string, string, error) {
+	r.hits.Add(1)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail {
+		return "", "", errors.New("resolver is down")
+	}
+	return r.address, "", nil
+}
+
+func (r *stubResolver) set(address string, fail bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.address = address
+	r.fail = fail
+}
+
+func newCachedStub(address string, config CacheConfig) (*CachingResolver, *stubResolver, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	stub := &stubResolver{address: address}
+	cached := NewCachingResolver(stub, config)
+	cached.now = clock.Now
+	return cached, stub, clock
+}
+
+func btcVault(address string, halted bool) []InboundAddress {
+	return []InboundAddress{{Chain: "BTC", Address: address, Halted: halted}}
+}
+
+func TestCachingResolver_TTL(t *testing.T) {
+	srv := newInboundServer(t, btcVault("bc1-vault-1", false))
+	cached, clock := newCachedVault(srv, CacheConfig{TTL: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		addr, _, err := cached.Resolve(context.Background(), types.MagicConstant_THORCHAIN_VAULT, "bitcoin", "default")
+		require.NoError(t, err)
+		require.Equal(t, "bc1-vault-1", addr)
+	}
+	require.EqualValues(t, 1, srv.hits.Load())
+
+	srv.set(btcVault("bc1-vault-2", false), false)
+	clock.Advance(time.Minute)
+
+	addr, _, err := cached.Resolve(context.Background(), types.MagicConstant_THORCHAIN_VAULT, "bitcoin", "default")
+	require.NoError(t, err)
+	require.Equal(t, "bc1-vault-2", addr)
+	require.EqualValues(t, 2, srv.hits.Load())
+}
+
+func TestCachingResolver_StaleWhileRevalidate(t *testing.T) {
+	cached, stub, clock := newCachedStub("0xrouter-1", CacheConfig{
+		TTL:                  time.Minute,
+		StaleWhileRevalidate: time.Minute,
+	})
+
+	_, _, err := cached.Resolve(context.Background(), types.MagicConstant_LIFI_ROUTER, "ethereum", "")
+	require.NoError(t, err)
+
+	stub.set("0xrouter-2", false)
+	clock.Advance(90 * time.Second)
+
+	// stale value is served at once, refreshed in the background
+	addr, _, err := cached.Resolve(context.Background(), types.MagicConstant_LIFI_ROUTER, "ethereum", "")
+	require.NoError(t, err)
+	require.Equal(t, "0xrouter-1", addr)
+
+	require.Eventually(t, func() bool {
+		addr, _, err := cached.Resolve(context.Background(), types.MagicConstant_LIFI_ROUTER, "ethereum", "")
+		return err == nil && addr == "0xrouter-2"
+	}, time.Second, 10*time.Millisecond)
+	require.EqualValues(t, 2, stub.hits.Load())
+}
+
+func TestCachingResolver_StaleOnFailure(t *testing.T) {
+	cached, stub, clock := newCachedStub("0xrouter-1", CacheConfig{
+		TTL:                  time.Minute,
+		StaleWhileRevalidate: time.Minute,
+	})
+
+	_, _, err := cached.Resolve(context.Background(), types.MagicConstant_LIFI_ROUTER, "ethereum", "")
+	require.NoError(t, err)
+
+	stub.set("", true)
+	clock.Advance(90 * time.Second)
+
+	addr, _, err := cached.Resolve(context.Background(), types.MagicConstant_LIFI_ROUTER, "ethereum", "")
+	require.NoError(t, err)
+	require.Equal(t, "0xrouter-1", addr)
+	require.Eventually(t, func() bool {
+		return stub.hits.Load() == 2
+	}, time.Second, 10*time.Millisecond)
+
+	// past the stale window the error surfaces
+	clock.Advance(time.Minute)
+	_, _, err = cached.Resolve(context.Background(), types.MagicConstant_LIFI_ROUTER, "ethereum", "")
+	require.Error(t, err)
+}
+
+func TestCachingResolver_Halted(t *testing.T) {
+	srv := newInboundServer(t, btcVault("bc1-vault-1", false))
+	cached, clock := newCachedVault(srv, CacheConfig{
+		TTL:                  time.Minute,
+		StaleWhileRevalidate: time.Hour,
+	})
+
+	_, _, err := cached.Resolve(context.Background(), types.MagicConstant_THORCHAIN_VAULT, "bitcoin", "default")
+	require.NoError(t, err)
+
+	// the vault halted between refreshes isn't served stale, the refresh stops the resolution
+	srv.set(btcVault("bc1-vault-1", true), false)
+	clock.Advance(2 * time.Minute)
+	_, _, err = cached.Resolve(context.Background(), types.MagicConstant_THORCHAIN_VAULT, "bitcoin", "default")
+	require.ErrorIs(t, err, ErrHalted)
+	require.EqualValues(t, 2, srv.hits.Load())
+
+	// halted resolution isn't cached
+	srv.set(btcVault("bc1-vault-2", false), false)
+	addr, _, err := cached.Resolve(context.Background(), types.MagicConstant_THORCHAIN_VAULT, "bitcoin", "default")
+	require.NoError(t, err)
+	require.Equal(t, "bc1-vault-2", addr)
+}
+
+func TestCachingResolver_HaltedDuringTTL(t *testing.T) {
+	srv := newInboundServer(t, btcVault("bc1-vault-1", false))
+	cached, clock := newCachedVault(srv, CacheConfig{TTL: time.Minute})
+
+	_, _, err := cached.Resolve(context.Background(), types.MagicConstant_THORCHAIN_VAULT, "bitcoin", "default")
+	require.NoError(t, err)
+
+	// the halt isn't noticed until the cached resolution expires
+	srv.set(btcVault("bc1-vault-1", true), false)
+	clock.Advance(30 * time.Second)
+	addr, _, err := cached.Resolve(context.Background(), types.MagicConstant_THORCHAIN_VAULT, "bitcoin", "default")
+	require.NoError(t, err)
+	require.Equal(t, "bc1-vault-1", addr)
+	require.EqualValues(t, 1, srv.hits.Load())
+
+	clock.Advance(30 * time.Second)
+	_, _, err = cached.Resolve(context.Background(), types.MagicConstant_THORCHAIN_VAULT, "bitcoin", "default")
+	require.ErrorIs(t, err, ErrHalted)
+	_, _, err = cached.Resolve(context.Background(), types.MagicConstant_THORCHAIN_VAULT, "bitcoin", "default")
+	require.ErrorIs(t, err, ErrHalted)
+	require.EqualValues(t, 3, srv.hits.Load())
+}
+
+func TestCachingResolver_KeyedByChain(t *testing.T) {
+	srv := newInboundServer(t, []InboundAddress{
+		{Chain: "BTC", Address: "bc1-vault"},
+		{Chain: "ETH", Address: "0xvault"},
+	})
+	cached, _ := newCachedVault(srv, CacheConfig{TTL: time.Minute})
+
+	btc, _, err := cached.Resolve(context.Background(), types.MagicConstant_THORCHAIN_VAULT, "bitcoin", "default")
+	require.NoError(t, err)
+	eth, _, err := cached.Resolve(context.Background(), types.MagicConstant_THORCHAIN_VAULT, "ethereum", "default")
+	require.NoError(t, err)
+
+	require.Equal(t, "bc1-vault", btc)
+	require.Equal(t, "0xvault", eth)
+	require.EqualValues(t, 2, srv.hits.Load())
+}
+
+func TestMagicConstantRegistry_WithCache(t *testing.T) {
+	registry := NewMagicConstantRegistry().WithCache(DefaultCacheConfig())
+	require.Len(t, registry.resolvers, len(NewMagicConstantRegistry().resolvers))
+
+	res, err := registry.GetResolver(types.MagicConstant_VULTISIG_TREASURY)
+	require.NoError(t, err)
+	require.IsType(t, &CachingResolver{}, res)
+}
@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -27,6 +28,8 @@ var lifiRouters = map[string]string{
 	"Zksync":    "0x341e94069f53234fE6DabeF707aD424830525715", // zkSync has different address
 }
 
+const defaultLiFiBaseURL = "https://li.quest/v1"
+
 type LiFiRouterResolver struct {
 	client  *http.Client
 	baseURL string
@@ -37,7 +40,7 @@ func NewLiFiRouterResolver() Resolver {
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
-		baseURL: "https://li.quest/v1",
+		baseURL: defaultLiFiBaseURL,
 	}
 }
 
@@ -45,7 +48,7 @@ func (r *LiFiRouterResolver) Supports(constant types.MagicConstant) bool {
 	return constant == types.MagicConstant_LIFI_ROUTER
 }
 
-func (r *LiFiRouterResolver) Resolve(constant types.MagicConstant, chainID, _ string) (string, string, error) {
+func (r *LiFiRouterResolver) Resolve(ctx context.Context, constant types.MagicConstant, chainID, _ string) (string, string, error) {
 	if !r.Supports(constant) {
 		return "", "", fmt.Errorf("LiFiRouterResolver does not support type: %v", constant)
 	}
@@ -56,7 +59,7 @@ func (r *LiFiRouterResolver) Resolve(constant types.MagicConstant, chainID, _ st
 	}
 
 	// Fallback: fetch from LiFi chains endpoint
-	router, err := r.fetchRouterFromAPI(chainID)
+	router, err := r.fetchRouterFromAPI(ctx, chainID)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to get LiFi router for chain %s: %w", chainID, err)
 	}
@@ -64,10 +67,15 @@ func (r *LiFiRouterResolver) Resolve(constant types.MagicConstant, chainID, _ st
 	return router, "", nil
 }
 
-func (r *LiFiRouterResolver) fetchRouterFromAPI(chainID string) (string, error) {
+func (r *LiFiRouterResolver) fetchRouterFromAPI(ctx context.Context, chainID string) (string, error) {
 	url := r.baseURL + "/chains"
 
-	resp, err := r.client.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := r.client.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("HTTP request failed: %w", err)
 	}
@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -22,7 +23,7 @@ func NewMayaChainRouterResolver() Resolver {
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
-		baseURL: "https://mayanode.mayachain.info",
+		baseURL: defaultMayaChainBaseURL,
 	}
 }
 
@@ -31,7 +32,7 @@ func (r *MayaChainRouterResolver) Supports(constant types.MagicConstant) bool {
 }
 
 // Resolve converts MAYACHAIN_ROUTER magic constant to current router address
-func (r *MayaChainRouterResolver) Resolve(constant types.MagicConstant, chainID, _ string) (string, string, error) {
+func (r *MayaChainRouterResolver) Resolve(ctx context.Context, constant types.MagicConstant, chainID, _ string) (string, string, error) {
 	if !r.Supports(constant) {
 		return "", "", fmt.Errorf("MayaChainRouterResolver does not support type: %v", constant)
 	}
@@ -48,7 +49,7 @@ func (r *MayaChainRouterResolver) Resolve(constant types.MagicConstant, chainID,
 	}
 
 	// Query MayaChain inbound addresses API
-	inboundAddresses, err := r.getInboundAddresses()
+	inboundAddresses, err := r.getInboundAddresses(ctx)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to get MayaChain inbound addresses: %w", err)
 	}
@@ -63,10 +64,15 @@ func (r *MayaChainRouterResolver) Resolve(constant types.MagicConstant, chainID,
 }
 
 // getInboundAddresses queries the MayaChain API for current inbound addresses
-func (r *MayaChainRouterResolver) getInboundAddresses() ([]MayaInboundAddress, error) {
+func (r *MayaChainRouterResolver) getInboundAddresses(ctx context.Context) ([]MayaInboundAddress, error) {
 	url := r.baseURL + "/mayachain/inbound_addresses"
 
-	resp, err := r.client.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := r.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
@@ -94,7 +100,7 @@ func (r *MayaChainRouterResolver) findRouterForChain(addresses []MayaInboundAddr
 	for _, addr := range addresses {
 		if strings.ToUpper(addr.Chain) == mayaSymbol {
 			if addr.Halted {
-				return "", fmt.Errorf("inbound address for chain %s is currently halted", chainID)
+				return "", fmt.Errorf("inbound address for chain %s is currently halted: %w", chainID, ErrHalted)
 			}
 
 			if addr.Router == "" {
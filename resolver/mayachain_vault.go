@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,6 +12,8 @@ import (
 	"github.com/vultisig/recipes/types"
 )
 
+const defaultMayaChainBaseURL = "https://mayanode.mayachain.info"
+
 type MayaChainVaultResolver struct {
 	client  *http.Client
 	baseURL string
@@ -32,7 +35,7 @@ func NewMayaChainVaultResolver() Resolver {
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
-		baseURL: "https://mayanode.mayachain.info",
+		baseURL: defaultMayaChainBaseURL,
 	}
 }
 
@@ -41,13 +44,13 @@ func (r *MayaChainVaultResolver) Supports(constant types.MagicConstant) bool {
 }
 
 // Resolve converts MAYACHAIN_VAULT magic constant to current Asgard vault address
-func (r *MayaChainVaultResolver) Resolve(constant types.MagicConstant, chainID, assetID string) (string, string, error) {
+func (r *MayaChainVaultResolver) Resolve(ctx context.Context, constant types.MagicConstant, chainID, assetID string) (string, string, error) {
 	if !r.Supports(constant) {
 		return "", "", fmt.Errorf("MayaChainVaultResolver does not support type: %v", constant)
 	}
 
 	// Query MayaChain inbound addresses API
-	inboundAddresses, err := r.getInboundAddresses()
+	inboundAddresses, err := r.getInboundAddresses(ctx)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to get MayaChain inbound addresses: %w", err)
 	}
@@ -62,10 +65,15 @@ func (r *MayaChainVaultResolver) Resolve(constant types.MagicConstant, chainID,
 }
 
 // getInboundAddresses queries the MayaChain API for current inbound addresses
-func (r *MayaChainVaultResolver) getInboundAddresses() ([]MayaInboundAddress, error) {
+func (r *MayaChainVaultResolver) getInboundAddresses(ctx context.Context) ([]MayaInboundAddress, error) {
 	url := r.baseURL + "/mayachain/inbound_addresses"
 
-	resp, err := r.client.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := r.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
@@ -100,7 +108,7 @@ func (r *MayaChainVaultResolver) findAddressForChain(addresses []MayaInboundAddr
 	for _, addr := range addresses {
 		if strings.ToUpper(addr.Chain) == mayaSymbol {
 			if addr.Halted {
-				return "", fmt.Errorf("inbound address for chain %s is currently halted", chainID)
+				return "", fmt.Errorf("inbound address for chain %s is currently halted: %w", chainID, ErrHalted)
 			}
 
 			return addr.Address, nil
@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -112,6 +113,7 @@ func TestMayaChainVaultResolver_Resolve_Integration(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			address, memo, err := resolver.Resolve(
+				context.Background(),
 				types.MagicConstant_MAYACHAIN_VAULT,
 				tt.chainID,
 				tt.assetID,
@@ -152,6 +154,7 @@ func TestMayaChainVaultResolver_UnsupportedConstant(t *testing.T) {
 	resolver := NewMayaChainVaultResolver()
 
 	_, _, err := resolver.Resolve(
+		context.Background(),
 		types.MagicConstant_VULTISIG_TREASURY,
 		"zcash",
 		"zec",
@@ -172,6 +175,7 @@ func TestMayaChainVaultResolver_UnsupportedChainError(t *testing.T) {
 
 	// Test that unsupported chains return immediate error without API call
 	_, _, err := resolver.Resolve(
+		context.Background(),
 		types.MagicConstant_MAYACHAIN_VAULT,
 		"polygon", // Not in our supported chainMap
 		"matic",
@@ -217,6 +221,7 @@ func TestMayaChainVaultResolver_APIConsistency(t *testing.T) {
 		t.Run(chain.chainID, func(t *testing.T) {
 			// Get address from our resolver
 			resolverAddress, _, err := resolver.Resolve(
+				context.Background(),
 				types.MagicConstant_MAYACHAIN_VAULT,
 				chain.chainID,
 				"asset", // assetID doesn't matter for vault resolution
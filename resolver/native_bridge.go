@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/vultisig/recipes/types"
@@ -72,7 +73,7 @@ func (r *NativeBridgeResolver) Supports(constant types.MagicConstant) bool {
 }
 
 // Resolve returns the bridge address for the given magic constant
-func (r *NativeBridgeResolver) Resolve(constant types.MagicConstant, _, _ string) (string, string, error) {
+func (r *NativeBridgeResolver) Resolve(ctx context.Context, constant types.MagicConstant, _, _ string) (string, string, error) {
 	if !r.Supports(constant) {
 		return "", "", fmt.Errorf("NativeBridgeResolver does not support type: %v", constant)
 	}
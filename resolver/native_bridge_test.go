@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"context"
 	"strings"
 	"testing"
 
@@ -80,7 +81,7 @@ func TestNativeBridgeResolverResolve(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
-		addr, memo, err := resolver.Resolve(tc.constant, "", "")
+		addr, memo, err := resolver.Resolve(context.Background(), tc.constant, "", "")
 		if err != nil {
 			t.Errorf("Resolve(%v) failed: %v", tc.constant, err)
 			continue
@@ -168,7 +169,7 @@ func TestLiFiRouterResolverResolve(t *testing.T) {
 	expectedMainnet := "0x1231DEB6f5749EF6cE6943a275A1D3E7486F4EaE"
 
 	for _, chain := range chains {
-		addr, memo, err := resolver.Resolve(types.MagicConstant_LIFI_ROUTER, chain, "")
+		addr, memo, err := resolver.Resolve(context.Background(), types.MagicConstant_LIFI_ROUTER, chain, "")
 		if err != nil {
 			t.Errorf("Resolve(LIFI_ROUTER, %s) failed: %v", chain, err)
 			continue
@@ -182,7 +183,7 @@ func TestLiFiRouterResolverResolve(t *testing.T) {
 	}
 
 	// zkSync has a different address
-	addr, _, err := resolver.Resolve(types.MagicConstant_LIFI_ROUTER, "Zksync", "")
+	addr, _, err := resolver.Resolve(context.Background(), types.MagicConstant_LIFI_ROUTER, "Zksync", "")
 	if err != nil {
 		t.Errorf("Resolve(LIFI_ROUTER, Zksync) failed: %v", err)
 	}
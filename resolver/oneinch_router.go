@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/vultisig/recipes/types"
@@ -31,7 +32,7 @@ func (r *OneInchRouterResolver) Supports(constant types.MagicConstant) bool {
 	return constant == types.MagicConstant_ONEINCH_ROUTER
 }
 
-func (r *OneInchRouterResolver) Resolve(constant types.MagicConstant, chainID, _ string) (string, string, error) {
+func (r *OneInchRouterResolver) Resolve(ctx context.Context, constant types.MagicConstant, chainID, _ string) (string, string, error) {
 	if !r.Supports(constant) {
 		return "", "", fmt.Errorf("OneInchRouterResolver does not support type: %v", constant)
 	}
@@ -2,6 +2,8 @@ package resolver
 
 import (
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/vultisig/recipes/types"
 )
@@ -10,19 +12,60 @@ type MagicConstantRegistry struct {
 	resolvers []Resolver
 }
 
+// RegistryConfig configures the network resolvers of the registry
+type RegistryConfig struct {
+	// HTTPClient is used by THORChain, MayaChain and LiFi resolvers
+	HTTPClient       *http.Client
+	THORChainBaseURL string
+	MayaChainBaseURL string
+	LiFiBaseURL      string
+}
+
+// DefaultRegistryConfig returns the config of NewMagicConstantRegistry: public endpoints, 10s timeout
+func DefaultRegistryConfig() RegistryConfig {
+	return RegistryConfig{
+		HTTPClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		THORChainBaseURL: defaultTHORChainBaseURL,
+		MayaChainBaseURL: defaultMayaChainBaseURL,
+		LiFiBaseURL:      defaultLiFiBaseURL,
+	}
+}
+
 func NewMagicConstantRegistry() *MagicConstantRegistry {
+	return NewMagicConstantRegistryWithConfig(DefaultRegistryConfig())
+}
+
+// NewMagicConstantRegistryWithConfig creates a registry with all resolvers registered,
+// empty config fields fall back to DefaultRegistryConfig
+func NewMagicConstantRegistryWithConfig(config RegistryConfig) *MagicConstantRegistry {
+	defaults := DefaultRegistryConfig()
+	if config.HTTPClient == nil {
+		config.HTTPClient = defaults.HTTPClient
+	}
+	if config.THORChainBaseURL == "" {
+		config.THORChainBaseURL = defaults.THORChainBaseURL
+	}
+	if config.MayaChainBaseURL == "" {
+		config.MayaChainBaseURL = defaults.MayaChainBaseURL
+	}
+	if config.LiFiBaseURL == "" {
+		config.LiFiBaseURL = defaults.LiFiBaseURL
+	}
+
 	registry := &MagicConstantRegistry{
 		resolvers: make([]Resolver, 0),
 	}
 
 	// Register all resolvers
 	registry.Register(NewDefaultTreasuryResolver())
-	registry.Register(NewTHORChainVaultResolver())
-	registry.Register(NewTHORChainRouterResolver())
-	registry.Register(NewMayaChainVaultResolver())
-	registry.Register(NewMayaChainRouterResolver())
+	registry.Register(&THORChainVaultResolver{client: config.HTTPClient, baseURL: config.THORChainBaseURL})
+	registry.Register(&THORChainRouterResolver{client: config.HTTPClient, baseURL: config.THORChainBaseURL})
+	registry.Register(&MayaChainVaultResolver{client: config.HTTPClient, baseURL: config.MayaChainBaseURL})
+	registry.Register(&MayaChainRouterResolver{client: config.HTTPClient, baseURL: config.MayaChainBaseURL})
 	// Swap aggregator routers
-	registry.Register(NewLiFiRouterResolver())
+	registry.Register(&LiFiRouterResolver{client: config.HTTPClient, baseURL: config.LiFiBaseURL})
 	registry.Register(NewOneInchRouterResolver())
 	registry.Register(NewUniswapRouterResolver())
 	// Native L2 bridge resolvers
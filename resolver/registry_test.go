@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"context"
 	"testing"
 
 	"github.com/vultisig/recipes/types"
@@ -62,6 +63,6 @@ func (m *mockResolver) Supports(constant types.MagicConstant) bool {
 	return constant == types.MagicConstant_UNSPECIFIED
 }
 
-func (m *mockResolver) Resolve(constant types.MagicConstant, chainID, assetID string) (string, string, error) {
+func (m *mockResolver) Resolve(_ context.Context, constant types.MagicConstant, chainID, assetID string) (string, string, error) {
 	return "mock-address", "", nil
 }
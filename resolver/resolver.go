@@ -1,14 +1,51 @@
 package resolver
 
 import (
+	"context"
+	"errors"
+
 	"github.com/vultisig/recipes/types"
 )
 
+// ErrHalted is returned when the resolved inbound address is halted and must not receive funds
+var ErrHalted = errors.New("inbound address halted")
+
 // Resolver defines the interface for magic constant resolution
 type Resolver interface {
 	// Supports returns true if this resolver can handle the given magic constant
 	Supports(constant types.MagicConstant) bool
 
-	// Resolve converts a magic constant to an actual address + memo
+	// Resolve converts a magic constant to an actual address + memo,
+	// network resolvers must abort the request once ctx is done
+	Resolve(ctx context.Context, constant types.MagicConstant, chainID, assetID string) (string, string, error)
+}
+
+// LegacyResolver is the resolver interface without context, use FromLegacy to register it
+type LegacyResolver interface {
+	Supports(constant types.MagicConstant) bool
 	Resolve(constant types.MagicConstant, chainID, assetID string) (string, string, error)
 }
+
+// FromLegacy adapts a LegacyResolver to Resolver, the context is only checked before resolving
+func FromLegacy(r LegacyResolver) Resolver {
+	return &legacyAdapter{inner: r}
+}
+
+type legacyAdapter struct {
+	inner LegacyResolver
+}
+
+func (a *legacyAdapter) Supports(constant types.MagicConstant) bool {
+	return a.inner.Supports(constant)
+}
+
+func (a *legacyAdapter) Resolve(
+	ctx context.Context,
+	constant types.MagicConstant,
+	chainID, assetID string,
+) (string, string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", "", err
+	}
+	return a.inner.Resolve(constant, chainID, assetID)
+}
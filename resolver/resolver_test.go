@@ -0,0 +1,71 @@
+package resolver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/vultisig/recipes/types"
+)
+
+type legacyResolver struct{}
+
+func (l *legacyResolver) Supports(constant types.MagicConstant) bool {
+	return constant == types.MagicConstant_UNSPECIFIED
+}
+
+func (l *legacyResolver) Resolve(_ types.MagicConstant, chainID, _ string) (string, string, error) {
+	return "legacy-" + chainID, "", nil
+}
+
+func TestFromLegacy(t *testing.T) {
+	res := FromLegacy(&legacyResolver{})
+	require.True(t, res.Supports(types.MagicConstant_UNSPECIFIED))
+
+	addr, _, err := res.Resolve(context.Background(), types.MagicConstant_UNSPECIFIED, "bitcoin", "")
+	require.NoError(t, err)
+	require.Equal(t, "legacy-bitcoin", addr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = res.Resolve(ctx, types.MagicConstant_UNSPECIFIED, "bitcoin", "")
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestNewMagicConstantRegistryWithConfig(t *testing.T) {
+	srv := newInboundServer(t, btcVault("bc1-local-vault", false))
+
+	registry := NewMagicConstantRegistryWithConfig(RegistryConfig{
+		THORChainBaseURL: srv.URL,
+	})
+
+	res, err := registry.GetResolver(types.MagicConstant_THORCHAIN_VAULT)
+	require.NoError(t, err)
+
+	addr, _, err := res.Resolve(context.Background(), types.MagicConstant_THORCHAIN_VAULT, "bitcoin", "default")
+	require.NoError(t, err)
+	require.Equal(t, "bc1-local-vault", addr)
+	require.EqualValues(t, 1, srv.hits.Load())
+}
+
+func TestTHORChainVaultResolver_ContextCancelled(t *testing.T) {
+	blocked := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-blocked:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(blocked)
+
+	res := &THORChainVaultResolver{client: srv.Client(), baseURL: srv.URL}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err := res.Resolve(ctx, types.MagicConstant_THORCHAIN_VAULT, "bitcoin", "default")
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"context"
 	"testing"
 
 	"github.com/vultisig/recipes/types"
@@ -22,7 +23,7 @@ func TestLiFiRouterResolver(t *testing.T) {
 	expectedAddress := "0x1231DEB6f5749EF6cE6943a275A1D3E7486F4EaE"
 
 	for _, chain := range chains {
-		addr, _, err := resolver.Resolve(types.MagicConstant_LIFI_ROUTER, chain, "")
+		addr, _, err := resolver.Resolve(context.Background(), types.MagicConstant_LIFI_ROUTER, chain, "")
 		if err != nil {
 			t.Errorf("LiFi router should be available for %s: %v", chain, err)
 			continue
@@ -53,7 +54,7 @@ func TestOneInchRouterResolver(t *testing.T) {
 	}
 
 	for chain, expectedAddress := range testCases {
-		addr, _, err := resolver.Resolve(types.MagicConstant_ONEINCH_ROUTER, chain, "")
+		addr, _, err := resolver.Resolve(context.Background(), types.MagicConstant_ONEINCH_ROUTER, chain, "")
 		if err != nil {
 			t.Errorf("1inch router should be available for %s: %v", chain, err)
 			continue
@@ -64,13 +65,13 @@ func TestOneInchRouterResolver(t *testing.T) {
 	}
 
 	// Test unsupported chain
-	_, _, err := resolver.Resolve(types.MagicConstant_ONEINCH_ROUTER, "Solana", "")
+	_, _, err := resolver.Resolve(context.Background(), types.MagicConstant_ONEINCH_ROUTER, "Solana", "")
 	if err == nil {
 		t.Error("1inch should not be available for Solana")
 	}
 
 	// Fantom removed from V6 - should error
-	_, _, err = resolver.Resolve(types.MagicConstant_ONEINCH_ROUTER, "Fantom", "")
+	_, _, err = resolver.Resolve(context.Background(), types.MagicConstant_ONEINCH_ROUTER, "Fantom", "")
 	if err == nil {
 		t.Error("1inch V6 should not be available for Fantom (removed)")
 	}
@@ -94,7 +95,7 @@ func TestUniswapRouterResolver(t *testing.T) {
 	}
 
 	for chain, expectedAddress := range testCases {
-		addr, _, err := resolver.Resolve(types.MagicConstant_UNISWAP_UNIVERSAL_ROUTER, chain, "")
+		addr, _, err := resolver.Resolve(context.Background(), types.MagicConstant_UNISWAP_UNIVERSAL_ROUTER, chain, "")
 		if err != nil {
 			t.Errorf("Uniswap router should be available for %s: %v", chain, err)
 			continue
@@ -105,7 +106,7 @@ func TestUniswapRouterResolver(t *testing.T) {
 	}
 
 	// Test unsupported chain
-	_, _, err := resolver.Resolve(types.MagicConstant_UNISWAP_UNIVERSAL_ROUTER, "Solana", "")
+	_, _, err := resolver.Resolve(context.Background(), types.MagicConstant_UNISWAP_UNIVERSAL_ROUTER, "Solana", "")
 	if err == nil {
 		t.Error("Uniswap should not be available for Solana")
 	}
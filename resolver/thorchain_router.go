@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -30,7 +31,7 @@ func (r *THORChainRouterResolver) Supports(constant types.MagicConstant) bool {
 	return constant == types.MagicConstant_THORCHAIN_ROUTER
 }
 
-func (r *THORChainRouterResolver) Resolve(constant types.MagicConstant, chainID, _ string) (string, string, error) {
+func (r *THORChainRouterResolver) Resolve(ctx context.Context, constant types.MagicConstant, chainID, _ string) (string, string, error) {
 	if !r.Supports(constant) {
 		return "", "", fmt.Errorf("THORChainRouterResolver does not support type: %v", constant)
 	}
@@ -44,7 +45,7 @@ func (r *THORChainRouterResolver) Resolve(constant types.MagicConstant, chainID,
 		return "", "", fmt.Errorf("THORCHAIN_ROUTER is only available for EVM chains, %s is not an EVM chain", chainID)
 	}
 
-	inboundAddresses, err := r.getInboundAddresses()
+	inboundAddresses, err := r.getInboundAddresses(ctx)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to get THORChain inbound addresses: %w", err)
 	}
@@ -57,10 +58,15 @@ func (r *THORChainRouterResolver) Resolve(constant types.MagicConstant, chainID,
 	return router, "", nil
 }
 
-func (r *THORChainRouterResolver) getInboundAddresses() ([]InboundAddress, error) {
+func (r *THORChainRouterResolver) getInboundAddresses(ctx context.Context) ([]InboundAddress, error) {
 	url := r.baseURL + "/thorchain/inbound_addresses"
 
-	resp, err := r.client.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := r.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
@@ -98,7 +104,7 @@ func (r *THORChainRouterResolver) findRouterForChain(addresses []InboundAddress,
 	for _, addr := range addresses {
 		if strings.ToUpper(addr.Chain) == thorchainSymbol {
 			if addr.Halted {
-				return "", fmt.Errorf("inbound address for chain %s is currently halted", chainID)
+				return "", fmt.Errorf("inbound address for chain %s is currently halted: %w", chainID, ErrHalted)
 			}
 
 			if addr.Router == "" {
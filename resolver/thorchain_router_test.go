@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"context"
 	"strings"
 	"testing"
 
@@ -107,6 +108,7 @@ func TestTHORChainRouterResolver_Resolve_Integration(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			address, memo, err := resolver.Resolve(
+				context.Background(),
 				types.MagicConstant_THORCHAIN_ROUTER,
 				tt.chainID,
 				tt.assetID,
@@ -145,6 +147,7 @@ func TestTHORChainRouterResolver_UnsupportedConstant(t *testing.T) {
 	resolver := NewTHORChainRouterResolver()
 
 	_, _, err := resolver.Resolve(
+		context.Background(),
 		types.MagicConstant_THORCHAIN_VAULT,
 		"ethereum",
 		"eth",
@@ -168,6 +171,7 @@ func TestTHORChainRouterResolver_NonEvmChainError(t *testing.T) {
 	for _, chainID := range nonEvmChains {
 		t.Run(chainID, func(t *testing.T) {
 			_, _, err := resolver.Resolve(
+				context.Background(),
 				types.MagicConstant_THORCHAIN_ROUTER,
 				chainID,
 				"asset",
@@ -211,6 +215,7 @@ func TestTHORChainRouterResolver_APIConsistency(t *testing.T) {
 	for _, chain := range supportedEvmChains {
 		t.Run(chain.chainID, func(t *testing.T) {
 			resolverAddress, _, err := resolver.Resolve(
+				context.Background(),
 				types.MagicConstant_THORCHAIN_ROUTER,
 				chain.chainID,
 				"asset",
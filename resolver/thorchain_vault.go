@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -42,13 +43,13 @@ func (r *THORChainVaultResolver) Supports(constant types.MagicConstant) bool {
 }
 
 // Resolve converts THORCHAIN_VAULT magic constant to current Asgard vault address
-func (r *THORChainVaultResolver) Resolve(constant types.MagicConstant, chainID, assetID string) (string, string, error) {
+func (r *THORChainVaultResolver) Resolve(ctx context.Context, constant types.MagicConstant, chainID, assetID string) (string, string, error) {
 	if !r.Supports(constant) {
 		return "", "", fmt.Errorf("THORChainVaultResolver does not support type: %v", constant)
 	}
 
 	// Query THORChain inbound addresses API
-	inboundAddresses, err := r.getInboundAddresses()
+	inboundAddresses, err := r.getInboundAddresses(ctx)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to get THORChain inbound addresses: %w", err)
 	}
@@ -63,10 +64,15 @@ func (r *THORChainVaultResolver) Resolve(constant types.MagicConstant, chainID,
 }
 
 // getInboundAddresses queries the THORChain API for current inbound addresses
-func (r *THORChainVaultResolver) getInboundAddresses() ([]InboundAddress, error) {
+func (r *THORChainVaultResolver) getInboundAddresses(ctx context.Context) ([]InboundAddress, error) {
 	url := r.baseURL + "/thorchain/inbound_addresses"
 
-	resp, err := r.client.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := r.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
@@ -107,7 +113,7 @@ func (r *THORChainVaultResolver) findAddressForChain(addresses []InboundAddress,
 	for _, addr := range addresses {
 		if strings.ToUpper(addr.Chain) == thorchainSymbol {
 			if addr.Halted {
-				return "", fmt.Errorf("inbound address for chain %s is currently halted", chainID)
+				return "", fmt.Errorf("inbound address for chain %s is currently halted: %w", chainID, ErrHalted)
 			}
 
 			return addr.Address, nil
@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -137,6 +138,7 @@ func TestTHORChainVaultResolver_Resolve_Integration(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			address, memo, err := resolver.Resolve(
+				context.Background(),
 				types.MagicConstant_THORCHAIN_VAULT,
 				tt.chainID,
 				tt.assetID,
@@ -176,6 +178,7 @@ func TestTHORChainVaultResolver_UnsupportedConstant(t *testing.T) {
 	resolver := NewTHORChainVaultResolver()
 
 	_, _, err := resolver.Resolve(
+		context.Background(),
 		types.MagicConstant_VULTISIG_TREASURY,
 		"bitcoin",
 		"btc",
@@ -196,6 +199,7 @@ func TestTHORChainVaultResolver_UnsupportedChainError(t *testing.T) {
 
 	// Test that unsupported chains return immediate error without API call
 	_, _, err := resolver.Resolve(
+		context.Background(),
 		types.MagicConstant_THORCHAIN_VAULT,
 		"polygon", // Not in our supported chainMap
 		"matic",
@@ -246,6 +250,7 @@ func TestTHORChainVaultResolver_APIConsistency(t *testing.T) {
 		t.Run(chain.chainID, func(t *testing.T) {
 			// Get address from our resolver
 			resolverAddress, _, err := resolver.Resolve(
+				context.Background(),
 				types.MagicConstant_THORCHAIN_VAULT,
 				chain.chainID,
 				"asset", // assetID doesn't matter for vault resolution
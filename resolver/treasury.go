@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/vultisig/recipes/types"
@@ -48,7 +49,7 @@ func (r *TreasuryResolver) Supports(constant types.MagicConstant) bool {
 	return constant == types.MagicConstant_VULTISIG_TREASURY
 }
 
-func (r *TreasuryResolver) Resolve(constant types.MagicConstant, chainID, assetID string) (string, string, error) {
+func (r *TreasuryResolver) Resolve(ctx context.Context, constant types.MagicConstant, chainID, assetID string) (string, string, error) {
 	if !r.Supports(constant) {
 		return "", "", fmt.Errorf("TreasuryResolver does not support type: %v", constant)
 	}
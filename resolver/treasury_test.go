@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"context"
 	"testing"
 
 	"github.com/vultisig/recipes/types"
@@ -76,13 +77,13 @@ func TestTreasuryResolverResolve(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, _, err := resolver.Resolve(types.MagicConstant_VULTISIG_TREASURY, tt.chainID, tt.assetID)
+			got, _, err := resolver.Resolve(context.Background(), types.MagicConstant_VULTISIG_TREASURY, tt.chainID, tt.assetID)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("TreasuryResolver.Resolve() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("TreasuryResolver.Resolve(context.Background(), ) error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if got != tt.expected {
-				t.Errorf("TreasuryResolver.Resolve() = %v, want %v", got, tt.expected)
+				t.Errorf("TreasuryResolver.Resolve(context.Background(), ) = %v, want %v", got, tt.expected)
 			}
 		})
 	}
@@ -92,9 +93,9 @@ func TestTreasuryResolverResolveNonTreasuryConstant(t *testing.T) {
 	resolver := NewDefaultTreasuryResolver()
 
 	// Test with non-treasury magic constant
-	_, _, err := resolver.Resolve(types.MagicConstant_UNSPECIFIED, "ethereum", "eth")
+	_, _, err := resolver.Resolve(context.Background(), types.MagicConstant_UNSPECIFIED, "ethereum", "eth")
 	if err == nil {
-		t.Error("TreasuryResolver.Resolve() should return error for non-treasury magic constant")
+		t.Error("TreasuryResolver.Resolve(context.Background(), ) should return error for non-treasury magic constant")
 	}
 }
 
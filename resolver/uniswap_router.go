@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/vultisig/recipes/types"
@@ -28,6 +29,12 @@ var uniswapSwapRouter02 = map[string]string{
 	"BSC":      "0xB971eF87ede563556b2ED4b1C0b0019111Dd85d2",
 }
 
+// UniswapSwapRouter02 returns the SwapRouter02 address on the chain
+func UniswapSwapRouter02(chainID string) (string, bool) {
+	router, ok := uniswapSwapRouter02[chainID]
+	return router, ok
+}
+
 type UniswapRouterResolver struct{}
 
 func NewUniswapRouterResolver() Resolver {
@@ -38,7 +45,7 @@ func (r *UniswapRouterResolver) Supports(constant types.MagicConstant) bool {
 	return constant == types.MagicConstant_UNISWAP_UNIVERSAL_ROUTER
 }
 
-func (r *UniswapRouterResolver) Resolve(constant types.MagicConstant, chainID, _ string) (string, string, error) {
+func (r *UniswapRouterResolver) Resolve(ctx context.Context, constant types.MagicConstant, chainID, _ string) (string, string, error) {
 	if !r.Supports(constant) {
 		return "", "", fmt.Errorf("UniswapRouterResolver does not support type: %v", constant)
 	}
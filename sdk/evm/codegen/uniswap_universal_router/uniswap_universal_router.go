@@ -0,0 +1,75 @@
+// Code generated via abigen V2 - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package uniswap_universal_router
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = bytes.Equal
+	_ = errors.New
+	_ = big.NewInt
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = abi.ConvertType
+)
+
+// UniswapUniversalRouterMetaData contains all meta data concerning the UniswapUniversalRouter contract.
+var UniswapUniversalRouterMetaData = bind.MetaData{
+	ABI: "[{\"inputs\":[{\"internalType\":\"bytes\",\"name\":\"commands\",\"type\":\"bytes\"},{\"internalType\":\"bytes[]\",\"name\":\"inputs\",\"type\":\"bytes[]\"}],\"name\":\"execute\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes\",\"name\":\"commands\",\"type\":\"bytes\"},{\"internalType\":\"bytes[]\",\"name\":\"inputs\",\"type\":\"bytes[]\"},{\"internalType\":\"uint256\",\"name\":\"deadline\",\"type\":\"uint256\"}],\"name\":\"execute\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"}]",
+	ID:  "UniswapUniversalRouter",
+}
+
+// UniswapUniversalRouter is an auto generated Go binding around an Ethereum contract.
+type UniswapUniversalRouter struct {
+	abi abi.ABI
+}
+
+// NewUniswapUniversalRouter creates a new instance of UniswapUniversalRouter.
+func NewUniswapUniversalRouter() *UniswapUniversalRouter {
+	parsed, err := UniswapUniversalRouterMetaData.ParseABI()
+	if err != nil {
+		panic(errors.New("invalid ABI: " + err.Error()))
+	}
+	return &UniswapUniversalRouter{abi: *parsed}
+}
+
+// Instance creates a wrapper for a deployed contract instance at the given address.
+// Use this to create the instance object passed to abigen v2 library functions Call, Transact, etc.
+func (c *UniswapUniversalRouter) Instance(backend bind.ContractBackend, addr common.Address) *bind.BoundContract {
+	return bind.NewBoundContract(addr, c.abi, backend, backend, backend)
+}
+
+// PackExecute is the Go binding used to pack the parameters required for calling
+// the contract method with ID 0x24856bc3.
+//
+// Solidity: function execute(bytes commands, bytes[] inputs) payable returns()
+func (uniswapUniversalRouter *UniswapUniversalRouter) PackExecute(commands []byte, inputs [][]byte) []byte {
+	enc, err := uniswapUniversalRouter.abi.Pack("execute", commands, inputs)
+	if err != nil {
+		panic(err)
+	}
+	return enc
+}
+
+// PackExecute0 is the Go binding used to pack the parameters required for calling
+// the contract method with ID 0x3593564c.
+//
+// Solidity: function execute(bytes commands, bytes[] inputs, uint256 deadline) payable returns()
+func (uniswapUniversalRouter *UniswapUniversalRouter) PackExecute0(commands []byte, inputs [][]byte, deadline *big.Int) []byte {
+	enc, err := uniswapUniversalRouter.abi.Pack("execute0", commands, inputs, deadline)
+	if err != nil {
+		panic(err)
+	}
+	return enc
+}
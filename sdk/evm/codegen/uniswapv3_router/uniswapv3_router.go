@@ -0,0 +1,312 @@
+// Code generated via abigen V2 - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package uniswapv3_router
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = bytes.Equal
+	_ = errors.New
+	_ = big.NewInt
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = abi.ConvertType
+)
+
+// IV3SwapRouterExactInputParams is an auto generated low-level Go binding around an user-defined struct.
+type IV3SwapRouterExactInputParams struct {
+	Path             []byte
+	Recipient        common.Address
+	AmountIn         *big.Int
+	AmountOutMinimum *big.Int
+}
+
+// IV3SwapRouterExactInputSingleParams is an auto generated low-level Go binding around an user-defined struct.
+type IV3SwapRouterExactInputSingleParams struct {
+	TokenIn           common.Address
+	TokenOut          common.Address
+	Fee               *big.Int
+	Recipient         common.Address
+	AmountIn          *big.Int
+	AmountOutMinimum  *big.Int
+	SqrtPriceLimitX96 *big.Int
+}
+
+// IV3SwapRouterExactOutputParams is an auto generated low-level Go binding around an user-defined struct.
+type IV3SwapRouterExactOutputParams struct {
+	Path            []byte
+	Recipient       common.Address
+	AmountOut       *big.Int
+	AmountInMaximum *big.Int
+}
+
+// IV3SwapRouterExactOutputSingleParams is an auto generated low-level Go binding around an user-defined struct.
+type IV3SwapRouterExactOutputSingleParams struct {
+	TokenIn           common.Address
+	TokenOut          common.Address
+	Fee               *big.Int
+	Recipient         common.Address
+	AmountOut         *big.Int
+	AmountInMaximum   *big.Int
+	SqrtPriceLimitX96 *big.Int
+}
+
+// Uniswapv3RouterMetaData contains all meta data concerning the Uniswapv3Router contract.
+var Uniswapv3RouterMetaData = bind.MetaData{
+	ABI: "[{\"inputs\":[{\"components\":[{\"internalType\":\"address\",\"name\":\"tokenIn\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"tokenOut\",\"type\":\"address\"},{\"internalType\":\"uint24\",\"name\":\"fee\",\"type\":\"uint24\"},{\"internalType\":\"address\",\"name\":\"recipient\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amountIn\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"amountOutMinimum\",\"type\":\"uint256\"},{\"internalType\":\"uint160\",\"name\":\"sqrtPriceLimitX96\",\"type\":\"uint160\"}],\"internalType\":\"structIV3SwapRouter.ExactInputSingleParams\",\"name\":\"params\",\"type\":\"tuple\"}],\"name\":\"exactInputSingle\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"amountOut\",\"type\":\"uint256\"}],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"components\":[{\"internalType\":\"bytes\",\"name\":\"path\",\"type\":\"bytes\"},{\"internalType\":\"address\",\"name\":\"recipient\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amountIn\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"amountOutMinimum\",\"type\":\"uint256\"}],\"internalType\":\"structIV3SwapRouter.ExactInputParams\",\"name\":\"params\",\"type\":\"tuple\"}],\"name\":\"exactInput\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"amountOut\",\"type\":\"uint256\"}],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"components\":[{\"internalType\":\"address\",\"name\":\"tokenIn\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"tokenOut\",\"type\":\"address\"},{\"internalType\":\"uint24\",\"name\":\"fee\",\"type\":\"uint24\"},{\"internalType\":\"address\",\"name\":\"recipient\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amountOut\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"amountInMaximum\",\"type\":\"uint256\"},{\"internalType\":\"uint160\",\"name\":\"sqrtPriceLimitX96\",\"type\":\"uint160\"}],\"internalType\":\"structIV3SwapRouter.ExactOutputSingleParams\",\"name\":\"params\",\"type\":\"tuple\"}],\"name\":\"exactOutputSingle\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"amountIn\",\"type\":\"uint256\"}],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"components\":[{\"internalType\":\"bytes\",\"name\":\"path\",\"type\":\"bytes\"},{\"internalType\":\"address\",\"name\":\"recipient\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amountOut\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"amountInMaximum\",\"type\":\"uint256\"}],\"internalType\":\"structIV3SwapRouter.ExactOutputParams\",\"name\":\"params\",\"type\":\"tuple\"}],\"name\":\"exactOutput\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"amountIn\",\"type\":\"uint256\"}],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes[]\",\"name\":\"data\",\"type\":\"bytes[]\"}],\"name\":\"multicall\",\"outputs\":[{\"internalType\":\"bytes[]\",\"name\":\"results\",\"type\":\"bytes[]\"}],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"deadline\",\"type\":\"uint256\"},{\"internalType\":\"bytes[]\",\"name\":\"data\",\"type\":\"bytes[]\"}],\"name\":\"multicall\",\"outputs\":[{\"internalType\":\"bytes[]\",\"name\":\"\",\"type\":\"bytes[]\"}],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"previousBlockhash\",\"type\":\"bytes32\"},{\"internalType\":\"bytes[]\",\"name\":\"data\",\"type\":\"bytes[]\"}],\"name\":\"multicall\",\"outputs\":[{\"internalType\":\"bytes[]\",\"name\":\"\",\"type\":\"bytes[]\"}],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"amountMinimum\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"recipient\",\"type\":\"address\"}],\"name\":\"unwrapWETH9\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"amountMinimum\",\"type\":\"uint256\"}],\"name\":\"unwrapWETH9\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"refundETH\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"token\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amountMinimum\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"recipient\",\"type\":\"address\"}],\"name\":\"sweepToken\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"}]",
+	ID:  "Uniswapv3Router",
+}
+
+// Uniswapv3Router is an auto generated Go binding around an Ethereum contract.
+type Uniswapv3Router struct {
+	abi abi.ABI
+}
+
+// NewUniswapv3Router creates a new instance of Uniswapv3Router.
+func NewUniswapv3Router() *Uniswapv3Router {
+	parsed, err := Uniswapv3RouterMetaData.ParseABI()
+	if err != nil {
+		panic(errors.New("invalid ABI: " + err.Error()))
+	}
+	return &Uniswapv3Router{abi: *parsed}
+}
+
+// Instance creates a wrapper for a deployed contract instance at the given address.
+// Use this to create the instance object passed to abigen v2 library functions Call, Transact, etc.
+func (c *Uniswapv3Router) Instance(backend bind.ContractBackend, addr common.Address) *bind.BoundContract {
+	return bind.NewBoundContract(addr, c.abi, backend, backend, backend)
+}
+
+// PackExactInput is the Go binding used to pack the parameters required for calling
+// the contract method with ID 0xb858183f.
+//
+// Solidity: function exactInput((bytes,address,uint256,uint256) params) payable returns(uint256 amountOut)
+func (uniswapv3Router *Uniswapv3Router) PackExactInput(params IV3SwapRouterExactInputParams) []byte {
+	enc, err := uniswapv3Router.abi.Pack("exactInput", params)
+	if err != nil {
+		panic(err)
+	}
+	return enc
+}
+
+// UnpackExactInput is the Go binding that unpacks the parameters returned
+// from invoking the contract method with ID 0xb858183f.
+//
+// Solidity: function exactInput((bytes,address,uint256,uint256) params) payable returns(uint256 amountOut)
+func (uniswapv3Router *Uniswapv3Router) UnpackExactInput(data []byte) (*big.Int, error) {
+	out, err := uniswapv3Router.abi.Unpack("exactInput", data)
+	if err != nil {
+		return new(big.Int), err
+	}
+	out0 := abi.ConvertType(out[0], new(big.Int)).(*big.Int)
+	return out0, err
+}
+
+// PackExactInputSingle is the Go binding used to pack the parameters required for calling
+// the contract method with ID 0x04e45aaf.
+//
+// Solidity: function exactInputSingle((address,address,uint24,address,uint256,uint256,uint160) params) payable returns(uint256 amountOut)
+func (uniswapv3Router *Uniswapv3Router) PackExactInputSingle(params IV3SwapRouterExactInputSingleParams) []byte {
+	enc, err := uniswapv3Router.abi.Pack("exactInputSingle", params)
+	if err != nil {
+		panic(err)
+	}
+	return enc
+}
+
+// UnpackExactInputSingle is the Go binding that unpacks the parameters returned
+// from invoking the contract method with ID 0x04e45aaf.
+//
+// Solidity: function exactInputSingle((address,address,uint24,address,uint256,uint256,uint160) params) payable returns(uint256 amountOut)
+func (uniswapv3Router *Uniswapv3Router) UnpackExactInputSingle(data []byte) (*big.Int, error) {
+	out, err := uniswapv3Router.abi.Unpack("exactInputSingle", data)
+	if err != nil {
+		return new(big.Int), err
+	}
+	out0 := abi.ConvertType(out[0], new(big.Int)).(*big.Int)
+	return out0, err
+}
+
+// PackExactOutput is the Go binding used to pack the parameters required for calling
+// the contract method with ID 0x09b81346.
+//
+// Solidity: function exactOutput((bytes,address,uint256,uint256) params) payable returns(uint256 amountIn)
+func (uniswapv3Router *Uniswapv3Router) PackExactOutput(params IV3SwapRouterExactOutputParams) []byte {
+	enc, err := uniswapv3Router.abi.Pack("exactOutput", params)
+	if err != nil {
+		panic(err)
+	}
+	return enc
+}
+
+// UnpackExactOutput is the Go binding that unpacks the parameters returned
+// from invoking the contract method with ID 0x09b81346.
+//
+// Solidity: function exactOutput((bytes,address,uint256,uint256) params) payable returns(uint256 amountIn)
+func (uniswapv3Router *Uniswapv3Router) UnpackExactOutput(data []byte) (*big.Int, error) {
+	out, err := uniswapv3Router.abi.Unpack("exactOutput", data)
+	if err != nil {
+		return new(big.Int), err
+	}
+	out0 := abi.ConvertType(out[0], new(big.Int)).(*big.Int)
+	return out0, err
+}
+
+// PackExactOutputSingle is the Go binding used to pack the parameters required for calling
+// the contract method with ID 0x5023b4df.
+//
+// Solidity: function exactOutputSingle((address,address,uint24,address,uint256,uint256,uint160) params) payable returns(uint256 amountIn)
+func (uniswapv3Router *Uniswapv3Router) PackExactOutputSingle(params IV3SwapRouterExactOutputSingleParams) []byte {
+	enc, err := uniswapv3Router.abi.Pack("exactOutputSingle", params)
+	if err != nil {
+		panic(err)
+	}
+	return enc
+}
+
+// UnpackExactOutputSingle is the Go binding that unpacks the parameters returned
+// from invoking the contract method with ID 0x5023b4df.
+//
+// Solidity: function exactOutputSingle((address,address,uint24,address,uint256,uint256,uint160) params) payable returns(uint256 amountIn)
+func (uniswapv3Router *Uniswapv3Router) UnpackExactOutputSingle(data []byte) (*big.Int, error) {
+	out, err := uniswapv3Router.abi.Unpack("exactOutputSingle", data)
+	if err != nil {
+		return new(big.Int), err
+	}
+	out0 := abi.ConvertType(out[0], new(big.Int)).(*big.Int)
+	return out0, err
+}
+
+// PackMulticall is the Go binding used to pack the parameters required for calling
+// the contract method with ID 0xac9650d8.
+//
+// Solidity: function multicall(bytes[] data) payable returns(bytes[] results)
+func (uniswapv3Router *Uniswapv3Router) PackMulticall(data [][]byte) []byte {
+	enc, err := uniswapv3Router.abi.Pack("multicall", data)
+	if err != nil {
+		panic(err)
+	}
+	return enc
+}
+
+// UnpackMulticall is the Go binding that unpacks the parameters returned
+// from invoking the contract method with ID 0xac9650d8.
+//
+// Solidity: function multicall(bytes[] data) payable returns(bytes[] results)
+func (uniswapv3Router *Uniswapv3Router) UnpackMulticall(data []byte) ([][]byte, error) {
+	out, err := uniswapv3Router.abi.Unpack("multicall", data)
+	if err != nil {
+		return *new([][]byte), err
+	}
+	out0 := *abi.ConvertType(out[0], new([][]byte)).(*[][]byte)
+	return out0, err
+}
+
+// PackMulticall0 is the Go binding used to pack the parameters required for calling
+// the contract method with ID 0x5ae401dc.
+//
+// Solidity: function multicall(uint256 deadline, bytes[] data) payable returns(bytes[])
+func (uniswapv3Router *Uniswapv3Router) PackMulticall0(deadline *big.Int, data [][]byte) []byte {
+	enc, err := uniswapv3Router.abi.Pack("multicall0", deadline, data)
+	if err != nil {
+		panic(err)
+	}
+	return enc
+}
+
+// UnpackMulticall0 is the Go binding that unpacks the parameters returned
+// from invoking the contract method with ID 0x5ae401dc.
+//
+// Solidity: function multicall(uint256 deadline, bytes[] data) payable returns(bytes[])
+func (uniswapv3Router *Uniswapv3Router) UnpackMulticall0(data []byte) ([][]byte, error) {
+	out, err := uniswapv3Router.abi.Unpack("multicall0", data)
+	if err != nil {
+		return *new([][]byte), err
+	}
+	out0 := *abi.ConvertType(out[0], new([][]byte)).(*[][]byte)
+	return out0, err
+}
+
+// PackMulticall1 is the Go binding used to pack the parameters required for calling
+// the contract method with ID 0x1f0464d1.
+//
+// Solidity: function multicall(bytes32 previousBlockhash, bytes[] data) payable returns(bytes[])
+func (uniswapv3Router *Uniswapv3Router) PackMulticall1(previousBlockhash [32]byte, data [][]byte) []byte {
+	enc, err := uniswapv3Router.abi.Pack("multicall1", previousBlockhash, data)
+	if err != nil {
+		panic(err)
+	}
+	return enc
+}
+
+// UnpackMulticall1 is the Go binding that unpacks the parameters returned
+// from invoking the contract method with ID 0x1f0464d1.
+//
+// Solidity: function multicall(bytes32 previousBlockhash, bytes[] data) payable returns(bytes[])
+func (uniswapv3Router *Uniswapv3Router) UnpackMulticall1(data []byte) ([][]byte, error) {
+	out, err := uniswapv3Router.abi.Unpack("multicall1", data)
+	if err != nil {
+		return *new([][]byte), err
+	}
+	out0 := *abi.ConvertType(out[0], new([][]byte)).(*[][]byte)
+	return out0, err
+}
+
+// PackRefundETH is the Go binding used to pack the parameters required for calling
+// the contract method with ID 0x12210e8a.
+//
+// Solidity: function refundETH() payable returns()
+func (uniswapv3Router *Uniswapv3Router) PackRefundETH() []byte {
+	enc, err := uniswapv3Router.abi.Pack("refundETH")
+	if err != nil {
+		panic(err)
+	}
+	return enc
+}
+
+// PackSweepToken is the Go binding used to pack the parameters required for calling
+// the contract method with ID 0xdf2ab5bb.
+//
+// Solidity: function sweepToken(address token, uint256 amountMinimum, address recipient) payable returns()
+func (uniswapv3Router *Uniswapv3Router) PackSweepToken(token common.Address, amountMinimum *big.Int, recipient common.Address) []byte {
+	enc, err := uniswapv3Router.abi.Pack("sweepToken", token, amountMinimum, recipient)
+	if err != nil {
+		panic(err)
+	}
+	return enc
+}
+
+// PackUnwrapWETH9 is the Go binding used to pack the parameters required for calling
+// the contract method with ID 0x49404b7c.
+//
+// Solidity: function unwrapWETH9(uint256 amountMinimum, address recipient) payable returns()
+func (uniswapv3Router *Uniswapv3Router) PackUnwrapWETH9(amountMinimum *big.Int, recipient common.Address) []byte {
+	enc, err := uniswapv3Router.abi.Pack("unwrapWETH9", amountMinimum, recipient)
+	if err != nil {
+		panic(err)
+	}
+	return enc
+}
+
+// PackUnwrapWETH90 is the Go binding used to pack the parameters required for calling
+// the contract method with ID 0x49616997.
+//
+// Solidity: function unwrapWETH9(uint256 amountMinimum) payable returns()
+func (uniswapv3Router *Uniswapv3Router) PackUnwrapWETH90(amountMinimum *big.Int) []byte {
+	enc, err := uniswapv3Router.abi.Pack("unwrapWETH90", amountMinimum)
+	if err != nil {
+		panic(err)
+	}
+	return enc
+}
@@ -115,6 +115,15 @@ var evmChainConfigs = map[string]EVMChainConfig{
 // GetEVMChainConfig returns the configuration for an EVM chain
 func GetEVMChainConfig(chain string) (*EVMChainConfig, error) {
 	config, ok := evmChainConfigs[chain]
+	if !ok {
+		// chain names differ in case between sources, e.g. ZkSync and Zksync
+		for name, c := range evmChainConfigs {
+			if strings.EqualFold(name, chain) {
+				config, ok = c, true
+				break
+			}
+		}
+	}
 	if !ok {
 		return nil, fmt.Errorf("unknown EVM chain: %s", chain)
 	}
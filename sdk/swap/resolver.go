@@ -1,6 +1,7 @@
 package swap
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
@@ -35,7 +36,7 @@ func ResolveLiFiRouter(chain string) (*RouterInfo, error) {
 		return nil, fmt.Errorf("no LiFi router resolver: %w", err)
 	}
 
-	address, _, err := r.Resolve(types.MagicConstant_LIFI_ROUTER, chain, "")
+	address, _, err := r.Resolve(context.Background(), types.MagicConstant_LIFI_ROUTER, chain, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve LiFi router for %s: %w", chain, err)
 	}
@@ -54,7 +55,7 @@ func ResolveOneInchRouter(chain string) (*RouterInfo, error) {
 		return nil, fmt.Errorf("no 1inch router resolver: %w", err)
 	}
 
-	address, _, err := r.Resolve(types.MagicConstant_ONEINCH_ROUTER, chain, "")
+	address, _, err := r.Resolve(context.Background(), types.MagicConstant_ONEINCH_ROUTER, chain, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve 1inch router for %s: %w", chain, err)
 	}
@@ -73,7 +74,7 @@ func ResolveUniswapRouter(chain string) (*RouterInfo, error) {
 		return nil, fmt.Errorf("no Uniswap router resolver: %w", err)
 	}
 
-	address, _, err := r.Resolve(types.MagicConstant_UNISWAP_UNIVERSAL_ROUTER, chain, "")
+	address, _, err := r.Resolve(context.Background(), types.MagicConstant_UNISWAP_UNIVERSAL_ROUTER, chain, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve Uniswap router for %s: %w", chain, err)
 	}
@@ -92,7 +93,7 @@ func ResolveTHORChainRouter(chain string) (*RouterInfo, error) {
 		return nil, fmt.Errorf("no THORChain router resolver: %w", err)
 	}
 
-	address, _, err := r.Resolve(types.MagicConstant_THORCHAIN_ROUTER, chain, "")
+	address, _, err := r.Resolve(context.Background(), types.MagicConstant_THORCHAIN_ROUTER, chain, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve THORChain router for %s: %w", chain, err)
 	}
@@ -111,7 +112,7 @@ func ResolveMayaChainRouter(chain string) (*RouterInfo, error) {
 		return nil, fmt.Errorf("no Mayachain router resolver: %w", err)
 	}
 
-	address, _, err := r.Resolve(types.MagicConstant_MAYACHAIN_ROUTER, chain, "")
+	address, _, err := r.Resolve(context.Background(), types.MagicConstant_MAYACHAIN_ROUTER, chain, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve Mayachain router for %s: %w", chain, err)
 	}
@@ -32,18 +32,36 @@ const (
 	ConstraintType_CONSTRAINT_TYPE_MAGIC_CONSTANT ConstraintType = 4
 	ConstraintType_CONSTRAINT_TYPE_ANY            ConstraintType = 5
 	ConstraintType_CONSTRAINT_TYPE_REGEXP         ConstraintType = 6
+	// Value must be within [min, max], both bounds are inclusive
+	ConstraintType_CONSTRAINT_TYPE_RANGE ConstraintType = 7
+	// Value must be equal to one of the set values
+	ConstraintType_CONSTRAINT_TYPE_IN_SET ConstraintType = 8
+	// Value must not be equal to any of the set values
+	ConstraintType_CONSTRAINT_TYPE_NOT_IN_SET ConstraintType = 9
+	// Value must satisfy every nested constraint
+	ConstraintType_CONSTRAINT_TYPE_ALL_OF ConstraintType = 10
+	// Value must satisfy at least one nested constraint
+	ConstraintType_CONSTRAINT_TYPE_ANY_OF ConstraintType = 11
+	// Value must not satisfy the single nested constraint
+	ConstraintType_CONSTRAINT_TYPE_NOT ConstraintType = 12
 )
 
 // Enum value maps for ConstraintType.
 var (
 	ConstraintType_name = map[int32]string{
-		0: "CONSTRAINT_TYPE_UNSPECIFIED",
-		1: "CONSTRAINT_TYPE_FIXED",
-		2: "CONSTRAINT_TYPE_MAX",
-		3: "CONSTRAINT_TYPE_MIN",
-		4: "CONSTRAINT_TYPE_MAGIC_CONSTANT",
-		5: "CONSTRAINT_TYPE_ANY",
-		6: "CONSTRAINT_TYPE_REGEXP",
+		0:  "CONSTRAINT_TYPE_UNSPECIFIED",
+		1:  "CONSTRAINT_TYPE_FIXED",
+		2:  "CONSTRAINT_TYPE_MAX",
+		3:  "CONSTRAINT_TYPE_MIN",
+		4:  "CONSTRAINT_TYPE_MAGIC_CONSTANT",
+		5:  "CONSTRAINT_TYPE_ANY",
+		6:  "CONSTRAINT_TYPE_REGEXP",
+		7:  "CONSTRAINT_TYPE_RANGE",
+		8:  "CONSTRAINT_TYPE_IN_SET",
+		9:  "CONSTRAINT_TYPE_NOT_IN_SET",
+		10: "CONSTRAINT_TYPE_ALL_OF",
+		11: "CONSTRAINT_TYPE_ANY_OF",
+		12: "CONSTRAINT_TYPE_NOT",
 	}
 	ConstraintType_value = map[string]int32{
 		"CONSTRAINT_TYPE_UNSPECIFIED":    0,
@@ -53,6 +71,12 @@ var (
 		"CONSTRAINT_TYPE_MAGIC_CONSTANT": 4,
 		"CONSTRAINT_TYPE_ANY":            5,
 		"CONSTRAINT_TYPE_REGEXP":         6,
+		"CONSTRAINT_TYPE_RANGE":          7,
+		"CONSTRAINT_TYPE_IN_SET":         8,
+		"CONSTRAINT_TYPE_NOT_IN_SET":     9,
+		"CONSTRAINT_TYPE_ALL_OF":         10,
+		"CONSTRAINT_TYPE_ANY_OF":         11,
+		"CONSTRAINT_TYPE_NOT":            12,
 	}
 )
 
@@ -185,6 +209,9 @@ type Constraint struct {
 	//	*Constraint_MinValue
 	//	*Constraint_MagicConstantValue
 	//	*Constraint_RegexpValue
+	//	*Constraint_RangeValue
+	//	*Constraint_SetValue
+	//	*Constraint_CompositeValue
 	Value isConstraint_Value `protobuf_oneof:"value"`
 	// Additional metadata for the constraint
 	DenominatedIn string `protobuf:"bytes,6,opt,name=denominated_in,json=denominatedIn,proto3" json:"denominated_in,omitempty"`
@@ -283,6 +310,33 @@ func (x *Constraint) GetRegexpValue() string {
 	return ""
 }
 
+func (x *Constraint) GetRangeValue() *RangeValue {
+	if x != nil {
+		if x, ok := x.Value.(*Constraint_RangeValue); ok {
+			return x.RangeValue
+		}
+	}
+	return nil
+}
+
+func (x *Constraint) GetSetValue() *SetValue {
+	if x != nil {
+		if x, ok := x.Value.(*Constraint_SetValue); ok {
+			return x.SetValue
+		}
+	}
+	return nil
+}
+
+func (x *Constraint) GetCompositeValue() *CompositeValue {
+	if x != nil {
+		if x, ok := x.Value.(*Constraint_CompositeValue); ok {
+			return x.CompositeValue
+		}
+	}
+	return nil
+}
+
 func (x *Constraint) GetDenominatedIn() string {
 	if x != nil {
 		return x.DenominatedIn
@@ -328,6 +382,18 @@ type Constraint_RegexpValue struct {
 	RegexpValue string `protobuf:"bytes,9,opt,name=regexp_value,json=regexpValue,proto3,oneof"`
 }
 
+type Constraint_RangeValue struct {
+	RangeValue *RangeValue `protobuf:"bytes,10,opt,name=range_value,json=rangeValue,proto3,oneof"`
+}
+
+type Constraint_SetValue struct {
+	SetValue *SetValue `protobuf:"bytes,11,opt,name=set_value,json=setValue,proto3,oneof"`
+}
+
+type Constraint_CompositeValue struct {
+	CompositeValue *CompositeValue `protobuf:"bytes,12,opt,name=composite_value,json=compositeValue,proto3,oneof"`
+}
+
 func (*Constraint_FixedValue) isConstraint_Value() {}
 
 func (*Constraint_MaxValue) isConstraint_Value() {}
@@ -338,11 +404,160 @@ func (*Constraint_MagicConstantValue) isConstraint_Value() {}
 
 func (*Constraint_RegexpValue) isConstraint_Value() {}
 
+func (*Constraint_RangeValue) isConstraint_Value() {}
+
+func (*Constraint_SetValue) isConstraint_Value() {}
+
+func (*Constraint_CompositeValue) isConstraint_Value() {}
+
+// RangeValue defines inclusive bounds for CONSTRAINT_TYPE_RANGE
+type RangeValue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Min           string                 `protobuf:"bytes,1,opt,name=min,proto3" json:"min,omitempty"`
+	Max           string                 `protobuf:"bytes,2,opt,name=max,proto3" json:"max,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RangeValue) Reset() {
+	*x = RangeValue{}
+	mi := &file_constraint_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RangeValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RangeValue) ProtoMessage() {}
+
+func (x *RangeValue) ProtoReflect() protoreflect.Message {
+	mi := &file_constraint_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RangeValue.ProtoReflect.Descriptor instead.
+func (*RangeValue) Descriptor() ([]byte, []int) {
+	return file_constraint_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RangeValue) GetMin() string {
+	if x != nil {
+		return x.Min
+	}
+	return ""
+}
+
+func (x *RangeValue) GetMax() string {
+	if x != nil {
+		return x.Max
+	}
+	return ""
+}
+
+// SetValue defines the values for CONSTRAINT_TYPE_IN_SET and CONSTRAINT_TYPE_NOT_IN_SET
+type SetValue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []string               `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetValue) Reset() {
+	*x = SetValue{}
+	mi := &file_constraint_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetValue) ProtoMessage() {}
+
+func (x *SetValue) ProtoReflect() protoreflect.Message {
+	mi := &file_constraint_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetValue.ProtoReflect.Descriptor instead.
+func (*SetValue) Descriptor() ([]byte, []int) {
+	return file_constraint_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SetValue) GetValues() []string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+// CompositeValue defines nested constraints for CONSTRAINT_TYPE_ALL_OF, CONSTRAINT_TYPE_ANY_OF and CONSTRAINT_TYPE_NOT
+type CompositeValue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Constraints   []*Constraint          `protobuf:"bytes,1,rep,name=constraints,proto3" json:"constraints,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompositeValue) Reset() {
+	*x = CompositeValue{}
+	mi := &file_constraint_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompositeValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompositeValue) ProtoMessage() {}
+
+func (x *CompositeValue) ProtoReflect() protoreflect.Message {
+	mi := &file_constraint_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompositeValue.ProtoReflect.Descriptor instead.
+func (*CompositeValue) Descriptor() ([]byte, []int) {
+	return file_constraint_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CompositeValue) GetConstraints() []*Constraint {
+	if x != nil {
+		return x.Constraints
+	}
+	return nil
+}
+
 var File_constraint_proto protoreflect.FileDescriptor
 
 const file_constraint_proto_rawDesc = "" +
 	"\n" +
-	"\x10constraint.proto\x12\x05types\"\xeb\x02\n" +
+	"\x10constraint.proto\x12\x05types\"\x93\x04\n" +
 	"\n" +
 	"Constraint\x12)\n" +
 	"\x04type\x18\x01 \x01(\x0e2\x15.types.ConstraintTypeR\x04type\x12!\n" +
@@ -351,11 +566,24 @@ const file_constraint_proto_rawDesc = "" +
 	"\tmax_value\x18\x03 \x01(\tH\x00R\bmaxValue\x12\x1d\n" +
 	"\tmin_value\x18\x04 \x01(\tH\x00R\bminValue\x12H\n" +
 	"\x14magic_constant_value\x18\x05 \x01(\x0e2\x14.types.MagicConstantH\x00R\x12magicConstantValue\x12#\n" +
-	"\fregexp_value\x18\t \x01(\tH\x00R\vregexpValue\x12%\n" +
+	"\fregexp_value\x18\t \x01(\tH\x00R\vregexpValue\x124\n" +
+	"\vrange_value\x18\n" +
+	" \x01(\v2\x11.types.RangeValueH\x00R\n" +
+	"rangeValue\x12.\n" +
+	"\tset_value\x18\v \x01(\v2\x0f.types.SetValueH\x00R\bsetValue\x12@\n" +
+	"\x0fcomposite_value\x18\f \x01(\v2\x15.types.CompositeValueH\x00R\x0ecompositeValue\x12%\n" +
 	"\x0edenominated_in\x18\x06 \x01(\tR\rdenominatedIn\x12\x16\n" +
 	"\x06period\x18\a \x01(\tR\x06period\x12\x1a\n" +
 	"\brequired\x18\b \x01(\bR\brequiredB\a\n" +
-	"\x05value*\xd7\x01\n" +
+	"\x05value\"0\n" +
+	"\n" +
+	"RangeValue\x12\x10\n" +
+	"\x03min\x18\x01 \x01(\tR\x03min\x12\x10\n" +
+	"\x03max\x18\x02 \x01(\tR\x03max\"\"\n" +
+	"\bSetValue\x12\x16\n" +
+	"\x06values\x18\x01 \x03(\tR\x06values\"E\n" +
+	"\x0eCompositeValue\x123\n" +
+	"\vconstraints\x18\x01 \x03(\v2\x11.types.ConstraintR\vconstraints*\xff\x02\n" +
 	"\x0eConstraintType\x12\x1f\n" +
 	"\x1bCONSTRAINT_TYPE_UNSPECIFIED\x10\x00\x12\x19\n" +
 	"\x15CONSTRAINT_TYPE_FIXED\x10\x01\x12\x17\n" +
@@ -363,7 +591,14 @@ const file_constraint_proto_rawDesc = "" +
 	"\x13CONSTRAINT_TYPE_MIN\x10\x03\x12\"\n" +
 	"\x1eCONSTRAINT_TYPE_MAGIC_CONSTANT\x10\x04\x12\x17\n" +
 	"\x13CONSTRAINT_TYPE_ANY\x10\x05\x12\x1a\n" +
-	"\x16CONSTRAINT_TYPE_REGEXP\x10\x06*\xda\x02\n" +
+	"\x16CONSTRAINT_TYPE_REGEXP\x10\x06\x12\x19\n" +
+	"\x15CONSTRAINT_TYPE_RANGE\x10\a\x12\x1a\n" +
+	"\x16CONSTRAINT_TYPE_IN_SET\x10\b\x12\x1e\n" +
+	"\x1aCONSTRAINT_TYPE_NOT_IN_SET\x10\t\x12\x1a\n" +
+	"\x16CONSTRAINT_TYPE_ALL_OF\x10\n" +
+	"\x12\x1a\n" +
+	"\x16CONSTRAINT_TYPE_ANY_OF\x10\v\x12\x17\n" +
+	"\x13CONSTRAINT_TYPE_NOT\x10\f*\xda\x02\n" +
 	"\rMagicConstant\x12\x0f\n" +
 	"\vUNSPECIFIED\x10\x00\x12\x15\n" +
 	"\x11VULTISIG_TREASURY\x10\x01\x12\x13\n" +
@@ -395,20 +630,27 @@ func file_constraint_proto_rawDescGZIP() []byte {
 }
 
 var file_constraint_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_constraint_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_constraint_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
 var file_constraint_proto_goTypes = []any{
-	(ConstraintType)(0), // 0: types.ConstraintType
-	(MagicConstant)(0),  // 1: types.MagicConstant
-	(*Constraint)(nil),  // 2: types.Constraint
+	(ConstraintType)(0),    // 0: types.ConstraintType
+	(MagicConstant)(0),     // 1: types.MagicConstant
+	(*Constraint)(nil),     // 2: types.Constraint
+	(*RangeValue)(nil),     // 3: types.RangeValue
+	(*SetValue)(nil),       // 4: types.SetValue
+	(*CompositeValue)(nil), // 5: types.CompositeValue
 }
 var file_constraint_proto_depIdxs = []int32{
 	0, // 0: types.Constraint.type:type_name -> types.ConstraintType
 	1, // 1: types.Constraint.magic_constant_value:type_name -> types.MagicConstant
-	2, // [2:2] is the sub-list for method output_type
-	2, // [2:2] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	3, // 2: types.Constraint.range_value:type_name -> types.RangeValue
+	4, // 3: types.Constraint.set_value:type_name -> types.SetValue
+	5, // 4: types.Constraint.composite_value:type_name -> types.CompositeValue
+	2, // 5: types.CompositeValue.constraints:type_name -> types.Constraint
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_constraint_proto_init() }
@@ -422,6 +664,9 @@ func file_constraint_proto_init() {
 		(*Constraint_MinValue)(nil),
 		(*Constraint_MagicConstantValue)(nil),
 		(*Constraint_RegexpValue)(nil),
+		(*Constraint_RangeValue)(nil),
+		(*Constraint_SetValue)(nil),
+		(*Constraint_CompositeValue)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -429,7 +674,7 @@ func file_constraint_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_constraint_proto_rawDesc), len(file_constraint_proto_rawDesc)),
 			NumEnums:      2,
-			NumMessages:   1,
+			NumMessages:   4,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
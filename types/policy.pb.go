@@ -249,8 +249,11 @@ type Policy struct {
 	// set 1 for erc20.transfer
 	// set 2 for erc20.approve + erc20.transferFrom
 	MaxTxsPerWindow *uint32 `protobuf:"varint,12,opt,name=max_txs_per_window,json=maxTxsPerWindow,proto3,oneof" json:"max_txs_per_window,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+	// EvmTxConstraints constrain fields of the tx itself (tx.gas_limit, tx.max_fee_per_gas, etc.),
+	// they are applied to every allow rule on EVM chains unless the rule constrains the same field
+	EvmTxConstraints []*ParameterConstraint `protobuf:"bytes,13,rep,name=evm_tx_constraints,json=evmTxConstraints,proto3" json:"evm_tx_constraints,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
 func (x *Policy) Reset() {
@@ -367,6 +370,13 @@ func (x *Policy) GetMaxTxsPerWindow() uint32 {
 	return 0
 }
 
+func (x *Policy) GetEvmTxConstraints() []*ParameterConstraint {
+	if x != nil {
+		return x.EvmTxConstraints
+	}
+	return nil
+}
+
 type PolicySuggest struct {
 	state           protoimpl.MessageState `protogen:"open.v1"`
 	RateLimitWindow *uint32                `protobuf:"varint,1,opt,name=rate_limit_window,json=rateLimitWindow,proto3,oneof" json:"rate_limit_window,omitempty"`
@@ -431,7 +441,7 @@ var File_policy_proto protoreflect.FileDescriptor
 
 const file_policy_proto_rawDesc = "" +
 	"\n" +
-	"\fpolicy.proto\x12\x05types\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\n" +
+	"\fpolicy.proto\x12\x05types\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1aparameter_constraint.proto\x1a\n" +
 	"rule.proto\"\xeb\x01\n" +
 	"\tFeePolicy\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\"\n" +
@@ -440,7 +450,7 @@ const file_policy_proto_rawDesc = "" +
 	"\x06amount\x18\x04 \x01(\x03R\x06amount\x129\n" +
 	"\n" +
 	"start_date\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tstartDate\x12 \n" +
-	"\vdescription\x18\x06 \x01(\tR\vdescription\"\x9d\x04\n" +
+	"\vdescription\x18\x06 \x01(\tR\vdescription\"\xe7\x04\n" +
 	"\x06Policy\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
@@ -456,7 +466,8 @@ const file_policy_proto_rawDesc = "" +
 	"\rconfiguration\x18\n" +
 	" \x01(\v2\x17.google.protobuf.StructR\rconfiguration\x12/\n" +
 	"\x11rate_limit_window\x18\v \x01(\rH\x00R\x0frateLimitWindow\x88\x01\x01\x120\n" +
-	"\x12max_txs_per_window\x18\f \x01(\rH\x01R\x0fmaxTxsPerWindow\x88\x01\x01B\x14\n" +
+	"\x12max_txs_per_window\x18\f \x01(\rH\x01R\x0fmaxTxsPerWindow\x88\x01\x01\x12H\n" +
+	"\x12evm_tx_constraints\x18\r \x03(\v2\x1a.types.ParameterConstraintR\x10evmTxConstraintsB\x14\n" +
 	"\x12_rate_limit_windowB\x15\n" +
 	"\x13_max_txs_per_window\"\xc2\x01\n" +
 	"\rPolicySuggest\x12/\n" +
@@ -501,22 +512,24 @@ var file_policy_proto_goTypes = []any{
 	(*timestamppb.Timestamp)(nil), // 5: google.protobuf.Timestamp
 	(*Rule)(nil),                  // 6: types.Rule
 	(*structpb.Struct)(nil),       // 7: google.protobuf.Struct
+	(*ParameterConstraint)(nil),   // 8: types.ParameterConstraint
 }
 var file_policy_proto_depIdxs = []int32{
-	0, // 0: types.FeePolicy.type:type_name -> types.FeeType
-	1, // 1: types.FeePolicy.frequency:type_name -> types.BillingFrequency
-	5, // 2: types.FeePolicy.start_date:type_name -> google.protobuf.Timestamp
-	6, // 3: types.Policy.rules:type_name -> types.Rule
-	5, // 4: types.Policy.created_at:type_name -> google.protobuf.Timestamp
-	5, // 5: types.Policy.updated_at:type_name -> google.protobuf.Timestamp
-	2, // 6: types.Policy.fee_policies:type_name -> types.FeePolicy
-	7, // 7: types.Policy.configuration:type_name -> google.protobuf.Struct
-	6, // 8: types.PolicySuggest.rules:type_name -> types.Rule
-	9, // [9:9] is the sub-list for method output_type
-	9, // [9:9] is the sub-list for method input_type
-	9, // [9:9] is the sub-list for extension type_name
-	9, // [9:9] is the sub-list for extension extendee
-	0, // [0:9] is the sub-list for field type_name
+	0,  // 0: types.FeePolicy.type:type_name -> types.FeeType
+	1,  // 1: types.FeePolicy.frequency:type_name -> types.BillingFrequency
+	5,  // 2: types.FeePolicy.start_date:type_name -> google.protobuf.Timestamp
+	6,  // 3: types.Policy.rules:type_name -> types.Rule
+	5,  // 4: types.Policy.created_at:type_name -> google.protobuf.Timestamp
+	5,  // 5: types.Policy.updated_at:type_name -> google.protobuf.Timestamp
+	2,  // 6: types.Policy.fee_policies:type_name -> types.FeePolicy
+	7,  // 7: types.Policy.configuration:type_name -> google.protobuf.Struct
+	8,  // 8: types.Policy.evm_tx_constraints:type_name -> types.ParameterConstraint
+	6,  // 9: types.PolicySuggest.rules:type_name -> types.Rule
+	10, // [10:10] is the sub-list for method output_type
+	10, // [10:10] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
 }
 
 func init() { file_policy_proto_init() }
@@ -524,6 +537,7 @@ func file_policy_proto_init() {
 	if File_policy_proto != nil {
 		return
 	}
+	file_parameter_constraint_proto_init()
 	file_rule_proto_init()
 	file_policy_proto_msgTypes[1].OneofWrappers = []any{}
 	file_policy_proto_msgTypes[2].OneofWrappers = []any{}